@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"snake/internal/kline"
 	"snake/internal/kline/interval"
+	"snake/internal/kline/storage/mysql/models"
+	"snake/internal/risk"
 	"snake/internal/strategy"
 	"snake/internal/types"
 	"time"
@@ -24,6 +26,16 @@ type Config struct {
 	MA20Period int
 	// MA60 周期
 	MA60Period int
+	// 挂单撮合策略
+	FillPolicy FillPolicy
+	// 保证金账户参数，nil 表示不允许做空
+	Margin *strategy.MarginConfig
+	// 合约参数，nil 表示现货回测；合约回测时K线仓库应使用合约K线仓库
+	Futures *strategy.FuturesConfig
+	// 没有资金费率历史时使用的资金费率，例如 0.0001 表示 0.01%
+	FundingRate decimal.Decimal
+	// 账户级风控规则，nil 表示不做风控
+	Risk *risk.Config
 }
 
 // Result 回测结果（每个K线的回测结果）
@@ -39,6 +51,8 @@ type Trade struct {
 	Amount decimal.Decimal
 	// 交易价格
 	Price decimal.Decimal
+	// 订单类型
+	OrderType types.OrderType
 	// 交易后的余额
 	Balance decimal.Decimal
 	// 交易后的持仓
@@ -47,6 +61,8 @@ type Trade struct {
 	ProfitLoss decimal.Decimal
 	// 盈亏百分比
 	ProfitLossPercentage decimal.Decimal
+	// 是否为强制平仓
+	Liquidation bool
 }
 
 // TradeType 交易类型
@@ -68,6 +84,20 @@ type Backtest struct {
 	peakValue decimal.Decimal
 	// 交易记录
 	trades []*Trade
+	// 未成交的挂单
+	orders *orderBook
+	// 资金费率仓库，合约回测时使用
+	fundingRepository kline.FundingRepository
+	// 标记价格K线仓库，合约回测时使用
+	markPriceRepository kline.Repository
+	// 按开盘时间索引的标记价格K线，非合约回测或没有标记价格时为空
+	markPrices map[int64]*kline.Kline
+	// 风控管理器
+	risk *risk.Manager
+	// 资金费结算计划，非合约回测时为 nil
+	funding *fundingSchedule
+	// K线事件推送器
+	feed *strategy.Feed
 }
 
 // New 创建回测实例
@@ -78,12 +108,56 @@ func New(config *Config, repository kline.Repository, strategy strategy.Strategy
 		strategy:   strategy,
 		peakValue:  decimal.Zero,
 		trades:     make([]*Trade, 0),
+		orders:     newOrderBook(config.FillPolicy),
 	}
 }
 
+// WithFundingRepository 设置资金费率仓库，合约回测时按历史资金费率结算
+func (b *Backtest) WithFundingRepository(repository kline.FundingRepository) *Backtest {
+	b.fundingRepository = repository
+	return b
+}
+
+// WithMarkPriceRepository 设置标记价格K线仓库，合约回测时按标记价格检查强平，未设置时使用成交价K线
+func (b *Backtest) WithMarkPriceRepository(repository kline.Repository) *Backtest {
+	b.markPriceRepository = repository
+	return b
+}
+
 // Run 执行回测
 func (b *Backtest) Run(ctx context.Context) (Result, error) {
+	klines, err := b.prepare(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 初始化回测结果
+	result := make(Result, 0, len(klines))
+
+	// 遍历 K 线
+	for _, k := range klines {
+		positionKline, err := b.step(k)
+		if err != nil {
+			return nil, err
+		}
+
+		// 记录当前 K 线的资产情况
+		result = append(result, positionKline)
+	}
+
+	return result, nil
+}
+
+// prepare 初始化策略并加载回测使用的K线和资金费率
+func (b *Backtest) prepare(ctx context.Context) ([]*kline.Kline, error) {
 	// 初始化策略
+	b.strategy.SetMargin(b.config.Margin)
+	b.strategy.SetFutures(b.config.Futures)
+	b.risk = nil
+	if b.config.Risk != nil {
+		b.risk = risk.New(b.config.Risk)
+	}
+	b.strategy.SetRisk(b.risk)
 	if err := b.strategy.Init(b.config.InitialPosition, b.config.InitialBalance); err != nil {
 		return nil, fmt.Errorf("初始化策略失败: %v", err)
 	}
@@ -97,77 +171,183 @@ func (b *Backtest) Run(ctx context.Context) (Result, error) {
 	// 转换 K 线数据
 	klines := convertKlines(mysqlKlines)
 
+	// 合约回测按资金费率历史结算资金费
+	b.funding, err = b.loadFunding(ctx, klines)
+	if err != nil {
+		return nil, fmt.Errorf("获取资金费率失败: %v", err)
+	}
+
+	// 合约按标记价格检查强平
+	b.markPrices, err = b.loadMarkPrices(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取标记价格K线失败: %v", err)
+	}
+
+	// 多周期策略从回测周期合成高周期K线
+	b.feed, err = strategy.NewFeed(b.strategy, b.config.Interval)
+	if err != nil {
+		return nil, fmt.Errorf("初始化多周期K线失败: %v", err)
+	}
+
 	// 记录交易
 	b.trades = make([]*Trade, 0)
+	b.orders = newOrderBook(b.config.FillPolicy)
 
 	// 初始化最高资产值
 	b.peakValue = b.config.InitialBalance.Add(b.config.InitialPosition.Mul(decimal.NewFromFloat(100.0)))
 
-	// 初始化回测结果
-	result := make(Result, 0)
+	return klines, nil
+}
 
-	// 遍历 K 线
-	for _, k := range klines {
-		// 更新策略
-		signal, err := b.strategy.Update(k)
-		if err != nil {
-			return nil, fmt.Errorf("更新策略失败: %v", err)
+// step 处理一根K线：结算资金费、盯市、撮合挂单、更新策略，返回当前K线的资产情况
+func (b *Backtest) step(k *kline.Kline) (*kline.PositionKline, error) {
+	// 合约在每个结算时间结算资金费
+	if b.funding != nil {
+		for _, settlement := range b.funding.due(k) {
+			b.strategy.SettleFunding(settlement.rate, settlement.markPrice)
+		}
+	}
+
+	// 盯市：计提借币利息，触及强平价时强制平仓，策略可以随强平信号撤销挂单
+	if mark, ok := b.markPrices[k.S]; ok {
+		b.strategy.SetMarkPrice(mark)
+	}
+	if signal := b.strategy.Mark(k); signal != nil {
+		b.handleSignals([]*strategy.Signal{signal}, signal.Price)
+	}
+
+	// 用当前K线撮合之前提交的挂单，成交信号中的撤单和新挂单同样生效
+	for _, fill := range b.orders.match(k) {
+		if signal := b.strategy.Fill(fill.order, fill.price); signal != nil {
+			b.handleSignals([]*strategy.Signal{signal}, fill.price)
 		}
+	}
 
-		// 记录当前资产情况
-		positionKline := &kline.PositionKline{
-			Time:           time.Unix(k.S/1000, 0),
-			Kline:          k,
-			PositionAmount: b.strategy.Position().Amount,
-			PositionCost:   b.strategy.Position().Cost,
-			Balance:        b.strategy.Balance().Amount,
+	// 盘中策略按K线的开高低收路径推送盘中更新，与实盘的未收盘推送对应
+	if b.strategy.Mode() == strategy.UpdateIntrabar {
+		for _, snapshot := range kline.IntrabarPath(k) {
+			signals, err := b.feed.Push(kline.UpdateEvent(snapshot))
+			if err != nil {
+				return nil, fmt.Errorf("更新策略失败: %v", err)
+			}
+			b.handleSignals(signals, snapshot.C)
 		}
+	}
+
+	// K线收盘，更新策略
+	signals, err := b.feed.Push(kline.CloseEvent(k))
+	if err != nil {
+		return nil, fmt.Errorf("更新策略失败: %v", err)
+	}
+
+	// 记录当前资产情况
+	positionKline := &kline.PositionKline{
+		Time:             time.Unix(k.S/1000, 0),
+		Kline:            k,
+		PositionAmount:   b.strategy.Position().Amount,
+		PositionCost:     b.strategy.Position().Cost,
+		Balance:          b.strategy.Balance().Amount,
+		Interest:         b.strategy.Position().Interest,
+		LiquidationPrice: b.strategy.Position().LiquidationPrice,
+		Margin:           b.strategy.Position().Margin,
+		Funding:          b.strategy.Position().Funding,
+	}
+
+	// 计算总资产
+	positionKline.TotalValue = b.strategy.Equity(k.C)
+
+	// 更新最高资产值
+	if positionKline.TotalValue.GreaterThan(b.peakValue) {
+		b.peakValue = positionKline.TotalValue
+	}
+	positionKline.PeakValue = b.peakValue
+
+	// 计算回撤
+	if !b.peakValue.IsZero() {
+		positionKline.Drawdown = b.peakValue.Sub(positionKline.TotalValue).Div(b.peakValue).Mul(decimal.NewFromInt(100))
+	}
+
+	// 计算盈亏
+	profitAbsolute, profitPercentage := b.strategy.Profit()
+	positionKline.ProfitAbsolute = profitAbsolute
+	positionKline.ProfitPercentage = profitPercentage
 
-		// 计算当前持仓市值
-		positionValue := positionKline.PositionAmount.Mul(k.C)
-		// 计算总资产
-		positionKline.TotalValue = positionValue.Add(positionKline.Balance)
+	// 处理交易信号
+	b.handleSignals(signals, k.C)
 
-		// 更新最高资产值
-		if positionKline.TotalValue.GreaterThan(b.peakValue) {
-			b.peakValue = positionKline.TotalValue
+	return positionKline, nil
+}
+
+// handleSignals 记录交易信号，登记随信号提交的挂单
+// 挂单从下一根K线开始撮合，避免使用当前K线的未来数据
+func (b *Backtest) handleSignals(signals []*strategy.Signal, price decimal.Decimal) {
+	for _, signal := range signals {
+		b.recordTrade(signal)
+
+		for _, id := range signal.Cancels {
+			b.orders.cancel(id)
 		}
-		positionKline.PeakValue = b.peakValue
+		for _, order := range signal.Orders {
+			b.orders.add(order, price)
+		}
+	}
+}
 
-		// 计算回撤
-		if !b.peakValue.IsZero() {
-			positionKline.Drawdown = b.peakValue.Sub(positionKline.TotalValue).Div(b.peakValue).Mul(decimal.NewFromInt(100))
+// loadFunding 加载回测区间内的资金费率，非合约回测时返回 nil
+func (b *Backtest) loadFunding(ctx context.Context, klines []*kline.Kline) (*fundingSchedule, error) {
+	if b.config.Futures == nil || len(klines) == 0 {
+		return nil, nil
+	}
+
+	var rates []*models.FundingRate
+	if b.fundingRepository != nil {
+		var err error
+		rates, err = b.fundingRepository.ListFundingRates(ctx, klines[0].S, klines[len(klines)-1].E)
+		if err != nil {
+			return nil, err
 		}
+	}
 
-		// 计算盈亏
-		profitAbsolute, profitPercentage := b.strategy.Profit()
-		positionKline.ProfitAbsolute = profitAbsolute
-		positionKline.ProfitPercentage = profitPercentage
+	return newFundingSchedule(rates, b.config.FundingRate), nil
+}
 
-		// 记录当前 K 线的资产情况
-		result = append(result, positionKline)
+// loadMarkPrices 加载标记价格K线并按开盘时间索引，非合约回测或没有标记价格仓库时返回 nil
+func (b *Backtest) loadMarkPrices(ctx context.Context) (map[int64]*kline.Kline, error) {
+	if b.config.Futures == nil || b.markPriceRepository == nil {
+		return nil, nil
+	}
 
-		// 处理交易信号
-		if signal != nil {
-			if signal.Type.IsBuy() {
-				b.trades = append(b.trades, &Trade{
-					Time:   signal.Time,
-					Type:   signal.Type,
-					Amount: signal.Amount,
-					Price:  signal.Price,
-				})
-			} else if signal.Type.IsSell() {
-				b.trades = append(b.trades, &Trade{
-					Time:   signal.Time,
-					Type:   signal.Type,
-					Amount: signal.Amount,
-					Price:  signal.Price,
-				})
-			}
-		}
+	mysqlKlines, err := b.markPriceRepository.ListAll(ctx, b.config.Interval)
+	if err != nil {
+		return nil, err
 	}
 
-	return result, nil
+	markPrices := make(map[int64]*kline.Kline, len(mysqlKlines))
+	for _, k := range convertKlines(mysqlKlines) {
+		markPrices[k.S] = k
+	}
+	return markPrices, nil
+}
+
+// recordTrade 记录买入或卖出信号对应的交易
+func (b *Backtest) recordTrade(signal *strategy.Signal) {
+	if signal == nil || signal.Type.IsHold() {
+		return
+	}
+
+	var orderType = types.OrderTypeMarket
+	if signal.Order != nil {
+		orderType = signal.Order.Type
+	}
+
+	b.trades = append(b.trades, &Trade{
+		Time:        signal.Time,
+		Type:        signal.Type,
+		Amount:      signal.Amount,
+		Price:       signal.Price,
+		OrderType:   orderType,
+		Liquidation: signal.Liquidation,
+	})
 }
 
 // DisplaySummary 显示回测结果摘要
@@ -184,7 +364,11 @@ func (b *Backtest) DisplaySummary(result Result) {
 	// 计算交易次数
 	buyCount := 0
 	sellCount := 0
+	liquidationCount := 0
 	for _, trade := range b.trades {
+		if trade.Liquidation {
+			liquidationCount++
+		}
 		if trade.Type.IsBuy() {
 			buyCount++
 		} else if trade.Type.IsSell() {
@@ -195,7 +379,7 @@ func (b *Backtest) DisplaySummary(result Result) {
 
 	// 计算收益率
 	initialValue := b.config.InitialBalance.Add(b.config.InitialPosition.Mul(initialKline.Kline.C))
-	finalValue := finalKline.Balance.Add(finalKline.PositionAmount.Mul(finalKline.Kline.C))
+	finalValue := finalKline.TotalValue
 	roi := decimal.Zero
 	if !initialValue.IsZero() {
 		roi = finalValue.Sub(initialValue).Div(initialValue).Mul(decimal.NewFromInt(100))
@@ -229,6 +413,20 @@ func (b *Backtest) DisplaySummary(result Result) {
 	fmt.Printf("买入次数: %d\n", buyCount)
 	fmt.Printf("卖出次数: %d\n", sellCount)
 	fmt.Printf("最大回撤: %.2f%%\n", maxDrawdown.InexactFloat64())
+	if b.config.Margin != nil {
+		fmt.Printf("借币利息: %.4f USDT\n", finalKline.Interest.InexactFloat64())
+	}
+	if b.config.Futures != nil {
+		fmt.Printf("杠杆倍数: %s\n", b.config.Futures.Leverage.String())
+		fmt.Printf("累计资金费: %.4f USDT\n", finalKline.Funding.InexactFloat64())
+	}
+	if b.config.Margin != nil || b.config.Futures != nil {
+		fmt.Printf("强平次数: %d\n", liquidationCount)
+	}
+
+	if b.risk != nil {
+		b.displayRisk()
+	}
 
 	// 显示部分交易记录
 	if len(b.trades) > 0 {
@@ -262,6 +460,39 @@ func (b *Backtest) DisplaySummary(result Result) {
 	fmt.Println("\n=============================================================")
 }
 
+// RiskDecisions 返回风控缩减和拒绝的记录
+func (b *Backtest) RiskDecisions() []*risk.Decision {
+	if b.risk == nil {
+		return nil
+	}
+	return b.risk.Decisions()
+}
+
+// displayRisk 按规则统计风控缩减和拒绝的次数
+func (b *Backtest) displayRisk() {
+	decisions := b.risk.Decisions()
+	resized := make(map[string]int)
+	rejected := make(map[string]int)
+	var rules []string
+	for _, d := range decisions {
+		if resized[d.Rule] == 0 && rejected[d.Rule] == 0 {
+			rules = append(rules, d.Rule)
+		}
+		if d.Action == risk.ActionReject {
+			rejected[d.Rule]++
+		} else {
+			resized[d.Rule]++
+		}
+	}
+
+	fmt.Println("\n---------------------- 风控统计 ------------------------")
+	fmt.Printf("风控处理次数: %d\n", len(decisions))
+	fmt.Printf("回撤熔断: %v\n", b.risk.Halted())
+	for _, rule := range rules {
+		fmt.Printf("%-24s 缩减 %d 次, 拒绝 %d 次\n", rule, resized[rule], rejected[rule])
+	}
+}
+
 // DisplayKlineResults 显示每个K线的回测结果
 func (b *Backtest) DisplayKlineResults(result Result, limit int) {
 	if len(result) == 0 {
@@ -388,8 +619,8 @@ func (b *Backtest) DisplayTrades(limit int) {
 
 	// 打印表头
 	fmt.Println("\n========================= 交易记录 =========================")
-	fmt.Printf("%-20s %-6s %-12s %-12s %-12s\n",
-		"时间", "类型", "数量", "价格", "交易额(USDT)")
+	fmt.Printf("%-20s %-6s %-14s %-12s %-12s %-12s\n",
+		"时间", "类型", "订单类型", "数量", "价格", "交易额(USDT)")
 
 	// 显示交易记录
 	for i := 0; i < displayCount; i++ {
@@ -400,9 +631,10 @@ func (b *Backtest) DisplayTrades(limit int) {
 		}
 
 		tradeValue := trade.Amount.Mul(trade.Price)
-		fmt.Printf("%-20s %-6s %-12.8f %-12.2f %-12.2f\n",
+		fmt.Printf("%-20s %-6s %-14s %-12.8f %-12.2f %-12.2f\n",
 			trade.Time.Format("2006-01-02 15:04:05"),
 			tradeType,
+			trade.OrderType.String(),
 			trade.Amount.InexactFloat64(),
 			trade.Price.InexactFloat64(),
 			tradeValue.InexactFloat64())
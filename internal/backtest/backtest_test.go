@@ -2,9 +2,16 @@ package backtest
 
 import (
 	"context"
+	"math"
+	"snake/internal/kline"
 	"snake/internal/kline/interval"
 	"snake/internal/kline/storage/mysql/models"
+	"snake/internal/strategy"
+	"snake/internal/strategy/strategies/ichimoku_strategy"
 	"snake/internal/strategy/strategies/ma_cross"
+	"snake/internal/strategy/strategies/rules"
+	"snake/internal/types"
+	"strconv"
 	"testing"
 	"time"
 
@@ -106,7 +113,7 @@ func TestBacktest(t *testing.T) {
 	}
 
 	// 创建策略
-	strategy := ma_cross.New()
+	strategy := ma_cross.New(context.WithCancel(context.Background()))
 
 	// 创建回测器
 	backtest := New(config, &mockKlineRepository{klines: klines}, strategy)
@@ -137,3 +144,217 @@ func TestBacktest(t *testing.T) {
 			expectedMaxDrawdown.InexactFloat64(), lastResult.Drawdown.InexactFloat64())
 	}
 }
+
+func TestBacktestIchimoku(t *testing.T) {
+	// 先下跌、再上涨、最后下跌，一目均衡表策略应在上涨中买入并在下跌中卖出
+	var closes []string
+	for i := range 240 {
+		price := 100 + 30*math.Sin(float64(i)/40-1.5)
+		closes = append(closes, strconv.FormatFloat(price, 'f', 2, 64))
+	}
+
+	strategy := ichimoku_strategy.New(context.WithCancel(context.Background()))
+	b := New(&Config{
+		InitialBalance: decimal.NewFromInt(1000),
+		Interval:       interval.Hour1(),
+	}, hourlyKlines(closes...), strategy)
+
+	result, err := b.Run(context.Background())
+	if err != nil {
+		t.Fatalf("回测失败: %v", err)
+	}
+	if len(result) != len(closes) {
+		t.Fatalf("预期 %d 条回测结果，实际为 %d", len(closes), len(result))
+	}
+
+	var bought bool
+	for _, r := range result {
+		if r.PositionAmount.IsPositive() {
+			bought = true
+		}
+	}
+	if !bought {
+		t.Errorf("预期回测中买入过")
+	}
+	if last := result[len(result)-1]; !last.PositionAmount.IsZero() {
+		t.Errorf("预期下跌后已卖出，实际持仓为 %s", last.PositionAmount)
+	}
+}
+
+func TestBacktestRules(t *testing.T) {
+	// 先下跌、再上涨、最后下跌，规则策略在均线金叉时买入，死叉或止损时卖出
+	var closes []string
+	for i := range 240 {
+		price := 100 - 30*math.Sin(float64(i)/40)
+		closes = append(closes, strconv.FormatFloat(price, 'f', 2, 64))
+	}
+
+	definition, err := rules.Parse([]byte(`
+name: EMA Cross
+entry: cross_over(ema(close, 10), ema(close, 30)) and rsi(close, 14) > 50
+exit: cross_under(ema(close, 10), ema(close, 30))
+stops:
+  stop_loss: 5
+`))
+	if err != nil {
+		t.Fatalf("解析规则失败: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	strategy, err := rules.New(ctx, cancel, definition)
+	if err != nil {
+		t.Fatalf("创建规则策略失败: %v", err)
+	}
+
+	b := New(&Config{
+		InitialBalance: decimal.NewFromInt(1000),
+		Interval:       interval.Hour1(),
+	}, hourlyKlines(closes...), strategy)
+
+	result, err := b.Run(context.Background())
+	if err != nil {
+		t.Fatalf("回测失败: %v", err)
+	}
+
+	var bought bool
+	for _, r := range result {
+		if r.PositionAmount.IsPositive() {
+			bought = true
+		}
+	}
+	if !bought {
+		t.Errorf("预期回测中买入过")
+	}
+	if last := result[len(result)-1]; !last.PositionAmount.IsZero() {
+		t.Errorf("预期下跌后已卖出，实际持仓为 %s", last.PositionAmount)
+	}
+}
+
+// signalOrderStrategy 第一根K线挂出买入止损单，可以在盯市时撤单，或在买入成交后挂出止盈单
+type signalOrderStrategy struct {
+	*strategy.BaseStrategy
+	stopPrice decimal.Decimal
+	// cancelAt 从第几次盯市开始随盯市信号撤销买入止损单，0 表示不撤销
+	cancelAt int
+	// takeProfit 买入成交后挂出的止盈价，0 表示不挂
+	takeProfit decimal.Decimal
+
+	orderID int64
+	marks   int
+}
+
+func (s *signalOrderStrategy) Update(k *kline.Kline) (*strategy.Signal, error) {
+	if s.orderID != 0 {
+		return s.Hold(), nil
+	}
+	order := strategy.StopOrder(types.SignalTypeBuy, decimal.NewFromInt(1), s.stopPrice)
+	signal := s.PlaceOrders(s.Hold(), order)
+	s.orderID = order.ID
+	return signal, nil
+}
+
+func (s *signalOrderStrategy) Mark(k *kline.Kline) *strategy.Signal {
+	s.marks++
+	if s.cancelAt == 0 || s.marks < s.cancelAt || s.orderID == 0 {
+		return s.BaseStrategy.Mark(k)
+	}
+	return s.CancelOrders(s.Hold(), s.orderID)
+}
+
+func (s *signalOrderStrategy) Fill(order *strategy.Order, price decimal.Decimal) *strategy.Signal {
+	signal := s.BaseStrategy.Fill(order, price)
+	if signal != nil && signal.Type.IsBuy() && s.takeProfit.IsPositive() {
+		s.PlaceOrders(signal, strategy.TakeProfitOrder(types.SignalTypeSell, s.Position().Amount, s.takeProfit))
+	}
+	return signal
+}
+
+func TestBacktestSignalOrders(t *testing.T) {
+	tests := []struct {
+		name       string
+		strategy   *signalOrderStrategy
+		closes     []string
+		wantOrders []types.OrderType
+	}{
+		{
+			name:       "盯市信号撤销挂单",
+			strategy:   &signalOrderStrategy{stopPrice: decimal.NewFromInt(200), cancelAt: 3},
+			closes:     []string{"100", "100", "100", "250", "250"},
+			wantOrders: nil,
+		},
+		{
+			name:       "成交信号挂出新单",
+			strategy:   &signalOrderStrategy{stopPrice: decimal.NewFromInt(150), takeProfit: decimal.NewFromInt(200)},
+			closes:     []string{"100", "160", "170", "210"},
+			wantOrders: []types.OrderType{types.OrderTypeStop, types.OrderTypeTakeProfit},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			tt.strategy.BaseStrategy = strategy.NewBaseStrategy(ctx, cancel, "signal orders")
+
+			b := New(&Config{
+				InitialBalance: decimal.NewFromInt(1000),
+				Interval:       interval.Hour1(),
+			}, hourlyKlines(tt.closes...), tt.strategy)
+			if _, err := b.Run(context.Background()); err != nil {
+				t.Fatalf("回测失败: %v", err)
+			}
+
+			var orders []types.OrderType
+			for _, trade := range b.trades {
+				orders = append(orders, trade.OrderType)
+			}
+			if len(orders) != len(tt.wantOrders) {
+				t.Fatalf("预期成交 %v，实际为 %v", tt.wantOrders, orders)
+			}
+			for i := range orders {
+				if orders[i] != tt.wantOrders[i] {
+					t.Errorf("第 %d 笔成交预期为 %s，实际为 %s", i+1, tt.wantOrders[i], orders[i])
+				}
+			}
+		})
+	}
+}
+
+func TestBacktestMarkPrice(t *testing.T) {
+	// 第二根K线成交价插针到 85，标记价格最低 94，强平价约为 90.36
+	trades := hourlyKlines("100", "95")
+	trades.klines[1].Low = "85"
+	marks := hourlyKlines("100", "95")
+	marks.klines[1].Low = "94"
+
+	tests := []struct {
+		name  string
+		marks kline.Repository
+		want  bool // 是否强平
+	}{
+		{name: "按标记价格检查强平", marks: marks},
+		{name: "没有标记价格时按成交价", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New(&Config{
+				InitialBalance: decimal.NewFromInt(1000),
+				Interval:       interval.Hour1(),
+				Futures:        &strategy.FuturesConfig{Leverage: decimal.NewFromInt(10), MaintenanceMarginRate: decimal.NewFromFloat(0.004)},
+			}, trades, newPartialBuyStrategy("futures", 1))
+			if tt.marks != nil {
+				b.WithMarkPriceRepository(tt.marks)
+			}
+
+			if _, err := b.Run(context.Background()); err != nil {
+				t.Fatalf("回测失败: %v", err)
+			}
+			var liquidated bool
+			for _, trade := range b.trades {
+				liquidated = liquidated || trade.Liquidation
+			}
+			if liquidated != tt.want {
+				t.Errorf("预期强平 %v，实际为 %v", tt.want, liquidated)
+			}
+		})
+	}
+}
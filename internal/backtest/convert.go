@@ -15,16 +15,26 @@ func convertKline(k *models.Kline) *kline.Kline {
 	l, _ := decimal.NewFromString(k.Low)
 	v, _ := decimal.NewFromString(k.Volume)
 	a, _ := decimal.NewFromString(k.Amount)
+	bv, _ := decimal.NewFromString(k.TakerBuyVolume)
+	ba, _ := decimal.NewFromString(k.TakerBuyAmount)
+	p, err := decimal.NewFromString(k.Average)
+	if err != nil {
+		p = kline.AveragePrice(a, v)
+	}
 
 	return &kline.Kline{
-		O: o,
-		C: c,
-		H: h,
-		L: l,
-		V: v,
-		A: a,
-		S: k.OpenTs,
-		E: k.CloseTs,
+		O:  o,
+		C:  c,
+		H:  h,
+		L:  l,
+		V:  v,
+		A:  a,
+		S:  k.OpenTs,
+		E:  k.CloseTs,
+		N:  k.TradeCount,
+		BV: bv,
+		BA: ba,
+		P:  p,
 	}
 }
 
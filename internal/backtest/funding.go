@@ -0,0 +1,83 @@
+package backtest
+
+import (
+	"snake/internal/kline"
+	"snake/internal/kline/storage/mysql/models"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// fundingIntervalMilliseconds 资金费结算间隔，每天 UTC 0、8、16 点结算
+	fundingIntervalMilliseconds = int64(8 * time.Hour / time.Millisecond)
+	// fundingToleranceMilliseconds 交易所记录的结算时间可能比整点晚几毫秒
+	fundingToleranceMilliseconds = int64(time.Minute / time.Millisecond)
+)
+
+// fundingRate 资金费结算记录
+type fundingRate struct {
+	time      int64
+	rate      decimal.Decimal
+	markPrice decimal.Decimal
+}
+
+// fundingSchedule 按K线确定需要结算的资金费
+type fundingSchedule struct {
+	// 资金费率历史，按结算时间升序排序
+	rates []*fundingRate
+	// 没有历史记录时使用的资金费率
+	defaultRate decimal.Decimal
+	// 上一根K线的结束时间
+	last int64
+}
+
+func newFundingSchedule(rates []*models.FundingRate, defaultRate decimal.Decimal) *fundingSchedule {
+	var schedule = &fundingSchedule{defaultRate: defaultRate}
+	for _, r := range rates {
+		rate, err := decimal.NewFromString(r.FundingRate)
+		if err != nil {
+			continue
+		}
+		markPrice, _ := decimal.NewFromString(r.MarkPrice)
+		schedule.rates = append(schedule.rates, &fundingRate{time: r.FundingTs, rate: rate, markPrice: markPrice})
+	}
+
+	sort.Slice(schedule.rates, func(i, j int) bool { return schedule.rates[i].time < schedule.rates[j].time })
+	return schedule
+}
+
+// due 返回结算时间落在 (上一根K线结束时间, 当前K线结束时间] 内的资金费
+// 标记价格缺失时使用K线开盘价
+func (f *fundingSchedule) due(k *kline.Kline) []*fundingRate {
+	last := f.last
+	if last == 0 {
+		last = k.S - 1
+	}
+	if k.E > f.last {
+		f.last = k.E
+	}
+
+	var result []*fundingRate
+	for t := (last/fundingIntervalMilliseconds + 1) * fundingIntervalMilliseconds; t <= k.E; t += fundingIntervalMilliseconds {
+		settlement := &fundingRate{time: t, rate: f.defaultRate, markPrice: k.O}
+		if r := f.find(t); r != nil {
+			settlement.rate = r.rate
+			if r.markPrice.IsPositive() {
+				settlement.markPrice = r.markPrice
+			}
+		}
+		result = append(result, settlement)
+	}
+	return result
+}
+
+// find 查找结算时间对应的资金费率记录
+func (f *fundingSchedule) find(t int64) *fundingRate {
+	i := sort.Search(len(f.rates), func(i int) bool { return f.rates[i].time >= t })
+	if i < len(f.rates) && f.rates[i].time < t+fundingToleranceMilliseconds {
+		return f.rates[i]
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package backtest
+
+import (
+	"snake/internal/kline"
+	"snake/internal/kline/storage/mysql/models"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestFundingScheduleDue(t *testing.T) {
+	const hour = int64(3600 * 1000)
+	rates := []*models.FundingRate{
+		{FundingTs: 8*hour + 3, FundingRate: "0.0003", MarkPrice: "101"},
+	}
+	schedule := newFundingSchedule(rates, decimal.NewFromFloat(0.0001))
+
+	newKline := func(start, duration int64) *kline.Kline {
+		return &kline.Kline{O: decimal.NewFromInt(100), S: start, E: start + duration - 1}
+	}
+
+	if due := schedule.due(newKline(7*hour, hour)); len(due) != 0 {
+		t.Fatalf("预期不结算，实际结算 %d 次", len(due))
+	}
+
+	due := schedule.due(newKline(8*hour, hour))
+	if len(due) != 1 {
+		t.Fatalf("预期结算 1 次，实际结算 %d 次", len(due))
+	}
+	if !due[0].rate.Equal(decimal.NewFromFloat(0.0003)) || !due[0].markPrice.Equal(decimal.NewFromInt(101)) {
+		t.Errorf("预期使用历史资金费率和标记价格，实际为 %s、%s", due[0].rate, due[0].markPrice)
+	}
+
+	// 一根 24 小时的K线跨过 3 个结算时间，没有历史记录时使用默认费率和开盘价
+	due = schedule.due(newKline(9*hour, 24*hour))
+	if len(due) != 3 {
+		t.Fatalf("预期结算 3 次，实际结算 %d 次", len(due))
+	}
+	if !due[0].rate.Equal(decimal.NewFromFloat(0.0001)) || !due[0].markPrice.Equal(decimal.NewFromInt(100)) {
+		t.Errorf("预期使用默认资金费率和开盘价")
+	}
+}
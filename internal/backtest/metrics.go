@@ -0,0 +1,289 @@
+package backtest
+
+import (
+	"math"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Metrics 资产曲线的绩效指标
+type Metrics struct {
+	// 总收益率（百分比）
+	TotalReturn decimal.Decimal
+	// 年化收益率（百分比）
+	AnnualizedReturn decimal.Decimal
+	// 年化波动率（百分比）
+	Volatility decimal.Decimal
+	// 夏普比率（无风险利率按 0 计算）
+	Sharpe decimal.Decimal
+	// 最大回撤（百分比）
+	MaxDrawdown decimal.Decimal
+}
+
+// yearDuration 一年的时长，用于年化
+const yearDuration = 365 * 24 * time.Hour
+
+// computeMetrics 根据资产曲线计算绩效指标，按资产点的平均间隔年化
+func computeMetrics(initial decimal.Decimal, values []decimal.Decimal, times []time.Time) *Metrics {
+	var metrics = &Metrics{}
+	if len(values) == 0 || !initial.IsPositive() {
+		return metrics
+	}
+
+	final := values[len(values)-1]
+	metrics.TotalReturn = final.Sub(initial).Div(initial).Mul(decimal.NewFromInt(100))
+
+	// 最大回撤
+	var peak = initial
+	for _, value := range values {
+		if value.GreaterThan(peak) {
+			peak = value
+		}
+		if peak.IsPositive() {
+			drawdown := peak.Sub(value).Div(peak).Mul(decimal.NewFromInt(100))
+			if drawdown.GreaterThan(metrics.MaxDrawdown) {
+				metrics.MaxDrawdown = drawdown
+			}
+		}
+	}
+
+	if len(values) < 2 {
+		return metrics
+	}
+
+	// 每个资产点的收益率
+	var returns = make([]float64, 0, len(values))
+	var previous = initial.InexactFloat64()
+	for _, value := range values {
+		current := value.InexactFloat64()
+		if previous > 0 {
+			returns = append(returns, current/previous-1)
+		}
+		previous = current
+	}
+
+	span := times[len(times)-1].Sub(times[0])
+	if span <= 0 {
+		return metrics
+	}
+	periodsPerYear := float64(yearDuration) / (float64(span) / float64(len(times)-1))
+
+	growth := final.InexactFloat64() / initial.InexactFloat64()
+	if growth > 0 {
+		years := float64(span) / float64(yearDuration)
+		metrics.AnnualizedReturn = finiteDecimal((math.Pow(growth, 1/years) - 1) * 100)
+	}
+
+	mean, std := meanStd(returns)
+	metrics.Volatility = finiteDecimal(std * math.Sqrt(periodsPerYear) * 100)
+	if std > 0 {
+		metrics.Sharpe = finiteDecimal(mean / std * math.Sqrt(periodsPerYear))
+	}
+	return metrics
+}
+
+// finiteDecimal 将 float64 转换为 decimal，回测区间过短导致年化溢出时返回 0
+func finiteDecimal(v float64) decimal.Decimal {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return decimal.Zero
+	}
+	return decimal.NewFromFloat(v)
+}
+
+// meanStd 计算均值和样本标准差
+func meanStd(values []float64) (mean, std float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+
+	if len(values) < 2 {
+		return mean, 0
+	}
+
+	var sum float64
+	for _, v := range values {
+		sum += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(sum / float64(len(values)-1))
+}
+
+// toFloats 将收益率序列转换为 float64，便于计算协方差
+func toFloats(values []decimal.Decimal) []float64 {
+	var result = make([]float64, len(values))
+	for i, v := range values {
+		result[i] = v.InexactFloat64()
+	}
+	return result
+}
+
+// alignTail 将多个序列截取为相同长度（保留最近的部分）
+func alignTail(series [][]decimal.Decimal) [][]float64 {
+	var n = -1
+	for _, s := range series {
+		if n < 0 || len(s) < n {
+			n = len(s)
+		}
+	}
+
+	var result = make([][]float64, len(series))
+	for i, s := range series {
+		result[i] = toFloats(s[len(s)-n:])
+	}
+	return result
+}
+
+// covariance 计算收益率序列的样本协方差矩阵
+func covariance(series [][]decimal.Decimal) [][]float64 {
+	aligned := alignTail(series)
+
+	var means = make([]float64, len(aligned))
+	for i, s := range aligned {
+		means[i], _ = meanStd(s)
+	}
+
+	var matrix = make([][]float64, len(aligned))
+	for i := range aligned {
+		matrix[i] = make([]float64, len(aligned))
+		for j := range aligned {
+			n := len(aligned[i])
+			if n < 2 {
+				continue
+			}
+
+			var sum float64
+			for k := 0; k < n; k++ {
+				sum += (aligned[i][k] - means[i]) * (aligned[j][k] - means[j])
+			}
+			matrix[i][j] = sum / float64(n-1)
+		}
+	}
+	return matrix
+}
+
+// correlation 计算收益率序列的皮尔逊相关系数矩阵，波动率为零时相关系数记为 0
+func correlation(series [][]decimal.Decimal) [][]decimal.Decimal {
+	cov := covariance(series)
+
+	var matrix = make([][]decimal.Decimal, len(cov))
+	for i := range cov {
+		matrix[i] = make([]decimal.Decimal, len(cov))
+		for j := range cov {
+			if i == j {
+				matrix[i][j] = decimal.NewFromInt(1)
+				continue
+			}
+
+			denominator := math.Sqrt(cov[i][i] * cov[j][j])
+			if denominator > 0 {
+				matrix[i][j] = decimal.NewFromFloat(cov[i][j] / denominator)
+			}
+		}
+	}
+	return matrix
+}
+
+// normalize 归一化权重，权重全为零时等权
+func normalize(weights []decimal.Decimal) []decimal.Decimal {
+	var total decimal.Decimal
+	for _, w := range weights {
+		total = total.Add(w)
+	}
+
+	var result = make([]decimal.Decimal, len(weights))
+	for i, w := range weights {
+		if total.IsPositive() {
+			result[i] = w.Div(total)
+		} else {
+			result[i] = decimal.NewFromInt(1).Div(decimal.NewFromInt(int64(len(weights))))
+		}
+	}
+	return result
+}
+
+// inverseVolatilityWeights 等风险权重：与波动率成反比，任一波动率为零时返回 nil
+func inverseVolatilityWeights(series [][]decimal.Decimal) []decimal.Decimal {
+	var weights = make([]decimal.Decimal, len(series))
+	for i, s := range series {
+		_, std := meanStd(toFloats(s))
+		if std <= 0 {
+			return nil
+		}
+		weights[i] = decimal.NewFromFloat(1 / std)
+	}
+	return normalize(weights)
+}
+
+// riskParityIterations 风险平价迭代次数上限
+const riskParityIterations = 500
+
+// riskParityWeights 风险平价权重：迭代使每个策略的风险贡献 w_i * (Σw)_i 相等，
+// 协方差矩阵对角线有零时返回 nil
+func riskParityWeights(cov [][]float64) []decimal.Decimal {
+	n := len(cov)
+	if n == 0 {
+		return nil
+	}
+
+	// 以反波动率权重作为初始值
+	var w = make([]float64, n)
+	var total float64
+	for i := range cov {
+		if cov[i][i] <= 0 {
+			return nil
+		}
+		w[i] = 1 / math.Sqrt(cov[i][i])
+		total += w[i]
+	}
+	for i := range w {
+		w[i] /= total
+	}
+
+	for iteration := 0; iteration < riskParityIterations; iteration++ {
+		// 组合方差和每个策略的边际风险
+		var marginal = make([]float64, n)
+		var variance float64
+		for i := range cov {
+			for j := range cov {
+				marginal[i] += cov[i][j] * w[j]
+			}
+			variance += w[i] * marginal[i]
+		}
+		if variance <= 0 {
+			return nil
+		}
+
+		// 按风险贡献与目标的比例调整权重
+		var next = make([]float64, n)
+		var sum, change float64
+		for i := range w {
+			contribution := w[i] * marginal[i] / variance
+			if contribution <= 0 {
+				next[i] = w[i]
+			} else {
+				next[i] = w[i] * math.Sqrt(1/float64(n)/contribution)
+			}
+			sum += next[i]
+		}
+		for i := range next {
+			next[i] /= sum
+			change = math.Max(change, math.Abs(next[i]-w[i]))
+		}
+		w = next
+
+		if change < 1e-10 {
+			break
+		}
+	}
+
+	var weights = make([]decimal.Decimal, n)
+	for i, v := range w {
+		weights[i] = decimal.NewFromFloat(v)
+	}
+	return weights
+}
@@ -0,0 +1,232 @@
+package backtest
+
+import (
+	"snake/internal/kline"
+	"snake/internal/strategy"
+	"snake/internal/types"
+
+	"github.com/shopspring/decimal"
+)
+
+// FillPolicy 挂单撮合策略
+//
+// 无论哪种策略，成交价都遵循以下规则：
+//   - 开盘价已越过触发价或限价（跳空）时，按开盘价成交
+//   - 否则按触发价或限价成交
+//
+// 撮合策略只决定同一根K线内 OCO 两条腿同时触发时由哪条腿成交
+type FillPolicy int
+
+const (
+	// FillPolicyConservative 保守撮合：OCO 两条腿同时触发时按止损腿成交
+	FillPolicyConservative FillPolicy = iota
+	// FillPolicyOptimistic 乐观撮合：OCO 两条腿同时触发时按限价/止盈腿成交
+	FillPolicyOptimistic
+)
+
+// pendingOrder 等待撮合的挂单
+type pendingOrder struct {
+	order *strategy.Order
+	// 止损限价单是否已触发
+	triggered bool
+	// 跟踪止损单记录的最有利价格
+	extreme decimal.Decimal
+}
+
+// orderFill 挂单成交结果
+type orderFill struct {
+	order *strategy.Order
+	price decimal.Decimal
+}
+
+// orderBook 回测挂单簿
+type orderBook struct {
+	policy FillPolicy
+	orders []*pendingOrder
+}
+
+func newOrderBook(policy FillPolicy) *orderBook {
+	return &orderBook{policy: policy}
+}
+
+// add 添加挂单，price 为下单时的参考价格，作为跟踪止损的初始最有利价格
+func (b *orderBook) add(order *strategy.Order, price decimal.Decimal) {
+	if order == nil || b.find(order.ID) != nil {
+		return
+	}
+
+	b.orders = append(b.orders, &pendingOrder{order: order, extreme: price})
+	if order.OCO != nil {
+		b.add(order.OCO, price)
+	}
+}
+
+// cancel 撤销挂单，OCO 的另一条腿一并撤销
+func (b *orderBook) cancel(id int64) {
+	p := b.find(id)
+	if p == nil {
+		return
+	}
+
+	b.remove(id)
+	if p.order.OCO != nil {
+		b.remove(p.order.OCO.ID)
+	}
+}
+
+func (b *orderBook) find(id int64) *pendingOrder {
+	for _, p := range b.orders {
+		if p.order.ID == id {
+			return p
+		}
+	}
+	return nil
+}
+
+func (b *orderBook) remove(id int64) {
+	for i, p := range b.orders {
+		if p.order.ID == id {
+			b.orders = append(b.orders[:i], b.orders[i+1:]...)
+			return
+		}
+	}
+}
+
+// match 按K线的开盘价、最高价、最低价撮合挂单，返回成交结果
+// 已成交的挂单和被 OCO 撤销的挂单会从挂单簿中移除
+func (b *orderBook) match(k *kline.Kline) []*orderFill {
+	var candidates = make(map[int64]*orderFill)
+	var sequence []*orderFill
+	for _, p := range b.orders {
+		price, ok := p.fillPrice(k)
+		if !ok {
+			continue
+		}
+
+		fill := &orderFill{order: p.order, price: price}
+		candidates[p.order.ID] = fill
+		sequence = append(sequence, fill)
+	}
+
+	var fills []*orderFill
+	var done = make(map[int64]struct{})
+	for _, fill := range sequence {
+		if _, ok := done[fill.order.ID]; ok {
+			continue
+		}
+
+		// 两条腿都要移除，选中另一条腿成交时 fill 会被替换，先记录当前这条腿
+		done[fill.order.ID] = struct{}{}
+		if oco := fill.order.OCO; oco != nil {
+			done[oco.ID] = struct{}{}
+			// OCO 两条腿同时触发，按撮合策略选择成交的一条腿
+			if other, ok := candidates[oco.ID]; ok && b.prefer(other.order, fill.order) {
+				fill = other
+			}
+		}
+
+		fills = append(fills, fill)
+	}
+
+	// 移除成交和被撤销的挂单
+	for id := range done {
+		b.remove(id)
+	}
+
+	// 更新跟踪止损的最有利价格
+	for _, p := range b.orders {
+		p.track(k)
+	}
+
+	return fills
+}
+
+// prefer 判断 OCO 同时触发时是否应由 a 而不是 b 成交
+func (b *orderBook) prefer(a, other *strategy.Order) bool {
+	if b.policy == FillPolicyOptimistic {
+		return a.Type.IsLimit() && !other.Type.IsLimit()
+	}
+	return a.Type.IsStop() && !other.Type.IsStop()
+}
+
+// fillPrice 计算挂单在K线内的成交价，未触发时返回 false
+func (p *pendingOrder) fillPrice(k *kline.Kline) (decimal.Decimal, bool) {
+	order := p.order
+	switch order.Type {
+	case types.OrderTypeMarket:
+		return k.O, true
+
+	case types.OrderTypeLimit:
+		return limitFill(order.Side, order.LimitPrice, k)
+
+	case types.OrderTypeTakeProfit:
+		return limitFill(order.Side, order.StopPrice, k)
+
+	case types.OrderTypeStop:
+		return stopFill(order.Side, order.StopPrice, k)
+
+	case types.OrderTypeStopLimit:
+		if p.triggered {
+			return limitFill(order.Side, order.LimitPrice, k)
+		}
+
+		stopPrice, ok := stopFill(order.Side, order.StopPrice, k)
+		if !ok {
+			return decimal.Zero, false
+		}
+		p.triggered = true
+
+		// 开盘跳空触发时，限价单从开盘价开始撮合
+		if stopPrice.Equal(k.O) {
+			return limitFill(order.Side, order.LimitPrice, k)
+		}
+
+		// 盘中触发时无法得知触发后的价格路径，只在K线范围覆盖限价时按限价成交
+		if order.Side.IsBuy() && k.L.LessThanOrEqual(order.LimitPrice) ||
+			order.Side.IsSell() && k.H.GreaterThanOrEqual(order.LimitPrice) {
+			return order.LimitPrice, true
+		}
+		return decimal.Zero, false
+
+	case types.OrderTypeTrailingStop:
+		return stopFill(order.Side, order.TrailingStopPrice(p.extreme), k)
+	}
+
+	return decimal.Zero, false
+}
+
+// track 根据K线更新跟踪止损的最有利价格
+func (p *pendingOrder) track(k *kline.Kline) {
+	if p.order.Type != types.OrderTypeTrailingStop {
+		return
+	}
+
+	if p.order.Side.IsSell() && k.H.GreaterThan(p.extreme) {
+		p.extreme = k.H
+	}
+	if p.order.Side.IsBuy() && k.L.LessThan(p.extreme) {
+		p.extreme = k.L
+	}
+}
+
+// limitFill 限价类订单：买入在价格跌至限价时成交，卖出在价格涨至限价时成交
+func limitFill(side types.SignalType, price decimal.Decimal, k *kline.Kline) (decimal.Decimal, bool) {
+	if side.IsBuy() && k.L.LessThanOrEqual(price) {
+		return decimal.Min(k.O, price), true
+	}
+	if side.IsSell() && k.H.GreaterThanOrEqual(price) {
+		return decimal.Max(k.O, price), true
+	}
+	return decimal.Zero, false
+}
+
+// stopFill 止损类订单：买入在价格涨至触发价时成交，卖出在价格跌至触发价时成交
+func stopFill(side types.SignalType, price decimal.Decimal, k *kline.Kline) (decimal.Decimal, bool) {
+	if side.IsBuy() && k.H.GreaterThanOrEqual(price) {
+		return decimal.Max(k.O, price), true
+	}
+	if side.IsSell() && k.L.LessThanOrEqual(price) {
+		return decimal.Min(k.O, price), true
+	}
+	return decimal.Zero, false
+}
@@ -0,0 +1,205 @@
+package backtest
+
+import (
+	"snake/internal/kline"
+	"snake/internal/strategy"
+	"snake/internal/types"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func newTestKline(o, h, l, c float64) *kline.Kline {
+	return &kline.Kline{
+		O: decimal.NewFromFloat(o),
+		H: decimal.NewFromFloat(h),
+		L: decimal.NewFromFloat(l),
+		C: decimal.NewFromFloat(c),
+	}
+}
+
+func TestOrderBookMatch(t *testing.T) {
+	one := decimal.NewFromInt(1)
+	price := decimal.NewFromFloat
+
+	tests := []struct {
+		name   string
+		order  *strategy.Order
+		kline  *kline.Kline
+		filled bool
+		price  decimal.Decimal
+	}{
+		{
+			name:   "卖出止损盘中触发，按触发价成交",
+			order:  strategy.StopOrder(types.SignalTypeSell, one, price(95)),
+			kline:  newTestKline(100, 101, 94, 99),
+			filled: true,
+			price:  price(95),
+		},
+		{
+			name:   "卖出止损跳空低开，按开盘价成交",
+			order:  strategy.StopOrder(types.SignalTypeSell, one, price(95)),
+			kline:  newTestKline(90, 92, 88, 91),
+			filled: true,
+			price:  price(90),
+		},
+		{
+			name:   "卖出止损未触发",
+			order:  strategy.StopOrder(types.SignalTypeSell, one, price(95)),
+			kline:  newTestKline(100, 101, 96, 99),
+			filled: false,
+		},
+		{
+			name:   "买入限价盘中触发，按限价成交",
+			order:  strategy.LimitOrder(types.SignalTypeBuy, one, price(98)),
+			kline:  newTestKline(100, 101, 97, 99),
+			filled: true,
+			price:  price(98),
+		},
+		{
+			name:   "买入限价跳空低开，按更优的开盘价成交",
+			order:  strategy.LimitOrder(types.SignalTypeBuy, one, price(98)),
+			kline:  newTestKline(96, 99, 95, 97),
+			filled: true,
+			price:  price(96),
+		},
+		{
+			name:   "卖出止盈盘中触发，按触发价成交",
+			order:  strategy.TakeProfitOrder(types.SignalTypeSell, one, price(105)),
+			kline:  newTestKline(100, 106, 99, 104),
+			filled: true,
+			price:  price(105),
+		},
+		{
+			name:   "买入止损限价触发后覆盖限价",
+			order:  strategy.StopLimitOrder(types.SignalTypeBuy, one, price(102), price(103)),
+			kline:  newTestKline(100, 104, 99, 103),
+			filled: true,
+			price:  price(103),
+		},
+		{
+			name:   "卖出跟踪止损按参考价回撤触发",
+			order:  strategy.TrailingStopOrder(types.SignalTypeSell, one, decimal.Zero, price(5)),
+			kline:  newTestKline(99, 100, 94, 96),
+			filled: true,
+			price:  price(95),
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.order.ID = int64(i + 1)
+			book := newOrderBook(FillPolicyConservative)
+			book.add(tt.order, price(100))
+
+			fills := book.match(tt.kline)
+			if !tt.filled {
+				if len(fills) != 0 {
+					t.Fatalf("预期未成交，实际成交 %d 笔", len(fills))
+				}
+				if len(book.orders) != 1 {
+					t.Fatalf("预期挂单保留在挂单簿中")
+				}
+				return
+			}
+
+			if len(fills) != 1 {
+				t.Fatalf("预期成交 1 笔，实际成交 %d 笔", len(fills))
+			}
+			if !fills[0].price.Equal(tt.price) {
+				t.Errorf("预期成交价 %s，实际为 %s", tt.price, fills[0].price)
+			}
+			if len(book.orders) != 0 {
+				t.Errorf("成交后挂单应从挂单簿中移除")
+			}
+		})
+	}
+}
+
+func TestOrderBookTrailingStop(t *testing.T) {
+	order := strategy.TrailingStopOrder(types.SignalTypeSell, decimal.NewFromInt(1), decimal.NewFromInt(5), decimal.Zero)
+	order.ID = 1
+
+	book := newOrderBook(FillPolicyConservative)
+	book.add(order, decimal.NewFromInt(100))
+
+	// 价格上涨，最有利价格提升到 110，触发价随之提升到 105
+	if fills := book.match(newTestKline(100, 110, 99, 108)); len(fills) != 0 {
+		t.Fatalf("预期未成交，实际成交 %d 笔", len(fills))
+	}
+
+	fills := book.match(newTestKline(108, 109, 104, 106))
+	if len(fills) != 1 {
+		t.Fatalf("预期成交 1 笔，实际成交 %d 笔", len(fills))
+	}
+	if !fills[0].price.Equal(decimal.NewFromInt(105)) {
+		t.Errorf("预期成交价 105，实际为 %s", fills[0].price)
+	}
+}
+
+func TestOrderBookOCO(t *testing.T) {
+	newOCO := func() *strategy.Order {
+		stop := strategy.StopOrder(types.SignalTypeSell, decimal.NewFromInt(1), decimal.NewFromInt(95))
+		stop.ID = 1
+		takeProfit := strategy.TakeProfitOrder(types.SignalTypeSell, decimal.NewFromInt(1), decimal.NewFromInt(105))
+		takeProfit.ID = 2
+		return strategy.OCOOrder(stop, takeProfit)
+	}
+
+	t.Run("一条腿成交后撤销另一条腿", func(t *testing.T) {
+		book := newOrderBook(FillPolicyConservative)
+		book.add(newOCO(), decimal.NewFromInt(100))
+
+		fills := book.match(newTestKline(100, 106, 99, 104))
+		if len(fills) != 1 || fills[0].order.ID != 2 {
+			t.Fatalf("预期止盈腿成交")
+		}
+		if len(book.orders) != 0 {
+			t.Errorf("预期 OCO 另一条腿被撤销")
+		}
+	})
+
+	// 两条腿的先后顺序不影响结果
+	tests := []struct {
+		name     string
+		policy   FillPolicy
+		reversed bool
+		want     int64
+	}{
+		{name: "同时触发时保守撮合按止损成交", policy: FillPolicyConservative, want: 1},
+		{name: "同时触发时保守撮合按止损成交（止盈腿在前）", policy: FillPolicyConservative, reversed: true, want: 1},
+		{name: "同时触发时乐观撮合按止盈成交", policy: FillPolicyOptimistic, want: 2},
+		{name: "同时触发时乐观撮合按止盈成交（止盈腿在前）", policy: FillPolicyOptimistic, reversed: true, want: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := newOCO()
+			if tt.reversed {
+				order = order.OCO
+			}
+			book := newOrderBook(tt.policy)
+			book.add(order, decimal.NewFromInt(100))
+
+			fills := book.match(newTestKline(100, 106, 94, 100))
+			if len(fills) != 1 || fills[0].order.ID != tt.want {
+				t.Fatalf("预期订单 %d 成交，实际为 %v", tt.want, fills)
+			}
+			if len(book.orders) != 0 {
+				t.Fatalf("预期两条腿都从挂单簿中移除，实际剩余 %d 个挂单", len(book.orders))
+			}
+			if fills := book.match(newTestKline(100, 106, 94, 100)); len(fills) != 0 {
+				t.Errorf("预期下一根K线不再成交，实际为 %v", fills)
+			}
+		})
+	}
+
+	t.Run("撤销一条腿时一并撤销另一条腿", func(t *testing.T) {
+		book := newOrderBook(FillPolicyConservative)
+		book.add(newOCO(), decimal.NewFromInt(100))
+
+		book.cancel(2)
+		if len(book.orders) != 0 {
+			t.Errorf("预期 OCO 两条腿都被撤销")
+		}
+	})
+}
@@ -0,0 +1,508 @@
+package backtest
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"snake/internal/kline"
+	"snake/internal/kline/interval"
+	"snake/internal/risk"
+	"snake/internal/strategy"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// AllocationMethod 组合资金分配方式
+type AllocationMethod int
+
+const (
+	// AllocationFixedWeights 按配置的固定权重分配，权重全为零时等权分配
+	AllocationFixedWeights AllocationMethod = iota
+	// AllocationEqualRisk 等风险分配：权重与策略收益率的波动率成反比，不考虑策略间的相关性
+	AllocationEqualRisk
+	// AllocationRiskParity 风险平价：考虑策略间的协方差，使每个策略对组合波动率的风险贡献相等
+	AllocationRiskParity
+)
+
+var allocationMethodNames = map[AllocationMethod]string{
+	AllocationFixedWeights: "FIXED_WEIGHTS",
+	AllocationEqualRisk:    "EQUAL_RISK",
+	AllocationRiskParity:   "RISK_PARITY",
+}
+
+func (m AllocationMethod) String() string { return allocationMethodNames[m] }
+
+// defaultRiskLookback 计算波动率和协方差默认使用的收益率数量
+const defaultRiskLookback = 30
+
+// PortfolioStrategy 组合中的一个策略，不同策略可以使用不同的K线周期和交易对（不同的K线仓库）
+type PortfolioStrategy struct {
+	// 策略名称，用于展示
+	Name string
+	// 策略实例
+	Strategy strategy.Strategy
+	// K线仓库
+	Repository kline.Repository
+	// K线时间间隔
+	Interval interval.Interval
+	// 固定权重，仅在 AllocationFixedWeights 时使用
+	Weight decimal.Decimal
+}
+
+// PortfolioConfig 组合回测配置
+type PortfolioConfig struct {
+	// 组合初始资金（USDT），所有策略共用
+	InitialBalance decimal.Decimal
+	// 资金分配方式
+	Allocation AllocationMethod
+	// 再平衡间隔，为零时只在开始时分配一次
+	RebalanceInterval time.Duration
+	// 计算波动率和协方差使用的收益率数量，为零时使用默认值
+	RiskLookback int
+	// 挂单撮合策略
+	FillPolicy FillPolicy
+	// 保证金账户参数，nil 表示不允许做空
+	Margin *strategy.MarginConfig
+	// 合约参数，nil 表示现货回测
+	Futures *strategy.FuturesConfig
+	// 没有资金费率历史时使用的资金费率
+	FundingRate decimal.Decimal
+	// 账户级风控规则，nil 表示不做风控；每个策略按各自分到的资金独立检查
+	Risk *risk.Config
+}
+
+// PortfolioPoint 组合在某个时间点的资产情况
+type PortfolioPoint struct {
+	// 时间（K线结束时间）
+	Time time.Time
+	// 组合总资产
+	TotalValue decimal.Decimal
+	// 未分配给任何策略的资金
+	Cash decimal.Decimal
+	// 各策略的资产
+	Values []decimal.Decimal
+	// 当前回撤（百分比）
+	Drawdown decimal.Decimal
+}
+
+// StrategyContribution 单个策略对组合的贡献
+type StrategyContribution struct {
+	// 策略名称
+	Name string
+	// 初始分配的资金
+	Initial decimal.Decimal
+	// 再平衡净转入的资金，负数表示净转出
+	Transferred decimal.Decimal
+	// 最终资产
+	Final decimal.Decimal
+	// 盈亏 = 最终资产 - 初始分配 - 净转入
+	Profit decimal.Decimal
+	// 对组合收益率的贡献（百分比，相对于组合初始资金）
+	Contribution decimal.Decimal
+	// 最终权重
+	Weight decimal.Decimal
+	// 策略的交易记录
+	Trades []*Trade
+}
+
+// PortfolioResult 组合回测结果
+type PortfolioResult struct {
+	// 组合资产曲线
+	Points []*PortfolioPoint
+	// 各策略的贡献
+	Strategies []*StrategyContribution
+	// 策略收益率的相关系数矩阵
+	Correlation [][]decimal.Decimal
+	// 再平衡次数
+	Rebalances int
+	// 组合指标
+	Metrics *Metrics
+}
+
+// portfolioSleeve 组合中一个策略的运行状态
+type portfolioSleeve struct {
+	*PortfolioStrategy
+	backtest *Backtest
+	klines   []*kline.Kline
+	next     int
+	// 最近一根已处理的K线
+	last *kline.Kline
+	// 初始分配的资金和再平衡净转入的资金
+	initial     decimal.Decimal
+	transferred decimal.Decimal
+	// 每根K线收盘时的收益率
+	returns []sleeveReturn
+	// 上一根K线收盘时的资产
+	value decimal.Decimal
+}
+
+// sleeveReturn 策略一根K线收盘时的收益率
+type sleeveReturn struct {
+	// K线结束时间
+	end   int64
+	value decimal.Decimal
+}
+
+// equity 按最近一根K线的收盘价计算策略资产
+func (s *portfolioSleeve) equity() decimal.Decimal {
+	if s.last == nil {
+		return s.Strategy.Balance().Amount
+	}
+	return s.Strategy.Equity(s.last.C)
+}
+
+// Portfolio 多策略组合回测，所有策略共用一个资金池
+//
+// 按时间顺序合并各策略的K线逐根回测，再平衡时只在策略之间划转空闲余额，不会强制调整持仓
+type Portfolio struct {
+	config  *PortfolioConfig
+	sleeves []*portfolioSleeve
+	cash    decimal.Decimal
+}
+
+// NewPortfolio 创建组合回测实例
+func NewPortfolio(config *PortfolioConfig, strategies ...*PortfolioStrategy) *Portfolio {
+	p := &Portfolio{config: config}
+	for _, s := range strategies {
+		p.sleeves = append(p.sleeves, &portfolioSleeve{PortfolioStrategy: s})
+	}
+	return p
+}
+
+// Run 执行组合回测
+func (p *Portfolio) Run(ctx context.Context) (*PortfolioResult, error) {
+	if len(p.sleeves) == 0 {
+		return nil, fmt.Errorf("组合中没有策略")
+	}
+
+	// 按初始权重分配资金
+	weights := p.weights()
+	p.cash = p.config.InitialBalance
+	for i, sleeve := range p.sleeves {
+		allocation := p.config.InitialBalance.Mul(weights[i])
+		sleeve.initial = allocation
+		sleeve.value = allocation
+		p.cash = p.cash.Sub(allocation)
+
+		sleeve.backtest = New(&Config{
+			InitialBalance: allocation,
+			Interval:       sleeve.Interval,
+			FillPolicy:     p.config.FillPolicy,
+			Margin:         p.config.Margin,
+			Futures:        p.config.Futures,
+			FundingRate:    p.config.FundingRate,
+			Risk:           p.config.Risk,
+		}, sleeve.Repository, sleeve.Strategy)
+
+		klines, err := sleeve.backtest.prepare(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("策略 %s: %v", sleeve.Name, err)
+		}
+		sleeve.klines = klines
+	}
+
+	var result = &PortfolioResult{}
+	var peak = p.config.InitialBalance
+	var nextRebalance int64
+	for {
+		// 取所有策略中最早结束的K线时间，同一时间结束的K线一起处理
+		end, ok := p.nextEnd()
+		if !ok {
+			break
+		}
+
+		for _, sleeve := range p.sleeves {
+			if sleeve.next >= len(sleeve.klines) || sleeve.klines[sleeve.next].E != end {
+				continue
+			}
+
+			k := sleeve.klines[sleeve.next]
+			if _, err := sleeve.backtest.step(k); err != nil {
+				return nil, fmt.Errorf("策略 %s: %v", sleeve.Name, err)
+			}
+			sleeve.last = k
+			sleeve.next++
+		}
+
+		point := p.record(end)
+		if point.TotalValue.GreaterThan(peak) {
+			peak = point.TotalValue
+		}
+		if peak.IsPositive() {
+			point.Drawdown = peak.Sub(point.TotalValue).Div(peak).Mul(decimal.NewFromInt(100))
+		}
+		result.Points = append(result.Points, point)
+
+		// 到达再平衡时间时按目标权重划转资金
+		if p.config.RebalanceInterval > 0 {
+			interval := p.config.RebalanceInterval.Milliseconds()
+			if nextRebalance == 0 {
+				nextRebalance = (end/interval + 1) * interval
+			} else if end >= nextRebalance {
+				p.rebalance()
+				result.Rebalances++
+				nextRebalance = (end/interval + 1) * interval
+			}
+		}
+	}
+
+	p.summarize(result)
+	return result, nil
+}
+
+// nextEnd 返回所有策略中下一根K线最早的结束时间
+func (p *Portfolio) nextEnd() (int64, bool) {
+	var end int64
+	var ok bool
+	for _, sleeve := range p.sleeves {
+		if sleeve.next >= len(sleeve.klines) {
+			continue
+		}
+
+		e := sleeve.klines[sleeve.next].E
+		if !ok || e < end {
+			end = e
+			ok = true
+		}
+	}
+	return end, ok
+}
+
+// record 记录当前时间点的组合资产，并更新在该时间点收盘的策略的收益率
+// 其他策略的K线还没有结束，资产只是按上一根K线的收盘价估算，不计入收益率
+func (p *Portfolio) record(end int64) *PortfolioPoint {
+	point := &PortfolioPoint{
+		Time:       time.UnixMilli(end),
+		TotalValue: p.cash,
+		Cash:       p.cash,
+		Values:     make([]decimal.Decimal, len(p.sleeves)),
+	}
+
+	for i, sleeve := range p.sleeves {
+		value := sleeve.equity()
+		point.Values[i] = value
+		point.TotalValue = point.TotalValue.Add(value)
+		if sleeve.last == nil || sleeve.last.E != end {
+			continue
+		}
+
+		var ret decimal.Decimal
+		if sleeve.value.IsPositive() {
+			ret = value.Div(sleeve.value).Sub(decimal.NewFromInt(1))
+		}
+		sleeve.returns = append(sleeve.returns, sleeveReturn{end: end, value: ret})
+		sleeve.value = value
+	}
+	return point
+}
+
+// rebalance 按目标权重在策略之间划转空闲余额：先从超配的策略转出，再转入低配的策略
+func (p *Portfolio) rebalance() {
+	weights := p.weights()
+
+	var total = p.cash
+	var values = make([]decimal.Decimal, len(p.sleeves))
+	for i, sleeve := range p.sleeves {
+		values[i] = sleeve.equity()
+		total = total.Add(values[i])
+	}
+
+	for i, sleeve := range p.sleeves {
+		excess := values[i].Sub(total.Mul(weights[i]))
+		if !excess.IsPositive() {
+			continue
+		}
+
+		moved := sleeve.Strategy.Transfer(excess.Neg())
+		sleeve.transferred = sleeve.transferred.Add(moved)
+		p.cash = p.cash.Sub(moved)
+	}
+
+	for i, sleeve := range p.sleeves {
+		deficit := total.Mul(weights[i]).Sub(values[i])
+		if !deficit.IsPositive() {
+			continue
+		}
+
+		moved := sleeve.Strategy.Transfer(decimal.Min(deficit, p.cash))
+		sleeve.transferred = sleeve.transferred.Add(moved)
+		p.cash = p.cash.Sub(moved)
+	}
+
+	// 划转后的资产作为下一个收益率的基准
+	for _, sleeve := range p.sleeves {
+		sleeve.value = sleeve.equity()
+	}
+}
+
+// weights 按分配方式计算目标权重
+// 按风险分配时，历史收益率不足或波动率为零会退化为固定权重
+func (p *Portfolio) weights() []decimal.Decimal {
+	fixed := make([]decimal.Decimal, len(p.sleeves))
+	for i, sleeve := range p.sleeves {
+		fixed[i] = decimal.Max(sleeve.Weight, decimal.Zero)
+	}
+	fixed = normalize(fixed)
+
+	if p.config.Allocation == AllocationFixedWeights {
+		return fixed
+	}
+
+	lookback := p.config.RiskLookback
+	if lookback <= 0 {
+		lookback = defaultRiskLookback
+	}
+
+	returns := p.alignedReturns(lookback)
+	if len(returns[0]) < 2 {
+		return fixed
+	}
+
+	var weights []decimal.Decimal
+	switch p.config.Allocation {
+	case AllocationEqualRisk:
+		weights = inverseVolatilityWeights(returns)
+	case AllocationRiskParity:
+		weights = riskParityWeights(covariance(returns))
+	}
+	if weights == nil {
+		return fixed
+	}
+	return weights
+}
+
+// alignedReturns 把各策略的收益率对齐到共同的时间网格后返回，lookback 为正时只保留最近的 lookback 个
+//
+// 网格为所有策略都有K线收盘的时间，例如 1h 和 4h 的策略按 4h 对齐；
+// 相邻网格点之间的多个收益率按复利合并，使不同周期的收益率可以计算协方差和相关系数
+func (p *Portfolio) alignedReturns(lookback int) [][]decimal.Decimal {
+	counts := make(map[int64]int)
+	for _, sleeve := range p.sleeves {
+		for _, r := range sleeve.returns {
+			counts[r.end]++
+		}
+	}
+
+	var grid []int64
+	for end, n := range counts {
+		if n == len(p.sleeves) {
+			grid = append(grid, end)
+		}
+	}
+	slices.Sort(grid)
+
+	one := decimal.NewFromInt(1)
+	result := make([][]decimal.Decimal, len(p.sleeves))
+	for i, sleeve := range p.sleeves {
+		var next int
+		for _, end := range grid {
+			growth := one
+			for ; next < len(sleeve.returns) && sleeve.returns[next].end <= end; next++ {
+				growth = growth.Mul(one.Add(sleeve.returns[next].value))
+			}
+			result[i] = append(result[i], growth.Sub(one))
+		}
+		if lookback > 0 {
+			result[i] = result[i][max(0, len(result[i])-lookback):]
+		}
+	}
+	return result
+}
+
+// summarize 计算组合指标、策略贡献和相关系数
+func (p *Portfolio) summarize(result *PortfolioResult) {
+	var values []decimal.Decimal
+	var times []time.Time
+	for _, point := range result.Points {
+		values = append(values, point.TotalValue)
+		times = append(times, point.Time)
+	}
+	result.Metrics = computeMetrics(p.config.InitialBalance, values, times)
+
+	var total = p.cash
+	for _, sleeve := range p.sleeves {
+		total = total.Add(sleeve.equity())
+	}
+
+	for _, sleeve := range p.sleeves {
+		final := sleeve.equity()
+		profit := final.Sub(sleeve.initial).Sub(sleeve.transferred)
+
+		contribution := &StrategyContribution{
+			Name:        sleeve.Name,
+			Initial:     sleeve.initial,
+			Transferred: sleeve.transferred,
+			Final:       final,
+			Profit:      profit,
+			Trades:      sleeve.backtest.trades,
+		}
+		if p.config.InitialBalance.IsPositive() {
+			contribution.Contribution = profit.Div(p.config.InitialBalance).Mul(decimal.NewFromInt(100))
+		}
+		if total.IsPositive() {
+			contribution.Weight = final.Div(total)
+		}
+		result.Strategies = append(result.Strategies, contribution)
+	}
+
+	result.Correlation = correlation(p.alignedReturns(0))
+}
+
+// DisplaySummary 显示组合回测结果摘要
+func (p *Portfolio) DisplaySummary(result *PortfolioResult) {
+	if result == nil || len(result.Points) == 0 {
+		fmt.Println("回测结果为空")
+		return
+	}
+
+	first := result.Points[0]
+	final := result.Points[len(result.Points)-1]
+	metrics := result.Metrics
+
+	fmt.Println("\n======================== 组合回测结果摘要 ========================")
+	fmt.Printf("策略数量: %d\n", len(p.sleeves))
+	fmt.Printf("资金分配方式: %s\n", p.config.Allocation)
+	fmt.Printf("再平衡次数: %d\n", result.Rebalances)
+	fmt.Printf("开始日期: %s\n", first.Time.Format("2006-01-02 15:04:05"))
+	fmt.Printf("结束日期: %s\n", final.Time.Format("2006-01-02 15:04:05"))
+	fmt.Println("\n---------------------- 组合指标 ------------------------")
+	fmt.Printf("初始资金: %.4f USDT\n", p.config.InitialBalance.InexactFloat64())
+	fmt.Printf("最终总资产: %.4f USDT\n", final.TotalValue.InexactFloat64())
+	fmt.Printf("收益率: %.2f%%\n", metrics.TotalReturn.InexactFloat64())
+	fmt.Printf("年化收益率: %.2f%%\n", metrics.AnnualizedReturn.InexactFloat64())
+	fmt.Printf("年化波动率: %.2f%%\n", metrics.Volatility.InexactFloat64())
+	fmt.Printf("夏普比率: %.2f\n", metrics.Sharpe.InexactFloat64())
+	fmt.Printf("最大回撤: %.2f%%\n", metrics.MaxDrawdown.InexactFloat64())
+
+	fmt.Println("\n---------------------- 策略贡献 ------------------------")
+	fmt.Printf("%-20s %-12s %-12s %-12s %-12s %-10s %-8s %-8s\n",
+		"策略", "初始资金", "净转入", "最终资产", "盈亏", "贡献(%)", "权重", "交易数")
+	for _, s := range result.Strategies {
+		fmt.Printf("%-20s %-12.4f %-12.4f %-12.4f %-12.4f %-10.2f %-8.4f %-8d\n",
+			s.Name,
+			s.Initial.InexactFloat64(),
+			s.Transferred.InexactFloat64(),
+			s.Final.InexactFloat64(),
+			s.Profit.InexactFloat64(),
+			s.Contribution.InexactFloat64(),
+			s.Weight.InexactFloat64(),
+			len(s.Trades))
+	}
+
+	fmt.Println("\n---------------------- 策略相关系数 ------------------------")
+	fmt.Printf("%-20s", "")
+	for _, s := range result.Strategies {
+		fmt.Printf(" %-10s", s.Name)
+	}
+	fmt.Println()
+	for i, row := range result.Correlation {
+		fmt.Printf("%-20s", result.Strategies[i].Name)
+		for _, c := range row {
+			fmt.Printf(" %-10.4f", c.InexactFloat64())
+		}
+		fmt.Println()
+	}
+
+	fmt.Println("\n=============================================================")
+}
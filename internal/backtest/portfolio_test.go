@@ -0,0 +1,270 @@
+package backtest
+
+import (
+	"context"
+	"math"
+	"snake/internal/kline"
+	"snake/internal/kline/interval"
+	"snake/internal/kline/storage/mysql/models"
+	"snake/internal/risk"
+	"snake/internal/strategy"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// partialBuyStrategy 第一根K线用一定比例的余额买入，之后一直持有
+type partialBuyStrategy struct {
+	*strategy.BaseStrategy
+	ratio  decimal.Decimal
+	bought bool
+}
+
+func newPartialBuyStrategy(name string, ratio float64) *partialBuyStrategy {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &partialBuyStrategy{
+		BaseStrategy: strategy.NewBaseStrategy(ctx, cancel, name),
+		ratio:        decimal.NewFromFloat(ratio),
+	}
+}
+
+func (s *partialBuyStrategy) Update(k *kline.Kline) (*strategy.Signal, error) {
+	if !s.bought && s.ratio.IsPositive() {
+		s.bought = true
+		if signal := s.Buy(s.Balance().Amount.Mul(s.ratio), k.C); signal != nil {
+			return signal, nil
+		}
+	}
+	return s.BaseStrategy.Update(k)
+}
+
+// hourlyKlines 按收盘价生成整点对齐的小时K线
+func hourlyKlines(closes ...string) *mockKlineRepository {
+	hour := time.Hour.Milliseconds()
+	var repository = &mockKlineRepository{}
+	for i, c := range closes {
+		repository.klines = append(repository.klines, &models.Kline{
+			OpenTs:  int64(i) * hour,
+			CloseTs: int64(i+1)*hour - 1,
+			Open:    c,
+			Close:   c,
+			High:    c,
+			Low:     c,
+			Volume:  "1",
+			Amount:  c,
+		})
+	}
+	return repository
+}
+
+func TestPortfolio(t *testing.T) {
+	t.Run("固定权重不再平衡", func(t *testing.T) {
+		portfolio := NewPortfolio(&PortfolioConfig{
+			InitialBalance: decimal.NewFromInt(1000),
+		},
+			&PortfolioStrategy{Name: "持有", Strategy: newPartialBuyStrategy("持有", 1), Repository: hourlyKlines("100", "150", "200"), Interval: interval.Hour1(), Weight: decimal.NewFromInt(1)},
+			&PortfolioStrategy{Name: "空仓", Strategy: newPartialBuyStrategy("空仓", 0), Repository: hourlyKlines("100", "150", "200"), Interval: interval.Hour1(), Weight: decimal.NewFromInt(1)},
+		)
+
+		result, err := portfolio.Run(context.Background())
+		if err != nil {
+			t.Fatalf("组合回测失败: %v", err)
+		}
+		if len(result.Points) != 3 {
+			t.Fatalf("预期 3 个资产点，实际为 %d", len(result.Points))
+		}
+
+		final := result.Points[len(result.Points)-1]
+		if !final.TotalValue.Equal(decimal.NewFromInt(1500)) {
+			t.Errorf("预期总资产 1500，实际为 %s", final.TotalValue)
+		}
+		if !result.Metrics.TotalReturn.Equal(decimal.NewFromInt(50)) {
+			t.Errorf("预期收益率 50%%，实际为 %s", result.Metrics.TotalReturn)
+		}
+
+		held := result.Strategies[0]
+		if !held.Profit.Equal(decimal.NewFromInt(500)) || !held.Contribution.Equal(decimal.NewFromInt(50)) {
+			t.Errorf("预期持有策略盈利 500、贡献 50%%，实际为 %s、%s", held.Profit, held.Contribution)
+		}
+		if !result.Strategies[1].Profit.IsZero() {
+			t.Errorf("预期空仓策略没有盈亏，实际为 %s", result.Strategies[1].Profit)
+		}
+		if result.Rebalances != 0 {
+			t.Errorf("预期不再平衡，实际为 %d 次", result.Rebalances)
+		}
+	})
+
+	t.Run("再平衡只划转空闲余额", func(t *testing.T) {
+		portfolio := NewPortfolio(&PortfolioConfig{
+			InitialBalance:    decimal.NewFromInt(1000),
+			RebalanceInterval: time.Hour,
+		},
+			&PortfolioStrategy{Name: "半仓", Strategy: newPartialBuyStrategy("半仓", 0.5), Repository: hourlyKlines("100", "300"), Interval: interval.Hour1()},
+			&PortfolioStrategy{Name: "空仓", Strategy: newPartialBuyStrategy("空仓", 0), Repository: hourlyKlines("100", "300"), Interval: interval.Hour1()},
+		)
+
+		result, err := portfolio.Run(context.Background())
+		if err != nil {
+			t.Fatalf("组合回测失败: %v", err)
+		}
+		if result.Rebalances != 1 {
+			t.Fatalf("预期再平衡 1 次，实际为 %d 次", result.Rebalances)
+		}
+
+		// 半仓策略资产 1000（余额 250 + 持仓 750），空仓策略 500，目标各 750
+		half, cash := result.Strategies[0], result.Strategies[1]
+		if !half.Transferred.Equal(decimal.NewFromInt(-250)) || !cash.Transferred.Equal(decimal.NewFromInt(250)) {
+			t.Errorf("预期划转 -250 和 250，实际为 %s 和 %s", half.Transferred, cash.Transferred)
+		}
+		if !half.Final.Equal(decimal.NewFromInt(750)) || !cash.Final.Equal(decimal.NewFromInt(750)) {
+			t.Errorf("预期再平衡后各 750，实际为 %s 和 %s", half.Final, cash.Final)
+		}
+		if !half.Profit.Equal(decimal.NewFromInt(500)) || !cash.Profit.IsZero() {
+			t.Errorf("预期盈亏不受划转影响，实际为 %s 和 %s", half.Profit, cash.Profit)
+		}
+	})
+
+	t.Run("不同周期的K线按结束时间合并", func(t *testing.T) {
+		twoHours := &mockKlineRepository{klines: []*models.Kline{
+			{OpenTs: 0, CloseTs: 2*time.Hour.Milliseconds() - 1, Open: "100", Close: "100", High: "100", Low: "100", Volume: "1", Amount: "100"},
+		}}
+		portfolio := NewPortfolio(&PortfolioConfig{InitialBalance: decimal.NewFromInt(1000)},
+			&PortfolioStrategy{Name: "1h", Strategy: newPartialBuyStrategy("1h", 0), Repository: hourlyKlines("100", "100"), Interval: interval.Hour1()},
+			&PortfolioStrategy{Name: "2h", Strategy: newPartialBuyStrategy("2h", 0), Repository: twoHours, Interval: interval.Hour2()},
+		)
+
+		result, err := portfolio.Run(context.Background())
+		if err != nil {
+			t.Fatalf("组合回测失败: %v", err)
+		}
+		if len(result.Points) != 2 {
+			t.Errorf("预期 2 个资产点，实际为 %d", len(result.Points))
+		}
+	})
+
+	t.Run("不同周期的收益率按共同时间网格对齐", func(t *testing.T) {
+		twoHours := &mockKlineRepository{klines: []*models.Kline{
+			{OpenTs: 0, CloseTs: 2*time.Hour.Milliseconds() - 1, Open: "100", Close: "100", High: "100", Low: "100", Volume: "1", Amount: "100"},
+			{OpenTs: 2 * time.Hour.Milliseconds(), CloseTs: 4*time.Hour.Milliseconds() - 1, Open: "121", Close: "121", High: "121", Low: "121", Volume: "1", Amount: "121"},
+		}}
+		portfolio := NewPortfolio(&PortfolioConfig{InitialBalance: decimal.NewFromInt(1000)},
+			&PortfolioStrategy{Name: "1h", Strategy: newPartialBuyStrategy("1h", 1), Repository: hourlyKlines("100", "110", "121", "133.1"), Interval: interval.Hour1()},
+			&PortfolioStrategy{Name: "2h", Strategy: newPartialBuyStrategy("2h", 1), Repository: twoHours, Interval: interval.Hour2()},
+		)
+
+		if _, err := portfolio.Run(context.Background()); err != nil {
+			t.Fatalf("组合回测失败: %v", err)
+		}
+		// 2h 策略只在自己的K线收盘时记录收益率
+		if len(portfolio.sleeves[0].returns) != 4 || len(portfolio.sleeves[1].returns) != 2 {
+			t.Fatalf("预期收益率数量为 4 和 2，实际为 %d 和 %d", len(portfolio.sleeves[0].returns), len(portfolio.sleeves[1].returns))
+		}
+
+		returns := portfolio.alignedReturns(0)
+		want := [][]string{{"0.1", "0.21"}, {"0", "0.21"}}
+		for i := range want {
+			if len(returns[i]) != len(want[i]) {
+				t.Fatalf("策略 %d 预期 %d 个对齐后的收益率，实际为 %d", i, len(want[i]), len(returns[i]))
+			}
+			for j, w := range want[i] {
+				if !returns[i][j].Equal(decimal.RequireFromString(w)) {
+					t.Errorf("策略 %d 第 %d 个收益率预期为 %s，实际为 %s", i, j, w, returns[i][j])
+				}
+			}
+		}
+	})
+
+	t.Run("策略回测使用组合的风控规则", func(t *testing.T) {
+		portfolio := NewPortfolio(&PortfolioConfig{
+			InitialBalance: decimal.NewFromInt(1000),
+			Risk:           &risk.Config{MaxPositionNotional: decimal.NewFromInt(100)},
+		},
+			&PortfolioStrategy{Name: "持有", Strategy: newPartialBuyStrategy("持有", 1), Repository: hourlyKlines("100", "100"), Interval: interval.Hour1()},
+		)
+
+		if _, err := portfolio.Run(context.Background()); err != nil {
+			t.Fatalf("组合回测失败: %v", err)
+		}
+		if position := portfolio.sleeves[0].Strategy.Position().Amount; !position.Equal(decimal.NewFromInt(1)) {
+			t.Errorf("预期持仓被风控缩减为 1，实际为 %s", position)
+		}
+	})
+}
+
+func TestAllocationWeights(t *testing.T) {
+	series := func(values ...float64) []decimal.Decimal {
+		var result []decimal.Decimal
+		for _, v := range values {
+			result = append(result, decimal.NewFromFloat(v))
+		}
+		return result
+	}
+	approx := func(a decimal.Decimal, b float64) bool {
+		return math.Abs(a.InexactFloat64()-b) < 1e-6
+	}
+
+	t.Run("固定权重归一化", func(t *testing.T) {
+		weights := normalize([]decimal.Decimal{decimal.NewFromInt(1), decimal.NewFromInt(3)})
+		if !approx(weights[0], 0.25) || !approx(weights[1], 0.75) {
+			t.Errorf("预期 0.25 和 0.75，实际为 %s 和 %s", weights[0], weights[1])
+		}
+
+		weights = normalize([]decimal.Decimal{decimal.Zero, decimal.Zero})
+		if !approx(weights[0], 0.5) || !approx(weights[1], 0.5) {
+			t.Errorf("预期权重全为零时等权，实际为 %s 和 %s", weights[0], weights[1])
+		}
+	})
+
+	a := series(0.01, -0.01, 0.01, -0.01)
+	b := series(0.02, -0.02, 0.02, -0.02)
+	c := series(-0.02, 0.02, -0.02, 0.02)
+
+	t.Run("等风险权重与波动率成反比", func(t *testing.T) {
+		weights := inverseVolatilityWeights([][]decimal.Decimal{a, b})
+		if !approx(weights[0], 2.0/3) || !approx(weights[1], 1.0/3) {
+			t.Errorf("预期 2/3 和 1/3，实际为 %s 和 %s", weights[0], weights[1])
+		}
+
+		if weights := inverseVolatilityWeights([][]decimal.Decimal{a, series(0, 0, 0, 0)}); weights != nil {
+			t.Errorf("预期波动率为零时返回 nil")
+		}
+	})
+
+	t.Run("风险平价的风险贡献相等", func(t *testing.T) {
+		flat := series(0.01, 0.01, -0.01, -0.01)
+		cov := covariance([][]decimal.Decimal{a, b, flat})
+		weights := riskParityWeights(cov)
+		if weights == nil {
+			t.Fatalf("预期计算出风险平价权重")
+		}
+
+		var contributions []float64
+		for i := range cov {
+			var marginal float64
+			for j := range cov {
+				marginal += cov[i][j] * weights[j].InexactFloat64()
+			}
+			contributions = append(contributions, weights[i].InexactFloat64()*marginal)
+		}
+		for i := 1; i < len(contributions); i++ {
+			if math.Abs(contributions[i]-contributions[0]) > 1e-8 {
+				t.Errorf("预期风险贡献相等，实际为 %v", contributions)
+				break
+			}
+		}
+	})
+
+	t.Run("相关系数", func(t *testing.T) {
+		matrix := correlation([][]decimal.Decimal{a, b, c})
+		if !approx(matrix[0][1], 1) {
+			t.Errorf("预期完全正相关，实际为 %s", matrix[0][1])
+		}
+		if !approx(matrix[0][2], -1) {
+			t.Errorf("预期完全负相关，实际为 %s", matrix[0][2])
+		}
+		if !matrix[1][1].Equal(decimal.NewFromInt(1)) {
+			t.Errorf("预期对角线为 1，实际为 %s", matrix[1][1])
+		}
+	})
+}
@@ -0,0 +1,123 @@
+package backtest
+
+import (
+	"context"
+	"snake/internal/kline"
+	"snake/internal/kline/interval"
+	"snake/internal/strategy"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+// timeframeStrategy 记录每个周期收到的K线
+type timeframeStrategy struct {
+	*strategy.BaseStrategy
+	received []*kline.IntervalKline
+	updates  int
+}
+
+func (s *timeframeStrategy) Intervals() []interval.Interval {
+	return []interval.Interval{interval.Hour2(), interval.Hour4()}
+}
+
+func (s *timeframeStrategy) UpdateInterval(iv interval.Interval, k *kline.Kline) (*strategy.Signal, error) {
+	s.received = append(s.received, &kline.IntervalKline{Interval: iv, Kline: k})
+	return s.Hold(), nil
+}
+
+func (s *timeframeStrategy) Update(k *kline.Kline) (*strategy.Signal, error) {
+	s.updates++
+	return s.Hold(), nil
+}
+
+func TestBacktestMultiTimeframe(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &timeframeStrategy{BaseStrategy: strategy.NewBaseStrategy(ctx, cancel, "mtf")}
+
+	b := New(&Config{
+		InitialBalance: decimal.NewFromInt(1000),
+		Interval:       interval.Hour1(),
+	}, hourlyKlines("1", "2", "3", "4", "5", "6", "7", "8"), s)
+
+	if _, err := b.Run(context.Background()); err != nil {
+		t.Fatalf("回测失败: %v", err)
+	}
+
+	if s.updates != 0 {
+		t.Errorf("多周期策略不应再调用 Update，实际调用 %d 次", s.updates)
+	}
+
+	var count = map[interval.Interval]int{}
+	var lastBase *kline.Kline
+	for _, k := range s.received {
+		count[k.Interval]++
+		if k.Interval == interval.Hour1() {
+			lastBase = k.Kline
+			continue
+		}
+
+		// 高周期K线推送时，对应的基础K线必须已经收盘，且先于同一时刻收盘的基础K线推送
+		if lastBase != nil && lastBase.E >= k.E {
+			t.Errorf("%s K线 %d 在基础K线 %d 之后推送", k.Interval, k.E, lastBase.E)
+		}
+	}
+	if count[interval.Hour1()] != 8 || count[interval.Hour2()] != 4 || count[interval.Hour4()] != 2 {
+		t.Fatalf("预期 8/4/2 根K线，实际为 %v", count)
+	}
+
+	first := s.received[0]
+	if first.Interval != interval.Hour1() || !first.C.Equal(decimal.NewFromInt(1)) {
+		t.Errorf("预期第一根为基础周期K线")
+	}
+
+	// 第二根基础K线收盘时，2 小时K线先收盘
+	second := s.received[1]
+	if second.Interval != interval.Hour2() || !second.O.Equal(decimal.NewFromInt(1)) || !second.C.Equal(decimal.NewFromInt(2)) {
+		t.Errorf("预期 2 小时K线合成第 1、2 根基础K线，实际为 %s %+v", second.Interval, second.Kline)
+	}
+}
+
+// intrabarStrategy 盘中价格跌破阈值时买入
+type intrabarStrategy struct {
+	*strategy.BaseStrategy
+	threshold decimal.Decimal
+	snapshots int
+}
+
+func (s *intrabarStrategy) Mode() strategy.UpdateMode { return strategy.UpdateIntrabar }
+
+func (s *intrabarStrategy) UpdateIntrabar(k *kline.Kline) (*strategy.Signal, error) {
+	s.snapshots++
+	if s.Position().Amount.IsZero() && k.C.LessThanOrEqual(s.threshold) {
+		if signal := s.Buy(s.Balance().Amount, k.C); signal != nil {
+			return signal, nil
+		}
+	}
+	return s.Hold(), nil
+}
+
+func TestBacktestIntrabar(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &intrabarStrategy{BaseStrategy: strategy.NewBaseStrategy(ctx, cancel, "intrabar"), threshold: decimal.NewFromInt(95)}
+
+	repository := hourlyKlines("100", "100")
+	// 第二根K线盘中跌到 90 后收回 100
+	repository.klines[1].Low = "90"
+
+	b := New(&Config{
+		InitialBalance: decimal.NewFromInt(1000),
+		Interval:       interval.Hour1(),
+	}, repository, s)
+
+	if _, err := b.Run(context.Background()); err != nil {
+		t.Fatalf("回测失败: %v", err)
+	}
+
+	if s.snapshots != 6 {
+		t.Errorf("预期每根K线 3 个盘中快照，实际共 %d 个", s.snapshots)
+	}
+	if len(b.trades) != 1 || !b.trades[0].Price.Equal(decimal.NewFromInt(90)) {
+		t.Fatalf("预期按盘中最低价 90 买入一次，实际交易 %d 次", len(b.trades))
+	}
+}
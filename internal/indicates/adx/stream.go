@@ -0,0 +1,161 @@
+package adx
+
+import (
+	"fmt"
+	"snake/internal/indicates"
+	"snake/internal/indicates/atr"
+	"snake/internal/indicates/ma"
+	"snake/internal/kline"
+
+	"github.com/shopspring/decimal"
+)
+
+// Stream 流式平均趋向指标（ADX）及趋向指标（DMI），全部使用 Wilder 平滑：
+//
+//	+DM = 最高价 - 前最高价，-DM = 前最低价 - 最低价，只保留较大且为正的一个，另一个为 0
+//	+DI = 100 * RMA(+DM) / RMA(TR)，-DI = 100 * RMA(-DM) / RMA(TR)
+//	DX  = 100 * |+DI - -DI| / (+DI + -DI)
+//	ADX = RMA(DX)
+type Stream struct {
+	period int
+	state  streamState
+}
+
+type streamState struct {
+	seq     indicates.Sequence
+	prev    bar // 上一根已收盘K线
+	last    bar // 最后一根K线
+	tr      ma.Average
+	plusDM  ma.Average
+	minusDM ma.Average
+	dx      ma.Average
+	plusDI  decimal.Decimal
+	minusDI decimal.Decimal
+	dxValue decimal.Decimal
+}
+
+type bar struct {
+	high  decimal.Decimal
+	low   decimal.Decimal
+	close decimal.Decimal
+}
+
+var _ indicates.Indicator = (*Stream)(nil)
+
+var hundred = decimal.NewFromInt(100)
+
+// NewStream 创建流式 ADX，常用周期为 14
+func NewStream(period int) *Stream {
+	s, _ := NewStreamWithPeriod(period)
+	return s
+}
+
+// NewStreamWithPeriod 创建流式 ADX，周期无效时返回错误
+func NewStreamWithPeriod(period int) (*Stream, error) {
+	if period < 1 {
+		return nil, fmt.Errorf("invalid adx period: %d", period)
+	}
+
+	s := &Stream{period: period}
+	s.Reset()
+	return s, nil
+}
+
+func (s *Stream) Name() string { return fmt.Sprintf("ADX%d", s.period) }
+
+func (s *Stream) Update(kline *kline.Kline) {
+	action := s.state.seq.Next(kline)
+	switch action {
+	case indicates.ActionIgnore:
+		return
+	case indicates.ActionAppend:
+		s.state.prev = s.state.last
+	}
+
+	s.state.last = bar{high: kline.H, low: kline.L, close: kline.C}
+	if s.state.seq.Count == 1 {
+		return
+	}
+
+	plusDM, minusDM := DirectionalMovement(kline, s.state.prev.high, s.state.prev.low)
+	s.state.tr.Push(action, atr.TrueRange(kline, s.state.prev.close))
+	s.state.plusDM.Push(action, plusDM)
+	s.state.minusDM.Push(action, minusDM)
+	if !s.state.tr.Ready() {
+		return
+	}
+
+	s.state.plusDI, s.state.minusDI = decimal.Zero, decimal.Zero
+	if tr := s.state.tr.Value(); !tr.IsZero() {
+		s.state.plusDI = s.state.plusDM.Value().Div(tr).Mul(hundred)
+		s.state.minusDI = s.state.minusDM.Value().Div(tr).Mul(hundred)
+	}
+
+	s.state.dxValue = decimal.Zero
+	if sum := s.state.plusDI.Add(s.state.minusDI); !sum.IsZero() {
+		s.state.dxValue = s.state.plusDI.Sub(s.state.minusDI).Abs().Div(sum).Mul(hundred)
+	}
+	s.state.dx.Push(action, s.state.dxValue)
+}
+
+// DirectionalMovement 计算趋向变动 +DM 和 -DM
+func DirectionalMovement(k *kline.Kline, prevHigh, prevLow decimal.Decimal) (plusDM, minusDM decimal.Decimal) {
+	up := k.H.Sub(prevHigh)
+	down := prevLow.Sub(k.L)
+
+	plusDM, minusDM = decimal.Zero, decimal.Zero
+	if up.GreaterThan(down) && up.IsPositive() {
+		plusDM = up
+	}
+	if down.GreaterThan(up) && down.IsPositive() {
+		minusDM = down
+	}
+	return plusDM, minusDM
+}
+
+// Value 返回 ADX
+func (s *Stream) Value() decimal.Decimal { return s.state.dx.Value() }
+
+func (s *Stream) Values() map[string]decimal.Decimal {
+	return map[string]decimal.Decimal{
+		"adx":      s.state.dx.Value(),
+		"plus_di":  s.state.plusDI,
+		"minus_di": s.state.minusDI,
+		"dx":       s.state.dxValue,
+	}
+}
+
+// Ready ADX 是否已就绪，+DI 和 -DI 在 period+1 根K线后即可用
+func (s *Stream) Ready() bool { return s.state.dx.Ready() }
+
+func (s *Stream) WarmUp() int { return 2 * s.period }
+
+func (s *Stream) Reset() {
+	newRMA := func() ma.Average {
+		average, _ := ma.NewAverage(ma.TypeRMA, s.period)
+		return average
+	}
+	s.state = streamState{tr: newRMA(), plusDM: newRMA(), minusDM: newRMA(), dx: newRMA()}
+}
+
+func (s *Stream) Snapshot() indicates.Snapshot {
+	state := s.state
+	state.tr = s.state.tr.Clone()
+	state.plusDM = s.state.plusDM.Clone()
+	state.minusDM = s.state.minusDM.Clone()
+	state.dx = s.state.dx.Clone()
+	return &state
+}
+
+func (s *Stream) Restore(snapshot indicates.Snapshot) error {
+	state, ok := snapshot.(*streamState)
+	if !ok {
+		return indicates.ErrSnapshotMismatch
+	}
+	s.state = *state
+	s.state.tr = state.tr.Clone()
+	s.state.plusDM = state.plusDM.Clone()
+	s.state.minusDM = state.minusDM.Clone()
+	s.state.dx = state.dx.Clone()
+	return nil
+}
@@ -0,0 +1,86 @@
+package adx
+
+import (
+	"snake/internal/indicates/indicatestest"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestDirectionalMovement(t *testing.T) {
+	tests := []struct {
+		name            string
+		bar             [3]float64
+		plusDM, minusDM string
+	}{
+		{"向上变动", [3]float64{12, 9.5, 11}, "1", "0"},
+		{"向下变动", [3]float64{10.5, 7, 8}, "0", "2"},
+		{"内包线", [3]float64{10.5, 9.5, 10}, "0", "0"},
+		{"外包线取较大的一个", [3]float64{13, 8.5, 10}, "2", "0"},
+		{"上下变动相等", [3]float64{12, 8, 10}, "0", "0"},
+	}
+
+	// 前一根K线最高价 11，最低价 9
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plusDM, minusDM := DirectionalMovement(indicatestest.HLC(tt.bar)[0], decimal.NewFromInt(11), decimal.NewFromInt(9))
+			if !plusDM.Equal(decimal.RequireFromString(tt.plusDM)) || !minusDM.Equal(decimal.RequireFromString(tt.minusDM)) {
+				t.Errorf("预期 +DM/-DM 为 %s/%s，实际为 %s/%s", tt.plusDM, tt.minusDM, plusDM, minusDM)
+			}
+		})
+	}
+}
+
+func TestStream(t *testing.T) {
+	klines := indicatestest.HLC(
+		[3]float64{10, 8, 9},
+		[3]float64{11, 9, 10},
+		[3]float64{12, 10, 11},
+		[3]float64{11, 8, 9},
+		[3]float64{10, 7, 8},
+	)
+
+	// 周期为 2：
+	// 第 3 根：TR 2,2 → 2，+DM 1,1 → 1，-DM 0,0 → 0；+DI 50，-DI 0，DX 100
+	// 第 4 根：TR (2+3)/2 = 2.5，+DM 0.5，-DM 1；+DI 20，-DI 40，DX 33.33，ADX (100+33.33)/2
+	// 第 5 根：TR 2.75，+DM 0.25，-DM 1；+DI 9.09，-DI 36.36，DX 60，ADX (66.67+60)/2
+	tests := []struct {
+		plusDI, minusDI, dx, adx string // 空字符串表示未计算
+	}{
+		{"", "", "", ""},
+		{"", "", "", ""},
+		{"50", "0", "100", ""},
+		{"20", "40", "33.3333333333", "66.6666666667"},
+		{"9.0909090909", "36.3636363636", "60", "63.3333333333"},
+	}
+
+	stream := NewStream(2)
+	if stream.Name() != "ADX2" || stream.WarmUp() != 4 {
+		t.Fatalf("预期名称 ADX2、WarmUp 为 4，实际为 %s、%d", stream.Name(), stream.WarmUp())
+	}
+
+	tolerance := decimal.New(1, -9)
+	for i, k := range klines {
+		stream.Update(k)
+		tt := tests[i]
+		if ready := tt.adx != ""; stream.Ready() != ready {
+			t.Fatalf("第 %d 根K线后预期就绪状态为 %v", i+1, ready)
+		}
+
+		values := stream.Values()
+		for _, field := range []struct{ key, expected string }{
+			{"plus_di", tt.plusDI}, {"minus_di", tt.minusDI}, {"dx", tt.dx}, {"adx", tt.adx},
+		} {
+			if field.expected == "" {
+				continue
+			}
+			if values[field.key].Sub(decimal.RequireFromString(field.expected)).Abs().GreaterThan(tolerance) {
+				t.Errorf("第 %d 根K线后预期 %s 为 %s，实际为 %s", i+1, field.key, field.expected, values[field.key])
+			}
+		}
+	}
+
+	if _, err := NewStreamWithPeriod(0); err == nil {
+		t.Errorf("预期周期为 0 时创建失败")
+	}
+}
@@ -0,0 +1,109 @@
+package atr
+
+import (
+	"fmt"
+	"snake/internal/indicates"
+	"snake/internal/indicates/ma"
+	"snake/internal/kline"
+
+	"github.com/shopspring/decimal"
+)
+
+// TrueRange 计算真实波幅：max(最高价-最低价, |最高价-前收盘价|, |最低价-前收盘价|)
+func TrueRange(k *kline.Kline, prevClose decimal.Decimal) decimal.Decimal {
+	return decimal.Max(k.H.Sub(k.L), k.H.Sub(prevClose).Abs(), k.L.Sub(prevClose).Abs())
+}
+
+// Stream 流式平均真实波幅（Average True Range）
+// 第一根K线没有前收盘价，不产生真实波幅，从第二根K线开始对真实波幅做平滑，
+// 默认使用 Wilder 平滑（ma.TypeRMA），也可以使用简单平均（ma.TypeSMA）等其他移动平均线
+type Stream struct {
+	kind   ma.Type
+	period int
+	state  streamState
+}
+
+type streamState struct {
+	seq       indicates.Sequence
+	average   ma.Average
+	prevClose decimal.Decimal // 上一根已收盘K线的收盘价
+	close     decimal.Decimal // 最后一根K线的收盘价
+	tr        decimal.Decimal // 最后一根K线的真实波幅
+}
+
+var _ indicates.Indicator = (*Stream)(nil)
+
+// NewStream 创建使用 Wilder 平滑的流式 ATR
+func NewStream(period int) *Stream {
+	s, _ := NewStreamWithMA(ma.TypeRMA, period)
+	return s
+}
+
+// NewStreamWithMA 创建使用指定移动平均线平滑的流式 ATR
+func NewStreamWithMA(kind ma.Type, period int) (*Stream, error) {
+	if _, err := ma.NewAverage(kind, period); err != nil {
+		return nil, err
+	}
+
+	s := &Stream{kind: kind, period: period}
+	s.Reset()
+	return s, nil
+}
+
+// Name Wilder 平滑时为 ATR<period>，否则为 ATR<period>_<type>，如 ATR14_SMA
+func (s *Stream) Name() string {
+	if s.kind == ma.TypeRMA {
+		return fmt.Sprintf("ATR%d", s.period)
+	}
+	return fmt.Sprintf("ATR%d_%s", s.period, s.kind)
+}
+
+func (s *Stream) Update(kline *kline.Kline) {
+	action := s.state.seq.Next(kline)
+	switch action {
+	case indicates.ActionIgnore:
+		return
+	case indicates.ActionAppend:
+		s.state.prevClose = s.state.close
+	}
+
+	s.state.close = kline.C
+	if s.state.seq.Count == 1 {
+		return
+	}
+
+	s.state.tr = TrueRange(kline, s.state.prevClose)
+	s.state.average.Push(action, s.state.tr)
+}
+
+// Value 返回 ATR 值
+func (s *Stream) Value() decimal.Decimal { return s.state.average.Value() }
+
+func (s *Stream) Values() map[string]decimal.Decimal {
+	return map[string]decimal.Decimal{"atr": s.state.average.Value(), "tr": s.state.tr}
+}
+
+func (s *Stream) Ready() bool { return s.state.average.Ready() }
+
+func (s *Stream) WarmUp() int { return s.state.average.WarmUp() + 1 }
+
+func (s *Stream) Reset() {
+	average, _ := ma.NewAverage(s.kind, s.period)
+	s.state = streamState{average: average}
+}
+
+func (s *Stream) Snapshot() indicates.Snapshot {
+	state := s.state
+	state.average = s.state.average.Clone()
+	return &state
+}
+
+func (s *Stream) Restore(snapshot indicates.Snapshot) error {
+	state, ok := snapshot.(*streamState)
+	if !ok {
+		return indicates.ErrSnapshotMismatch
+	}
+	s.state = *state
+	s.state.average = state.average.Clone()
+	return nil
+}
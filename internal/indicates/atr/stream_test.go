@@ -0,0 +1,84 @@
+package atr
+
+import (
+	"snake/internal/indicates/indicatestest"
+	"snake/internal/indicates/ma"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestTrueRange(t *testing.T) {
+	tests := []struct {
+		name      string
+		bar       [3]float64
+		prevClose float64
+		expected  string
+	}{
+		{"最高价减最低价", [3]float64{12, 10, 11}, 11, "2"},
+		{"向上跳空", [3]float64{15, 14, 14.5}, 11, "4"},
+		{"向下跳空", [3]float64{9, 8, 8.5}, 11, "3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := indicatestest.HLC(tt.bar)[0]
+			if tr := TrueRange(k, decimal.NewFromFloat(tt.prevClose)); !tr.Equal(decimal.RequireFromString(tt.expected)) {
+				t.Errorf("预期 %s，实际为 %s", tt.expected, tr)
+			}
+		})
+	}
+}
+
+func TestStream(t *testing.T) {
+	// 真实波幅依次为 2, 4, 3, 2, 5
+	klines := indicatestest.HLC(
+		[3]float64{11, 9, 10},
+		[3]float64{11, 9, 10},
+		[3]float64{14, 12, 13},
+		[3]float64{12, 10, 11},
+		[3]float64{12, 10, 11},
+		[3]float64{16, 13, 15},
+	)
+
+	tests := []struct {
+		name     string
+		kind     ma.Type
+		expected []string // 每根K线后的 ATR，空字符串表示未就绪
+	}{
+		// 初始值 (2+4+3)/3 = 3，之后 (2*3+2)/3 = 2.6667，(2*2.6667+5)/3 = 3.4444
+		{"Wilder", ma.TypeRMA, []string{"", "", "", "3", "2.6666666666666667", "3.4444444444444445"}},
+		{"简单平均", ma.TypeSMA, []string{"", "", "", "3", "3", "3.3333333333333333"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream, err := NewStreamWithMA(tt.kind, 3)
+			if err != nil {
+				t.Fatalf("创建失败: %v", err)
+			}
+			if stream.WarmUp() != 4 {
+				t.Fatalf("预期 WarmUp 为 4，实际为 %d", stream.WarmUp())
+			}
+
+			for i, k := range klines {
+				stream.Update(k)
+				if tt.expected[i] == "" {
+					if stream.Ready() {
+						t.Fatalf("第 %d 根K线后预期未就绪", i+1)
+					}
+					continue
+				}
+
+				expected := decimal.RequireFromString(tt.expected[i])
+				if stream.Value().Sub(expected).Abs().GreaterThan(decimal.New(1, -12)) {
+					t.Errorf("第 %d 根K线后预期 %s，实际为 %s", i+1, expected, stream.Value())
+				}
+			}
+		})
+	}
+
+	if NewStream(14).Name() != "ATR14" {
+		t.Errorf("预期名称 ATR14，实际为 %s", NewStream(14).Name())
+	}
+}
@@ -9,35 +9,50 @@ import (
 )
 
 type BB struct {
-	count     int
-	prices    []decimal.Decimal
-	MA        decimal.Decimal // 中轨
-	Upper     decimal.Decimal // 上轨
-	Lower     decimal.Decimal // 下轨
-	Timestamp int64
-	LastPrice decimal.Decimal // 最新价格
+	count      int
+	prices     []decimal.Decimal
+	multiplier decimal.Decimal
+	deviation  Deviation
+	MA         decimal.Decimal // 中轨
+	Upper      decimal.Decimal // 上轨
+	Lower      decimal.Decimal // 下轨
+	PercentB   decimal.Decimal // 最新价格的 %B
+	Bandwidth  decimal.Decimal // 带宽
+	Timestamp  int64
+	LastPrice  decimal.Decimal // 最新价格
 }
 
+// New 按 2 倍总体标准差计算布林带
 func New(klines ...*kline.Kline) *BB {
+	return NewWithParams(decimal.NewFromInt(2), DeviationPopulation, klines...)
+}
+
+// NewWithParams 按指定的标准差倍数和计算方式计算布林带
+func NewWithParams(multiplier decimal.Decimal, deviation Deviation, klines ...*kline.Kline) *BB {
 	var prices = collector.Slice(klines, func(_ int, k *kline.Kline) (bool, decimal.Decimal) {
 		return true, k.C
 	})
 	var count = len(klines)
-	var ma = math.AverageDecimals(prices...)
+	var bb = &BB{count: count, multiplier: multiplier, deviation: deviation}
+	bb.calculate(prices, klines[count-1])
+	return bb
+}
+
+// calculate 按窗口内的价格计算布林带，kline 为窗口内最后一根K线
+func (b *BB) calculate(prices []decimal.Decimal, kline *kline.Kline) {
 	var std = math.StandardDeviation(prices...)
-	var upper = ma.Add(std.Mul(decimal.NewFromInt(2)))
-	var lower = ma.Sub(std.Mul(decimal.NewFromInt(2)))
-	var ts = klines[count-1].E
-	var lastPrice = klines[count-1].C
-	return &BB{
-		count:     count,
-		prices:    prices,
-		MA:        ma,
-		Upper:     upper,
-		Lower:     lower,
-		Timestamp: ts,
-		LastPrice: lastPrice,
+	if b.deviation == DeviationSample {
+		std = math.SampleStandardDeviation(prices...)
 	}
+
+	b.prices = prices
+	b.MA = math.AverageDecimals(prices...)
+	b.Upper = b.MA.Add(std.Mul(b.multiplier))
+	b.Lower = b.MA.Sub(std.Mul(b.multiplier))
+	b.PercentB = PercentB(kline.C, b.Upper, b.Lower)
+	b.Bandwidth = Bandwidth(b.Upper, b.MA, b.Lower)
+	b.Timestamp = kline.E
+	b.LastPrice = kline.C
 }
 
 // NextKline 计算下一个 Kline 对应的布林带
@@ -63,18 +78,7 @@ func (b *BB) NextKline(kline *kline.Kline) *BB {
 	}
 
 	// 计算新的布林带
-	var ma = math.AverageDecimals(prices...)
-	var std = math.StandardDeviation(prices...)
-	var upper = ma.Add(std.Mul(decimal.NewFromInt(2)))
-	var lower = ma.Sub(std.Mul(decimal.NewFromInt(2)))
-
-	return &BB{
-		count:     b.count,
-		prices:    prices,
-		MA:        ma,
-		Upper:     upper,
-		Lower:     lower,
-		Timestamp: kline.E,
-		LastPrice: kline.C,
-	}
+	var next = &BB{count: b.count, multiplier: b.multiplier, deviation: b.deviation}
+	next.calculate(prices, kline)
+	return next
 }
@@ -0,0 +1,82 @@
+package bollingband
+
+import (
+	"fmt"
+	"snake/internal/indicates/ma"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// Deviation 标准差的计算方式
+type Deviation string
+
+const (
+	// DeviationPopulation 总体标准差（除以 n），与大多数行情软件一致
+	DeviationPopulation Deviation = "POPULATION"
+	// DeviationSample 样本标准差（除以 n-1），同样周期下带宽略大
+	DeviationSample Deviation = "SAMPLE"
+)
+
+func (d Deviation) String() string { return string(d) }
+
+// ParseDeviation 解析标准差的计算方式，不区分大小写，空字符串为总体标准差
+func ParseDeviation(s string) (Deviation, error) {
+	switch Deviation(strings.ToUpper(s)) {
+	case "", DeviationPopulation:
+		return DeviationPopulation, nil
+	case DeviationSample:
+		return DeviationSample, nil
+	}
+	return "", fmt.Errorf("unknown deviation: %s", s)
+}
+
+// Config 布林带参数
+type Config struct {
+	// MA 中轨的移动平均线类型
+	MA     ma.Type
+	Period int
+	// Multiplier 上下轨距离中轨的标准差倍数
+	Multiplier decimal.Decimal
+	Deviation  Deviation
+}
+
+// DefaultConfig 返回常用参数：20 周期简单移动平均线，2 倍总体标准差
+func DefaultConfig() Config {
+	return Config{MA: ma.TypeSMA, Period: 20, Multiplier: decimal.NewFromInt(2), Deviation: DeviationPopulation}
+}
+
+// Validate 检查参数是否有效
+func (c Config) Validate() error {
+	if _, err := ma.NewAverage(c.MA, c.Period); err != nil {
+		return err
+	}
+	if !c.Multiplier.IsPositive() {
+		return fmt.Errorf("invalid bollinger multiplier: %s", c.Multiplier)
+	}
+	if c.Deviation != DeviationPopulation && c.Deviation != DeviationSample {
+		return fmt.Errorf("unknown deviation: %s", c.Deviation)
+	}
+	if c.Deviation == DeviationSample && c.Period < 2 {
+		return fmt.Errorf("invalid bollinger period for sample deviation: %d", c.Period)
+	}
+	return nil
+}
+
+// PercentB 计算 %B = (价格 - 下轨) / (上轨 - 下轨)：价格在下轨时为 0，在上轨时为 1，
+// 超出通道时小于 0 或大于 1；上下轨重合时为 0.5
+func PercentB(price, upper, lower decimal.Decimal) decimal.Decimal {
+	width := upper.Sub(lower)
+	if width.IsZero() {
+		return decimal.NewFromFloat(0.5)
+	}
+	return price.Sub(lower).Div(width)
+}
+
+// Bandwidth 计算带宽 = (上轨 - 下轨) / 中轨，中轨为零时为零
+func Bandwidth(upper, middle, lower decimal.Decimal) decimal.Decimal {
+	if middle.IsZero() {
+		return decimal.Zero
+	}
+	return upper.Sub(lower).Div(middle)
+}
@@ -0,0 +1,82 @@
+package bollingband
+
+import (
+	"snake/internal/indicates/ma"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestParseDeviation(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Deviation
+		wantErr bool
+	}{
+		{input: "", want: DeviationPopulation},
+		{input: "population", want: DeviationPopulation},
+		{input: "SAMPLE", want: DeviationSample},
+		{input: "std", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDeviation(tt.input)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseDeviation(%q) = %s, %v，预期 %s", tt.input, got, err, tt.want)
+		}
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(c *Config)
+		wantErr bool
+	}{
+		{name: "默认参数", modify: func(c *Config) {}},
+		{name: "EMA 中轨", modify: func(c *Config) { c.MA = ma.TypeEMA }},
+		{name: "周期为零", modify: func(c *Config) { c.Period = 0 }, wantErr: true},
+		{name: "倍数为零", modify: func(c *Config) { c.Multiplier = decimal.Zero }, wantErr: true},
+		{name: "未知的标准差", modify: func(c *Config) { c.Deviation = "std" }, wantErr: true},
+		{name: "样本标准差周期为 1", modify: func(c *Config) { c.Period, c.Deviation = 1, DeviationSample }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			tt.modify(&config)
+			if err := config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("预期错误为 %v，实际为 %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestPercentBAndBandwidth(t *testing.T) {
+	upper, middle, lower := decimal.NewFromInt(110), decimal.NewFromInt(100), decimal.NewFromInt(90)
+	tests := []struct {
+		price decimal.Decimal
+		want  string
+	}{
+		{price: decimal.NewFromInt(90), want: "0"},
+		{price: decimal.NewFromInt(100), want: "0.5"},
+		{price: decimal.NewFromInt(110), want: "1"},
+		{price: decimal.NewFromInt(115), want: "1.25"},
+		{price: decimal.NewFromInt(85), want: "-0.25"},
+	}
+	for _, tt := range tests {
+		if got := PercentB(tt.price, upper, lower); got.String() != tt.want {
+			t.Errorf("价格 %s 的 %%B 为 %s，预期 %s", tt.price, got, tt.want)
+		}
+	}
+
+	if got := PercentB(middle, middle, middle); got.String() != "0.5" {
+		t.Errorf("上下轨重合时预期 %%B 为 0.5，实际为 %s", got)
+	}
+	if got := Bandwidth(upper, middle, lower); got.String() != "0.2" {
+		t.Errorf("预期带宽为 0.2，实际为 %s", got)
+	}
+	if got := Bandwidth(upper, decimal.Zero, lower); !got.IsZero() {
+		t.Errorf("中轨为零时预期带宽为零，实际为 %s", got)
+	}
+}
@@ -0,0 +1,144 @@
+package bollingband
+
+import (
+	"fmt"
+	"snake/internal/indicates"
+	"snake/internal/indicates/ma"
+	"snake/internal/kline"
+	"snake/pkg/math"
+
+	"github.com/shopspring/decimal"
+)
+
+// Stream 流式布林带，用滑动窗口的和与平方和计算标准差，每根K线 O(1)
+// 中轨默认为简单移动平均线，也可以使用其他类型的移动平均线
+type Stream struct {
+	config Config
+	state  streamState
+}
+
+type streamState struct {
+	seq      indicates.Sequence
+	average  ma.Average
+	variance indicates.RollingVariance
+	close    decimal.Decimal
+	middle   decimal.Decimal
+	upper    decimal.Decimal
+	lower    decimal.Decimal
+}
+
+var _ indicates.Indicator = (*Stream)(nil)
+
+// NewStream 创建流式布林带，multiplier 为标准差倍数（通常为 2）
+func NewStream(period int, multiplier decimal.Decimal) *Stream {
+	s, _ := NewStreamWithMA(ma.TypeSMA, period, multiplier)
+	return s
+}
+
+// NewStreamWithMA 创建以指定类型移动平均线为中轨的流式布林带
+func NewStreamWithMA(kind ma.Type, period int, multiplier decimal.Decimal) (*Stream, error) {
+	return NewStreamWithConfig(Config{MA: kind, Period: period, Multiplier: multiplier, Deviation: DeviationPopulation})
+}
+
+// NewStreamWithConfig 按参数创建流式布林带，参数无效时返回错误
+func NewStreamWithConfig(config Config) (*Stream, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	s := &Stream{config: config}
+	s.Reset()
+	return s, nil
+}
+
+// Name 中轨为简单移动平均线时为 BB<period>，否则为 BB<period>_<type>，如 BB20_EMA；
+// 标准差倍数不是 2 时加上倍数，使用样本标准差时加上 _SAMPLE，如 BB20_2.5_SAMPLE
+func (s *Stream) Name() string {
+	name := fmt.Sprintf("BB%d", s.config.Period)
+	if s.config.MA != ma.TypeSMA {
+		name += "_" + s.config.MA.String()
+	}
+	if !s.config.Multiplier.Equal(decimal.NewFromInt(2)) {
+		name += "_" + s.config.Multiplier.String()
+	}
+	if s.config.Deviation == DeviationSample {
+		name += "_" + s.config.Deviation.String()
+	}
+	return name
+}
+
+func (s *Stream) Update(kline *kline.Kline) {
+	action := s.state.seq.Next(kline)
+	if action == indicates.ActionIgnore {
+		return
+	}
+
+	s.state.average.Push(action, kline.C)
+	s.state.variance.Push(action, kline.C)
+	s.state.close = kline.C
+	if !s.Ready() {
+		return
+	}
+
+	variance := s.state.variance.Variance()
+	if s.config.Deviation == DeviationSample {
+		variance = s.state.variance.SampleVariance()
+	}
+	width := math.Sqrt(variance).Mul(s.config.Multiplier)
+	s.state.middle = s.state.average.Value()
+	s.state.upper = s.state.middle.Add(width)
+	s.state.lower = s.state.middle.Sub(width)
+}
+
+// Value 返回中轨
+func (s *Stream) Value() decimal.Decimal { return s.state.middle }
+
+// PercentB 返回最后一根K线收盘价的 %B，未就绪时为零
+func (s *Stream) PercentB() decimal.Decimal {
+	if !s.Ready() {
+		return decimal.Zero
+	}
+	return PercentB(s.state.close, s.state.upper, s.state.lower)
+}
+
+// Bandwidth 返回带宽，未就绪时为零
+func (s *Stream) Bandwidth() decimal.Decimal {
+	return Bandwidth(s.state.upper, s.state.middle, s.state.lower)
+}
+
+func (s *Stream) Values() map[string]decimal.Decimal {
+	return map[string]decimal.Decimal{
+		"upper":     s.state.upper,
+		"middle":    s.state.middle,
+		"lower":     s.state.lower,
+		"percent_b": s.PercentB(),
+		"bandwidth": s.Bandwidth(),
+	}
+}
+
+func (s *Stream) Ready() bool { return s.state.variance.Full() && s.state.average.Ready() }
+
+func (s *Stream) WarmUp() int { return max(s.config.Period, s.state.average.WarmUp()) }
+
+func (s *Stream) Reset() {
+	average, _ := ma.NewAverage(s.config.MA, s.config.Period)
+	s.state = streamState{average: average, variance: indicates.NewRollingVariance(s.config.Period)}
+}
+
+func (s *Stream) Snapshot() indicates.Snapshot {
+	state := s.state
+	state.average = s.state.average.Clone()
+	state.variance = s.state.variance.Clone()
+	return &state
+}
+
+func (s *Stream) Restore(snapshot indicates.Snapshot) error {
+	state, ok := snapshot.(*streamState)
+	if !ok {
+		return indicates.ErrSnapshotMismatch
+	}
+	s.state = *state
+	s.state.average = state.average.Clone()
+	s.state.variance = state.variance.Clone()
+	return nil
+}
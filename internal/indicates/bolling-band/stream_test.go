@@ -0,0 +1,81 @@
+package bollingband
+
+import (
+	"snake/internal/indicates/ma"
+	"snake/internal/kline"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+// sequentialKlines 按收盘价生成连续的 1 分钟K线
+func sequentialKlines(closes ...float64) []*kline.Kline {
+	klines := make([]*kline.Kline, len(closes))
+	for i, c := range closes {
+		price := decimal.NewFromFloat(c)
+		klines[i] = &kline.Kline{O: price, C: price, H: price, L: price, S: int64(i) * 60000, E: int64(i)*60000 + 59999}
+	}
+	return klines
+}
+
+func TestStream(t *testing.T) {
+	klines := sequentialKlines(10, 12, 11, 15, 14, 18, 20, 19, 17, 16)
+	stream := NewStream(5, decimal.NewFromInt(2))
+
+	for i, k := range klines {
+		stream.Update(k)
+		if i < 4 {
+			continue
+		}
+
+		expected := New(klines[i-4 : i+1]...)
+		values := stream.Values()
+		if !values["middle"].Equal(expected.MA) || !values["upper"].Equal(expected.Upper) || !values["lower"].Equal(expected.Lower) {
+			t.Errorf("第 %d 根K线后预期 %s/%s/%s，实际为 %v", i+1, expected.Upper, expected.MA, expected.Lower, values)
+		}
+	}
+}
+
+func TestStreamWithConfig(t *testing.T) {
+	klines := sequentialKlines(10, 12, 11, 15, 14, 18, 20, 19, 17, 16)
+	multiplier := decimal.NewFromFloat(2.5)
+	stream, err := NewStreamWithConfig(Config{MA: ma.TypeSMA, Period: 5, Multiplier: multiplier, Deviation: DeviationSample})
+	if err != nil {
+		t.Fatalf("创建布林带失败: %v", err)
+	}
+	if stream.Name() != "BB5_2.5_SAMPLE" {
+		t.Errorf("预期名称为 BB5_2.5_SAMPLE，实际为 %s", stream.Name())
+	}
+
+	for i, k := range klines {
+		stream.Update(k)
+		if i < 4 {
+			if !stream.PercentB().IsZero() || !stream.Bandwidth().IsZero() {
+				t.Errorf("第 %d 根K线后未就绪，预期 %%B 和带宽为零", i+1)
+			}
+			continue
+		}
+
+		expected := NewWithParams(multiplier, DeviationSample, klines[i-4:i+1]...)
+		values := stream.Values()
+		for name, want := range map[string]decimal.Decimal{
+			"upper":     expected.Upper,
+			"middle":    expected.MA,
+			"lower":     expected.Lower,
+			"percent_b": expected.PercentB,
+			"bandwidth": expected.Bandwidth,
+		} {
+			if !values[name].Round(8).Equal(want.Round(8)) {
+				t.Errorf("第 %d 根K线后预期 %s 为 %s，实际为 %s", i+1, name, want, values[name])
+			}
+		}
+	}
+
+	// 样本标准差比总体标准差大 sqrt(n/(n-1)) 倍
+	population := New(klines[5:]...)
+	sample := NewWithParams(decimal.NewFromInt(2), DeviationSample, klines[5:]...)
+	ratio := sample.Upper.Sub(sample.MA).Div(population.Upper.Sub(population.MA))
+	if !ratio.Round(6).Equal(decimal.NewFromFloat(1.118034)) {
+		t.Errorf("预期样本与总体标准差之比为 sqrt(5/4)，实际为 %s", ratio)
+	}
+}
@@ -0,0 +1,304 @@
+package catalog
+
+import (
+	"fmt"
+	"slices"
+	"snake/internal/indicates"
+	"snake/internal/indicates/adx"
+	"snake/internal/indicates/atr"
+	bollingband "snake/internal/indicates/bolling-band"
+	"snake/internal/indicates/cmf"
+	"snake/internal/indicates/delta"
+	"snake/internal/indicates/divergence"
+	donchianchannel "snake/internal/indicates/donchian-channel"
+	"snake/internal/indicates/ichimoku"
+	"snake/internal/indicates/keltner"
+	"snake/internal/indicates/ma"
+	"snake/internal/indicates/macd"
+	"snake/internal/indicates/mfi"
+	"snake/internal/indicates/obv"
+	"snake/internal/indicates/patterns"
+	"snake/internal/indicates/pivot"
+	"snake/internal/indicates/psar"
+	"snake/internal/indicates/rsi"
+	"snake/internal/indicates/squeeze"
+	"snake/internal/indicates/stochastic"
+	"snake/internal/indicates/stochrsi"
+	"snake/internal/indicates/supertrend"
+	"snake/internal/indicates/vwap"
+	"snake/internal/kline/interval"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// MaxPeriod 整数参数（周期、窗口等）的上限，指标按窗口预先分配内存，过大的参数可能耗尽内存
+const MaxPeriod = 5000
+
+// Params 指标参数，键为参数名，值为字符串形式的参数值，例如 {"period": "14", "ma": "ema"}
+type Params map[string]string
+
+// builder 按参数创建指标
+type builder func(a *args) (indicates.Indicator, error)
+
+// builders 按名称（小写）注册的指标，参数缺省时使用各指标的常用参数
+var builders = map[string]builder{
+	"ma": func(a *args) (indicates.Indicator, error) {
+		return ma.NewTypedStream(a.maType("type", ma.TypeSMA), a.int("period", 20))
+	},
+	"bollinger": func(a *args) (indicates.Indicator, error) {
+		deviation, err := bollingband.ParseDeviation(a.string("deviation", ""))
+		if err != nil {
+			return nil, err
+		}
+		return bollingband.NewStreamWithConfig(bollingband.Config{
+			MA:         a.maType("ma", ma.TypeSMA),
+			Period:     a.int("period", 20),
+			Multiplier: a.decimal("multiplier", "2"),
+			Deviation:  deviation,
+		})
+	},
+	"squeeze": func(a *args) (indicates.Indicator, error) {
+		return squeeze.NewStreamWithConfig(squeeze.Config{
+			MA:           a.maType("ma", ma.TypeSMA),
+			Period:       a.int("period", 20),
+			BBMultiplier: a.decimal("bb_multiplier", "2"),
+			KCMultiplier: a.decimal("kc_multiplier", "1.5"),
+			ATRPeriod:    a.int("atr_period", 20),
+		})
+	},
+	"donchian": func(a *args) (indicates.Indicator, error) {
+		period := a.period("period", 20)
+		return donchianchannel.NewStream(period), nil
+	},
+	"keltner": func(a *args) (indicates.Indicator, error) {
+		return keltner.NewStreamWithMA(a.maType("ma", ma.TypeEMA), a.int("period", 20), a.int("atr_period", 10), a.decimal("multiplier", "2"))
+	},
+	"rsi": func(a *args) (indicates.Indicator, error) {
+		period := a.period("period", 14)
+		return rsi.NewStream(period), nil
+	},
+	"macd": func(a *args) (indicates.Indicator, error) {
+		return macd.NewStreamWithMA(a.maType("ma", ma.TypeEMA), a.int("fast", 12), a.int("slow", 26), a.int("signal", 9))
+	},
+	"atr": func(a *args) (indicates.Indicator, error) {
+		return atr.NewStreamWithMA(a.maType("ma", ma.TypeRMA), a.int("period", 14))
+	},
+	"stochastic": func(a *args) (indicates.Indicator, error) {
+		return stochastic.NewStreamWithMA(a.maType("ma", ma.TypeSMA), a.int("period", 14), a.int("k", 3), a.int("d", 3))
+	},
+	"stochrsi": func(a *args) (indicates.Indicator, error) {
+		return stochrsi.NewStreamWithMA(a.maType("ma", ma.TypeSMA), a.int("rsi_period", 14), a.int("period", 14), a.int("k", 3), a.int("d", 3))
+	},
+	"adx": func(a *args) (indicates.Indicator, error) {
+		return adx.NewStreamWithPeriod(a.int("period", 14))
+	},
+	"ichimoku": func(a *args) (indicates.Indicator, error) {
+		return ichimoku.NewStreamWithPeriod(a.int("tenkan", 9), a.int("kijun", 26), a.int("senkou_b", 52), a.int("displacement", 26))
+	},
+	"psar": func(a *args) (indicates.Indicator, error) {
+		return psar.NewStreamWithParams(a.decimal("start", "0.02"), a.decimal("step", "0.02"), a.decimal("max", "0.2"))
+	},
+	"supertrend": func(a *args) (indicates.Indicator, error) {
+		return supertrend.NewStreamWithParams(a.int("period", 10), a.decimal("multiplier", "3"))
+	},
+	"obv": func(a *args) (indicates.Indicator, error) {
+		return obv.NewStream(), nil
+	},
+	"cvd": func(a *args) (indicates.Indicator, error) {
+		return delta.NewStream(), nil
+	},
+	"cmf": func(a *args) (indicates.Indicator, error) {
+		return cmf.NewStreamWithPeriod(a.int("period", 20))
+	},
+	"mfi": func(a *args) (indicates.Indicator, error) {
+		return mfi.NewStreamWithPeriod(a.int("period", 14))
+	},
+	"vwap": func(a *args) (indicates.Indicator, error) {
+		anchor := a.interval("anchor", interval.Day1())
+		return vwap.NewStreamWithAnchor(anchor), nil
+	},
+	"patterns": func(a *args) (indicates.Indicator, error) {
+		return patterns.NewStream(), nil
+	},
+	"pivot": func(a *args) (indicates.Indicator, error) {
+		return pivot.NewStreamWithMethod(a.string("method", ""))
+	},
+	"swing": func(a *args) (indicates.Indicator, error) {
+		return pivot.NewSwingStreamWithParams(a.int("strength", 3), a.decimal("tolerance", "0.005"), a.int("max_zones", 10))
+	},
+}
+
+// sourced 可以用 source 参数指定价格来源的指标，只读取收盘价。
+// 其余指标读取最高价、最低价或成交量，换成单一来源后每根K线都没有振幅，结果没有意义
+var sourced = map[string]bool{"ma": true, "bollinger": true, "macd": true, "rsi": true}
+
+func init() {
+	// 背离检测按名称创建振荡指标，在 init 中注册以避免初始化循环
+	builders["divergence"] = newDivergence
+}
+
+// newDivergence 创建背离检测，振荡指标由 oscillator 指定（默认 rsi），其余参数同时传给振荡指标，
+// key 选择振荡指标的某个输出，例如 oscillator=macd&key=histogram
+func newDivergence(a *args) (indicates.Indicator, error) {
+	name := a.string("oscillator", "rsi")
+	key := a.string("key", "")
+	config := divergence.DefaultConfig()
+	config.Left = a.int("left", config.Left)
+	config.Right = a.int("right", config.Right)
+	config.MinDistance = a.int("min_distance", config.MinDistance)
+	config.MaxDistance = a.int("max_distance", config.MaxDistance)
+	config.Hidden = a.bool("hidden", config.Hidden)
+
+	build, ok := builders[name]
+	if !ok || name == "divergence" {
+		return nil, fmt.Errorf("unknown oscillator: %s", name)
+	}
+	oscillator, err := build(a)
+	if err != nil {
+		return nil, err
+	}
+	if key != "" {
+		oscillator = divergence.Select(oscillator, key)
+	}
+	return divergence.NewStreamWithConfig(oscillator, config)
+}
+
+// Names 返回所有可用的指标名称，按字母排序
+func Names() []string {
+	names := make([]string, 0, len(builders))
+	for name := range builders {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// New 按名称和参数创建指标，名称不区分大小写。ma、bollinger、macd、rsi 可以用 source 参数指定价格来源，
+// 见 indicates.ParseSource。名称未知、参数无法解析或无效、整数参数超过 MaxPeriod、有指标不使用的参数时返回错误
+func New(name string, params Params) (indicates.Indicator, error) {
+	build, ok := builders[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown indicator: %s", name)
+	}
+
+	a := &args{params: params, used: make(map[string]bool, len(params))}
+	source, err := indicates.ParseSource(a.string("source", ""))
+	if err != nil {
+		return nil, err
+	}
+	if source != indicates.SourceClose && !sourced[strings.ToLower(name)] {
+		return nil, fmt.Errorf("%s does not support param source", name)
+	}
+	indicator, err := build(a)
+	if a.err != nil {
+		return nil, a.err
+	}
+	if err != nil {
+		return nil, err
+	}
+	for key := range params {
+		if !a.used[key] {
+			return nil, fmt.Errorf("unknown %s param: %s", name, key)
+		}
+	}
+	return indicates.WithSource(indicator, source), nil
+}
+
+// args 读取参数并记录用到的参数和第一个解析错误
+type args struct {
+	params Params
+	used   map[string]bool
+	err    error
+}
+
+func (a *args) lookup(key string) (string, bool) {
+	a.used[key] = true
+	value, ok := a.params[key]
+	return value, ok && value != ""
+}
+
+func (a *args) fail(key, value string) {
+	if a.err == nil {
+		a.err = fmt.Errorf("invalid param %s: %s", key, value)
+	}
+}
+
+func (a *args) string(key, def string) string {
+	if value, ok := a.lookup(key); ok {
+		return value
+	}
+	return def
+}
+
+// int 读取整数参数，超过 MaxPeriod 时记录错误并返回默认值，避免指标按过大的窗口分配内存
+func (a *args) int(key string, def int) int {
+	value, ok := a.lookup(key)
+	if !ok {
+		return def
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n > MaxPeriod {
+		a.fail(key, value)
+		return def
+	}
+	return n
+}
+
+// period 读取周期，用于本身不检查周期的指标
+func (a *args) period(key string, def int) int {
+	n := a.int(key, def)
+	if n < 1 {
+		a.fail(key, strconv.Itoa(n))
+	}
+	return n
+}
+
+func (a *args) bool(key string, def bool) bool {
+	value, ok := a.lookup(key)
+	if !ok {
+		return def
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		a.fail(key, value)
+	}
+	return b
+}
+
+func (a *args) decimal(key, def string) decimal.Decimal {
+	value, ok := a.lookup(key)
+	if !ok {
+		value = def
+	}
+	d, err := decimal.NewFromString(value)
+	if err != nil {
+		a.fail(key, value)
+	}
+	return d
+}
+
+func (a *args) maType(key string, def ma.Type) ma.Type {
+	value, ok := a.lookup(key)
+	if !ok {
+		return def
+	}
+	kind, err := ma.ParseType(value)
+	if err != nil {
+		a.fail(key, value)
+	}
+	return kind
+}
+
+func (a *args) interval(key string, def interval.Interval) interval.Interval {
+	value, ok := a.lookup(key)
+	if !ok {
+		return def
+	}
+	iv, err := interval.Parse(value)
+	if err != nil {
+		a.fail(key, value)
+	}
+	return iv
+}
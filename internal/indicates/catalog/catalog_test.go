@@ -0,0 +1,85 @@
+package catalog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name   string
+		params Params
+		want   string // 指标名称
+		err    string // 期望返回错误时，错误信息包含的内容
+	}{
+		{name: "ma", want: "MA20"},
+		{name: "MA", params: Params{"period": "5", "type": "ema"}, want: "EMA5"},
+		{name: "rsi", params: Params{"period": "7"}, want: "RSI7"},
+		{name: "macd", params: Params{"fast": "3", "slow": "6", "signal": "4"}, want: "MACD3_6_4"},
+		{name: "bollinger", params: Params{"period": "10", "deviation": "sample"}, want: "BB10_SAMPLE"},
+		{name: "squeeze", want: "SQUEEZE20_2_1.5"},
+		{name: "rsi", params: Params{"source": "hlc3"}, want: "RSI14_HLC3"},
+		{name: "ma", params: Params{"source": "volume", "period": "10"}, want: "MA10_VOLUME"},
+		{name: "ma", params: Params{"source": "close"}, want: "MA20"},
+		{name: "pivot", params: Params{"method": "camarilla"}, want: "PIVOT_CAMARILLA"},
+		{name: "vwap", params: Params{"anchor": "1w"}, want: "VWAP_1w"},
+		{name: "divergence", params: Params{"period": "5"}, want: "DIVERGENCE_RSI5"},
+		{name: "divergence", params: Params{"oscillator": "macd", "key": "histogram"}, want: "DIVERGENCE_MACD12_26_9.histogram"},
+		// 参数值为空字符串时视为未传入
+		{name: "rsi", params: Params{"period": ""}, want: "RSI14"},
+		{name: "unknown", err: "unknown indicator"},
+		{name: "rsi", params: Params{"period": "abc"}, err: "invalid param period"},
+		{name: "rsi", params: Params{"period": "0"}, err: "invalid param period"},
+		{name: "ma", params: Params{"type": "xma"}, err: "invalid param type"},
+		{name: "rsi", params: Params{"perod": "14"}, err: "unknown rsi param: perod"},
+		{name: "bollinger", params: Params{"period": "0"}, err: "invalid"},
+		{name: "bollinger", params: Params{"deviation": "std"}, err: "unknown deviation"},
+		{name: "rsi", params: Params{"source": "vwap"}, err: "unknown price source"},
+		{name: "macd", params: Params{"source": "ohlc4"}, want: "MACD12_26_9_OHLC4"},
+		// 读取最高价、最低价的指标不能换价格来源，收盘价为默认来源仍然可以传入
+		{name: "atr", params: Params{"source": "hlc3"}, err: "atr does not support param source"},
+		{name: "stochastic", params: Params{"source": "volume"}, err: "does not support param source"},
+		{name: "divergence", params: Params{"source": "hl2"}, err: "does not support param source"},
+		{name: "atr", params: Params{"source": "close"}, want: "ATR14"},
+		{name: "divergence", params: Params{"oscillator": "divergence"}, err: "unknown oscillator"},
+		{name: "divergence", params: Params{"left": "0"}, err: "invalid swing strength"},
+		// 整数参数有上限，避免按过大的窗口分配内存
+		{name: "ma", params: Params{"period": "5000"}, want: "MA5000"},
+		{name: "ma", params: Params{"period": "20000000"}, err: "invalid param period: 20000000"},
+		{name: "ichimoku", params: Params{"senkou_b": "5001"}, err: "invalid param senkou_b"},
+		{name: "divergence", params: Params{"max_distance": "99999999"}, err: "invalid param max_distance"},
+	}
+
+	for _, tt := range tests {
+		indicator, err := New(tt.name, tt.params)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("New(%s, %v) 错误 = %v, 期望包含 %q", tt.name, tt.params, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("New(%s, %v) 返回错误: %v", tt.name, tt.params, err)
+			continue
+		}
+		if indicator.Name() != tt.want {
+			t.Errorf("New(%s, %v) 名称 = %s, 期望 %s", tt.name, tt.params, indicator.Name(), tt.want)
+		}
+	}
+}
+
+// TestNamesDefaults 所有注册的指标都能使用默认参数创建
+func TestNamesDefaults(t *testing.T) {
+	names := Names()
+	if len(names) == 0 {
+		t.Fatal("没有注册的指标")
+	}
+	for i, name := range names {
+		if i > 0 && names[i-1] >= name {
+			t.Errorf("名称没有排序: %v", names)
+		}
+		if _, err := New(name, nil); err != nil {
+			t.Errorf("New(%s) 使用默认参数返回错误: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package catalog
+
+import (
+	"snake/internal/indicates"
+	"snake/internal/kline"
+
+	"github.com/shopspring/decimal"
+)
+
+// Point 指标在一根K线上的取值
+type Point struct {
+	// Time K线的开盘时间（毫秒）
+	Time  int64
+	Ready bool
+	// Value 指标的主值，Values 为所有输出，未就绪时均为零
+	Value  decimal.Decimal
+	Values map[string]decimal.Decimal
+}
+
+// NewPoint 返回指标推入开盘时间为 start 的K线后的取值
+func NewPoint(indicator indicates.Indicator, start int64) Point {
+	point := Point{Time: start, Ready: indicator.Ready()}
+	if point.Ready {
+		point.Value = indicator.Value()
+		point.Values = indicator.Values()
+	}
+	return point
+}
+
+// Compute 依次推入K线，返回开盘时间不早于 from 的每根K线的取值，与K线一一对应。
+// from 之前的K线只用于预热，调用方应至少多传入 WarmUp() 根K线，否则开头的取值未就绪
+func Compute(indicator indicates.Indicator, klines []*kline.Kline, from int64) []Point {
+	var points []Point
+	for _, k := range klines {
+		indicator.Update(k)
+		if k.S >= from {
+			points = append(points, NewPoint(indicator, k.S))
+		}
+	}
+	return points
+}
@@ -0,0 +1,39 @@
+package catalog
+
+import (
+	"snake/internal/indicates/ma"
+	"snake/internal/kline"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestCompute(t *testing.T) {
+	var klines []*kline.Kline
+	for i := range 6 {
+		price := decimal.NewFromInt(int64(i + 1))
+		klines = append(klines, &kline.Kline{O: price, H: price, L: price, C: price, S: int64(i) * 60000, E: int64(i)*60000 + 59999})
+	}
+
+	// 前 2 根K线只用于预热，从第 3 根开始输出，MA3 推入第 3 根时就绪
+	points := Compute(ma.NewStream(3), klines, 120000)
+	if len(points) != 4 {
+		t.Fatalf("取值数量 = %d, 期望 4", len(points))
+	}
+	for i, point := range points {
+		start := int64(i+2) * 60000
+		want := decimal.NewFromInt(int64(i + 2)) // (i+1 + i+2 + i+3) / 3
+		if point.Time != start || !point.Ready || !point.Value.Equal(want) {
+			t.Errorf("第 %d 个取值 = %+v, 期望时间 %d 值 %s", i, point, start, want)
+		}
+		if !point.Values["ma"].Equal(want) {
+			t.Errorf("第 %d 个取值的输出 = %v, 期望 ma=%s", i, point.Values, want)
+		}
+	}
+
+	// 没有预热时开头的取值未就绪，输出为空
+	points = Compute(ma.NewStream(3), klines, 0)
+	if len(points) != 6 || points[1].Ready || points[1].Values != nil || !points[2].Ready {
+		t.Errorf("未预热的取值 = %+v", points)
+	}
+}
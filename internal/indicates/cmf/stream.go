@@ -0,0 +1,102 @@
+package cmf
+
+import (
+	"fmt"
+	"snake/internal/indicates"
+	"snake/internal/kline"
+
+	"github.com/shopspring/decimal"
+)
+
+// Stream 流式蔡金资金流量（Chaikin Money Flow）
+//
+//	资金流量乘数 = ((收盘价 - 最低价) - (最高价 - 收盘价)) / (最高价 - 最低价)，最高价等于最低价时为 0
+//	资金流量 = 资金流量乘数 * 成交量
+//	CMF = period 内资金流量之和 / period 内成交量之和
+type Stream struct {
+	period int
+	state  streamState
+}
+
+type streamState struct {
+	seq    indicates.Sequence
+	flow   indicates.RollingSum
+	volume indicates.RollingSum
+}
+
+var _ indicates.Indicator = (*Stream)(nil)
+
+// NewStream 创建流式蔡金资金流量，常用周期为 20
+func NewStream(period int) *Stream {
+	s, _ := NewStreamWithPeriod(period)
+	return s
+}
+
+// NewStreamWithPeriod 创建流式蔡金资金流量，周期无效时返回错误
+func NewStreamWithPeriod(period int) (*Stream, error) {
+	if period < 1 {
+		return nil, fmt.Errorf("invalid cmf period: %d", period)
+	}
+
+	s := &Stream{period: period}
+	s.Reset()
+	return s, nil
+}
+
+func (s *Stream) Name() string { return fmt.Sprintf("CMF%d", s.period) }
+
+func (s *Stream) Update(kline *kline.Kline) {
+	action := s.state.seq.Next(kline)
+	if action == indicates.ActionIgnore {
+		return
+	}
+	s.state.flow.Push(action, Multiplier(kline).Mul(kline.V))
+	s.state.volume.Push(action, kline.V)
+}
+
+// Multiplier 计算资金流量乘数，取值范围 -1 到 1，收盘于最高价时为 1，收盘于最低价时为 -1
+func Multiplier(k *kline.Kline) decimal.Decimal {
+	width := k.H.Sub(k.L)
+	if width.IsZero() {
+		return decimal.Zero
+	}
+	return k.C.Sub(k.L).Sub(k.H.Sub(k.C)).Div(width)
+}
+
+func (s *Stream) Value() decimal.Decimal {
+	volume := s.state.volume.Sum()
+	if !s.Ready() || volume.IsZero() {
+		return decimal.Zero
+	}
+	return s.state.flow.Sum().Div(volume)
+}
+
+func (s *Stream) Values() map[string]decimal.Decimal {
+	return map[string]decimal.Decimal{"cmf": s.Value()}
+}
+
+func (s *Stream) Ready() bool { return s.state.volume.Full() }
+
+func (s *Stream) WarmUp() int { return s.period }
+
+func (s *Stream) Reset() {
+	s.state = streamState{flow: indicates.NewRollingSum(s.period), volume: indicates.NewRollingSum(s.period)}
+}
+
+func (s *Stream) Snapshot() indicates.Snapshot {
+	state := s.state
+	state.flow = s.state.flow.Clone()
+	state.volume = s.state.volume.Clone()
+	return &state
+}
+
+func (s *Stream) Restore(snapshot indicates.Snapshot) error {
+	state, ok := snapshot.(*streamState)
+	if !ok {
+		return indicates.ErrSnapshotMismatch
+	}
+	s.state = *state
+	s.state.flow = state.flow.Clone()
+	s.state.volume = state.volume.Clone()
+	return nil
+}
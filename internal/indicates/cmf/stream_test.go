@@ -0,0 +1,56 @@
+package cmf
+
+import (
+	"snake/internal/kline"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestStream(t *testing.T) {
+	// [最高价, 最低价, 收盘价, 成交量]
+	bars := [][4]float64{
+		{12, 10, 12, 100}, // 乘数 1
+		{12, 10, 10, 300}, // 乘数 -1
+		{12, 10, 11, 200}, // 乘数 0
+		{12, 10, 11.5, 400},
+	}
+	expected := []string{
+		"0", "0",
+		// (100 - 300 + 0) / 600
+		"-0.3333333333333333",
+		// (-300 + 0 + 0.5*400) / 900
+		"-0.1111111111111111",
+	}
+
+	stream := NewStream(3)
+	if stream.Name() != "CMF3" {
+		t.Fatalf("预期名称 CMF3，实际为 %s", stream.Name())
+	}
+	for i, bar := range bars {
+		stream.Update(&kline.Kline{
+			H: decimal.NewFromFloat(bar[0]),
+			L: decimal.NewFromFloat(bar[1]),
+			C: decimal.NewFromFloat(bar[2]),
+			V: decimal.NewFromFloat(bar[3]),
+			S: int64(i) * 60000,
+		})
+		if ready := i+1 >= 3; stream.Ready() != ready {
+			t.Fatalf("第 %d 根K线后预期就绪状态为 %v", i+1, ready)
+		}
+		if !stream.Value().Round(10).Equal(decimal.RequireFromString(expected[i]).Round(10)) {
+			t.Errorf("第 %d 根K线后预期 %s，实际为 %s", i+1, expected[i], stream.Value())
+		}
+	}
+
+	if _, err := NewStreamWithPeriod(0); err == nil {
+		t.Errorf("预期周期为 0 时创建失败")
+	}
+}
+
+func TestMultiplier(t *testing.T) {
+	k := &kline.Kline{H: decimal.NewFromInt(10), L: decimal.NewFromInt(10), C: decimal.NewFromInt(10)}
+	if !Multiplier(k).IsZero() {
+		t.Errorf("预期最高价等于最低价时乘数为 0，实际为 %s", Multiplier(k))
+	}
+}
@@ -0,0 +1,87 @@
+package delta
+
+import (
+	"snake/internal/indicates"
+	"snake/internal/kline"
+
+	"github.com/shopspring/decimal"
+)
+
+// Stream 流式主动买卖量差（Cumulative Volume Delta）
+// 每根K线的量差 = 主动买入成交量 - 主动卖出成交量 = 2 * 主动买入成交量 - 成交量，累计量差为所有K线量差之和
+type Stream struct {
+	state streamState
+}
+
+type streamState struct {
+	seq indicates.Sequence
+	// 推入最后一根K线前后的状态，最后一根K线更新时从 prev 重新计算
+	prev deltaState
+	cur  deltaState
+}
+
+type deltaState struct {
+	count      int
+	buy        decimal.Decimal
+	sell       decimal.Decimal
+	delta      decimal.Decimal
+	cumulative decimal.Decimal
+}
+
+var _ indicates.Indicator = (*Stream)(nil)
+
+// NewStream 创建流式主动买卖量差
+func NewStream() *Stream {
+	return &Stream{}
+}
+
+func (s *Stream) Name() string { return "CVD" }
+
+func (s *Stream) Update(kline *kline.Kline) {
+	switch s.state.seq.Next(kline) {
+	case indicates.ActionAppend:
+		s.state.prev = s.state.cur
+	case indicates.ActionIgnore:
+		return
+	}
+
+	state := s.state.prev
+	state.count++
+	state.buy = kline.BV
+	state.sell = kline.TakerSellVolume()
+	state.delta = state.buy.Sub(state.sell)
+	state.cumulative = state.cumulative.Add(state.delta)
+	s.state.cur = state
+}
+
+// Value 返回累计量差
+func (s *Stream) Value() decimal.Decimal { return s.state.cur.cumulative }
+
+func (s *Stream) Values() map[string]decimal.Decimal {
+	return map[string]decimal.Decimal{
+		"cumulative": s.state.cur.cumulative,
+		"delta":      s.state.cur.delta,
+		"buy":        s.state.cur.buy,
+		"sell":       s.state.cur.sell,
+	}
+}
+
+func (s *Stream) Ready() bool { return s.state.cur.count >= 1 }
+
+func (s *Stream) WarmUp() int { return 1 }
+
+func (s *Stream) Reset() { s.state = streamState{} }
+
+func (s *Stream) Snapshot() indicates.Snapshot {
+	state := s.state
+	return &state
+}
+
+func (s *Stream) Restore(snapshot indicates.Snapshot) error {
+	state, ok := snapshot.(*streamState)
+	if !ok {
+		return indicates.ErrSnapshotMismatch
+	}
+	s.state = *state
+	return nil
+}
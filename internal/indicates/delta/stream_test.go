@@ -0,0 +1,43 @@
+package delta
+
+import (
+	"snake/internal/kline"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestStream(t *testing.T) {
+	tests := []struct {
+		volume     int64
+		buy        int64
+		delta      string
+		cumulative string
+	}{
+		{100, 60, "20", "20"},
+		{200, 50, "-100", "-80"},
+		{80, 80, "80", "0"},
+		// 没有主动买入成交量时全部视为主动卖出
+		{40, 0, "-40", "-40"},
+	}
+
+	stream := NewStream()
+	for i, tt := range tests {
+		k := &kline.Kline{
+			V:  decimal.NewFromInt(tt.volume),
+			BV: decimal.NewFromInt(tt.buy),
+			S:  int64(i) * 60000,
+		}
+		// 未收盘K线的成交量随后被替换
+		stream.Update(&kline.Kline{V: k.V.Add(decimal.NewFromInt(1000)), BV: k.BV, S: k.S})
+		stream.Update(k)
+
+		values := stream.Values()
+		if !values["delta"].Equal(decimal.RequireFromString(tt.delta)) {
+			t.Errorf("第 %d 根K线后预期量差 %s，实际为 %s", i+1, tt.delta, values["delta"])
+		}
+		if !stream.Value().Equal(decimal.RequireFromString(tt.cumulative)) {
+			t.Errorf("第 %d 根K线后预期累计量差 %s，实际为 %s", i+1, tt.cumulative, stream.Value())
+		}
+	}
+}
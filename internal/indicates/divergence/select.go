@@ -0,0 +1,27 @@
+package divergence
+
+import (
+	"snake/internal/indicates"
+
+	"github.com/shopspring/decimal"
+)
+
+// selected 以指标的某个输出作为主值，例如 MACD 的柱状图
+type selected struct {
+	indicates.Indicator
+	key string
+}
+
+// Select 返回以 key 对应的输出为主值（Value）的指标，其余方法与原指标相同
+func Select(indicator indicates.Indicator, key string) indicates.Indicator {
+	return &selected{Indicator: indicator, key: key}
+}
+
+func (s *selected) Name() string { return s.Indicator.Name() + "." + s.key }
+
+func (s *selected) Value() decimal.Decimal {
+	if !s.Ready() {
+		return decimal.Zero
+	}
+	return s.Values()[s.key]
+}
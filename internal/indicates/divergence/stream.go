@@ -0,0 +1,341 @@
+package divergence
+
+import (
+	"fmt"
+	"slices"
+	"snake/internal/indicates"
+	"snake/internal/kline"
+
+	"github.com/shopspring/decimal"
+)
+
+// Kind 背离类型
+type Kind string
+
+const (
+	// RegularBullish 常规看涨背离：价格创更低的低点，振荡指标的低点抬高，预示下跌动能衰竭
+	RegularBullish Kind = "regular_bullish"
+	// HiddenBullish 隐藏看涨背离：价格的低点抬高，振荡指标创更低的低点，预示上涨趋势延续
+	HiddenBullish Kind = "hidden_bullish"
+	// RegularBearish 常规看跌背离：价格创更高的高点，振荡指标的高点降低，预示上涨动能衰竭
+	RegularBearish Kind = "regular_bearish"
+	// HiddenBearish 隐藏看跌背离：价格的高点降低，振荡指标创更高的高点，预示下跌趋势延续
+	HiddenBearish Kind = "hidden_bearish"
+)
+
+var kinds = []Kind{RegularBullish, HiddenBullish, RegularBearish, HiddenBearish}
+
+func (k Kind) String() string { return string(k) }
+
+// Bullish 是否为看涨背离
+func (k Kind) Bullish() bool { return k == RegularBullish || k == HiddenBullish }
+
+// Swing 价格的摆动高点或低点，以及该K线的振荡指标值
+type Swing struct {
+	// Time 摆动点所在K线的开盘时间（毫秒）
+	Time       int64
+	Price      decimal.Decimal
+	Oscillator decimal.Decimal
+	index      int
+}
+
+// Event 检测到的背离
+type Event struct {
+	Kind Kind
+	// Oscillator 振荡指标名称
+	Oscillator string
+	// Previous、Current 构成背离的前后两个摆动点
+	Previous Swing
+	Current  Swing
+	// Time 确认背离的K线开盘时间（毫秒），即 Current 之后第 Right 根K线
+	Time int64
+}
+
+// Config 背离检测的参数
+type Config struct {
+	// Left、Right 摆动点左右各需要的K线数量，摆动点在其后第 Right 根K线推入后确认
+	Left  int
+	Right int
+	// MinDistance、MaxDistance 前后两个摆动点之间最少、最多相隔的K线数量
+	MinDistance int
+	MaxDistance int
+	// Hidden 是否检测隐藏背离
+	Hidden bool
+}
+
+// DefaultConfig 返回默认参数：左右各 3 根K线，摆动点相隔 5 到 60 根K线，检测隐藏背离
+func DefaultConfig() Config {
+	return Config{Left: 3, Right: 3, MinDistance: 5, MaxDistance: 60, Hidden: true}
+}
+
+// Validate 检查参数是否有效
+func (c Config) Validate() error {
+	if c.Left < 1 || c.Right < 1 {
+		return fmt.Errorf("invalid swing strength: left %d, right %d", c.Left, c.Right)
+	}
+	if c.MinDistance < 1 || c.MaxDistance < c.MinDistance {
+		return fmt.Errorf("invalid swing distance: min %d, max %d", c.MinDistance, c.MaxDistance)
+	}
+	return nil
+}
+
+// Stream 流式价格与振荡指标的背离检测
+//
+// 振荡指标可以是任意 Indicator，默认使用其 Value，例如 rsi.Stream；
+// 需要其他输出时用 Select 选择，例如 MACD 的柱状图 Select(macd.NewStream(12, 26, 9), "histogram")。
+// 摆动低点比较常规/隐藏看涨背离，摆动高点比较常规/隐藏看跌背离，只和最近一个距离在范围内的同类摆动点比较，
+// 两个摆动点的振荡指标都需要已经就绪
+//
+// 未收盘K线上的背离可能在收盘前消失，订阅者只在确认背离的K线收盘后收到通知，见 Subscribe
+type Stream struct {
+	oscillator indicates.Indicator
+	config     Config
+	listeners  []func(Event)
+	// 已经通知过的最后一根收盘K线的开盘时间
+	notified     bool
+	notifiedTime int64
+	state        streamState
+}
+
+type streamState struct {
+	seq indicates.Sequence
+	// 推入最后一根K线前后的状态，最后一根K线更新时从 prev 重新计算
+	prev detectState
+	cur  detectState
+}
+
+type detectState struct {
+	bars   []bar // 最近 Left+Right+1 根K线
+	lows   []Swing
+	highs  []Swing
+	events []Event // 最后一根K线确认的背离
+}
+
+type bar struct {
+	start int64
+	high  decimal.Decimal
+	low   decimal.Decimal
+	value decimal.Decimal
+	ready bool
+}
+
+func (s detectState) clone() detectState {
+	return detectState{
+		bars:   slices.Clone(s.bars),
+		lows:   slices.Clone(s.lows),
+		highs:  slices.Clone(s.highs),
+		events: slices.Clone(s.events),
+	}
+}
+
+// snapshot 背离检测和振荡指标的状态
+type snapshot struct {
+	oscillator indicates.Snapshot
+	state      streamState
+}
+
+var _ indicates.Indicator = (*Stream)(nil)
+
+// NewStream 使用默认参数创建背离检测
+func NewStream(oscillator indicates.Indicator) *Stream {
+	s, _ := NewStreamWithConfig(oscillator, DefaultConfig())
+	return s
+}
+
+// NewStreamWithConfig 使用指定参数创建背离检测，参数无效时返回错误
+func NewStreamWithConfig(oscillator indicates.Indicator, config Config) (*Stream, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return &Stream{oscillator: oscillator, config: config}, nil
+}
+
+func (s *Stream) Name() string { return "DIVERGENCE_" + s.oscillator.Name() }
+
+// Subscribe 订阅检测到的背离，每个背离只在确认它的K线收盘后通知一次
+//
+// 推入下一根K线时认为上一根K线已经收盘；只推入已收盘K线时，每次 Update 之后调用 CloseBar 可以立即收到通知
+func (s *Stream) Subscribe(listener func(Event)) {
+	s.listeners = append(s.listeners, listener)
+}
+
+// CloseBar 标记最后一根K线已经收盘，通知其确认的背离；同一根K线只通知一次
+func (s *Stream) CloseBar() {
+	s.notify(s.state.cur)
+}
+
+func (s *Stream) Update(k *kline.Kline) {
+	action := s.state.seq.Next(k)
+	switch action {
+	case indicates.ActionIgnore:
+		return
+	case indicates.ActionAppend:
+		// 上一根K线已经收盘
+		s.notify(s.state.cur)
+		s.state.prev = s.state.cur
+	}
+	s.oscillator.Update(k)
+
+	state := s.state.prev.clone()
+	state.events = nil
+	state.bars = append(state.bars, bar{
+		start: k.S,
+		high:  k.H,
+		low:   k.L,
+		value: s.oscillator.Value(),
+		ready: s.oscillator.Ready(),
+	})
+	size := s.config.Left + s.config.Right + 1
+	if len(state.bars) > size {
+		state.bars = state.bars[len(state.bars)-size:]
+	}
+
+	if len(state.bars) == size {
+		index := s.state.seq.Count - 1 - s.config.Right
+		middle := state.bars[s.config.Left]
+		if middle.ready && s.extreme(state.bars, func(a, b bar) bool { return a.low.LessThan(b.low) }) {
+			swing := Swing{Time: middle.start, Price: middle.low, Oscillator: middle.value, index: index}
+			state.lows = s.detect(&state, state.lows, swing, false, k.S)
+		}
+		if middle.ready && s.extreme(state.bars, func(a, b bar) bool { return a.high.GreaterThan(b.high) }) {
+			swing := Swing{Time: middle.start, Price: middle.high, Oscillator: middle.value, index: index}
+			state.highs = s.detect(&state, state.highs, swing, true, k.S)
+		}
+	}
+	s.state.cur = state
+}
+
+// extreme 判断中间的K线是否严格优于其他所有K线
+func (s *Stream) extreme(bars []bar, better func(a, b bar) bool) bool {
+	middle := bars[s.config.Left]
+	for i, b := range bars {
+		if i != s.config.Left && !better(middle, b) {
+			return false
+		}
+	}
+	return true
+}
+
+// detect 把新的摆动点与之前最近的同类摆动点比较，记录背离并返回更新后的摆动点列表
+func (s *Stream) detect(state *detectState, swings []Swing, swing Swing, high bool, confirmed int64) []Swing {
+	// 丢弃超出回看范围的摆动点
+	swings = slices.DeleteFunc(swings, func(prev Swing) bool {
+		return swing.index-prev.index > s.config.MaxDistance
+	})
+
+	for i := len(swings) - 1; i >= 0; i-- {
+		prev := swings[i]
+		if swing.index-prev.index < s.config.MinDistance {
+			continue
+		}
+
+		var kind Kind
+		priceUp := swing.Price.GreaterThan(prev.Price)
+		priceDown := swing.Price.LessThan(prev.Price)
+		oscillatorUp := swing.Oscillator.GreaterThan(prev.Oscillator)
+		oscillatorDown := swing.Oscillator.LessThan(prev.Oscillator)
+		switch {
+		case !high && priceDown && oscillatorUp:
+			kind = RegularBullish
+		case !high && priceUp && oscillatorDown && s.config.Hidden:
+			kind = HiddenBullish
+		case high && priceUp && oscillatorDown:
+			kind = RegularBearish
+		case high && priceDown && oscillatorUp && s.config.Hidden:
+			kind = HiddenBearish
+		}
+		if kind != "" {
+			state.events = append(state.events, Event{
+				Kind:       kind,
+				Oscillator: s.oscillator.Name(),
+				Previous:   prev,
+				Current:    swing,
+				Time:       confirmed,
+			})
+		}
+		break
+	}
+	return append(swings, swing)
+}
+
+// notify 通知已收盘K线确认的背离，已经通知过的K线不再通知
+func (s *Stream) notify(state detectState) {
+	if len(state.bars) == 0 {
+		return
+	}
+	start := state.bars[len(state.bars)-1].start
+	if s.notified && start <= s.notifiedTime {
+		return
+	}
+	s.notified, s.notifiedTime = true, start
+
+	for _, event := range state.events {
+		for _, listener := range s.listeners {
+			listener(event)
+		}
+	}
+}
+
+// Events 返回最后一根K线确认的背离，最后一根K线未收盘时背离可能在收盘前消失
+func (s *Stream) Events() []Event { return slices.Clone(s.state.cur.events) }
+
+// Value 最后一根K线确认了看涨背离时为 1，看跌背离时为 -1，同时确认时相加
+func (s *Stream) Value() decimal.Decimal {
+	value := decimal.Zero
+	for _, event := range s.state.cur.events {
+		if event.Kind.Bullish() {
+			value = value.Add(decimal.NewFromInt(1))
+		} else {
+			value = value.Sub(decimal.NewFromInt(1))
+		}
+	}
+	return value
+}
+
+// Values 返回最后一根K线确认的每种背离（1 或 0）和振荡指标的值
+func (s *Stream) Values() map[string]decimal.Decimal {
+	values := make(map[string]decimal.Decimal, len(kinds)+1)
+	for _, kind := range kinds {
+		values[kind.String()] = decimal.Zero
+	}
+	for _, event := range s.state.cur.events {
+		values[event.Kind.String()] = decimal.NewFromInt(1)
+	}
+	values["oscillator"] = s.oscillator.Value()
+	return values
+}
+
+func (s *Stream) Ready() bool {
+	return s.oscillator.Ready() && s.state.seq.Count >= s.config.Left+s.config.Right+1
+}
+
+func (s *Stream) WarmUp() int {
+	return max(s.oscillator.WarmUp(), s.config.Left+s.config.Right+1)
+}
+
+func (s *Stream) Reset() {
+	s.oscillator.Reset()
+	s.state = streamState{}
+	s.notified, s.notifiedTime = false, 0
+}
+
+func (s *Stream) Snapshot() indicates.Snapshot {
+	state := s.state
+	state.prev = s.state.prev.clone()
+	state.cur = s.state.cur.clone()
+	return &snapshot{oscillator: s.oscillator.Snapshot(), state: state}
+}
+
+func (s *Stream) Restore(snap indicates.Snapshot) error {
+	saved, ok := snap.(*snapshot)
+	if !ok {
+		return indicates.ErrSnapshotMismatch
+	}
+	if err := s.oscillator.Restore(saved.oscillator); err != nil {
+		return err
+	}
+	s.state = saved.state
+	s.state.prev = saved.state.prev.clone()
+	s.state.cur = saved.state.cur.clone()
+	return nil
+}
@@ -0,0 +1,223 @@
+package divergence
+
+import (
+	"snake/internal/indicates"
+	"snake/internal/indicates/macd"
+	"snake/internal/kline"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+// volumeOscillator 以成交量作为振荡指标值，方便构造测试数据
+type volumeOscillator struct {
+	seq   indicates.Sequence
+	value decimal.Decimal
+}
+
+func (o *volumeOscillator) Name() string { return "VOL" }
+func (o *volumeOscillator) Update(k *kline.Kline) {
+	if o.seq.Next(k) != indicates.ActionIgnore {
+		o.value = k.V
+	}
+}
+func (o *volumeOscillator) Value() decimal.Decimal { return o.value }
+func (o *volumeOscillator) Values() map[string]decimal.Decimal {
+	return map[string]decimal.Decimal{"value": o.value}
+}
+func (o *volumeOscillator) Ready() bool { return o.seq.Count >= 1 }
+func (o *volumeOscillator) WarmUp() int { return 1 }
+func (o *volumeOscillator) Reset()      { *o = volumeOscillator{} }
+func (o *volumeOscillator) Snapshot() indicates.Snapshot {
+	state := *o
+	return &state
+}
+func (o *volumeOscillator) Restore(snapshot indicates.Snapshot) error {
+	*o = *snapshot.(*volumeOscillator)
+	return nil
+}
+
+// swingKlines 生成K线，lows 为 true 时 prices 为最低价（最高价高 10），否则为最高价（最低价低 10），成交量为振荡指标值
+func swingKlines(lows bool, prices, oscillator []float64) []*kline.Kline {
+	klines := make([]*kline.Kline, len(prices))
+	for i, price := range prices {
+		p := decimal.NewFromFloat(price)
+		k := &kline.Kline{H: p.Add(decimal.NewFromInt(10)), L: p, V: decimal.NewFromFloat(oscillator[i]), S: int64(i) * 60000}
+		if !lows {
+			k.H, k.L = p, p.Sub(decimal.NewFromInt(10))
+		}
+		k.C = k.L.Add(decimal.NewFromInt(5))
+		klines[i] = k
+	}
+	return klines
+}
+
+func testConfig() Config {
+	return Config{Left: 1, Right: 1, MinDistance: 2, MaxDistance: 10, Hidden: true}
+}
+
+func TestStream(t *testing.T) {
+	tests := []struct {
+		kind       Kind
+		lows       bool
+		price      float64
+		oscillator float64
+	}{
+		// 低点 95、30 之后：价格 93 更低，指标 35 更高
+		{RegularBullish, true, 93, 35},
+		// 价格 96 更高，指标 25 更低
+		{HiddenBullish, true, 96, 25},
+		// 高点 105、70 之后：价格 107 更高，指标 65 更低
+		{RegularBearish, false, 107, 65},
+		// 价格 104 更低，指标 75 更高
+		{HiddenBearish, false, 104, 75},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.kind.String(), func(t *testing.T) {
+			prices := []float64{100, 95, 98, 99, tt.price, 97}
+			oscillator := []float64{50, 30, 40, 45, tt.oscillator, 42}
+			if !tt.lows {
+				prices = []float64{100, 105, 102, 101, tt.price, 103}
+				oscillator = []float64{50, 70, 60, 55, tt.oscillator, 58}
+			}
+			klines := swingKlines(tt.lows, prices, oscillator)
+
+			stream, _ := NewStreamWithConfig(&volumeOscillator{}, testConfig())
+			var events []Event
+			stream.Subscribe(func(e Event) { events = append(events, e) })
+			for _, k := range klines {
+				stream.Update(k)
+				stream.CloseBar()
+			}
+
+			if len(events) != 1 {
+				t.Fatalf("预期 1 个背离，实际为 %v", events)
+			}
+			event := events[0]
+			if event.Kind != tt.kind || event.Previous.Time != klines[1].S || event.Current.Time != klines[4].S ||
+				event.Time != klines[5].S || event.Oscillator != "VOL" {
+				t.Errorf("背离错误: %+v", event)
+			}
+			if !event.Current.Oscillator.Equal(decimal.NewFromFloat(tt.oscillator)) {
+				t.Errorf("预期摆动点的指标值为 %v，实际为 %s", tt.oscillator, event.Current.Oscillator)
+			}
+
+			values := stream.Values()
+			if !values[tt.kind.String()].Equal(decimal.NewFromInt(1)) {
+				t.Errorf("输出错误: %v", values)
+			}
+			expected := decimal.NewFromInt(1)
+			if !tt.kind.Bullish() {
+				expected = expected.Neg()
+			}
+			if !stream.Value().Equal(expected) {
+				t.Errorf("预期 %s，实际为 %s", expected, stream.Value())
+			}
+		})
+	}
+}
+
+func TestStreamConfig(t *testing.T) {
+	hidden := swingKlines(true, []float64{100, 95, 98, 99, 96, 97}, []float64{50, 30, 40, 45, 25, 42})
+	regular := swingKlines(true, []float64{100, 95, 98, 99, 93, 97}, []float64{50, 30, 40, 45, 35, 42})
+
+	tests := []struct {
+		name   string
+		config func(*Config)
+		klines []*kline.Kline
+	}{
+		{"不检测隐藏背离", func(c *Config) { c.Hidden = false }, hidden},
+		{"摆动点距离太近", func(c *Config) { c.MinDistance = 4 }, regular},
+		{"摆动点超出回看范围", func(c *Config) { c.MaxDistance = 2 }, regular},
+		{"摆动点需要左边更多K线", func(c *Config) { c.Left = 2 }, regular},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := testConfig()
+			tt.config(&config)
+			stream, err := NewStreamWithConfig(&volumeOscillator{}, config)
+			if err != nil {
+				t.Fatalf("创建失败: %v", err)
+			}
+			for _, k := range tt.klines {
+				stream.Update(k)
+				if len(stream.Events()) != 0 {
+					t.Fatalf("预期没有背离，实际为 %v", stream.Events())
+				}
+			}
+		})
+	}
+
+	if _, err := NewStreamWithConfig(&volumeOscillator{}, Config{Left: 1, Right: 1, MinDistance: 5, MaxDistance: 2}); err == nil {
+		t.Errorf("预期最大距离小于最小距离时创建失败")
+	}
+}
+
+func TestStreamNotifyOnClose(t *testing.T) {
+	klines := swingKlines(true, []float64{100, 95, 98, 99, 93, 97, 99}, []float64{50, 30, 40, 45, 35, 42, 44})
+
+	t.Run("收盘后通知一次", func(t *testing.T) {
+		stream, _ := NewStreamWithConfig(&volumeOscillator{}, testConfig())
+		var count int
+		stream.Subscribe(func(Event) { count++ })
+
+		for _, k := range klines[:6] {
+			stream.Update(k)
+		}
+		// 确认背离的K线未收盘，多次更新都不通知
+		last := *klines[5]
+		last.C = last.C.Add(decimal.NewFromInt(1))
+		stream.Update(&last)
+		stream.Update(klines[5])
+		if len(stream.Events()) != 1 || count != 0 {
+			t.Fatalf("预期检测到背离但未通知，实际背离 %v，通知 %d 次", stream.Events(), count)
+		}
+
+		// 下一根K线推入时上一根K线收盘，之后 CloseBar 不重复通知
+		stream.Update(klines[6])
+		stream.CloseBar()
+		if count != 1 {
+			t.Errorf("预期通知 1 次，实际为 %d 次", count)
+		}
+	})
+
+	t.Run("收盘前消失的背离不通知", func(t *testing.T) {
+		stream, _ := NewStreamWithConfig(&volumeOscillator{}, testConfig())
+		var count int
+		stream.Subscribe(func(Event) { count++ })
+
+		for _, k := range klines[:6] {
+			stream.Update(k)
+		}
+		// 更新后不再确认摆动点，背离消失
+		last := *klines[5]
+		last.L = decimal.NewFromInt(90)
+		stream.Update(&last)
+		if len(stream.Events()) != 0 {
+			t.Errorf("预期背离消失，实际为 %v", stream.Events())
+		}
+
+		stream.Update(klines[6])
+		if count != 0 {
+			t.Errorf("预期不通知，实际通知 %d 次", count)
+		}
+	})
+}
+
+func TestSelect(t *testing.T) {
+	stream := macd.NewStream(3, 6, 4)
+	histogram := Select(stream, "histogram")
+	if histogram.Name() != stream.Name()+".histogram" {
+		t.Fatalf("名称错误: %s", histogram.Name())
+	}
+
+	for i := 0; i < 20; i++ {
+		price := decimal.NewFromInt(int64(100 + i*i%7))
+		histogram.Update(&kline.Kline{H: price, L: price, C: price, S: int64(i) * 60000})
+	}
+	if !histogram.Value().Equal(stream.Values()["histogram"]) || histogram.Value().Equal(stream.Value()) {
+		t.Errorf("预期主值为柱状图 %s，实际为 %s", stream.Values()["histogram"], histogram.Value())
+	}
+}
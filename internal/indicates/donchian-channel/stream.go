@@ -0,0 +1,90 @@
+package donchianchannel
+
+import (
+	"fmt"
+	"snake/internal/indicates"
+	"snake/internal/kline"
+
+	"github.com/shopspring/decimal"
+)
+
+// Stream 流式唐奇安通道，用单调队列维护窗口内的最高价和最低价，每根K线均摊 O(1)
+type Stream struct {
+	period int
+	state  streamState
+}
+
+type streamState struct {
+	seq    indicates.Sequence
+	highs  indicates.RollingExtreme
+	lows   indicates.RollingExtreme
+	upper  decimal.Decimal
+	middle decimal.Decimal
+	lower  decimal.Decimal
+}
+
+var _ indicates.Indicator = (*Stream)(nil)
+
+// NewStream 创建流式唐奇安通道
+func NewStream(period int) *Stream {
+	s := &Stream{period: period}
+	s.Reset()
+	return s
+}
+
+func (s *Stream) Name() string { return fmt.Sprintf("DC%d", s.period) }
+
+func (s *Stream) Update(kline *kline.Kline) {
+	action := s.state.seq.Next(kline)
+	if action == indicates.ActionIgnore {
+		return
+	}
+
+	s.state.highs.Push(action, kline.H)
+	s.state.lows.Push(action, kline.L)
+	if !s.state.highs.Full() {
+		return
+	}
+
+	highest, lowest := s.state.highs.Value(), s.state.lows.Value()
+	s.state.upper = highest
+	s.state.lower = lowest
+	s.state.middle = highest.Add(lowest).Div(decimal.NewFromInt(2))
+}
+
+// Value 返回中轨
+func (s *Stream) Value() decimal.Decimal { return s.state.middle }
+
+func (s *Stream) Values() map[string]decimal.Decimal {
+	return map[string]decimal.Decimal{
+		"upper":  s.state.upper,
+		"middle": s.state.middle,
+		"lower":  s.state.lower,
+	}
+}
+
+func (s *Stream) Ready() bool { return s.state.highs.Full() }
+
+func (s *Stream) WarmUp() int { return s.period }
+
+func (s *Stream) Reset() {
+	s.state = streamState{highs: indicates.NewRollingMax(s.period), lows: indicates.NewRollingMin(s.period)}
+}
+
+func (s *Stream) Snapshot() indicates.Snapshot {
+	state := s.state
+	state.highs = s.state.highs.Clone()
+	state.lows = s.state.lows.Clone()
+	return &state
+}
+
+func (s *Stream) Restore(snapshot indicates.Snapshot) error {
+	state, ok := snapshot.(*streamState)
+	if !ok {
+		return indicates.ErrSnapshotMismatch
+	}
+	s.state = *state
+	s.state.highs = state.highs.Clone()
+	s.state.lows = state.lows.Clone()
+	return nil
+}
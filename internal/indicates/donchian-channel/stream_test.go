@@ -0,0 +1,27 @@
+package donchianchannel
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStream(t *testing.T) {
+	klines := generateTestKlines(time.Now().UnixMilli(), 40)
+	stream := NewStream(20)
+
+	for i, k := range klines {
+		stream.Update(k)
+		if i < 19 {
+			if stream.Ready() {
+				t.Fatalf("第 %d 根K线后预期未就绪", i+1)
+			}
+			continue
+		}
+
+		expected := NewWithPeriod(20, klines[:i+1]...)
+		values := stream.Values()
+		if !values["upper"].Equal(expected.Upper) || !values["middle"].Equal(expected.Middle) || !values["lower"].Equal(expected.Lower) {
+			t.Errorf("第 %d 根K线后预期 %s/%s/%s，实际为 %v", i+1, expected.Upper, expected.Middle, expected.Lower, values)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package ichimoku
+
+import (
+	"fmt"
+	"snake/internal/indicates"
+	"snake/internal/kline"
+
+	"github.com/shopspring/decimal"
+)
+
+// Stream 流式一目均衡表（Ichimoku Kinko Hyo）
+//
+//	转换线 Tenkan = (tenkan 周期最高价 + 最低价) / 2
+//	基准线 Kijun  = (kijun 周期最高价 + 最低价) / 2
+//	先行带 A      = (转换线 + 基准线) / 2，向前平移 displacement 根K线
+//	先行带 B      = (senkouB 周期最高价 + 最低价) / 2，向前平移 displacement 根K线
+//	迟行线 Chikou = 收盘价，向后平移 displacement 根K线
+//
+// 先行带向前平移，所以当前K线对应的云是 displacement 根K线之前计算的先行带，
+// 当前计算的先行带是 displacement 根K线之后的云，分别以 senkou_a/senkou_b 和 senkou_a_lead/senkou_b_lead 输出；
+// 迟行线向后平移，当前的迟行线即当前收盘价，与 displacement 根K线之前的价格比较
+type Stream struct {
+	tenkanPeriod  int
+	kijunPeriod   int
+	senkouBPeriod int
+	displacement  int
+	state         streamState
+}
+
+type streamState struct {
+	seq indicates.Sequence
+	// 三个周期的最高价和最低价
+	tenkanHigh  indicates.RollingExtreme
+	tenkanLow   indicates.RollingExtreme
+	kijunHigh   indicates.RollingExtreme
+	kijunLow    indicates.RollingExtreme
+	senkouBHigh indicates.RollingExtreme
+	senkouBLow  indicates.RollingExtreme
+	// 最近 displacement+1 根K线计算的先行带和收盘价，最旧的一个即当前K线对应的值
+	senkouA indicates.Window
+	senkouB indicates.Window
+	closes  indicates.Window
+	// 推入最后一根K线前后的转换线和基准线，用于判断交叉
+	prev lines
+	cur  lines
+}
+
+type lines struct {
+	ready  bool
+	tenkan decimal.Decimal
+	kijun  decimal.Decimal
+}
+
+var _ indicates.Indicator = (*Stream)(nil)
+
+var two = decimal.NewFromInt(2)
+
+// NewStream 创建使用常用参数 (9, 26, 52, 26) 的流式一目均衡表
+func NewStream() *Stream {
+	s, _ := NewStreamWithPeriod(9, 26, 52, 26)
+	return s
+}
+
+// NewStreamWithPeriod 按转换线、基准线、先行带 B 的周期和平移的K线数量创建流式一目均衡表
+func NewStreamWithPeriod(tenkan, kijun, senkouB, displacement int) (*Stream, error) {
+	for _, period := range []int{tenkan, kijun, senkouB, displacement} {
+		if period < 1 {
+			return nil, fmt.Errorf("invalid ichimoku period: %d", period)
+		}
+	}
+
+	s := &Stream{tenkanPeriod: tenkan, kijunPeriod: kijun, senkouBPeriod: senkouB, displacement: displacement}
+	s.Reset()
+	return s, nil
+}
+
+// Name 常用参数时为 ICHIMOKU，否则为 ICHIMOKU<tenkan>_<kijun>_<senkouB>_<displacement>
+func (s *Stream) Name() string {
+	if s.tenkanPeriod == 9 && s.kijunPeriod == 26 && s.senkouBPeriod == 52 && s.displacement == 26 {
+		return "ICHIMOKU"
+	}
+	return fmt.Sprintf("ICHIMOKU%d_%d_%d_%d", s.tenkanPeriod, s.kijunPeriod, s.senkouBPeriod, s.displacement)
+}
+
+func (s *Stream) Update(kline *kline.Kline) {
+	action := s.state.seq.Next(kline)
+	switch action {
+	case indicates.ActionIgnore:
+		return
+	case indicates.ActionAppend:
+		s.state.prev = s.state.cur
+	}
+
+	s.state.tenkanHigh.Push(action, kline.H)
+	s.state.tenkanLow.Push(action, kline.L)
+	s.state.kijunHigh.Push(action, kline.H)
+	s.state.kijunLow.Push(action, kline.L)
+	s.state.senkouBHigh.Push(action, kline.H)
+	s.state.senkouBLow.Push(action, kline.L)
+	s.state.closes.Push(action, kline.C)
+
+	tenkan := midpoint(s.state.tenkanHigh, s.state.tenkanLow)
+	kijun := midpoint(s.state.kijunHigh, s.state.kijunLow)
+	s.state.cur = lines{
+		ready:  s.state.tenkanHigh.Full() && s.state.kijunHigh.Full(),
+		tenkan: tenkan,
+		kijun:  kijun,
+	}
+
+	// 未就绪时也推入，保持平移的K线数量不变
+	s.state.senkouA.Push(action, tenkan.Add(kijun).Div(two))
+	s.state.senkouB.Push(action, midpoint(s.state.senkouBHigh, s.state.senkouBLow))
+}
+
+// midpoint 返回周期内最高价和最低价的中点
+func midpoint(high, low indicates.RollingExtreme) decimal.Decimal {
+	return high.Value().Add(low.Value()).Div(two)
+}
+
+// Value 返回基准线
+func (s *Stream) Value() decimal.Decimal { return s.state.cur.kijun }
+
+// Values 返回各条线，senkou_a/senkou_b 为当前K线对应的云，senkou_a_lead/senkou_b_lead 为 displacement 根K线之后的云
+func (s *Stream) Values() map[string]decimal.Decimal {
+	senkouA, senkouB := s.Cloud()
+	return map[string]decimal.Decimal{
+		"tenkan":        s.state.cur.tenkan,
+		"kijun":         s.state.cur.kijun,
+		"senkou_a":      senkouA,
+		"senkou_b":      senkouB,
+		"senkou_a_lead": latest(s.state.senkouA),
+		"senkou_b_lead": latest(s.state.senkouB),
+		"chikou":        latest(s.state.closes),
+	}
+}
+
+// Cloud 返回当前K线对应的先行带 A 和 B，即 displacement 根K线之前计算的先行带，未就绪时为零
+func (s *Stream) Cloud() (senkouA, senkouB decimal.Decimal) {
+	if !s.Ready() {
+		return decimal.Zero, decimal.Zero
+	}
+	return s.state.senkouA.Values()[0], s.state.senkouB.Values()[0]
+}
+
+func latest(w indicates.Window) decimal.Decimal {
+	values := w.Values()
+	if len(values) == 0 {
+		return decimal.Zero
+	}
+	return values[len(values)-1]
+}
+
+// Ready 当前K线对应的云是否已就绪，转换线和基准线更早可用
+func (s *Stream) Ready() bool { return s.state.seq.Count >= s.WarmUp() }
+
+func (s *Stream) WarmUp() int {
+	return max(s.tenkanPeriod, s.kijunPeriod, s.senkouBPeriod) + s.displacement
+}
+
+// AboveCloud 价格是否在云的上方
+func (s *Stream) AboveCloud(price decimal.Decimal) bool {
+	senkouA, senkouB := s.Cloud()
+	return s.Ready() && price.GreaterThan(decimal.Max(senkouA, senkouB))
+}
+
+// BelowCloud 价格是否在云的下方
+func (s *Stream) BelowCloud(price decimal.Decimal) bool {
+	senkouA, senkouB := s.Cloud()
+	return s.Ready() && price.LessThan(decimal.Min(senkouA, senkouB))
+}
+
+// InCloud 价格是否在云中
+func (s *Stream) InCloud(price decimal.Decimal) bool {
+	return s.Ready() && !s.AboveCloud(price) && !s.BelowCloud(price)
+}
+
+// BullishCloud 未来的云是否为阳云，即当前计算的先行带 A 高于先行带 B
+func (s *Stream) BullishCloud() bool {
+	return s.state.senkouBHigh.Full() && latest(s.state.senkouA).GreaterThan(latest(s.state.senkouB))
+}
+
+// Cross 交叉方向
+type Cross int
+
+const (
+	CrossNone Cross = iota // 没有交叉
+	CrossUp                // 向上交叉（金叉）
+	CrossDown              // 向下交叉（死叉）
+)
+
+// TKCross 返回最后一根K线上转换线与基准线的交叉方向
+func (s *Stream) TKCross() Cross {
+	prev, cur := s.state.prev, s.state.cur
+	if !prev.ready || !cur.ready {
+		return CrossNone
+	}
+	switch {
+	case prev.tenkan.LessThanOrEqual(prev.kijun) && cur.tenkan.GreaterThan(cur.kijun):
+		return CrossUp
+	case prev.tenkan.GreaterThanOrEqual(prev.kijun) && cur.tenkan.LessThan(cur.kijun):
+		return CrossDown
+	default:
+		return CrossNone
+	}
+}
+
+// ChikouAbovePrice 迟行线是否高于 displacement 根K线之前的收盘价
+func (s *Stream) ChikouAbovePrice() bool {
+	values := s.state.closes.Values()
+	return s.state.closes.Full() && values[len(values)-1].GreaterThan(values[0])
+}
+
+// ChikouBelowPrice 迟行线是否低于 displacement 根K线之前的收盘价
+func (s *Stream) ChikouBelowPrice() bool {
+	values := s.state.closes.Values()
+	return s.state.closes.Full() && values[len(values)-1].LessThan(values[0])
+}
+
+func (s *Stream) Reset() {
+	s.state = streamState{
+		tenkanHigh:  indicates.NewRollingMax(s.tenkanPeriod),
+		tenkanLow:   indicates.NewRollingMin(s.tenkanPeriod),
+		kijunHigh:   indicates.NewRollingMax(s.kijunPeriod),
+		kijunLow:    indicates.NewRollingMin(s.kijunPeriod),
+		senkouBHigh: indicates.NewRollingMax(s.senkouBPeriod),
+		senkouBLow:  indicates.NewRollingMin(s.senkouBPeriod),
+		senkouA:     indicates.NewWindow(s.displacement + 1),
+		senkouB:     indicates.NewWindow(s.displacement + 1),
+		closes:      indicates.NewWindow(s.displacement + 1),
+	}
+}
+
+func (s *Stream) Snapshot() indicates.Snapshot {
+	state := s.state.clone()
+	return &state
+}
+
+func (s *Stream) Restore(snapshot indicates.Snapshot) error {
+	state, ok := snapshot.(*streamState)
+	if !ok {
+		return indicates.ErrSnapshotMismatch
+	}
+	s.state = state.clone()
+	return nil
+}
+
+func (s streamState) clone() streamState {
+	s.tenkanHigh = s.tenkanHigh.Clone()
+	s.tenkanLow = s.tenkanLow.Clone()
+	s.kijunHigh = s.kijunHigh.Clone()
+	s.kijunLow = s.kijunLow.Clone()
+	s.senkouBHigh = s.senkouBHigh.Clone()
+	s.senkouBLow = s.senkouBLow.Clone()
+	s.senkouA = s.senkouA.Clone()
+	s.senkouB = s.senkouB.Clone()
+	s.closes = s.closes.Clone()
+	return s
+}
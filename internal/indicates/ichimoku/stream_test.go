@@ -0,0 +1,135 @@
+package ichimoku
+
+import (
+	"math"
+	"snake/internal/kline"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+// waveKlines 生成价格按正弦波动的连续 1 分钟K线
+func waveKlines(count int) []*kline.Kline {
+	klines := make([]*kline.Kline, count)
+	for i := range klines {
+		price := decimal.NewFromFloat(100 + float64(i)*0.1 + 6*math.Sin(float64(i)/5)).Round(2)
+		klines[i] = &kline.Kline{
+			O: price,
+			C: price,
+			H: price.Add(decimal.NewFromFloat(0.5 + float64(i%3)*0.25)),
+			L: price.Sub(decimal.NewFromFloat(0.5 + float64(i%4)*0.25)),
+			S: int64(i) * 60000,
+			E: int64(i)*60000 + 59999,
+		}
+	}
+	return klines
+}
+
+// referenceMidpoint 按定义计算第 i 根K线 period 周期最高价和最低价的中点
+func referenceMidpoint(klines []*kline.Kline, i, period int) decimal.Decimal {
+	high, low := klines[i].H, klines[i].L
+	for _, k := range klines[i+1-period : i+1] {
+		high, low = decimal.Max(high, k.H), decimal.Min(low, k.L)
+	}
+	return high.Add(low).Div(decimal.NewFromInt(2))
+}
+
+func TestStream(t *testing.T) {
+	const tenkan, kijun, senkouB, displacement = 3, 5, 8, 4
+	klines := waveKlines(80)
+
+	stream, err := NewStreamWithPeriod(tenkan, kijun, senkouB, displacement)
+	if err != nil {
+		t.Fatalf("创建失败: %v", err)
+	}
+	if stream.Name() != "ICHIMOKU3_5_8_4" || stream.WarmUp() != 12 {
+		t.Fatalf("预期名称 ICHIMOKU3_5_8_4、WarmUp 为 12，实际为 %s、%d", stream.Name(), stream.WarmUp())
+	}
+
+	senkouA := func(i int) decimal.Decimal {
+		return referenceMidpoint(klines, i, tenkan).Add(referenceMidpoint(klines, i, kijun)).Div(decimal.NewFromInt(2))
+	}
+	crosses := 0
+	for i, k := range klines {
+		stream.Update(k)
+		if ready := i+1 >= stream.WarmUp(); stream.Ready() != ready {
+			t.Fatalf("第 %d 根K线后预期就绪状态为 %v", i+1, ready)
+		}
+		if !stream.Ready() {
+			continue
+		}
+
+		values := stream.Values()
+		expected := map[string]decimal.Decimal{
+			"tenkan":        referenceMidpoint(klines, i, tenkan),
+			"kijun":         referenceMidpoint(klines, i, kijun),
+			"senkou_a":      senkouA(i - displacement),
+			"senkou_b":      referenceMidpoint(klines, i-displacement, senkouB),
+			"senkou_a_lead": senkouA(i),
+			"senkou_b_lead": referenceMidpoint(klines, i, senkouB),
+			"chikou":        k.C,
+		}
+		for key, v := range expected {
+			if !values[key].Equal(v) {
+				t.Fatalf("第 %d 根K线后预期 %s 为 %s，实际为 %s", i+1, key, v, values[key])
+			}
+		}
+
+		// 云和迟行线的判断
+		top := decimal.Max(expected["senkou_a"], expected["senkou_b"])
+		bottom := decimal.Min(expected["senkou_a"], expected["senkou_b"])
+		if stream.AboveCloud(k.C) != k.C.GreaterThan(top) || stream.BelowCloud(k.C) != k.C.LessThan(bottom) {
+			t.Errorf("第 %d 根K线收盘价 %s，云为 %s-%s，判断错误", i+1, k.C, bottom, top)
+		}
+		if stream.ChikouAbovePrice() != k.C.GreaterThan(klines[i-displacement].C) {
+			t.Errorf("第 %d 根K线迟行线判断错误", i+1)
+		}
+		if stream.BullishCloud() != expected["senkou_a_lead"].GreaterThan(expected["senkou_b_lead"]) {
+			t.Errorf("第 %d 根K线阳云判断错误", i+1)
+		}
+
+		// 转换线与基准线的交叉
+		prevDiff := referenceMidpoint(klines, i-1, tenkan).Sub(referenceMidpoint(klines, i-1, kijun))
+		diff := expected["tenkan"].Sub(expected["kijun"])
+		expectedCross := CrossNone
+		if !prevDiff.IsPositive() && diff.IsPositive() {
+			expectedCross = CrossUp
+		} else if !prevDiff.IsNegative() && diff.IsNegative() {
+			expectedCross = CrossDown
+		}
+		if stream.TKCross() != expectedCross {
+			t.Errorf("第 %d 根K线预期交叉为 %d，实际为 %d", i+1, expectedCross, stream.TKCross())
+		}
+		if expectedCross != CrossNone {
+			crosses++
+		}
+	}
+	if crosses == 0 {
+		t.Errorf("测试数据中预期出现交叉")
+	}
+}
+
+func TestStreamParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		periods [4]int
+		wantErr bool
+	}{
+		{"常用参数", [4]int{9, 26, 52, 26}, false},
+		{"转换线周期无效", [4]int{0, 26, 52, 26}, true},
+		{"平移数量无效", [4]int{9, 26, 52, 0}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewStreamWithPeriod(tt.periods[0], tt.periods[1], tt.periods[2], tt.periods[3])
+			if (err != nil) != tt.wantErr {
+				t.Errorf("预期错误为 %v，实际为 %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	if stream := NewStream(); stream.Name() != "ICHIMOKU" || stream.WarmUp() != 78 {
+		t.Errorf("预期名称 ICHIMOKU、WarmUp 为 78，实际为 %s、%d", stream.Name(), stream.WarmUp())
+	}
+}
@@ -0,0 +1,52 @@
+// Package indicatestest 提供指标测试共用的K线构造函数
+package indicatestest
+
+import (
+	"snake/internal/kline"
+
+	"github.com/shopspring/decimal"
+)
+
+// OHLC 按 [开盘价, 最高价, 最低价, 收盘价] 生成连续的 1 分钟K线，成交量均为 1
+func OHLC(bars ...[4]float64) []*kline.Kline {
+	klines := make([]*kline.Kline, len(bars))
+	for i, bar := range bars {
+		klines[i] = &kline.Kline{
+			O: decimal.NewFromFloat(bar[0]),
+			H: decimal.NewFromFloat(bar[1]),
+			L: decimal.NewFromFloat(bar[2]),
+			C: decimal.NewFromFloat(bar[3]),
+			V: decimal.NewFromInt(1),
+			S: int64(i) * 60000,
+			E: int64(i)*60000 + 59999,
+		}
+	}
+	return klines
+}
+
+// HLC 按 [最高价, 最低价, 收盘价] 生成连续的 1 分钟K线，开盘价等于收盘价
+func HLC(bars ...[3]float64) []*kline.Kline {
+	ohlc := make([][4]float64, len(bars))
+	for i, bar := range bars {
+		ohlc[i] = [4]float64{bar[2], bar[0], bar[1], bar[2]}
+	}
+	return OHLC(ohlc...)
+}
+
+// HL 按 [最高价, 最低价] 生成连续的 1 分钟K线，开盘价为最低价，收盘价为最高价
+func HL(bars ...[2]float64) []*kline.Kline {
+	ohlc := make([][4]float64, len(bars))
+	for i, bar := range bars {
+		ohlc[i] = [4]float64{bar[1], bar[0], bar[1], bar[0]}
+	}
+	return OHLC(ohlc...)
+}
+
+// Closes 按收盘价生成连续的 1 分钟K线，开高低收相同
+func Closes(closes ...float64) []*kline.Kline {
+	ohlc := make([][4]float64, len(closes))
+	for i, c := range closes {
+		ohlc[i] = [4]float64{c, c, c, c}
+	}
+	return OHLC(ohlc...)
+}
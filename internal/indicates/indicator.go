@@ -0,0 +1,113 @@
+package indicates
+
+import (
+	"errors"
+	"snake/internal/kline"
+
+	"github.com/shopspring/decimal"
+)
+
+// Indicator 流式指标接口，所有指标都按K线逐根推入
+//
+// 推入的K线开盘时间与最后一根相同时视为未收盘K线的更新，替换最后一根重新计算；
+// 开盘时间早于最后一根的K线会被忽略
+type Indicator interface {
+	// Name 返回指标名称，包含参数，例如 MA20、RSI14
+	Name() string
+	// Update 推入一根K线
+	Update(kline *kline.Kline)
+	// Value 返回指标的主值，未就绪时为零
+	Value() decimal.Decimal
+	// Values 返回指标的所有输出，例如布林带的上轨、中轨、下轨
+	Values() map[string]decimal.Decimal
+	// Ready 是否已经推入足够的K线，指标值有效
+	Ready() bool
+	// WarmUp 指标就绪需要的K线数量
+	WarmUp() int
+	// Reset 清空已推入的K线，恢复到初始状态
+	Reset()
+	// Snapshot 保存指标的当前状态
+	Snapshot() Snapshot
+	// Restore 恢复到之前保存的状态，快照必须来自同类型的指标
+	Restore(snapshot Snapshot) error
+}
+
+// Snapshot 指标状态快照，内容由各指标自行定义
+type Snapshot any
+
+// ErrSnapshotMismatch 快照与指标类型不匹配
+var ErrSnapshotMismatch = errors.New("快照与指标类型不匹配")
+
+// Action 推入K线的处理方式
+type Action int
+
+const (
+	// ActionIgnore 忽略（早于最后一根K线）
+	ActionIgnore Action = iota
+	// ActionAppend 追加一根新K线
+	ActionAppend
+	// ActionReplace 替换最后一根K线（未收盘K线的更新）
+	ActionReplace
+)
+
+// Sequence 记录已推入的K线数量和最后一根K线的开盘时间
+type Sequence struct {
+	// 已推入的K线数量
+	Count int
+	// 最后一根K线的开盘时间（毫秒）
+	Last int64
+}
+
+// Next 判断K线的处理方式，并更新记录
+func (s *Sequence) Next(k *kline.Kline) Action {
+	switch {
+	case s.Count > 0 && k.S < s.Last:
+		return ActionIgnore
+	case s.Count > 0 && k.S == s.Last:
+		return ActionReplace
+	default:
+		s.Count++
+		s.Last = k.S
+		return ActionAppend
+	}
+}
+
+// Window 定长的滑动窗口，保存最近 size 个值
+type Window struct {
+	size   int
+	values []decimal.Decimal
+}
+
+// NewWindow 创建滑动窗口
+func NewWindow(size int) Window {
+	return Window{size: size, values: make([]decimal.Decimal, 0, size)}
+}
+
+// Push 按处理方式推入一个值
+func (w *Window) Push(action Action, value decimal.Decimal) {
+	switch action {
+	case ActionAppend:
+		if len(w.values) == w.size {
+			copy(w.values, w.values[1:])
+			w.values = w.values[:w.size-1]
+		}
+		w.values = append(w.values, value)
+	case ActionReplace:
+		if len(w.values) > 0 {
+			w.values[len(w.values)-1] = value
+		}
+	}
+}
+
+// Values 返回窗口内的值，从旧到新
+func (w *Window) Values() []decimal.Decimal { return w.values }
+
+// Full 窗口是否已满
+func (w *Window) Full() bool { return len(w.values) == w.size }
+
+// Clone 复制窗口，用于快照
+func (w Window) Clone() Window {
+	values := make([]decimal.Decimal, len(w.values), w.size)
+	copy(values, w.values)
+	return Window{size: w.size, values: values}
+}
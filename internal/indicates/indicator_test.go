@@ -0,0 +1,216 @@
+package indicates_test
+
+import (
+	"math"
+	"snake/internal/indicates"
+	"snake/internal/indicates/adx"
+	"snake/internal/indicates/atr"
+	bollingband "snake/internal/indicates/bolling-band"
+	"snake/internal/indicates/cmf"
+	"snake/internal/indicates/delta"
+	"snake/internal/indicates/divergence"
+	donchianchannel "snake/internal/indicates/donchian-channel"
+	"snake/internal/indicates/ichimoku"
+	"snake/internal/indicates/keltner"
+	"snake/internal/indicates/ma"
+	"snake/internal/indicates/macd"
+	"snake/internal/indicates/mfi"
+	"snake/internal/indicates/obv"
+	"snake/internal/indicates/patterns"
+	"snake/internal/indicates/pivot"
+	"snake/internal/indicates/psar"
+	"snake/internal/indicates/rsi"
+	"snake/internal/indicates/squeeze"
+	"snake/internal/indicates/stochastic"
+	"snake/internal/indicates/stochrsi"
+	"snake/internal/indicates/supertrend"
+	"snake/internal/indicates/vwap"
+	"snake/internal/kline"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+// waveKlines 生成价格按正弦波动的连续 1 分钟K线
+func waveKlines(count int) []*kline.Kline {
+	klines := make([]*kline.Kline, count)
+	for i := range klines {
+		price := decimal.NewFromFloat(100 + 10*math.Sin(float64(i)/5)).Round(4)
+		klines[i] = &kline.Kline{
+			O: price.Sub(decimal.NewFromFloat(0.5)),
+			C: price,
+			H: price.Add(decimal.NewFromInt(1)),
+			L: price.Sub(decimal.NewFromInt(1)),
+			V: decimal.NewFromInt(1000),
+			S: int64(i) * 60000,
+			E: int64(i)*60000 + 59999,
+		}
+	}
+	return klines
+}
+
+func must[T any](v T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func equalValues(a, b map[string]decimal.Decimal) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, v := range a {
+		if !v.Equal(b[name]) {
+			return false
+		}
+	}
+	return true
+}
+
+// TestIndicatorContract 所有指标都遵守 Indicator 接口的约定
+func TestIndicatorContract(t *testing.T) {
+	factories := []func() indicates.Indicator{
+		func() indicates.Indicator { return ma.NewStream(5) },
+		func() indicates.Indicator { return bollingband.NewStream(5, decimal.NewFromInt(2)) },
+		func() indicates.Indicator { return donchianchannel.NewStream(5) },
+		func() indicates.Indicator { return rsi.NewStream(5) },
+		func() indicates.Indicator { return macd.NewStream(3, 6, 4) },
+		func() indicates.Indicator { return must(ma.NewTypedStream(ma.TypeEMA, 5)) },
+		func() indicates.Indicator { return must(ma.NewTypedStream(ma.TypeHMA, 5)) },
+		func() indicates.Indicator { return must(ma.NewTypedStream(ma.TypeKAMA, 5)) },
+		func() indicates.Indicator {
+			return must(bollingband.NewStreamWithMA(ma.TypeTEMA, 5, decimal.NewFromInt(2)))
+		},
+		func() indicates.Indicator { return must(macd.NewStreamWithMA(ma.TypeWMA, 3, 6, 4)) },
+		func() indicates.Indicator { return atr.NewStream(5) },
+		func() indicates.Indicator { return must(atr.NewStreamWithMA(ma.TypeSMA, 5)) },
+		func() indicates.Indicator { return keltner.NewStream(6, 4, decimal.NewFromInt(2)) },
+		func() indicates.Indicator { return stochastic.NewStream(5, 3, 3) },
+		func() indicates.Indicator { return must(stochastic.NewStreamWithMA(ma.TypeEMA, 5, 3, 3)) },
+		func() indicates.Indicator { return stochrsi.NewStream(5, 5, 3, 3) },
+		func() indicates.Indicator { return adx.NewStream(5) },
+		func() indicates.Indicator { return must(ichimoku.NewStreamWithPeriod(3, 5, 8, 4)) },
+		func() indicates.Indicator { return psar.NewStream() },
+		func() indicates.Indicator { return supertrend.NewStream(5, decimal.NewFromInt(2)) },
+		func() indicates.Indicator { return obv.NewStream() },
+		func() indicates.Indicator { return vwap.NewStream() },
+		func() indicates.Indicator { return mfi.NewStream(5) },
+		func() indicates.Indicator { return cmf.NewStream(5) },
+		func() indicates.Indicator { return delta.NewStream() },
+		func() indicates.Indicator { return patterns.NewStream() },
+		func() indicates.Indicator { return pivot.NewStream(pivot.MethodCamarilla) },
+		func() indicates.Indicator { return pivot.NewSwingStream(2, decimal.NewFromFloat(0.01)) },
+		func() indicates.Indicator { return divergence.NewStream(rsi.NewStream(5)) },
+		func() indicates.Indicator {
+			config := divergence.Config{Left: 2, Right: 2, MinDistance: 3, MaxDistance: 20, Hidden: true}
+			return must(divergence.NewStreamWithConfig(divergence.Select(macd.NewStream(3, 6, 4), "histogram"), config))
+		},
+		func() indicates.Indicator {
+			config := bollingband.Config{MA: ma.TypeEMA, Period: 5, Multiplier: decimal.NewFromFloat(2.5), Deviation: bollingband.DeviationSample}
+			return must(bollingband.NewStreamWithConfig(config))
+		},
+		func() indicates.Indicator {
+			config := squeeze.Config{MA: ma.TypeSMA, Period: 5, BBMultiplier: decimal.NewFromInt(2), KCMultiplier: decimal.NewFromFloat(1.5), ATRPeriod: 5}
+			return must(squeeze.NewStreamWithConfig(config))
+		},
+		func() indicates.Indicator { return indicates.WithSource(rsi.NewStream(5), indicates.SourceHLC3) },
+		func() indicates.Indicator { return indicates.WithSource(ma.NewStream(5), indicates.SourceVolume) },
+	}
+	klines := waveKlines(40)
+
+	for _, factory := range factories {
+		indicator := factory()
+		t.Run(indicator.Name(), func(t *testing.T) {
+			t.Run("推入 WarmUp 根K线后就绪", func(t *testing.T) {
+				indicator := factory()
+				for i, k := range klines {
+					indicator.Update(k)
+					if ready := i+1 >= indicator.WarmUp(); indicator.Ready() != ready {
+						t.Fatalf("第 %d 根K线后预期就绪状态为 %v", i+1, ready)
+					}
+				}
+			})
+
+			t.Run("未收盘K线的更新替换最后一根", func(t *testing.T) {
+				expected := factory()
+				for _, k := range klines[:20] {
+					expected.Update(k)
+				}
+
+				indicator := factory()
+				for _, k := range klines[:19] {
+					indicator.Update(k)
+				}
+				// 第 20 根K线先推入一次其他价格，再推入最终价格
+				last := *klines[19]
+				last.C = last.C.Add(decimal.NewFromInt(7))
+				last.H = last.H.Add(decimal.NewFromInt(7))
+				indicator.Update(&last)
+				indicator.Update(klines[19])
+
+				if !equalValues(indicator.Values(), expected.Values()) {
+					t.Errorf("预期 %v，实际为 %v", expected.Values(), indicator.Values())
+				}
+			})
+
+			t.Run("忽略早于最后一根的K线", func(t *testing.T) {
+				indicator := factory()
+				for _, k := range klines[:20] {
+					indicator.Update(k)
+				}
+				values := indicator.Values()
+				indicator.Update(klines[3])
+				if !equalValues(indicator.Values(), values) {
+					t.Errorf("推入旧K线后指标值发生变化")
+				}
+			})
+
+			t.Run("快照和恢复", func(t *testing.T) {
+				indicator := factory()
+				for _, k := range klines[:20] {
+					indicator.Update(k)
+				}
+				snapshot := indicator.Snapshot()
+				values := indicator.Values()
+
+				for _, k := range klines[20:] {
+					indicator.Update(k)
+				}
+				if err := indicator.Restore(snapshot); err != nil {
+					t.Fatalf("恢复失败: %v", err)
+				}
+				if !equalValues(indicator.Values(), values) {
+					t.Fatalf("恢复后预期 %v，实际为 %v", values, indicator.Values())
+				}
+
+				// 恢复后继续推入，结果与不中断时一致
+				expected := factory()
+				for _, k := range klines {
+					expected.Update(k)
+				}
+				for _, k := range klines[20:] {
+					indicator.Update(k)
+				}
+				if !equalValues(indicator.Values(), expected.Values()) {
+					t.Errorf("恢复后继续推入，预期 %v，实际为 %v", expected.Values(), indicator.Values())
+				}
+
+				if err := indicator.Restore(struct{}{}); err != indicates.ErrSnapshotMismatch {
+					t.Errorf("预期快照类型不匹配时报错")
+				}
+			})
+
+			t.Run("重置", func(t *testing.T) {
+				indicator := factory()
+				for _, k := range klines {
+					indicator.Update(k)
+				}
+				indicator.Reset()
+				if indicator.Ready() || !indicator.Value().IsZero() {
+					t.Errorf("预期重置后未就绪")
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package keltner
+
+import (
+	"fmt"
+	"snake/internal/indicates"
+	"snake/internal/indicates/atr"
+	"snake/internal/indicates/ma"
+	"snake/internal/kline"
+
+	"github.com/shopspring/decimal"
+)
+
+// Stream 流式肯特纳通道（Keltner Channel）
+// 中轨为收盘价的移动平均线（默认 EMA），上下轨为中轨加减 multiplier 倍的 ATR（Wilder 平滑）
+type Stream struct {
+	kind       ma.Type
+	period     int
+	atrPeriod  int
+	multiplier decimal.Decimal
+	state      streamState
+}
+
+type streamState struct {
+	seq     indicates.Sequence
+	average ma.Average
+	atr     *atr.Stream
+	middle  decimal.Decimal
+	upper   decimal.Decimal
+	lower   decimal.Decimal
+}
+
+var _ indicates.Indicator = (*Stream)(nil)
+
+// NewStream 创建中轨为 EMA 的流式肯特纳通道，multiplier 通常为 2
+func NewStream(period, atrPeriod int, multiplier decimal.Decimal) *Stream {
+	s, _ := NewStreamWithMA(ma.TypeEMA, period, atrPeriod, multiplier)
+	return s
+}
+
+// NewStreamWithMA 创建中轨为指定类型移动平均线的流式肯特纳通道
+func NewStreamWithMA(kind ma.Type, period, atrPeriod int, multiplier decimal.Decimal) (*Stream, error) {
+	if _, err := ma.NewAverage(kind, period); err != nil {
+		return nil, err
+	}
+	if atrPeriod < 1 {
+		return nil, fmt.Errorf("invalid atr period: %d", atrPeriod)
+	}
+
+	s := &Stream{kind: kind, period: period, atrPeriod: atrPeriod, multiplier: multiplier}
+	s.Reset()
+	return s, nil
+}
+
+// Name 中轨为 EMA 时为 KC<period>_<atrPeriod>，否则在末尾加上类型，如 KC20_10_SMA
+func (s *Stream) Name() string {
+	name := fmt.Sprintf("KC%d_%d", s.period, s.atrPeriod)
+	if s.kind != ma.TypeEMA {
+		name += "_" + s.kind.String()
+	}
+	return name
+}
+
+func (s *Stream) Update(kline *kline.Kline) {
+	action := s.state.seq.Next(kline)
+	if action == indicates.ActionIgnore {
+		return
+	}
+
+	s.state.average.Push(action, kline.C)
+	s.state.atr.Update(kline)
+	if !s.Ready() {
+		return
+	}
+
+	width := s.state.atr.Value().Mul(s.multiplier)
+	s.state.middle = s.state.average.Value()
+	s.state.upper = s.state.middle.Add(width)
+	s.state.lower = s.state.middle.Sub(width)
+}
+
+// Value 返回中轨
+func (s *Stream) Value() decimal.Decimal { return s.state.middle }
+
+func (s *Stream) Values() map[string]decimal.Decimal {
+	return map[string]decimal.Decimal{
+		"upper":  s.state.upper,
+		"middle": s.state.middle,
+		"lower":  s.state.lower,
+	}
+}
+
+func (s *Stream) Ready() bool { return s.state.average.Ready() && s.state.atr.Ready() }
+
+func (s *Stream) WarmUp() int { return max(s.state.average.WarmUp(), s.state.atr.WarmUp()) }
+
+func (s *Stream) Reset() {
+	average, _ := ma.NewAverage(s.kind, s.period)
+	s.state = streamState{average: average, atr: atr.NewStream(s.atrPeriod)}
+}
+
+func (s *Stream) Snapshot() indicates.Snapshot {
+	state := s.state
+	state.average = s.state.average.Clone()
+	state.atr = atr.NewStream(s.atrPeriod)
+	state.atr.Restore(s.state.atr.Snapshot())
+	return &state
+}
+
+func (s *Stream) Restore(snapshot indicates.Snapshot) error {
+	state, ok := snapshot.(*streamState)
+	if !ok {
+		return indicates.ErrSnapshotMismatch
+	}
+	s.state = *state
+	s.state.average = state.average.Clone()
+	s.state.atr = atr.NewStream(s.atrPeriod)
+	return s.state.atr.Restore(state.atr.Snapshot())
+}
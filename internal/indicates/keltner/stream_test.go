@@ -0,0 +1,58 @@
+package keltner
+
+import (
+	"math"
+	"snake/internal/indicates/atr"
+	"snake/internal/indicates/ma"
+	"snake/internal/kline"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestStream(t *testing.T) {
+	multiplier := decimal.NewFromInt(2)
+	stream := NewStream(10, 5, multiplier)
+	average, _ := ma.NewTypedStream(ma.TypeEMA, 10)
+	volatility := atr.NewStream(5)
+
+	if stream.Name() != "KC10_5" || stream.WarmUp() != 10 {
+		t.Fatalf("预期名称 KC10_5、WarmUp 为 10，实际为 %s、%d", stream.Name(), stream.WarmUp())
+	}
+
+	for i := range 40 {
+		price := decimal.NewFromFloat(100 + 5*math.Sin(float64(i)/4)).Round(4)
+		k := &kline.Kline{
+			O: price, C: price,
+			H: price.Add(decimal.NewFromFloat(1.5)),
+			L: price.Sub(decimal.NewFromInt(1)),
+			S: int64(i) * 60000,
+			E: int64(i)*60000 + 59999,
+		}
+		stream.Update(k)
+		average.Update(k)
+		volatility.Update(k)
+
+		if ready := i+1 >= 10; stream.Ready() != ready {
+			t.Fatalf("第 %d 根K线后预期就绪状态为 %v", i+1, ready)
+		}
+		if !stream.Ready() {
+			continue
+		}
+
+		values := stream.Values()
+		width := volatility.Value().Mul(multiplier)
+		if !values["middle"].Equal(average.Value()) ||
+			!values["upper"].Equal(average.Value().Add(width)) ||
+			!values["lower"].Equal(average.Value().Sub(width)) {
+			t.Errorf("第 %d 根K线后通道为 %v，预期中轨 %s、宽度 %s", i+1, values, average.Value(), width)
+		}
+	}
+
+	if _, err := NewStreamWithMA(ma.Type("VWMA"), 10, 5, multiplier); err == nil {
+		t.Errorf("预期未知类型创建失败")
+	}
+	if _, err := NewStreamWithMA(ma.TypeSMA, 10, 0, multiplier); err == nil {
+		t.Errorf("预期 ATR 周期为 0 时创建失败")
+	}
+}
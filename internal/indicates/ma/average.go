@@ -0,0 +1,358 @@
+package ma
+
+import (
+	"fmt"
+	"math"
+	"snake/internal/indicates"
+
+	"github.com/shopspring/decimal"
+)
+
+// Average 对数值序列增量计算的移动平均线
+// Push 使用与K线相同的处理方式：追加新值或替换最后一个值，每次推入 O(1)
+type Average interface {
+	Push(action indicates.Action, value decimal.Decimal)
+	Value() decimal.Decimal
+	Ready() bool
+	// WarmUp 返回就绪所需的值的数量
+	WarmUp() int
+	Clone() Average
+}
+
+// NewAverage 按类型创建移动平均线
+func NewAverage(kind Type, period int) (Average, error) {
+	if period < 1 {
+		return nil, fmt.Errorf("invalid moving average period: %d", period)
+	}
+
+	switch kind {
+	case TypeSMA:
+		return &sma{period: period, sum: indicates.NewRollingSum(period)}, nil
+	case TypeEMA:
+		return newEMA(period), nil
+	case TypeWMA:
+		return newWMA(period), nil
+	case TypeDEMA:
+		return &dema{ema1: newEMA(period), ema2: newEMA(period)}, nil
+	case TypeTEMA:
+		return &tema{ema1: newEMA(period), ema2: newEMA(period), ema3: newEMA(period)}, nil
+	case TypeHMA:
+		return newHMA(period), nil
+	case TypeKAMA:
+		return newKAMA(period), nil
+	case TypeRMA:
+		return &rma{period: period}, nil
+	default:
+		return nil, fmt.Errorf("unknown moving average type: %s", kind)
+	}
+}
+
+// chain 把 source 的输出推入 target，source 就绪前不推入
+// 就绪只取决于值的数量，所以同一个值的追加和替换总会得到相同的就绪状态
+func chain(target, source Average, action indicates.Action) {
+	if source.Ready() {
+		target.Push(action, source.Value())
+	}
+}
+
+// sma 简单移动平均
+type sma struct {
+	period int
+	sum    indicates.RollingSum
+}
+
+func (a *sma) Push(action indicates.Action, value decimal.Decimal) { a.sum.Push(action, value) }
+
+func (a *sma) Value() decimal.Decimal {
+	if !a.sum.Full() {
+		return decimal.Zero
+	}
+	return a.sum.Sum().Div(decimal.NewFromInt(int64(a.period)))
+}
+
+func (a *sma) Ready() bool { return a.sum.Full() }
+func (a *sma) WarmUp() int { return a.period }
+func (a *sma) Clone() Average {
+	clone := *a
+	clone.sum = a.sum.Clone()
+	return &clone
+}
+
+// smoothState 以前 period 个值的简单平均值为初始值的平滑状态
+type smoothState struct {
+	count int
+	sum   decimal.Decimal
+	value decimal.Decimal
+}
+
+// ema 指数移动平均，k = 2/(period+1)
+// 保存推入最后一个值前后的状态，替换最后一个值时从 prev 重新计算
+type ema struct {
+	period int
+	k      decimal.Decimal
+	prev   smoothState
+	cur    smoothState
+}
+
+func newEMA(period int) *ema {
+	return &ema{period: period, k: decimal.NewFromFloat(2.0 / float64(period+1))}
+}
+
+func (a *ema) Push(action indicates.Action, value decimal.Decimal) {
+	switch action {
+	case indicates.ActionAppend:
+		a.prev = a.cur
+	case indicates.ActionIgnore:
+		return
+	}
+
+	state := a.prev
+	state.count++
+	switch {
+	case state.count < a.period:
+		state.sum = state.sum.Add(value)
+	case state.count == a.period:
+		state.sum = state.sum.Add(value)
+		state.value = state.sum.Div(decimal.NewFromInt(int64(a.period)))
+	default:
+		// EMA(today) = Price(today) * k + EMA(yesterday) * (1-k)
+		state.value = value.Mul(a.k).Add(state.value.Mul(decimal.NewFromInt(1).Sub(a.k)))
+	}
+	a.cur = state
+}
+
+func (a *ema) Value() decimal.Decimal { return a.cur.value }
+func (a *ema) Ready() bool            { return a.cur.count >= a.period }
+func (a *ema) WarmUp() int            { return a.period }
+func (a *ema) Clone() Average {
+	clone := *a
+	return &clone
+}
+
+// rma Wilder 平滑移动平均：RMA = ((period-1) * RMA(yesterday) + Price(today)) / period
+type rma struct {
+	period int
+	prev   smoothState
+	cur    smoothState
+}
+
+func (a *rma) Push(action indicates.Action, value decimal.Decimal) {
+	switch action {
+	case indicates.ActionAppend:
+		a.prev = a.cur
+	case indicates.ActionIgnore:
+		return
+	}
+
+	period := decimal.NewFromInt(int64(a.period))
+	state := a.prev
+	state.count++
+	switch {
+	case state.count < a.period:
+		state.sum = state.sum.Add(value)
+	case state.count == a.period:
+		state.sum = state.sum.Add(value)
+		state.value = state.sum.Div(period)
+	default:
+		state.value = period.Sub(decimal.NewFromInt(1)).Mul(state.value).Add(value).Div(period)
+	}
+	a.cur = state
+}
+
+func (a *rma) Value() decimal.Decimal { return a.cur.value }
+func (a *rma) Ready() bool            { return a.cur.count >= a.period }
+func (a *rma) WarmUp() int            { return a.period }
+func (a *rma) Clone() Average {
+	clone := *a
+	return &clone
+}
+
+// wma 线性加权移动平均，最新的值权重为 period，最旧的值权重为 1
+type wma struct {
+	period int
+	sum    indicates.RollingWeightedSum
+}
+
+func newWMA(period int) *wma {
+	return &wma{period: period, sum: indicates.NewRollingWeightedSum(period)}
+}
+
+func (a *wma) Push(action indicates.Action, value decimal.Decimal) { a.sum.Push(action, value) }
+
+func (a *wma) Value() decimal.Decimal {
+	if !a.sum.Full() {
+		return decimal.Zero
+	}
+	return a.sum.Sum().Div(a.sum.Weights())
+}
+
+func (a *wma) Ready() bool { return a.sum.Full() }
+func (a *wma) WarmUp() int { return a.period }
+func (a *wma) Clone() Average {
+	clone := *a
+	clone.sum = a.sum.Clone()
+	return &clone
+}
+
+// dema 双重指数移动平均：2 * EMA - EMA(EMA)
+type dema struct {
+	ema1 *ema
+	ema2 *ema
+}
+
+func (a *dema) Push(action indicates.Action, value decimal.Decimal) {
+	a.ema1.Push(action, value)
+	chain(a.ema2, a.ema1, action)
+}
+
+func (a *dema) Value() decimal.Decimal {
+	if !a.Ready() {
+		return decimal.Zero
+	}
+	return a.ema1.Value().Mul(decimal.NewFromInt(2)).Sub(a.ema2.Value())
+}
+
+func (a *dema) Ready() bool { return a.ema2.Ready() }
+func (a *dema) WarmUp() int { return 2*a.ema1.period - 1 }
+func (a *dema) Clone() Average {
+	return &dema{ema1: a.ema1.Clone().(*ema), ema2: a.ema2.Clone().(*ema)}
+}
+
+// tema 三重指数移动平均：3 * EMA - 3 * EMA(EMA) + EMA(EMA(EMA))
+type tema struct {
+	ema1 *ema
+	ema2 *ema
+	ema3 *ema
+}
+
+func (a *tema) Push(action indicates.Action, value decimal.Decimal) {
+	a.ema1.Push(action, value)
+	chain(a.ema2, a.ema1, action)
+	chain(a.ema3, a.ema2, action)
+}
+
+func (a *tema) Value() decimal.Decimal {
+	if !a.Ready() {
+		return decimal.Zero
+	}
+	three := decimal.NewFromInt(3)
+	return a.ema1.Value().Mul(three).Sub(a.ema2.Value().Mul(three)).Add(a.ema3.Value())
+}
+
+func (a *tema) Ready() bool { return a.ema3.Ready() }
+func (a *tema) WarmUp() int { return 3*a.ema1.period - 2 }
+func (a *tema) Clone() Average {
+	return &tema{ema1: a.ema1.Clone().(*ema), ema2: a.ema2.Clone().(*ema), ema3: a.ema3.Clone().(*ema)}
+}
+
+// hma Hull 移动平均：WMA(2 * WMA(n/2) - WMA(n), sqrt(n))
+type hma struct {
+	half   *wma
+	full   *wma
+	smooth *wma
+}
+
+func newHMA(period int) *hma {
+	return &hma{
+		half:   newWMA(max(period/2, 1)),
+		full:   newWMA(period),
+		smooth: newWMA(max(int(math.Sqrt(float64(period))), 1)),
+	}
+}
+
+func (a *hma) Push(action indicates.Action, value decimal.Decimal) {
+	a.half.Push(action, value)
+	a.full.Push(action, value)
+	if a.full.Ready() {
+		a.smooth.Push(action, a.half.Value().Mul(decimal.NewFromInt(2)).Sub(a.full.Value()))
+	}
+}
+
+func (a *hma) Value() decimal.Decimal { return a.smooth.Value() }
+func (a *hma) Ready() bool            { return a.smooth.Ready() }
+func (a *hma) WarmUp() int            { return a.full.period + a.smooth.period - 1 }
+func (a *hma) Clone() Average {
+	return &hma{half: a.half.Clone().(*wma), full: a.full.Clone().(*wma), smooth: a.smooth.Clone().(*wma)}
+}
+
+// kama Kaufman 自适应移动平均，快慢平滑周期分别为 2 和 30：
+// ER = |period 内的价格变化| / period 内逐根变化的绝对值之和
+// SC = (ER * (2/3 - 2/31) + 2/31)²
+// KAMA = KAMA(yesterday) + SC * (Price(today) - KAMA(yesterday))，以 ER 首次可用时的前一个价格为初始值
+type kama struct {
+	period     int
+	direction  indicates.RollingSum // 逐根价格变化之和，即 period 内的价格变化
+	volatility indicates.RollingSum // 逐根价格变化的绝对值之和
+	prev       kamaState
+	cur        kamaState
+}
+
+type kamaState struct {
+	count int
+	price decimal.Decimal
+	value decimal.Decimal
+	ready bool
+}
+
+var (
+	kamaFastSC = decimal.NewFromInt(2).Div(decimal.NewFromInt(3))
+	kamaSlowSC = decimal.NewFromInt(2).Div(decimal.NewFromInt(31))
+)
+
+func newKAMA(period int) *kama {
+	return &kama{
+		period:     period,
+		direction:  indicates.NewRollingSum(period),
+		volatility: indicates.NewRollingSum(period),
+	}
+}
+
+func (a *kama) Push(action indicates.Action, value decimal.Decimal) {
+	switch action {
+	case indicates.ActionAppend:
+		a.prev = a.cur
+	case indicates.ActionIgnore:
+		return
+	}
+
+	state := a.prev
+	state.count++
+	state.price = value
+	if state.count == 1 {
+		a.cur = state
+		return
+	}
+
+	change := value.Sub(a.prev.price)
+	a.direction.Push(action, change)
+	a.volatility.Push(action, change.Abs())
+	if !a.volatility.Full() {
+		a.cur = state
+		return
+	}
+
+	var er = decimal.Zero
+	if volatility := a.volatility.Sum(); !volatility.IsZero() {
+		er = a.direction.Sum().Abs().Div(volatility)
+	}
+	sc := er.Mul(kamaFastSC.Sub(kamaSlowSC)).Add(kamaSlowSC)
+	sc = sc.Mul(sc)
+
+	base := a.prev.value
+	if !a.prev.ready {
+		base = a.prev.price
+	}
+	state.value = base.Add(sc.Mul(value.Sub(base)))
+	state.ready = true
+	a.cur = state
+}
+
+func (a *kama) Value() decimal.Decimal { return a.cur.value }
+func (a *kama) Ready() bool            { return a.cur.ready }
+func (a *kama) WarmUp() int            { return a.period + 1 }
+func (a *kama) Clone() Average {
+	clone := *a
+	clone.direction = a.direction.Clone()
+	clone.volatility = a.volatility.Clone()
+	return &clone
+}
@@ -0,0 +1,314 @@
+package ma
+
+import (
+	"math"
+	"snake/internal/indicates"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+// 以下为按定义对整个序列逐点计算的参考实现，未就绪的位置为 nil
+
+func referenceSMA(values []decimal.Decimal, period int) []*decimal.Decimal {
+	result := make([]*decimal.Decimal, len(values))
+	for i := period - 1; i < len(values); i++ {
+		sum := decimal.Zero
+		for _, v := range values[i+1-period : i+1] {
+			sum = sum.Add(v)
+		}
+		v := sum.Div(decimal.NewFromInt(int64(period)))
+		result[i] = &v
+	}
+	return result
+}
+
+// referenceSmooth 以简单平均值为初始值的递推平滑，next 为递推公式
+func referenceSmooth(values []decimal.Decimal, period int, next func(prev, v decimal.Decimal) decimal.Decimal) []*decimal.Decimal {
+	result := make([]*decimal.Decimal, len(values))
+	if len(values) < period {
+		return result
+	}
+	seed := *referenceSMA(values[:period], period)[period-1]
+	result[period-1] = &seed
+	value := seed
+	for i := period; i < len(values); i++ {
+		value = next(value, values[i])
+		v := value
+		result[i] = &v
+	}
+	return result
+}
+
+func referenceEMA(values []decimal.Decimal, period int) []*decimal.Decimal {
+	k := decimal.NewFromFloat(2.0 / float64(period+1))
+	return referenceSmooth(values, period, func(prev, v decimal.Decimal) decimal.Decimal {
+		return v.Mul(k).Add(prev.Mul(decimal.NewFromInt(1).Sub(k)))
+	})
+}
+
+func referenceRMA(values []decimal.Decimal, period int) []*decimal.Decimal {
+	n := decimal.NewFromInt(int64(period))
+	return referenceSmooth(values, period, func(prev, v decimal.Decimal) decimal.Decimal {
+		return n.Sub(decimal.NewFromInt(1)).Mul(prev).Add(v).Div(n)
+	})
+}
+
+func referenceWMA(values []decimal.Decimal, period int) []*decimal.Decimal {
+	result := make([]*decimal.Decimal, len(values))
+	weights := decimal.NewFromInt(int64(period * (period + 1) / 2))
+	for i := period - 1; i < len(values); i++ {
+		sum := decimal.Zero
+		for j, v := range values[i+1-period : i+1] {
+			sum = sum.Add(v.Mul(decimal.NewFromInt(int64(j + 1))))
+		}
+		v := sum.Div(weights)
+		result[i] = &v
+	}
+	return result
+}
+
+// compact 去掉未就绪的位置，用于把一个平均线的输出作为另一个的输入
+func compact(values []*decimal.Decimal) ([]decimal.Decimal, int) {
+	var result []decimal.Decimal
+	offset := -1
+	for i, v := range values {
+		if v != nil {
+			if offset < 0 {
+				offset = i
+			}
+			result = append(result, *v)
+		}
+	}
+	return result, offset
+}
+
+// expand 把 compact 后计算的结果放回原序列的位置
+func expand(values []*decimal.Decimal, offset, length int) []*decimal.Decimal {
+	result := make([]*decimal.Decimal, length)
+	if offset < 0 {
+		return result
+	}
+	copy(result[offset:], values)
+	return result
+}
+
+func referenceDEMA(values []decimal.Decimal, period int) []*decimal.Decimal {
+	ema1 := referenceEMA(values, period)
+	inner, offset := compact(ema1)
+	ema2 := expand(referenceEMA(inner, period), offset, len(values))
+
+	result := make([]*decimal.Decimal, len(values))
+	for i := range values {
+		if ema2[i] != nil {
+			v := ema1[i].Mul(decimal.NewFromInt(2)).Sub(*ema2[i])
+			result[i] = &v
+		}
+	}
+	return result
+}
+
+func referenceTEMA(values []decimal.Decimal, period int) []*decimal.Decimal {
+	ema1 := referenceEMA(values, period)
+	inner, offset := compact(ema1)
+	ema2 := expand(referenceEMA(inner, period), offset, len(values))
+	inner, offset = compact(ema2)
+	ema3 := expand(referenceEMA(inner, period), offset, len(values))
+
+	result := make([]*decimal.Decimal, len(values))
+	three := decimal.NewFromInt(3)
+	for i := range values {
+		if ema3[i] != nil {
+			v := ema1[i].Mul(three).Sub(ema2[i].Mul(three)).Add(*ema3[i])
+			result[i] = &v
+		}
+	}
+	return result
+}
+
+func referenceHMA(values []decimal.Decimal, period int) []*decimal.Decimal {
+	half := referenceWMA(values, period/2)
+	full := referenceWMA(values, period)
+
+	raw := make([]*decimal.Decimal, len(values))
+	for i := range values {
+		if full[i] != nil {
+			v := half[i].Mul(decimal.NewFromInt(2)).Sub(*full[i])
+			raw[i] = &v
+		}
+	}
+	inner, offset := compact(raw)
+	return expand(referenceWMA(inner, int(math.Sqrt(float64(period)))), offset, len(values))
+}
+
+func referenceKAMA(values []decimal.Decimal, period int) []*decimal.Decimal {
+	fast := decimal.NewFromInt(2).Div(decimal.NewFromInt(3))
+	slow := decimal.NewFromInt(2).Div(decimal.NewFromInt(31))
+
+	result := make([]*decimal.Decimal, len(values))
+	var kama = decimal.Zero
+	for i := period; i < len(values); i++ {
+		volatility := decimal.Zero
+		for j := i + 1 - period; j <= i; j++ {
+			volatility = volatility.Add(values[j].Sub(values[j-1]).Abs())
+		}
+		er := decimal.Zero
+		if !volatility.IsZero() {
+			er = values[i].Sub(values[i-period]).Abs().Div(volatility)
+		}
+		sc := er.Mul(fast.Sub(slow)).Add(slow)
+		sc = sc.Mul(sc)
+
+		if i == period {
+			kama = values[i-1]
+		}
+		kama = kama.Add(sc.Mul(values[i].Sub(kama)))
+		v := kama
+		result[i] = &v
+	}
+	return result
+}
+
+// waveValues 生成带趋势和波动的测试序列
+func waveValues(count int) []decimal.Decimal {
+	values := make([]decimal.Decimal, count)
+	for i := range values {
+		values[i] = decimal.NewFromFloat(100 + float64(i)*0.3 + 8*math.Sin(float64(i)/3)).Round(4)
+	}
+	return values
+}
+
+func TestAverage(t *testing.T) {
+	const period = 9
+	values := waveValues(120)
+	tolerance := decimal.New(1, -10)
+
+	tests := []struct {
+		kind      Type
+		warmUp    int
+		reference func([]decimal.Decimal, int) []*decimal.Decimal
+	}{
+		{TypeSMA, period, referenceSMA},
+		{TypeEMA, period, referenceEMA},
+		{TypeWMA, period, referenceWMA},
+		{TypeDEMA, 2*period - 1, referenceDEMA},
+		{TypeTEMA, 3*period - 2, referenceTEMA},
+		{TypeHMA, period + 2, referenceHMA},
+		{TypeKAMA, period + 1, referenceKAMA},
+		{TypeRMA, period, referenceRMA},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.kind.String(), func(t *testing.T) {
+			average, err := NewAverage(tt.kind, period)
+			if err != nil {
+				t.Fatalf("创建失败: %v", err)
+			}
+			if average.WarmUp() != tt.warmUp {
+				t.Fatalf("预期 WarmUp 为 %d，实际为 %d", tt.warmUp, average.WarmUp())
+			}
+
+			expected := tt.reference(values, period)
+			for i, v := range values {
+				// 每个值先推入一个偏离的值，再替换为实际值
+				average.Push(indicates.ActionAppend, v.Add(decimal.NewFromInt(50)))
+				average.Push(indicates.ActionReplace, v)
+
+				ready := i+1 >= tt.warmUp
+				if average.Ready() != ready || (expected[i] != nil) != ready {
+					t.Fatalf("第 %d 个值后预期就绪状态为 %v", i+1, ready)
+				}
+				if ready && average.Value().Sub(*expected[i]).Abs().GreaterThan(tolerance) {
+					t.Fatalf("第 %d 个值后预期 %s，实际为 %s", i+1, expected[i], average.Value())
+				}
+			}
+		})
+	}
+}
+
+func TestAverageKnownValues(t *testing.T) {
+	values := []decimal.Decimal{
+		decimal.NewFromInt(1), decimal.NewFromInt(2), decimal.NewFromInt(3),
+		decimal.NewFromInt(4), decimal.NewFromInt(5), decimal.NewFromInt(6),
+	}
+
+	tests := []struct {
+		kind     Type
+		period   int
+		expected string
+	}{
+		{TypeSMA, 3, "5"},
+		// (4*1 + 5*2 + 6*3) / 6
+		{TypeWMA, 3, "5.3333333333333333"},
+		// 初始值 (1+2+3)/3 = 2，之后每步 v*0.5 + prev*0.5：3, 4, 5
+		{TypeEMA, 3, "5"},
+		// 初始值 2，之后每步 (2*prev + v)/3：2.6667, 3.4444, 4.2963
+		{TypeRMA, 3, "4.2962962962962963"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.kind.String(), func(t *testing.T) {
+			average, _ := NewAverage(tt.kind, tt.period)
+			for _, v := range values {
+				average.Push(indicates.ActionAppend, v)
+			}
+			if !average.Value().Round(10).Equal(decimal.RequireFromString(tt.expected).Round(10)) {
+				t.Errorf("预期 %s，实际为 %s", tt.expected, average.Value())
+			}
+		})
+	}
+}
+
+func TestAverageClone(t *testing.T) {
+	values := waveValues(40)
+	for _, kind := range Types() {
+		t.Run(kind.String(), func(t *testing.T) {
+			average, _ := NewAverage(kind, 5)
+			for _, v := range values[:30] {
+				average.Push(indicates.ActionAppend, v)
+			}
+
+			clone := average.Clone()
+			value := clone.Value()
+			for _, v := range values[30:] {
+				average.Push(indicates.ActionAppend, v)
+			}
+			if !clone.Value().Equal(value) {
+				t.Errorf("预期克隆不受影响，值为 %s，实际为 %s", value, clone.Value())
+			}
+		})
+	}
+}
+
+func TestParseType(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Type
+		wantErr  bool
+	}{
+		{"", TypeSMA, false},
+		{"ema", TypeEMA, false},
+		{"Hma", TypeHMA, false},
+		{"KAMA", TypeKAMA, false},
+		{"vwma", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			kind, err := ParseType(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("预期错误为 %v，实际为 %v", tt.wantErr, err)
+			}
+			if kind != tt.expected {
+				t.Errorf("预期 %s，实际为 %s", tt.expected, kind)
+			}
+		})
+	}
+
+	if _, err := NewAverage(Type("VWMA"), 5); err == nil {
+		t.Errorf("预期未知类型创建失败")
+	}
+	if _, err := NewAverage(TypeEMA, 0); err == nil {
+		t.Errorf("预期周期为 0 时创建失败")
+	}
+}
@@ -0,0 +1,90 @@
+package ma
+
+import (
+	"fmt"
+	"snake/internal/indicates"
+	"snake/internal/kline"
+
+	"github.com/shopspring/decimal"
+)
+
+// Stream 流式移动平均线，对收盘价计算，每根K线 O(1)
+type Stream struct {
+	kind   Type
+	period int
+	state  streamState
+}
+
+type streamState struct {
+	seq     indicates.Sequence
+	average Average
+}
+
+var _ indicates.Indicator = (*Stream)(nil)
+
+// NewStream 创建流式简单移动平均线
+func NewStream(period int) *Stream {
+	s, _ := NewTypedStream(TypeSMA, period)
+	return s
+}
+
+// NewTypedStream 创建指定类型的流式移动平均线
+func NewTypedStream(kind Type, period int) (*Stream, error) {
+	if _, err := NewAverage(kind, period); err != nil {
+		return nil, err
+	}
+
+	s := &Stream{kind: kind, period: period}
+	s.Reset()
+	return s, nil
+}
+
+// Name 简单移动平均线为 MA<period>，其他类型为 <type><period>，如 EMA20
+func (s *Stream) Name() string {
+	if s.kind == TypeSMA {
+		return fmt.Sprintf("MA%d", s.period)
+	}
+	return fmt.Sprintf("%s%d", s.kind, s.period)
+}
+
+// Type 返回移动平均线类型
+func (s *Stream) Type() Type { return s.kind }
+
+func (s *Stream) Update(kline *kline.Kline) {
+	action := s.state.seq.Next(kline)
+	if action == indicates.ActionIgnore {
+		return
+	}
+	s.state.average.Push(action, kline.C)
+}
+
+func (s *Stream) Value() decimal.Decimal { return s.state.average.Value() }
+
+func (s *Stream) Values() map[string]decimal.Decimal {
+	return map[string]decimal.Decimal{"ma": s.state.average.Value()}
+}
+
+func (s *Stream) Ready() bool { return s.state.average.Ready() }
+
+func (s *Stream) WarmUp() int { return s.state.average.WarmUp() }
+
+func (s *Stream) Reset() {
+	average, _ := NewAverage(s.kind, s.period)
+	s.state = streamState{average: average}
+}
+
+func (s *Stream) Snapshot() indicates.Snapshot {
+	state := s.state
+	state.average = s.state.average.Clone()
+	return &state
+}
+
+func (s *Stream) Restore(snapshot indicates.Snapshot) error {
+	state, ok := snapshot.(*streamState)
+	if !ok {
+		return indicates.ErrSnapshotMismatch
+	}
+	s.state = *state
+	s.state.average = state.average.Clone()
+	return nil
+}
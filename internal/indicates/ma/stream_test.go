@@ -0,0 +1,42 @@
+package ma
+
+import (
+	"snake/internal/kline"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+// sequentialKlines 按收盘价生成连续的 1 分钟K线
+func sequentialKlines(closes ...float64) []*kline.Kline {
+	klines := make([]*kline.Kline, len(closes))
+	for i, c := range closes {
+		price := decimal.NewFromFloat(c)
+		klines[i] = &kline.Kline{O: price, C: price, H: price, L: price, S: int64(i) * 60000, E: int64(i)*60000 + 59999}
+	}
+	return klines
+}
+
+func TestStream(t *testing.T) {
+	klines := sequentialKlines(10, 12, 11, 15, 14, 18, 20, 19)
+	stream := NewStream(3)
+
+	for i, k := range klines {
+		stream.Update(k)
+		if i < 2 {
+			if stream.Ready() {
+				t.Fatalf("第 %d 根K线后预期未就绪", i+1)
+			}
+			continue
+		}
+
+		expected := New(klines[i-2 : i+1]...)
+		if !stream.Value().Equal(expected.Price) {
+			t.Errorf("第 %d 根K线后预期 %s，实际为 %s", i+1, expected.Price, stream.Value())
+		}
+	}
+
+	if stream.Name() != "MA3" {
+		t.Errorf("预期名称 MA3，实际为 %s", stream.Name())
+	}
+}
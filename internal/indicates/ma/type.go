@@ -0,0 +1,50 @@
+package ma
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Type 移动平均线类型
+type Type string
+
+const (
+	TypeSMA  Type = "SMA"  // 简单移动平均
+	TypeEMA  Type = "EMA"  // 指数移动平均
+	TypeWMA  Type = "WMA"  // 线性加权移动平均
+	TypeDEMA Type = "DEMA" // 双重指数移动平均
+	TypeTEMA Type = "TEMA" // 三重指数移动平均
+	TypeHMA  Type = "HMA"  // Hull 移动平均
+	TypeKAMA Type = "KAMA" // Kaufman 自适应移动平均
+	TypeRMA  Type = "RMA"  // Wilder 平滑移动平均
+)
+
+// Types 返回所有支持的移动平均线类型
+func Types() []Type {
+	return []Type{TypeSMA, TypeEMA, TypeWMA, TypeDEMA, TypeTEMA, TypeHMA, TypeKAMA, TypeRMA}
+}
+
+func (t Type) String() string { return string(t) }
+
+// Valid 是否为支持的类型
+func (t Type) Valid() bool {
+	for _, v := range Types() {
+		if t == v {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseType 解析移动平均线类型，不区分大小写，空字符串为 SMA
+func ParseType(s string) (Type, error) {
+	if s == "" {
+		return TypeSMA, nil
+	}
+
+	t := Type(strings.ToUpper(s))
+	if !t.Valid() {
+		return "", fmt.Errorf("unknown moving average type: %s", s)
+	}
+	return t, nil
+}
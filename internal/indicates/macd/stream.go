@@ -0,0 +1,124 @@
+package macd
+
+import (
+	"fmt"
+	"snake/internal/indicates"
+	"snake/internal/indicates/ma"
+	"snake/internal/kline"
+
+	"github.com/shopspring/decimal"
+)
+
+// Stream 流式 MACD
+// 快慢线默认为 EMA（以前 period 个价格的简单平均值作为初始值），慢线就绪后开始计算 MACD，
+// 信号线为 MACD 值的同类型移动平均线
+type Stream struct {
+	kind         ma.Type
+	fastPeriod   int
+	slowPeriod   int
+	signalPeriod int
+	state        streamState
+}
+
+type streamState struct {
+	seq       indicates.Sequence
+	fast      ma.Average
+	slow      ma.Average
+	signal    ma.Average
+	macd      decimal.Decimal
+	histogram decimal.Decimal
+}
+
+var _ indicates.Indicator = (*Stream)(nil)
+
+// NewStream 创建以 EMA 计算的流式 MACD
+func NewStream(fastPeriod, slowPeriod, signalPeriod int) *Stream {
+	s, _ := NewStreamWithMA(ma.TypeEMA, fastPeriod, slowPeriod, signalPeriod)
+	return s
+}
+
+// NewStreamWithMA 创建以指定类型移动平均线计算的流式 MACD
+func NewStreamWithMA(kind ma.Type, fastPeriod, slowPeriod, signalPeriod int) (*Stream, error) {
+	for _, period := range []int{fastPeriod, slowPeriod, signalPeriod} {
+		if _, err := ma.NewAverage(kind, period); err != nil {
+			return nil, err
+		}
+	}
+
+	s := &Stream{kind: kind, fastPeriod: fastPeriod, slowPeriod: slowPeriod, signalPeriod: signalPeriod}
+	s.Reset()
+	return s, nil
+}
+
+// Name 以 EMA 计算时为 MACD<fast>_<slow>_<signal>，否则在末尾加上类型，如 MACD12_26_9_HMA
+func (s *Stream) Name() string {
+	name := fmt.Sprintf("MACD%d_%d_%d", s.fastPeriod, s.slowPeriod, s.signalPeriod)
+	if s.kind != ma.TypeEMA {
+		name += "_" + s.kind.String()
+	}
+	return name
+}
+
+func (s *Stream) Update(kline *kline.Kline) {
+	action := s.state.seq.Next(kline)
+	if action == indicates.ActionIgnore {
+		return
+	}
+
+	s.state.fast.Push(action, kline.C)
+	s.state.slow.Push(action, kline.C)
+	if !s.state.fast.Ready() || !s.state.slow.Ready() {
+		return
+	}
+
+	// 就绪只取决于K线数量，同一根K线的追加和替换会得到相同的就绪状态
+	s.state.macd = s.state.fast.Value().Sub(s.state.slow.Value())
+	s.state.signal.Push(action, s.state.macd)
+	if s.state.signal.Ready() {
+		s.state.histogram = s.state.macd.Sub(s.state.signal.Value())
+	}
+}
+
+// Value 返回 MACD 值
+func (s *Stream) Value() decimal.Decimal { return s.state.macd }
+
+func (s *Stream) Values() map[string]decimal.Decimal {
+	return map[string]decimal.Decimal{
+		"macd":      s.state.macd,
+		"signal":    s.state.signal.Value(),
+		"histogram": s.state.histogram,
+	}
+}
+
+func (s *Stream) Ready() bool { return s.state.signal.Ready() }
+
+func (s *Stream) WarmUp() int {
+	return max(s.state.fast.WarmUp(), s.state.slow.WarmUp()) + s.state.signal.WarmUp() - 1
+}
+
+func (s *Stream) Reset() {
+	fast, _ := ma.NewAverage(s.kind, s.fastPeriod)
+	slow, _ := ma.NewAverage(s.kind, s.slowPeriod)
+	signal, _ := ma.NewAverage(s.kind, s.signalPeriod)
+	s.state = streamState{fast: fast, slow: slow, signal: signal}
+}
+
+func (s *Stream) Snapshot() indicates.Snapshot {
+	state := s.state
+	state.fast = s.state.fast.Clone()
+	state.slow = s.state.slow.Clone()
+	state.signal = s.state.signal.Clone()
+	return &state
+}
+
+func (s *Stream) Restore(snapshot indicates.Snapshot) error {
+	state, ok := snapshot.(*streamState)
+	if !ok {
+		return indicates.ErrSnapshotMismatch
+	}
+	s.state = *state
+	s.state.fast = state.fast.Clone()
+	s.state.slow = state.slow.Clone()
+	s.state.signal = state.signal.Clone()
+	return nil
+}
@@ -0,0 +1,42 @@
+package macd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestStream(t *testing.T) {
+	klines := generateTestKlines(time.Now().UnixMilli(), 60)
+	stream := NewStream(12, 26, 9)
+
+	var macdValues []decimal.Decimal
+	for i, k := range klines {
+		stream.Update(k)
+		if i < 25 {
+			continue
+		}
+
+		// MACD 与按全部历史计算的快慢 EMA 之差一致
+		expected := NewWithParams(12, 26, 9, klines[:i+1]...)
+		if !stream.Value().Equal(expected.MACD) {
+			t.Errorf("第 %d 根K线后预期 MACD %s，实际为 %s", i+1, expected.MACD, stream.Value())
+		}
+
+		// 信号线为全部 MACD 值的 EMA
+		macdValues = append(macdValues, expected.MACD)
+		if len(macdValues) < 9 {
+			if stream.Ready() {
+				t.Fatalf("第 %d 根K线后预期未就绪", i+1)
+			}
+			continue
+		}
+
+		signal := calculateEMA(macdValues, 9)
+		values := stream.Values()
+		if !values["signal"].Equal(signal) || !values["histogram"].Equal(expected.MACD.Sub(signal)) {
+			t.Errorf("第 %d 根K线后预期信号线 %s，实际为 %s", i+1, signal, values["signal"])
+		}
+	}
+}
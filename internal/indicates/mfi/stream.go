@@ -0,0 +1,127 @@
+package mfi
+
+import (
+	"fmt"
+	"snake/internal/indicates"
+	"snake/internal/kline"
+
+	"github.com/shopspring/decimal"
+)
+
+// Stream 流式资金流量指标（Money Flow Index），即以成交量加权的 RSI
+//
+//	典型价格 = (最高价 + 最低价 + 收盘价) / 3，资金流量 = 典型价格 * 成交量
+//	典型价格高于上一根时计入正资金流量，低于时计入负资金流量，相等时都不计入
+//	MFI = 100 * period 内正资金流量之和 / (正资金流量之和 + 负资金流量之和)
+type Stream struct {
+	period int
+	state  streamState
+}
+
+type streamState struct {
+	seq      indicates.Sequence
+	prevTP   decimal.Decimal // 上一根已收盘K线的典型价格
+	lastTP   decimal.Decimal // 最后一根K线的典型价格
+	positive indicates.RollingSum
+	negative indicates.RollingSum
+}
+
+var _ indicates.Indicator = (*Stream)(nil)
+
+var (
+	three   = decimal.NewFromInt(3)
+	hundred = decimal.NewFromInt(100)
+)
+
+// NewStream 创建流式资金流量指标，常用周期为 14
+func NewStream(period int) *Stream {
+	s, _ := NewStreamWithPeriod(period)
+	return s
+}
+
+// NewStreamWithPeriod 创建流式资金流量指标，周期无效时返回错误
+func NewStreamWithPeriod(period int) (*Stream, error) {
+	if period < 1 {
+		return nil, fmt.Errorf("invalid mfi period: %d", period)
+	}
+
+	s := &Stream{period: period}
+	s.Reset()
+	return s, nil
+}
+
+func (s *Stream) Name() string { return fmt.Sprintf("MFI%d", s.period) }
+
+func (s *Stream) Update(kline *kline.Kline) {
+	action := s.state.seq.Next(kline)
+	switch action {
+	case indicates.ActionIgnore:
+		return
+	case indicates.ActionAppend:
+		s.state.prevTP = s.state.lastTP
+	}
+
+	tp := TypicalPrice(kline)
+	s.state.lastTP = tp
+	if s.state.seq.Count == 1 {
+		return
+	}
+
+	var positive, negative = decimal.Zero, decimal.Zero
+	switch flow := tp.Mul(kline.V); tp.Cmp(s.state.prevTP) {
+	case 1:
+		positive = flow
+	case -1:
+		negative = flow
+	}
+	s.state.positive.Push(action, positive)
+	s.state.negative.Push(action, negative)
+}
+
+// TypicalPrice 计算典型价格 (最高价 + 最低价 + 收盘价) / 3
+func TypicalPrice(k *kline.Kline) decimal.Decimal {
+	return k.H.Add(k.L).Add(k.C).Div(three)
+}
+
+// Value 返回 MFI，没有资金流量时为 50
+func (s *Stream) Value() decimal.Decimal {
+	if !s.Ready() {
+		return decimal.Zero
+	}
+	positive := s.state.positive.Sum()
+	total := positive.Add(s.state.negative.Sum())
+	if total.IsZero() {
+		return decimal.NewFromInt(50)
+	}
+	return positive.Div(total).Mul(hundred)
+}
+
+func (s *Stream) Values() map[string]decimal.Decimal {
+	return map[string]decimal.Decimal{"mfi": s.Value()}
+}
+
+func (s *Stream) Ready() bool { return s.state.positive.Full() }
+
+func (s *Stream) WarmUp() int { return s.period + 1 }
+
+func (s *Stream) Reset() {
+	s.state = streamState{positive: indicates.NewRollingSum(s.period), negative: indicates.NewRollingSum(s.period)}
+}
+
+func (s *Stream) Snapshot() indicates.Snapshot {
+	state := s.state
+	state.positive = s.state.positive.Clone()
+	state.negative = s.state.negative.Clone()
+	return &state
+}
+
+func (s *Stream) Restore(snapshot indicates.Snapshot) error {
+	state, ok := snapshot.(*streamState)
+	if !ok {
+		return indicates.ErrSnapshotMismatch
+	}
+	s.state = *state
+	s.state.positive = state.positive.Clone()
+	s.state.negative = state.negative.Clone()
+	return nil
+}
@@ -0,0 +1,60 @@
+package mfi
+
+import (
+	"snake/internal/kline"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestStream(t *testing.T) {
+	// 最高价、最低价、收盘价相同，典型价格即收盘价
+	bars := [][2]float64{
+		{10, 100},
+		{11, 100}, // 正资金流量 1100
+		{10, 50},  // 负资金流量 500
+		{10, 70},  // 典型价格不变，不计入
+		{12, 100}, // 正资金流量 1200
+	}
+	expected := []string{
+		"0", "0", "0",
+		// 1100 / (1100 + 500)
+		"68.75",
+		// 1200 / (1200 + 500)
+		"70.5882352941176471",
+	}
+
+	stream := NewStream(3)
+	if stream.WarmUp() != 4 {
+		t.Fatalf("预期 WarmUp 为 4，实际为 %d", stream.WarmUp())
+	}
+	for i, bar := range bars {
+		price := decimal.NewFromFloat(bar[0])
+		k := &kline.Kline{H: price, L: price, C: price, V: decimal.NewFromFloat(bar[1]), S: int64(i) * 60000}
+		// 先推入一个偏离的价格，再替换为实际值
+		stream.Update(&kline.Kline{H: price.Add(decimal.NewFromInt(5)), L: price, C: price, V: k.V, S: k.S})
+		stream.Update(k)
+
+		if ready := i+1 >= 4; stream.Ready() != ready {
+			t.Fatalf("第 %d 根K线后预期就绪状态为 %v", i+1, ready)
+		}
+		if !stream.Value().Round(10).Equal(decimal.RequireFromString(expected[i]).Round(10)) {
+			t.Errorf("第 %d 根K线后预期 %s，实际为 %s", i+1, expected[i], stream.Value())
+		}
+	}
+}
+
+func TestStreamWithoutFlow(t *testing.T) {
+	stream := NewStream(2)
+	price := decimal.NewFromInt(10)
+	for i := 0; i < 3; i++ {
+		stream.Update(&kline.Kline{H: price, L: price, C: price, V: decimal.NewFromInt(100), S: int64(i) * 60000})
+	}
+	if !stream.Value().Equal(decimal.NewFromInt(50)) {
+		t.Errorf("预期没有资金流量时为 50，实际为 %s", stream.Value())
+	}
+
+	if _, err := NewStreamWithPeriod(0); err == nil {
+		t.Errorf("预期周期为 0 时创建失败")
+	}
+}
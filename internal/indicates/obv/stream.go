@@ -0,0 +1,84 @@
+package obv
+
+import (
+	"snake/internal/indicates"
+	"snake/internal/kline"
+
+	"github.com/shopspring/decimal"
+)
+
+// Stream 流式能量潮（On-Balance Volume）
+// 收盘价高于上一根收盘价时加上成交量，低于时减去成交量，相等时不变，第一根K线的 OBV 为 0
+type Stream struct {
+	state streamState
+}
+
+type streamState struct {
+	seq indicates.Sequence
+	// 推入最后一根K线前后的状态，最后一根K线更新时从 prev 重新计算
+	prev obvState
+	cur  obvState
+}
+
+type obvState struct {
+	count int
+	close decimal.Decimal
+	value decimal.Decimal
+}
+
+var _ indicates.Indicator = (*Stream)(nil)
+
+// NewStream 创建流式能量潮
+func NewStream() *Stream {
+	return &Stream{}
+}
+
+func (s *Stream) Name() string { return "OBV" }
+
+func (s *Stream) Update(kline *kline.Kline) {
+	switch s.state.seq.Next(kline) {
+	case indicates.ActionAppend:
+		s.state.prev = s.state.cur
+	case indicates.ActionIgnore:
+		return
+	}
+
+	state := s.state.prev
+	state.count++
+	if state.count > 1 {
+		switch kline.C.Cmp(state.close) {
+		case 1:
+			state.value = state.value.Add(kline.V)
+		case -1:
+			state.value = state.value.Sub(kline.V)
+		}
+	}
+	state.close = kline.C
+	s.state.cur = state
+}
+
+func (s *Stream) Value() decimal.Decimal { return s.state.cur.value }
+
+func (s *Stream) Values() map[string]decimal.Decimal {
+	return map[string]decimal.Decimal{"obv": s.state.cur.value}
+}
+
+func (s *Stream) Ready() bool { return s.state.cur.count >= 1 }
+
+func (s *Stream) WarmUp() int { return 1 }
+
+func (s *Stream) Reset() { s.state = streamState{} }
+
+func (s *Stream) Snapshot() indicates.Snapshot {
+	state := s.state
+	return &state
+}
+
+func (s *Stream) Restore(snapshot indicates.Snapshot) error {
+	state, ok := snapshot.(*streamState)
+	if !ok {
+		return indicates.ErrSnapshotMismatch
+	}
+	s.state = *state
+	return nil
+}
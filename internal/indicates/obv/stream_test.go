@@ -0,0 +1,44 @@
+package obv
+
+import (
+	"snake/internal/kline"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestStream(t *testing.T) {
+	tests := []struct {
+		close    float64
+		volume   int64
+		expected string
+	}{
+		{10, 100, "0"},
+		// 上涨，加上成交量
+		{11, 200, "200"},
+		// 下跌，减去成交量
+		{10.5, 50, "150"},
+		// 收盘价不变，OBV 不变
+		{10.5, 80, "150"},
+		{12, 30, "180"},
+	}
+
+	stream := NewStream()
+	for i, tt := range tests {
+		k := &kline.Kline{
+			C: decimal.NewFromFloat(tt.close),
+			V: decimal.NewFromInt(tt.volume),
+			S: int64(i) * 60000,
+		}
+		// 先推入一次相反方向的价格，再替换为实际值
+		stream.Update(&kline.Kline{C: k.C.Neg(), V: k.V, S: k.S})
+		stream.Update(k)
+
+		if !stream.Ready() {
+			t.Fatalf("第 %d 根K线后预期就绪", i+1)
+		}
+		if !stream.Value().Equal(decimal.RequireFromString(tt.expected)) {
+			t.Errorf("第 %d 根K线后预期 %s，实际为 %s", i+1, tt.expected, stream.Value())
+		}
+	}
+}
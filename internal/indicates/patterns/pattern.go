@@ -0,0 +1,310 @@
+package patterns
+
+import (
+	"fmt"
+	"snake/internal/indicates"
+	"snake/internal/kline"
+
+	"github.com/shopspring/decimal"
+)
+
+// Pattern K线形态
+type Pattern string
+
+const (
+	// Doji 十字星：实体极小，方向不确定
+	Doji Pattern = "doji"
+	// Hammer 锤子线：下影线长、上影线短、实体在上方，看涨
+	Hammer Pattern = "hammer"
+	// ShootingStar 射击之星：上影线长、下影线短、实体在下方，看跌
+	ShootingStar Pattern = "shooting_star"
+	// BullishEngulfing 看涨吞没：阳线实体完全包住前一根阴线实体
+	BullishEngulfing Pattern = "bullish_engulfing"
+	// BearishEngulfing 看跌吞没：阴线实体完全包住前一根阳线实体
+	BearishEngulfing Pattern = "bearish_engulfing"
+	// BullishHarami 看涨孕线：小阳线实体位于前一根阴线实体内
+	BullishHarami Pattern = "bullish_harami"
+	// BearishHarami 看跌孕线：小阴线实体位于前一根阳线实体内
+	BearishHarami Pattern = "bearish_harami"
+	// MorningStar 启明星：长阴线、低位小实体、收复第一根实体的长阳线
+	MorningStar Pattern = "morning_star"
+	// EveningStar 黄昏星：长阳线、高位小实体、跌回第一根实体的长阴线
+	EveningStar Pattern = "evening_star"
+	// ThreeWhiteSoldiers 红三兵：三根收盘价依次抬高的长阳线
+	ThreeWhiteSoldiers Pattern = "three_white_soldiers"
+	// ThreeBlackCrows 三只乌鸦：三根收盘价依次降低的长阴线
+	ThreeBlackCrows Pattern = "three_black_crows"
+)
+
+var all = []Pattern{
+	Doji, Hammer, ShootingStar,
+	BullishEngulfing, BearishEngulfing, BullishHarami, BearishHarami,
+	MorningStar, EveningStar, ThreeWhiteSoldiers, ThreeBlackCrows,
+}
+
+// All 返回所有形态
+func All() []Pattern { return all }
+
+func (p Pattern) String() string { return string(p) }
+
+// Bars 返回形态包含的K线数量
+func (p Pattern) Bars() int {
+	switch p {
+	case BullishEngulfing, BearishEngulfing, BullishHarami, BearishHarami:
+		return 2
+	case MorningStar, EveningStar, ThreeWhiteSoldiers, ThreeBlackCrows:
+		return 3
+	default:
+		return 1
+	}
+}
+
+// Parse 将字符串解析为形态
+func Parse(s string) (Pattern, error) {
+	for _, p := range all {
+		if string(p) == s {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("unknown candlestick pattern: %s", s)
+}
+
+// Match 识别到的形态
+type Match struct {
+	Pattern Pattern
+	// Direction 看涨为 TrendUp，看跌为 TrendDown，十字星为 TrendNone
+	Direction indicates.Trend
+	// Strength 形态强度，取值范围 0 到 1，越大形态越标准
+	Strength decimal.Decimal
+}
+
+// Signed 返回带方向的强度，看涨为正，看跌为负，十字星为 0
+func (m Match) Signed() decimal.Decimal {
+	return m.Strength.Mul(m.Direction.Decimal())
+}
+
+// Thresholds 形态识别的阈值，比例均为小数
+type Thresholds struct {
+	// DojiBody 十字星的实体不超过K线振幅的比例
+	DojiBody decimal.Decimal
+	// LongShadow 锤子线、射击之星的长影线至少为实体的倍数
+	LongShadow decimal.Decimal
+	// ShortShadow 锤子线、射击之星的短影线不超过K线振幅的比例
+	ShortShadow decimal.Decimal
+	// LongBody 长实体K线的实体至少为K线振幅的比例，用于星线的第一根和三兵/三鸦
+	LongBody decimal.Decimal
+	// StarBody 星线的实体不超过第一根K线实体的比例
+	StarBody decimal.Decimal
+	// Penetration 星线形态的第三根K线至少收复第一根K线实体的比例
+	Penetration decimal.Decimal
+}
+
+// DefaultThresholds 返回默认阈值
+func DefaultThresholds() Thresholds {
+	return Thresholds{
+		DojiBody:    decimal.NewFromFloat(0.1),
+		LongShadow:  decimal.NewFromInt(2),
+		ShortShadow: decimal.NewFromFloat(0.1),
+		LongBody:    decimal.NewFromFloat(0.6),
+		StarBody:    decimal.NewFromFloat(0.3),
+		Penetration: decimal.NewFromFloat(0.5),
+	}
+}
+
+// Validate 检查阈值是否有效
+func (t Thresholds) Validate() error {
+	ratios := map[string]decimal.Decimal{
+		"doji body":    t.DojiBody,
+		"short shadow": t.ShortShadow,
+		"long body":    t.LongBody,
+		"star body":    t.StarBody,
+		"penetration":  t.Penetration,
+	}
+	for name, v := range ratios {
+		if !v.IsPositive() || v.GreaterThan(decimal.NewFromInt(1)) {
+			return fmt.Errorf("invalid %s threshold: %s", name, v)
+		}
+	}
+	if !t.LongShadow.IsPositive() {
+		return fmt.Errorf("invalid long shadow threshold: %s", t.LongShadow)
+	}
+	return nil
+}
+
+// candle K线的实体和影线
+type candle struct {
+	*kline.Kline
+	body  decimal.Decimal
+	span  decimal.Decimal
+	upper decimal.Decimal
+	lower decimal.Decimal
+}
+
+func newCandle(k *kline.Kline) candle {
+	top, bottom := decimal.Max(k.O, k.C), decimal.Min(k.O, k.C)
+	return candle{
+		Kline: k,
+		body:  k.C.Sub(k.O).Abs(),
+		span:  k.H.Sub(k.L),
+		upper: k.H.Sub(top),
+		lower: bottom.Sub(k.L),
+	}
+}
+
+func (c candle) bullish() bool           { return c.C.GreaterThan(c.O) }
+func (c candle) bearish() bool           { return c.C.LessThan(c.O) }
+func (c candle) top() decimal.Decimal    { return decimal.Max(c.O, c.C) }
+func (c candle) bottom() decimal.Decimal { return decimal.Min(c.O, c.C) }
+
+// long 实体是否至少为振幅的 ratio
+func (c candle) long(ratio decimal.Decimal) bool {
+	return c.span.IsPositive() && c.body.GreaterThanOrEqual(c.span.Mul(ratio))
+}
+
+// ratio 计算 a / b 并限制在 0 到 1 之间，b 为 0 时返回 1
+func ratio(a, b decimal.Decimal) decimal.Decimal {
+	if !b.IsPositive() {
+		return decimal.NewFromInt(1)
+	}
+	return decimal.Min(decimal.Max(a.Div(b), decimal.Zero), decimal.NewFromInt(1))
+}
+
+// Detect 识别以最后一根K线结尾的所有形态，按 All 的顺序返回
+// 形态只根据K线形状判断，不考虑之前的趋势，例如锤子线出现在上涨趋势中即为上吊线，由调用方结合趋势指标判断
+func Detect(klines []*kline.Kline, thresholds Thresholds) []Match {
+	if len(klines) == 0 {
+		return nil
+	}
+
+	candles := make([]candle, 0, 3)
+	for _, k := range klines[max(len(klines)-3, 0):] {
+		candles = append(candles, newCandle(k))
+	}
+
+	var matches []Match
+	for _, p := range all {
+		if p.Bars() > len(candles) {
+			continue
+		}
+		if m, ok := detect(p, candles[len(candles)-p.Bars():], thresholds); ok {
+			matches = append(matches, m)
+		}
+	}
+	return matches
+}
+
+func detect(p Pattern, c []candle, t Thresholds) (Match, bool) {
+	one := decimal.NewFromInt(1)
+	switch p {
+	case Doji:
+		k := c[0]
+		if k.body.GreaterThan(k.span.Mul(t.DojiBody)) {
+			return Match{}, false
+		}
+		// 实体越小越标准，四价相同的K线强度为 1
+		if !k.span.IsPositive() {
+			return Match{Pattern: p, Strength: one}, true
+		}
+		return Match{Pattern: p, Strength: one.Sub(ratio(k.body, k.span.Mul(t.DojiBody)))}, true
+
+	case Hammer, ShootingStar:
+		k := c[0]
+		long, short := k.lower, k.upper
+		direction := indicates.TrendUp
+		if p == ShootingStar {
+			long, short, direction = k.upper, k.lower, indicates.TrendDown
+		}
+		if !k.span.IsPositive() || long.LessThan(k.body.Mul(t.LongShadow)) ||
+			short.GreaterThan(k.span.Mul(t.ShortShadow)) || !long.GreaterThan(short) {
+			return Match{}, false
+		}
+		// 长影线占振幅的比例
+		return Match{Pattern: p, Direction: direction, Strength: ratio(long, k.span)}, true
+
+	case BullishEngulfing:
+		prev, cur := c[0], c[1]
+		if !prev.bearish() || !cur.bullish() || cur.O.GreaterThan(prev.C) || cur.C.LessThan(prev.O) ||
+			!cur.body.GreaterThan(prev.body) {
+			return Match{}, false
+		}
+		// 吞没的实体越大越强
+		return Match{Pattern: p, Direction: indicates.TrendUp, Strength: one.Sub(ratio(prev.body, cur.body))}, true
+
+	case BearishEngulfing:
+		prev, cur := c[0], c[1]
+		if !prev.bullish() || !cur.bearish() || cur.O.LessThan(prev.C) || cur.C.GreaterThan(prev.O) ||
+			!cur.body.GreaterThan(prev.body) {
+			return Match{}, false
+		}
+		return Match{Pattern: p, Direction: indicates.TrendDown, Strength: one.Sub(ratio(prev.body, cur.body))}, true
+
+	case BullishHarami:
+		prev, cur := c[0], c[1]
+		if !prev.bearish() || !cur.bullish() || cur.O.LessThan(prev.C) || cur.C.GreaterThan(prev.O) ||
+			!cur.body.LessThan(prev.body) {
+			return Match{}, false
+		}
+		// 孕出的实体越小越强
+		return Match{Pattern: p, Direction: indicates.TrendUp, Strength: one.Sub(ratio(cur.body, prev.body))}, true
+
+	case BearishHarami:
+		prev, cur := c[0], c[1]
+		if !prev.bullish() || !cur.bearish() || cur.O.GreaterThan(prev.C) || cur.C.LessThan(prev.O) ||
+			!cur.body.LessThan(prev.body) {
+			return Match{}, false
+		}
+		return Match{Pattern: p, Direction: indicates.TrendDown, Strength: one.Sub(ratio(cur.body, prev.body))}, true
+
+	case MorningStar:
+		first, star, last := c[0], c[1], c[2]
+		middle := first.C.Add(first.body.Div(decimal.NewFromInt(2)))
+		if !first.bearish() || !first.long(t.LongBody) || star.body.GreaterThan(first.body.Mul(t.StarBody)) ||
+			star.top().GreaterThan(middle) || !last.bullish() {
+			return Match{}, false
+		}
+		recovered := last.C.Sub(first.C)
+		if recovered.LessThan(first.body.Mul(t.Penetration)) {
+			return Match{}, false
+		}
+		// 第三根K线收复第一根实体的比例
+		return Match{Pattern: p, Direction: indicates.TrendUp, Strength: ratio(recovered, first.body)}, true
+
+	case EveningStar:
+		first, star, last := c[0], c[1], c[2]
+		middle := first.C.Sub(first.body.Div(decimal.NewFromInt(2)))
+		if !first.bullish() || !first.long(t.LongBody) || star.body.GreaterThan(first.body.Mul(t.StarBody)) ||
+			star.bottom().LessThan(middle) || !last.bearish() {
+			return Match{}, false
+		}
+		recovered := first.C.Sub(last.C)
+		if recovered.LessThan(first.body.Mul(t.Penetration)) {
+			return Match{}, false
+		}
+		return Match{Pattern: p, Direction: indicates.TrendDown, Strength: ratio(recovered, first.body)}, true
+
+	case ThreeWhiteSoldiers, ThreeBlackCrows:
+		up := p == ThreeWhiteSoldiers
+		strength := decimal.Zero
+		for i, k := range c {
+			if (up && !k.bullish()) || (!up && !k.bearish()) || !k.long(t.LongBody) {
+				return Match{}, false
+			}
+			// 收盘价依次推进，开盘价位于前一根实体内
+			if i > 0 {
+				prev := c[i-1]
+				if (up && !k.C.GreaterThan(prev.C)) || (!up && !k.C.LessThan(prev.C)) ||
+					k.O.LessThan(prev.bottom()) || k.O.GreaterThan(prev.top()) {
+					return Match{}, false
+				}
+			}
+			strength = strength.Add(ratio(k.body, k.span))
+		}
+		direction := indicates.TrendUp
+		if !up {
+			direction = indicates.TrendDown
+		}
+		// 三根K线实体占振幅比例的平均值
+		return Match{Pattern: p, Direction: direction, Strength: strength.Div(decimal.NewFromInt(3))}, true
+	}
+	return Match{}, false
+}
@@ -0,0 +1,120 @@
+package patterns
+
+import (
+	"snake/internal/indicates"
+	"snake/internal/indicates/indicatestest"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		pattern   Pattern
+		bars      [][4]float64
+		direction indicates.Trend
+		strength  string
+	}{
+		// 实体 0.05，振幅 2：1 - 0.05/(2*0.1)
+		{Doji, [][4]float64{{10, 11, 9, 10.05}}, indicates.TrendNone, "0.75"},
+		// 下影线 1.6，振幅 2.1
+		{Hammer, [][4]float64{{9.6, 10.1, 8, 10}}, indicates.TrendUp, "0.7619047619"},
+		// 上影线 1.7，振幅 2.2
+		{ShootingStar, [][4]float64{{10.4, 12.1, 9.9, 10}}, indicates.TrendDown, "0.7727272727"},
+		// 1 - 0.5/1.1
+		{BullishEngulfing, [][4]float64{{10, 10.2, 9.4, 9.5}, {9.4, 10.6, 9.3, 10.5}}, indicates.TrendUp, "0.5454545455"},
+		// 1 - 0.5/0.8
+		{BearishEngulfing, [][4]float64{{9.5, 10.1, 9.4, 10}, {10.1, 10.2, 9.2, 9.3}}, indicates.TrendDown, "0.375"},
+		// 1 - 0.5/2
+		{BullishHarami, [][4]float64{{11, 11.1, 8.9, 9}, {9.5, 10.1, 9.4, 10}}, indicates.TrendUp, "0.75"},
+		{BearishHarami, [][4]float64{{9, 11.1, 8.9, 11}, {10.5, 10.6, 9.9, 10}}, indicates.TrendDown, "0.75"},
+		// 第三根收复第一根实体 2 中的 1.5
+		{MorningStar, [][4]float64{{12, 12.1, 9.9, 10}, {9.8, 9.9, 9.5, 9.7}, {9.8, 11.6, 9.7, 11.5}}, indicates.TrendUp, "0.75"},
+		{EveningStar, [][4]float64{{10, 12.1, 9.9, 12}, {12.2, 12.5, 12.1, 12.3}, {12.2, 12.3, 10.4, 10.5}}, indicates.TrendDown, "0.75"},
+		// 每根实体 1，振幅 1.2
+		{ThreeWhiteSoldiers, [][4]float64{{10, 11.1, 9.9, 11}, {11, 12.1, 10.9, 12}, {12, 13.1, 11.9, 13}}, indicates.TrendUp, "0.8333333333"},
+		{ThreeBlackCrows, [][4]float64{{13, 13.1, 11.9, 12}, {12.5, 12.6, 11.4, 11.5}, {12, 12.1, 10.9, 11}}, indicates.TrendDown, "0.8333333333"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern.String(), func(t *testing.T) {
+			var found bool
+			for _, m := range Detect(indicatestest.OHLC(tt.bars...), DefaultThresholds()) {
+				if m.Pattern != tt.pattern {
+					continue
+				}
+				found = true
+				if m.Direction != tt.direction {
+					t.Errorf("预期方向 %s，实际为 %s", tt.direction, m.Direction)
+				}
+				if !m.Strength.Round(10).Equal(decimal.RequireFromString(tt.strength)) {
+					t.Errorf("预期强度 %s，实际为 %s", tt.strength, m.Strength)
+				}
+			}
+			if !found {
+				t.Fatalf("预期识别到形态 %s", tt.pattern)
+			}
+		})
+	}
+}
+
+func TestDetectNoPattern(t *testing.T) {
+	tests := []struct {
+		name string
+		bars [][4]float64
+	}{
+		{"普通阳线", [][4]float64{{10, 11.2, 9.8, 11}}},
+		// 第二根实体没有包住第一根
+		{"不构成吞没", [][4]float64{{10, 10.2, 9.4, 9.5}, {9.6, 10.2, 9.5, 10.1}}},
+		// 第三根只收复了第一根实体的 25%
+		{"收复不足的启明星", [][4]float64{{12, 12.1, 9.9, 10}, {9.8, 9.9, 9.5, 9.7}, {9.8, 10.6, 9.7, 10.5}}},
+		// 第三根收盘价没有抬高
+		{"不构成红三兵", [][4]float64{{10, 11.1, 9.9, 11}, {10.5, 12.1, 10.4, 12}, {11.2, 12.1, 10.9, 11.9}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if matches := Detect(indicatestest.OHLC(tt.bars...), DefaultThresholds()); len(matches) != 0 {
+				t.Errorf("预期没有形态，实际为 %v", matches)
+			}
+		})
+	}
+
+	if Detect(nil, DefaultThresholds()) != nil {
+		t.Errorf("预期没有K线时没有形态")
+	}
+}
+
+func TestThresholds(t *testing.T) {
+	bar := indicatestest.OHLC([4]float64{10, 11, 9, 10.3})
+	if len(Detect(bar, DefaultThresholds())) != 0 {
+		t.Fatalf("预期默认阈值下不是十字星")
+	}
+
+	// 放宽十字星的实体比例后识别为十字星
+	thresholds := DefaultThresholds()
+	thresholds.DojiBody = decimal.NewFromFloat(0.2)
+	matches := Detect(bar, thresholds)
+	if len(matches) != 1 || matches[0].Pattern != Doji {
+		t.Errorf("预期识别为十字星，实际为 %v", matches)
+	}
+
+	thresholds.LongBody = decimal.NewFromInt(2)
+	if thresholds.Validate() == nil {
+		t.Errorf("预期比例大于 1 时无效")
+	}
+	if _, err := NewStreamWithThresholds(Thresholds{}); err == nil {
+		t.Errorf("预期阈值为零时创建失败")
+	}
+}
+
+func TestParse(t *testing.T) {
+	for _, p := range All() {
+		if parsed, err := Parse(p.String()); err != nil || parsed != p {
+			t.Errorf("预期解析为 %s，实际为 %s: %v", p, parsed, err)
+		}
+	}
+	if _, err := Parse("unknown"); err == nil {
+		t.Errorf("预期未知形态解析失败")
+	}
+}
@@ -0,0 +1,117 @@
+package patterns
+
+import (
+	"snake/internal/indicates"
+	"snake/internal/kline"
+
+	"github.com/shopspring/decimal"
+)
+
+// Stream 流式K线形态识别，保存最近 3 根K线，识别以最后一根K线结尾的形态
+type Stream struct {
+	thresholds Thresholds
+	state      streamState
+}
+
+type streamState struct {
+	seq  indicates.Sequence
+	bars []kline.Kline
+}
+
+var _ indicates.Indicator = (*Stream)(nil)
+
+// NewStream 使用默认阈值创建流式形态识别
+func NewStream() *Stream {
+	return &Stream{thresholds: DefaultThresholds()}
+}
+
+// NewStreamWithThresholds 使用指定阈值创建流式形态识别，阈值无效时返回错误
+func NewStreamWithThresholds(thresholds Thresholds) (*Stream, error) {
+	if err := thresholds.Validate(); err != nil {
+		return nil, err
+	}
+	return &Stream{thresholds: thresholds}, nil
+}
+
+func (s *Stream) Name() string { return "PATTERNS" }
+
+func (s *Stream) Update(k *kline.Kline) {
+	switch s.state.seq.Next(k) {
+	case indicates.ActionIgnore:
+		return
+	case indicates.ActionReplace:
+		s.state.bars[len(s.state.bars)-1] = *k
+	case indicates.ActionAppend:
+		if len(s.state.bars) == 3 {
+			s.state.bars = append(s.state.bars[:0], s.state.bars[1:]...)
+		}
+		s.state.bars = append(s.state.bars, *k)
+	}
+}
+
+// Matches 返回以最后一根K线结尾的所有形态
+func (s *Stream) Matches() []Match {
+	klines := make([]*kline.Kline, len(s.state.bars))
+	for i := range s.state.bars {
+		klines[i] = &s.state.bars[i]
+	}
+	return Detect(klines, s.thresholds)
+}
+
+// Match 返回指定形态的识别结果，可以直接作为策略的条件
+func (s *Stream) Match(p Pattern) (Match, bool) {
+	for _, m := range s.Matches() {
+		if m.Pattern == p {
+			return m, true
+		}
+	}
+	return Match{}, false
+}
+
+// Value 返回带方向的强度之和，看涨形态为正，看跌形态为负，未就绪时为零
+func (s *Stream) Value() decimal.Decimal {
+	if !s.Ready() {
+		return decimal.Zero
+	}
+	value := decimal.Zero
+	for _, m := range s.Matches() {
+		value = value.Add(m.Signed())
+	}
+	return value
+}
+
+// Values 返回每个形态的强度，未识别到的形态为 0
+func (s *Stream) Values() map[string]decimal.Decimal {
+	values := make(map[string]decimal.Decimal, len(all)+1)
+	for _, p := range all {
+		values[p.String()] = decimal.Zero
+	}
+	for _, m := range s.Matches() {
+		values[m.Pattern.String()] = m.Strength
+	}
+	values["signal"] = s.Value()
+	return values
+}
+
+// Ready 推入 3 根K线后所有形态都可以识别
+func (s *Stream) Ready() bool { return s.state.seq.Count >= s.WarmUp() }
+
+func (s *Stream) WarmUp() int { return 3 }
+
+func (s *Stream) Reset() { s.state = streamState{} }
+
+func (s *Stream) Snapshot() indicates.Snapshot {
+	state := s.state
+	state.bars = append([]kline.Kline(nil), s.state.bars...)
+	return &state
+}
+
+func (s *Stream) Restore(snapshot indicates.Snapshot) error {
+	state, ok := snapshot.(*streamState)
+	if !ok {
+		return indicates.ErrSnapshotMismatch
+	}
+	s.state = *state
+	s.state.bars = append([]kline.Kline(nil), state.bars...)
+	return nil
+}
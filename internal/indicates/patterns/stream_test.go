@@ -0,0 +1,45 @@
+package patterns
+
+import (
+	"snake/internal/indicates/indicatestest"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestStream(t *testing.T) {
+	klines := indicatestest.OHLC(
+		[4]float64{10, 10.2, 9.9, 10.1},
+		[4]float64{12, 12.1, 9.9, 10},
+		[4]float64{9.8, 9.9, 9.5, 9.7},
+		[4]float64{9.8, 11.6, 9.7, 11.5},
+	)
+
+	stream := NewStream()
+	for i, k := range klines {
+		stream.Update(k)
+		if ready := i+1 >= 3; stream.Ready() != ready {
+			t.Fatalf("第 %d 根K线后预期就绪状态为 %v", i+1, ready)
+		}
+	}
+
+	m, ok := stream.Match(MorningStar)
+	if !ok || !m.Strength.Equal(decimal.NewFromFloat(0.75)) {
+		t.Fatalf("预期识别到启明星，强度 0.75，实际为 %v", m)
+	}
+	values := stream.Values()
+	if !values[MorningStar.String()].Equal(decimal.NewFromFloat(0.75)) || !values[Doji.String()].IsZero() {
+		t.Errorf("输出错误: %v", values)
+	}
+	if !stream.Value().Equal(decimal.NewFromFloat(0.75)) {
+		t.Errorf("预期信号为 0.75，实际为 %s", stream.Value())
+	}
+
+	// 最后一根未收盘K线回落，形态消失
+	last := *klines[3]
+	last.C = decimal.NewFromFloat(9.9)
+	stream.Update(&last)
+	if _, ok := stream.Match(MorningStar); ok {
+		t.Errorf("预期替换最后一根K线后不再是启明星")
+	}
+}
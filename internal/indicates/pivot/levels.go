@@ -0,0 +1,123 @@
+package pivot
+
+import (
+	"fmt"
+	"snake/internal/kline"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// Method 枢轴点的计算方法
+type Method string
+
+const (
+	// MethodClassic 经典枢轴点（Floor）
+	MethodClassic Method = "CLASSIC"
+	// MethodFibonacci 斐波那契枢轴点
+	MethodFibonacci Method = "FIBONACCI"
+	// MethodCamarilla Camarilla 枢轴点，以收盘价为中心，适合日内反转
+	MethodCamarilla Method = "CAMARILLA"
+	// MethodWoodie Woodie 枢轴点，收盘价权重加倍
+	MethodWoodie Method = "WOODIE"
+)
+
+var methods = []Method{MethodClassic, MethodFibonacci, MethodCamarilla, MethodWoodie}
+
+// Methods 返回所有计算方法
+func Methods() []Method { return methods }
+
+func (m Method) String() string { return string(m) }
+
+// ParseMethod 将字符串解析为计算方法，不区分大小写，空字符串为经典枢轴点
+func ParseMethod(s string) (Method, error) {
+	if s == "" {
+		return MethodClassic, nil
+	}
+	for _, m := range methods {
+		if strings.EqualFold(string(m), s) {
+			return m, nil
+		}
+	}
+	return "", fmt.Errorf("unknown pivot method: %s", s)
+}
+
+// Levels 枢轴点和支撑阻力位，R4、S4 只有 Camarilla 方法有
+type Levels struct {
+	P  decimal.Decimal
+	R1 decimal.Decimal
+	R2 decimal.Decimal
+	R3 decimal.Decimal
+	R4 decimal.Decimal
+	S1 decimal.Decimal
+	S2 decimal.Decimal
+	S3 decimal.Decimal
+	S4 decimal.Decimal
+}
+
+// Map 返回所有价位，键为 p、r1 到 r4、s1 到 s4
+func (l Levels) Map() map[string]decimal.Decimal {
+	return map[string]decimal.Decimal{
+		"p":  l.P,
+		"r1": l.R1, "r2": l.R2, "r3": l.R3, "r4": l.R4,
+		"s1": l.S1, "s2": l.S2, "s3": l.S3, "s4": l.S4,
+	}
+}
+
+var (
+	two   = decimal.NewFromInt(2)
+	three = decimal.NewFromInt(3)
+	four  = decimal.NewFromInt(4)
+
+	fib382 = decimal.NewFromFloat(0.382)
+	fib618 = decimal.NewFromFloat(0.618)
+
+	// Camarilla 的系数 1.1/12、1.1/6、1.1/4、1.1/2
+	camarilla = []decimal.Decimal{
+		decimal.NewFromFloat(1.1).Div(decimal.NewFromInt(12)),
+		decimal.NewFromFloat(1.1).Div(decimal.NewFromInt(6)),
+		decimal.NewFromFloat(1.1).Div(decimal.NewFromInt(4)),
+		decimal.NewFromFloat(1.1).Div(decimal.NewFromInt(2)),
+	}
+)
+
+// Calculate 根据上一根已收盘的高周期K线（例如日线、周线）计算本周期的枢轴点
+func Calculate(method Method, bar *kline.Kline) Levels {
+	h, l, c := bar.H, bar.L, bar.C
+	span := h.Sub(l)
+
+	switch method {
+	case MethodFibonacci:
+		p := h.Add(l).Add(c).Div(three)
+		return Levels{
+			P:  p,
+			R1: p.Add(span.Mul(fib382)), R2: p.Add(span.Mul(fib618)), R3: p.Add(span),
+			S1: p.Sub(span.Mul(fib382)), S2: p.Sub(span.Mul(fib618)), S3: p.Sub(span),
+		}
+
+	case MethodCamarilla:
+		return Levels{
+			P:  h.Add(l).Add(c).Div(three),
+			R1: c.Add(span.Mul(camarilla[0])), R2: c.Add(span.Mul(camarilla[1])),
+			R3: c.Add(span.Mul(camarilla[2])), R4: c.Add(span.Mul(camarilla[3])),
+			S1: c.Sub(span.Mul(camarilla[0])), S2: c.Sub(span.Mul(camarilla[1])),
+			S3: c.Sub(span.Mul(camarilla[2])), S4: c.Sub(span.Mul(camarilla[3])),
+		}
+
+	case MethodWoodie:
+		return floor(h.Add(l).Add(c.Mul(two)).Div(four), h, l)
+
+	default:
+		return floor(h.Add(l).Add(c).Div(three), h, l)
+	}
+}
+
+// floor 经典和 Woodie 方法共用的支撑阻力位
+func floor(p, h, l decimal.Decimal) Levels {
+	span := h.Sub(l)
+	return Levels{
+		P:  p,
+		R1: p.Mul(two).Sub(l), R2: p.Add(span), R3: h.Add(p.Sub(l).Mul(two)),
+		S1: p.Mul(two).Sub(h), S2: p.Sub(span), S3: l.Sub(h.Sub(p).Mul(two)),
+	}
+}
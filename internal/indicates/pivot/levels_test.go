@@ -0,0 +1,99 @@
+package pivot
+
+import (
+	"snake/internal/kline"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestCalculate(t *testing.T) {
+	bar := &kline.Kline{H: decimal.NewFromInt(110), L: decimal.NewFromInt(90), C: decimal.NewFromInt(100)}
+
+	tests := []struct {
+		method   Method
+		close    int64
+		expected map[string]string
+	}{
+		{MethodClassic, 100, map[string]string{
+			"p": "100", "r1": "110", "r2": "120", "r3": "130", "s1": "90", "s2": "80", "s3": "70", "r4": "0", "s4": "0",
+		}},
+		// 振幅 20 的 0.382、0.618、1 倍
+		{MethodFibonacci, 100, map[string]string{
+			"p": "100", "r1": "107.64", "r2": "112.36", "r3": "120", "s1": "92.36", "s2": "87.64", "s3": "80",
+		}},
+		// 收盘价 ± 振幅 * 1.1/12、1.1/6、1.1/4、1.1/2
+		{MethodCamarilla, 100, map[string]string{
+			"p": "100", "r1": "101.8333333333", "r2": "103.6666666667", "r3": "105.5", "r4": "111",
+			"s1": "98.1666666667", "s2": "96.3333333333", "s3": "94.5", "s4": "89",
+		}},
+		// P = (110 + 90 + 2*104) / 4
+		{MethodWoodie, 104, map[string]string{
+			"p": "102", "r1": "114", "r2": "122", "r3": "134", "s1": "94", "s2": "82", "s3": "74",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method.String(), func(t *testing.T) {
+			bar := *bar
+			bar.C = decimal.NewFromInt(tt.close)
+			levels := Calculate(tt.method, &bar).Map()
+			for name, expected := range tt.expected {
+				if !levels[name].Round(10).Equal(decimal.RequireFromString(expected)) {
+					t.Errorf("预期 %s 为 %s，实际为 %s", name, expected, levels[name])
+				}
+			}
+		})
+	}
+}
+
+func TestParseMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Method
+		wantErr  bool
+	}{
+		{"", MethodClassic, false},
+		{"fibonacci", MethodFibonacci, false},
+		{"Camarilla", MethodCamarilla, false},
+		{"WOODIE", MethodWoodie, false},
+		{"demark", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			method, err := ParseMethod(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("预期错误为 %v，实际为 %v", tt.wantErr, err)
+			}
+			if method != tt.expected {
+				t.Errorf("预期 %s，实际为 %s", tt.expected, method)
+			}
+		})
+	}
+}
+
+func TestStream(t *testing.T) {
+	stream, err := NewStreamWithMethod("classic")
+	if err != nil {
+		t.Fatalf("创建失败: %v", err)
+	}
+	if stream.Name() != "PIVOT_CLASSIC" {
+		t.Fatalf("预期名称 PIVOT_CLASSIC，实际为 %s", stream.Name())
+	}
+
+	day := int64(24 * 3600000)
+	stream.Update(&kline.Kline{H: decimal.NewFromInt(110), L: decimal.NewFromInt(90), C: decimal.NewFromInt(100), S: 0})
+	stream.Update(&kline.Kline{H: decimal.NewFromInt(130), L: decimal.NewFromInt(100), C: decimal.NewFromInt(124), S: day})
+	// 早于最后一根的K线被忽略
+	stream.Update(&kline.Kline{H: decimal.NewFromInt(1), L: decimal.NewFromInt(1), C: decimal.NewFromInt(1), S: 0})
+
+	// (130 + 100 + 124) / 3 = 118
+	if !stream.Value().Equal(decimal.NewFromInt(118)) || !stream.Levels().R1.Equal(decimal.NewFromInt(136)) {
+		t.Errorf("预期 P 为 118、R1 为 136，实际为 %s、%s", stream.Value(), stream.Levels().R1)
+	}
+
+	if _, err := NewStreamWithMethod("demark"); err == nil {
+		t.Errorf("预期未知方法创建失败")
+	}
+}
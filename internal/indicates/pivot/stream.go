@@ -0,0 +1,75 @@
+package pivot
+
+import (
+	"snake/internal/indicates"
+	"snake/internal/kline"
+
+	"github.com/shopspring/decimal"
+)
+
+// Stream 流式枢轴点，推入高周期K线（例如日线、周线），用最后一根K线计算下一个周期的支撑阻力位
+//
+// 低周期策略通过 MultiTimeframeStrategy 获取收盘的高周期K线，推入后得到的价位在下一个周期内使用。
+// 推入未收盘的K线时，价位按当前的最高价、最低价、收盘价计算，收盘前会随K线变化
+type Stream struct {
+	method Method
+	state  streamState
+}
+
+type streamState struct {
+	seq    indicates.Sequence
+	levels Levels
+}
+
+var _ indicates.Indicator = (*Stream)(nil)
+
+// NewStream 创建流式枢轴点
+func NewStream(method Method) *Stream {
+	return &Stream{method: method}
+}
+
+// NewStreamWithMethod 按方法名称创建流式枢轴点，名称无效时返回错误
+func NewStreamWithMethod(method string) (*Stream, error) {
+	m, err := ParseMethod(method)
+	if err != nil {
+		return nil, err
+	}
+	return NewStream(m), nil
+}
+
+func (s *Stream) Name() string { return "PIVOT_" + s.method.String() }
+
+func (s *Stream) Update(kline *kline.Kline) {
+	if s.state.seq.Next(kline) == indicates.ActionIgnore {
+		return
+	}
+	s.state.levels = Calculate(s.method, kline)
+}
+
+// Value 返回枢轴点 P
+func (s *Stream) Value() decimal.Decimal { return s.state.levels.P }
+
+// Levels 返回所有支撑阻力位
+func (s *Stream) Levels() Levels { return s.state.levels }
+
+func (s *Stream) Values() map[string]decimal.Decimal { return s.state.levels.Map() }
+
+func (s *Stream) Ready() bool { return s.state.seq.Count >= 1 }
+
+func (s *Stream) WarmUp() int { return 1 }
+
+func (s *Stream) Reset() { s.state = streamState{} }
+
+func (s *Stream) Snapshot() indicates.Snapshot {
+	state := s.state
+	return &state
+}
+
+func (s *Stream) Restore(snapshot indicates.Snapshot) error {
+	state, ok := snapshot.(*streamState)
+	if !ok {
+		return indicates.ErrSnapshotMismatch
+	}
+	s.state = *state
+	return nil
+}
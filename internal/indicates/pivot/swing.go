@@ -0,0 +1,230 @@
+package pivot
+
+import (
+	"fmt"
+	"slices"
+	"snake/internal/indicates"
+	"snake/internal/kline"
+
+	"github.com/shopspring/decimal"
+)
+
+// Zone 由价格相近的摆动高点、低点聚合成的支撑阻力区，位于当前价格下方为支撑，上方为阻力
+type Zone struct {
+	// Price 区内摆动点价格的平均值
+	Price decimal.Decimal
+	Low   decimal.Decimal
+	High  decimal.Decimal
+	// Touches 区内摆动点的数量，越多越重要
+	Touches int
+	// Last 最后一个摆动点所在K线的开盘时间（毫秒）
+	Last int64
+}
+
+// SwingStream 流式摆动高低点支撑阻力区
+//
+// 最高价严格高于左右各 strength 根K线的K线为摆动高点，最低价严格低于左右各 strength 根K线的为摆动低点，
+// 摆动点在其后第 strength 根K线推入后确认。与已有区的价格相差不超过 tolerance（比例）的摆动点并入该区，
+// 否则新建一个区，区的数量超过 maxZones 时丢弃最久没有摆动点的区
+type SwingStream struct {
+	strength  int
+	tolerance decimal.Decimal
+	maxZones  int
+	state     swingStreamState
+}
+
+type swingStreamState struct {
+	seq indicates.Sequence
+	// 推入最后一根K线前后的状态，最后一根K线更新时从 prev 重新计算
+	prev swingState
+	cur  swingState
+}
+
+type swingState struct {
+	bars  []*kline.Kline // 最近 2*strength+1 根K线
+	zones []Zone
+}
+
+func (s swingState) clone() swingState {
+	return swingState{bars: slices.Clone(s.bars), zones: slices.Clone(s.zones)}
+}
+
+var _ indicates.Indicator = (*SwingStream)(nil)
+
+// NewSwingStream 创建流式支撑阻力区，最多保留 10 个区
+func NewSwingStream(strength int, tolerance decimal.Decimal) *SwingStream {
+	s, _ := NewSwingStreamWithParams(strength, tolerance, 10)
+	return s
+}
+
+// NewSwingStreamWithParams 创建流式支撑阻力区，参数无效时返回错误
+func NewSwingStreamWithParams(strength int, tolerance decimal.Decimal, maxZones int) (*SwingStream, error) {
+	if strength < 1 {
+		return nil, fmt.Errorf("invalid swing strength: %d", strength)
+	}
+	if tolerance.IsNegative() {
+		return nil, fmt.Errorf("invalid zone tolerance: %s", tolerance)
+	}
+	if maxZones < 1 {
+		return nil, fmt.Errorf("invalid max zones: %d", maxZones)
+	}
+	return &SwingStream{strength: strength, tolerance: tolerance, maxZones: maxZones}, nil
+}
+
+func (s *SwingStream) Name() string { return fmt.Sprintf("SWING%d_%s", s.strength, s.tolerance) }
+
+func (s *SwingStream) Update(k *kline.Kline) {
+	switch s.state.seq.Next(k) {
+	case indicates.ActionAppend:
+		s.state.prev = s.state.cur
+	case indicates.ActionIgnore:
+		return
+	}
+
+	state := s.state.prev.clone()
+	bar := *k
+	state.bars = append(state.bars, &bar)
+	if size := 2*s.strength + 1; len(state.bars) > size {
+		state.bars = state.bars[len(state.bars)-size:]
+	}
+	if len(state.bars) == 2*s.strength+1 {
+		middle := state.bars[s.strength]
+		if s.extreme(state.bars, func(a, b *kline.Kline) bool { return a.H.GreaterThan(b.H) }) {
+			state.zones = s.merge(state.zones, middle.H, middle.S)
+		}
+		if s.extreme(state.bars, func(a, b *kline.Kline) bool { return a.L.LessThan(b.L) }) {
+			state.zones = s.merge(state.zones, middle.L, middle.S)
+		}
+	}
+	s.state.cur = state
+}
+
+// extreme 判断中间的K线是否严格优于其他所有K线
+func (s *SwingStream) extreme(bars []*kline.Kline, better func(a, b *kline.Kline) bool) bool {
+	middle := bars[s.strength]
+	for i, bar := range bars {
+		if i != s.strength && !better(middle, bar) {
+			return false
+		}
+	}
+	return true
+}
+
+// merge 把摆动点并入最近的区，或者新建一个区
+func (s *SwingStream) merge(zones []Zone, price decimal.Decimal, ts int64) []Zone {
+	nearest := -1
+	for i, zone := range zones {
+		distance := zone.Price.Sub(price).Abs()
+		if distance.GreaterThan(zone.Price.Mul(s.tolerance)) {
+			continue
+		}
+		if nearest < 0 || distance.LessThan(zones[nearest].Price.Sub(price).Abs()) {
+			nearest = i
+		}
+	}
+
+	if nearest >= 0 {
+		zone := &zones[nearest]
+		touches := decimal.NewFromInt(int64(zone.Touches))
+		zone.Price = zone.Price.Mul(touches).Add(price).Div(touches.Add(decimal.NewFromInt(1)))
+		zone.Low = decimal.Min(zone.Low, price)
+		zone.High = decimal.Max(zone.High, price)
+		zone.Touches++
+		zone.Last = ts
+		return zones
+	}
+
+	zones = append(zones, Zone{Price: price, Low: price, High: price, Touches: 1, Last: ts})
+	if len(zones) > s.maxZones {
+		oldest := 0
+		for i, zone := range zones {
+			if zone.Last < zones[oldest].Last {
+				oldest = i
+			}
+		}
+		zones = slices.Delete(zones, oldest, oldest+1)
+	}
+	return zones
+}
+
+// Zones 返回所有支撑阻力区，按价格从低到高排序
+func (s *SwingStream) Zones() []Zone {
+	zones := slices.Clone(s.state.cur.zones)
+	slices.SortFunc(zones, func(a, b Zone) int { return a.Price.Cmp(b.Price) })
+	return zones
+}
+
+// Support 返回价格下方最近的区
+func (s *SwingStream) Support(price decimal.Decimal) (Zone, bool) {
+	var support Zone
+	var found bool
+	for _, zone := range s.state.cur.zones {
+		if zone.Price.LessThan(price) && (!found || zone.Price.GreaterThan(support.Price)) {
+			support, found = zone, true
+		}
+	}
+	return support, found
+}
+
+// Resistance 返回价格上方最近的区
+func (s *SwingStream) Resistance(price decimal.Decimal) (Zone, bool) {
+	var resistance Zone
+	var found bool
+	for _, zone := range s.state.cur.zones {
+		if zone.Price.GreaterThan(price) && (!found || zone.Price.LessThan(resistance.Price)) {
+			resistance, found = zone, true
+		}
+	}
+	return resistance, found
+}
+
+// close 返回最后一根K线的收盘价
+func (s *SwingStream) close() decimal.Decimal {
+	bars := s.state.cur.bars
+	if len(bars) == 0 {
+		return decimal.Zero
+	}
+	return bars[len(bars)-1].C
+}
+
+// Value 返回最后一根K线收盘价下方最近的支撑价格，没有时为 0
+func (s *SwingStream) Value() decimal.Decimal {
+	support, _ := s.Support(s.close())
+	return support.Price
+}
+
+// Values 返回收盘价下方最近的支撑、上方最近的阻力和区的数量，没有支撑或阻力时为 0
+func (s *SwingStream) Values() map[string]decimal.Decimal {
+	support, _ := s.Support(s.close())
+	resistance, _ := s.Resistance(s.close())
+	return map[string]decimal.Decimal{
+		"support":    support.Price,
+		"resistance": resistance.Price,
+		"zones":      decimal.NewFromInt(int64(len(s.state.cur.zones))),
+	}
+}
+
+// Ready 推入 2*strength+1 根K线后开始确认摆动点
+func (s *SwingStream) Ready() bool { return s.state.seq.Count >= s.WarmUp() }
+
+func (s *SwingStream) WarmUp() int { return 2*s.strength + 1 }
+
+func (s *SwingStream) Reset() { s.state = swingStreamState{} }
+
+func (s *SwingStream) Snapshot() indicates.Snapshot {
+	state := s.state
+	state.prev = s.state.prev.clone()
+	state.cur = s.state.cur.clone()
+	return &state
+}
+
+func (s *SwingStream) Restore(snapshot indicates.Snapshot) error {
+	state, ok := snapshot.(*swingStreamState)
+	if !ok {
+		return indicates.ErrSnapshotMismatch
+	}
+	s.state = *state
+	s.state.prev = state.prev.clone()
+	s.state.cur = state.cur.clone()
+	return nil
+}
@@ -0,0 +1,99 @@
+package pivot
+
+import (
+	"snake/internal/kline"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+// swingKlines 按 [最高价, 最低价, 收盘价] 生成连续的 1 分钟K线
+func swingKlines(bars ...[3]float64) []*kline.Kline {
+	klines := make([]*kline.Kline, len(bars))
+	for i, bar := range bars {
+		klines[i] = &kline.Kline{
+			H: decimal.NewFromFloat(bar[0]),
+			L: decimal.NewFromFloat(bar[1]),
+			C: decimal.NewFromFloat(bar[2]),
+			S: int64(i) * 60000,
+		}
+	}
+	return klines
+}
+
+func TestSwingStream(t *testing.T) {
+	klines := swingKlines(
+		[3]float64{105, 100, 103},
+		[3]float64{110, 104, 108}, // 摆动高点
+		[3]float64{107, 98, 99},
+		[3]float64{100, 95, 97}, // 摆动低点
+		[3]float64{106, 97, 105},
+		[3]float64{110.5, 104, 109}, // 摆动高点，并入 110 的区
+		[3]float64{108, 103, 104},
+	)
+
+	stream := NewSwingStream(1, decimal.NewFromFloat(0.01))
+	for i, k := range klines {
+		stream.Update(k)
+		if ready := i+1 >= 3; stream.Ready() != ready {
+			t.Fatalf("第 %d 根K线后预期就绪状态为 %v", i+1, ready)
+		}
+	}
+
+	zones := stream.Zones()
+	if len(zones) != 2 {
+		t.Fatalf("预期 2 个区，实际为 %v", zones)
+	}
+	if !zones[0].Price.Equal(decimal.NewFromInt(95)) || zones[0].Touches != 1 {
+		t.Errorf("预期支撑区 95，实际为 %+v", zones[0])
+	}
+	high := zones[1]
+	if !high.Price.Equal(decimal.NewFromFloat(110.25)) || high.Touches != 2 ||
+		!high.Low.Equal(decimal.NewFromInt(110)) || !high.High.Equal(decimal.NewFromFloat(110.5)) || high.Last != klines[5].S {
+		t.Errorf("预期阻力区 110.25，实际为 %+v", high)
+	}
+
+	values := stream.Values()
+	if !values["support"].Equal(decimal.NewFromInt(95)) || !values["resistance"].Equal(decimal.NewFromFloat(110.25)) {
+		t.Errorf("输出错误: %v", values)
+	}
+
+	// 最后一根未收盘K线涨过 110.5，上一根不再是摆动高点
+	last := *klines[6]
+	last.H = decimal.NewFromInt(111)
+	stream.Update(&last)
+	if zones := stream.Zones(); zones[1].Touches != 1 || !zones[1].Price.Equal(decimal.NewFromInt(110)) {
+		t.Errorf("预期替换后阻力区只有一个摆动点，实际为 %+v", zones[1])
+	}
+	if _, ok := stream.Resistance(decimal.NewFromInt(120)); ok {
+		t.Errorf("预期 120 上方没有阻力")
+	}
+}
+
+func TestSwingStreamMaxZones(t *testing.T) {
+	stream, err := NewSwingStreamWithParams(1, decimal.Zero, 1)
+	if err != nil {
+		t.Fatalf("创建失败: %v", err)
+	}
+	for _, k := range swingKlines(
+		[3]float64{105, 100, 103},
+		[3]float64{110, 104, 108},
+		[3]float64{107, 98, 99},
+		[3]float64{100, 95, 97},
+		[3]float64{106, 97, 105},
+	) {
+		stream.Update(k)
+	}
+
+	// 只保留最近的摆动低点
+	if zones := stream.Zones(); len(zones) != 1 || !zones[0].Price.Equal(decimal.NewFromInt(95)) {
+		t.Errorf("预期只保留 95 的区，实际为 %v", zones)
+	}
+
+	if _, err := NewSwingStreamWithParams(0, decimal.Zero, 1); err == nil {
+		t.Errorf("预期 strength 为 0 时创建失败")
+	}
+	if _, err := NewSwingStreamWithParams(1, decimal.NewFromInt(-1), 1); err == nil {
+		t.Errorf("预期 tolerance 为负数时创建失败")
+	}
+}
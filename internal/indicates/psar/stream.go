@@ -0,0 +1,166 @@
+package psar
+
+import (
+	"fmt"
+	"snake/internal/indicates"
+	"snake/internal/kline"
+
+	"github.com/shopspring/decimal"
+)
+
+// Stream 流式抛物线转向指标（Parabolic SAR）
+//
+// 第二根K线按趋向变动确定初始趋势：上涨时 SAR 为第一根K线的最低价，极值点为第二根K线的最高价，下跌时相反。
+// 之后每根K线 SAR = 上一个 SAR + AF * (极值点 - 上一个 SAR)，上涨时不高于前两根K线的最低价，下跌时不低于前两根K线的最高价；
+// 创出新极值时 AF 增加 step，不超过 max；价格触及 SAR 时趋势反转，SAR 改为反转前的极值点，AF 恢复为 start
+type Stream struct {
+	start decimal.Decimal
+	step  decimal.Decimal
+	max   decimal.Decimal
+	state streamState
+}
+
+type streamState struct {
+	seq indicates.Sequence
+	// 推入最后一根K线前后的状态，最后一根K线更新时从 prev 重新计算
+	prev sarState
+	cur  sarState
+}
+
+type sarState struct {
+	count   int
+	trend   indicates.Trend
+	flipped bool
+	sar     decimal.Decimal
+	ep      decimal.Decimal // 极值点：上涨时为最高价，下跌时为最低价
+	af      decimal.Decimal
+	// 最近两根K线的最高价和最低价，1 为最近一根
+	high1, low1 decimal.Decimal
+	high2, low2 decimal.Decimal
+}
+
+var _ indicates.Indicator = (*Stream)(nil)
+
+// NewStream 创建使用常用参数 (0.02, 0.02, 0.2) 的流式抛物线转向指标
+func NewStream() *Stream {
+	s, _ := NewStreamWithParams(decimal.NewFromFloat(0.02), decimal.NewFromFloat(0.02), decimal.NewFromFloat(0.2))
+	return s
+}
+
+// NewStreamWithParams 按初始加速因子、加速因子步长和最大加速因子创建流式抛物线转向指标
+func NewStreamWithParams(start, step, max decimal.Decimal) (*Stream, error) {
+	if !start.IsPositive() || !step.IsPositive() || max.LessThan(start) {
+		return nil, fmt.Errorf("invalid parabolic sar params: start %s, step %s, max %s", start, step, max)
+	}
+
+	return &Stream{start: start, step: step, max: max}, nil
+}
+
+// Name 为 PSAR<start>_<step>_<max>，如 PSAR0.02_0.02_0.2
+func (s *Stream) Name() string { return fmt.Sprintf("PSAR%s_%s_%s", s.start, s.step, s.max) }
+
+func (s *Stream) Update(kline *kline.Kline) {
+	switch s.state.seq.Next(kline) {
+	case indicates.ActionAppend:
+		s.state.prev = s.state.cur
+	case indicates.ActionIgnore:
+		return
+	}
+	s.state.cur = s.next(s.state.prev, kline)
+}
+
+// next 计算推入K线后的状态
+func (s *Stream) next(state sarState, k *kline.Kline) sarState {
+	state.count++
+	state.flipped = false
+
+	switch state.count {
+	case 1:
+		// 第一根K线只记录最高价和最低价
+	case 2:
+		// 下跌变动大于上涨变动时为下跌趋势，否则为上涨趋势
+		if state.low1.Sub(k.L).GreaterThan(k.H.Sub(state.high1)) {
+			state.trend, state.sar, state.ep = indicates.TrendDown, state.high1, k.L
+		} else {
+			state.trend, state.sar, state.ep = indicates.TrendUp, state.low1, k.H
+		}
+		state.af = s.start
+	default:
+		state = s.advance(state, k)
+	}
+
+	state.high2, state.low2 = state.high1, state.low1
+	state.high1, state.low1 = k.H, k.L
+	return state
+}
+
+// advance 按上一根K线的 SAR 计算当前K线的 SAR，并判断是否反转
+func (s *Stream) advance(state sarState, k *kline.Kline) sarState {
+	sar := state.sar.Add(state.af.Mul(state.ep.Sub(state.sar)))
+
+	if state.trend == indicates.TrendUp {
+		sar = decimal.Min(sar, state.low1, state.low2)
+		if k.L.LessThanOrEqual(sar) {
+			state.trend, state.flipped = indicates.TrendDown, true
+			state.sar = decimal.Max(state.ep, k.H, state.high1)
+			state.ep, state.af = k.L, s.start
+			return state
+		}
+		if k.H.GreaterThan(state.ep) {
+			state.ep, state.af = k.H, decimal.Min(state.af.Add(s.step), s.max)
+		}
+	} else {
+		sar = decimal.Max(sar, state.high1, state.high2)
+		if k.H.GreaterThanOrEqual(sar) {
+			state.trend, state.flipped = indicates.TrendUp, true
+			state.sar = decimal.Min(state.ep, k.L, state.low1)
+			state.ep, state.af = k.H, s.start
+			return state
+		}
+		if k.L.LessThan(state.ep) {
+			state.ep, state.af = k.L, decimal.Min(state.af.Add(s.step), s.max)
+		}
+	}
+
+	state.sar = sar
+	return state
+}
+
+// Value 返回 SAR
+func (s *Stream) Value() decimal.Decimal { return s.state.cur.sar }
+
+func (s *Stream) Values() map[string]decimal.Decimal {
+	return map[string]decimal.Decimal{
+		"sar":   s.state.cur.sar,
+		"ep":    s.state.cur.ep,
+		"af":    s.state.cur.af,
+		"trend": s.state.cur.trend.Decimal(),
+		"flip":  indicates.FlipDecimal(s.state.cur.flipped),
+	}
+}
+
+// Trend 返回当前趋势方向，价格在 SAR 上方为上涨
+func (s *Stream) Trend() indicates.Trend { return s.state.cur.trend }
+
+// Flipped 最后一根K线上趋势是否反转
+func (s *Stream) Flipped() bool { return s.state.cur.flipped }
+
+func (s *Stream) Ready() bool { return s.state.cur.count >= 2 }
+
+func (s *Stream) WarmUp() int { return 2 }
+
+func (s *Stream) Reset() { s.state = streamState{} }
+
+func (s *Stream) Snapshot() indicates.Snapshot {
+	state := s.state
+	return &state
+}
+
+func (s *Stream) Restore(snapshot indicates.Snapshot) error {
+	state, ok := snapshot.(*streamState)
+	if !ok {
+		return indicates.ErrSnapshotMismatch
+	}
+	s.state = *state
+	return nil
+}
@@ -0,0 +1,106 @@
+package psar
+
+import (
+	"snake/internal/indicates"
+	"snake/internal/indicates/indicatestest"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestStream(t *testing.T) {
+	klines := indicatestest.HL(
+		[2]float64{10, 9},
+		[2]float64{11, 10},
+		[2]float64{12, 11},
+		[2]float64{13, 12},
+		[2]float64{12.5, 9},
+		[2]float64{11, 8.5},
+		[2]float64{10, 8},
+	)
+
+	tests := []struct {
+		sar   string
+		af    string
+		trend indicates.Trend
+		flip  bool
+	}{
+		{"0", "0", indicates.TrendNone, false},
+		// 上涨变动大于下跌变动，初始为上涨趋势，SAR 为第一根K线的最低价
+		{"9", "0.02", indicates.TrendUp, false},
+		// 9 + 0.02*(11-9) = 9.04，不高于前两根K线的最低价 9
+		{"9", "0.04", indicates.TrendUp, false},
+		// 9 + 0.04*(12-9)
+		{"9.12", "0.06", indicates.TrendUp, false},
+		// 9.12 + 0.06*(13-9.12) = 9.3528，最低价 9 跌破 SAR，反转为极值点 13
+		{"13", "0.02", indicates.TrendDown, true},
+		// 13 + 0.02*(9-13) = 12.92，不低于前两根K线的最高价 13
+		{"13", "0.04", indicates.TrendDown, false},
+		// 13 + 0.04*(8.5-13)
+		{"12.82", "0.06", indicates.TrendDown, false},
+	}
+
+	stream := NewStream()
+	if stream.Name() != "PSAR0.02_0.02_0.2" {
+		t.Fatalf("预期名称 PSAR0.02_0.02_0.2，实际为 %s", stream.Name())
+	}
+
+	for i, k := range klines {
+		stream.Update(k)
+		tt := tests[i]
+		values := stream.Values()
+		if !values["sar"].Equal(decimal.RequireFromString(tt.sar)) || !values["af"].Equal(decimal.RequireFromString(tt.af)) {
+			t.Errorf("第 %d 根K线后预期 SAR/AF 为 %s/%s，实际为 %s/%s", i+1, tt.sar, tt.af, values["sar"], values["af"])
+		}
+		if stream.Trend() != tt.trend || stream.Flipped() != tt.flip {
+			t.Errorf("第 %d 根K线后预期趋势 %s、反转 %v，实际为 %s、%v", i+1, tt.trend, tt.flip, stream.Trend(), stream.Flipped())
+		}
+		if !values["trend"].Equal(tt.trend.Decimal()) {
+			t.Errorf("第 %d 根K线后 trend 输出为 %s", i+1, values["trend"])
+		}
+	}
+}
+
+func TestStreamMaxAF(t *testing.T) {
+	// 持续创新高，AF 增加到最大值后不再增加
+	var bars [][2]float64
+	for i := range 20 {
+		bars = append(bars, [2]float64{float64(10 + i), float64(9 + i)})
+	}
+
+	stream, err := NewStreamWithParams(decimal.NewFromFloat(0.02), decimal.NewFromFloat(0.05), decimal.NewFromFloat(0.2))
+	if err != nil {
+		t.Fatalf("创建失败: %v", err)
+	}
+	for _, k := range indicatestest.HL(bars...) {
+		stream.Update(k)
+		if stream.Flipped() {
+			t.Fatalf("持续上涨时预期不反转")
+		}
+	}
+	if af := stream.Values()["af"]; !af.Equal(decimal.NewFromFloat(0.2)) {
+		t.Errorf("预期 AF 为 0.2，实际为 %s", af)
+	}
+}
+
+func TestStreamParams(t *testing.T) {
+	tests := []struct {
+		name             string
+		start, step, max float64
+		wantErr          bool
+	}{
+		{"常用参数", 0.02, 0.02, 0.2, false},
+		{"初始值为0", 0, 0.02, 0.2, true},
+		{"步长为0", 0.02, 0, 0.2, true},
+		{"最大值小于初始值", 0.02, 0.02, 0.01, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewStreamWithParams(decimal.NewFromFloat(tt.start), decimal.NewFromFloat(tt.step), decimal.NewFromFloat(tt.max))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("预期错误为 %v，实际为 %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,299 @@
+package indicates
+
+import "github.com/shopspring/decimal"
+
+// 滑动窗口的基础结构，每次推入 O(1)（单调队列为均摊 O(1)）
+//
+// 窗口由已收盘的前 size-1 个值和最后一个值（可能是未收盘K线）组成，
+// 替换最后一个值时只需修改 current，不需要回滚已收盘部分
+
+// ring 定长环形缓冲区
+type ring struct {
+	values []decimal.Decimal
+	head   int
+	length int
+}
+
+func newRing(size int) ring {
+	return ring{values: make([]decimal.Decimal, size)}
+}
+
+// push 追加一个值，缓冲区已满时返回被移出的最旧值
+func (r *ring) push(value decimal.Decimal) (decimal.Decimal, bool) {
+	if len(r.values) == 0 {
+		return value, true
+	}
+
+	if r.length < len(r.values) {
+		r.values[(r.head+r.length)%len(r.values)] = value
+		r.length++
+		return decimal.Zero, false
+	}
+
+	evicted := r.values[r.head]
+	r.values[r.head] = value
+	r.head = (r.head + 1) % len(r.values)
+	return evicted, true
+}
+
+func (r ring) clone() ring {
+	values := make([]decimal.Decimal, len(r.values))
+	copy(values, r.values)
+	r.values = values
+	return r
+}
+
+// RollingSum 滑动窗口求和
+type RollingSum struct {
+	size    int
+	count   int
+	closed  ring
+	sum     decimal.Decimal
+	current decimal.Decimal
+}
+
+// NewRollingSum 创建滑动窗口求和
+func NewRollingSum(size int) RollingSum {
+	return RollingSum{size: size, closed: newRing(size - 1)}
+}
+
+// Push 按处理方式推入一个值
+func (r *RollingSum) Push(action Action, value decimal.Decimal) {
+	switch action {
+	case ActionAppend:
+		if r.count > 0 {
+			r.sum = r.sum.Add(r.current)
+			if evicted, ok := r.closed.push(r.current); ok {
+				r.sum = r.sum.Sub(evicted)
+			}
+		}
+		r.count++
+		r.current = value
+	case ActionReplace:
+		if r.count > 0 {
+			r.current = value
+		}
+	}
+}
+
+// Sum 返回窗口内的和
+func (r *RollingSum) Sum() decimal.Decimal {
+	if r.count == 0 {
+		return decimal.Zero
+	}
+	return r.sum.Add(r.current)
+}
+
+// Len 返回窗口内值的数量
+func (r *RollingSum) Len() int { return min(r.count, r.size) }
+
+// Full 窗口是否已满
+func (r *RollingSum) Full() bool { return r.count >= r.size }
+
+// Clone 复制，用于快照
+func (r RollingSum) Clone() RollingSum {
+	r.closed = r.closed.clone()
+	return r
+}
+
+// RollingWeightedSum 滑动窗口线性加权求和，最旧的值权重为 1，最新的值权重为窗口长度
+type RollingWeightedSum struct {
+	size     int
+	count    int
+	closed   ring
+	sum      decimal.Decimal // 已收盘部分的和
+	weighted decimal.Decimal // 已收盘部分的加权和
+	current  decimal.Decimal
+}
+
+// NewRollingWeightedSum 创建滑动窗口加权求和
+func NewRollingWeightedSum(size int) RollingWeightedSum {
+	return RollingWeightedSum{size: size, closed: newRing(size - 1)}
+}
+
+// Push 按处理方式推入一个值
+func (r *RollingWeightedSum) Push(action Action, value decimal.Decimal) {
+	switch action {
+	case ActionAppend:
+		if r.count > 0 {
+			length := decimal.NewFromInt(int64(r.closed.length))
+			if evicted, ok := r.closed.push(r.current); ok {
+				// 窗口已满：所有值的权重减一，最旧的值权重变为 0 被移出
+				r.weighted = r.weighted.Sub(r.sum).Add(r.current.Mul(length))
+				r.sum = r.sum.Sub(evicted).Add(r.current)
+			} else {
+				r.weighted = r.weighted.Add(r.current.Mul(length.Add(decimal.NewFromInt(1))))
+				r.sum = r.sum.Add(r.current)
+			}
+		}
+		r.count++
+		r.current = value
+	case ActionReplace:
+		if r.count > 0 {
+			r.current = value
+		}
+	}
+}
+
+// Sum 返回窗口内的加权和
+func (r *RollingWeightedSum) Sum() decimal.Decimal {
+	if r.count == 0 {
+		return decimal.Zero
+	}
+	return r.weighted.Add(r.current.Mul(decimal.NewFromInt(int64(r.closed.length + 1))))
+}
+
+// Weights 返回窗口内权重之和
+func (r *RollingWeightedSum) Weights() decimal.Decimal {
+	n := int64(r.Len())
+	return decimal.NewFromInt(n * (n + 1) / 2)
+}
+
+// Len 返回窗口内值的数量
+func (r *RollingWeightedSum) Len() int { return min(r.count, r.size) }
+
+// Full 窗口是否已满
+func (r *RollingWeightedSum) Full() bool { return r.count >= r.size }
+
+// Clone 复制，用于快照
+func (r RollingWeightedSum) Clone() RollingWeightedSum {
+	r.closed = r.closed.clone()
+	return r
+}
+
+// RollingVariance 滑动窗口的均值和总体、样本方差，用和与平方和计算
+type RollingVariance struct {
+	sum     RollingSum
+	squares RollingSum
+}
+
+// NewRollingVariance 创建滑动窗口方差
+func NewRollingVariance(size int) RollingVariance {
+	return RollingVariance{sum: NewRollingSum(size), squares: NewRollingSum(size)}
+}
+
+// Push 按处理方式推入一个值
+func (r *RollingVariance) Push(action Action, value decimal.Decimal) {
+	r.sum.Push(action, value)
+	r.squares.Push(action, value.Mul(value))
+}
+
+// Mean 返回窗口内的均值
+func (r *RollingVariance) Mean() decimal.Decimal {
+	if r.sum.Len() == 0 {
+		return decimal.Zero
+	}
+	return r.sum.Sum().Div(decimal.NewFromInt(int64(r.sum.Len())))
+}
+
+// Variance 返回窗口内的总体方差：(n·Σx² - (Σx)²) / n²
+// 分子的加减乘都是精确计算，只在最后做一次除法
+func (r *RollingVariance) Variance() decimal.Decimal {
+	n := decimal.NewFromInt(int64(r.sum.Len()))
+	if n.IsZero() {
+		return decimal.Zero
+	}
+
+	sum := r.sum.Sum()
+	return n.Mul(r.squares.Sum()).Sub(sum.Mul(sum)).Div(n.Mul(n))
+}
+
+// SampleVariance 返回窗口内的样本方差：(n·Σx² - (Σx)²) / (n·(n-1))，少于两个值时为零
+func (r *RollingVariance) SampleVariance() decimal.Decimal {
+	n := decimal.NewFromInt(int64(r.sum.Len()))
+	if r.sum.Len() < 2 {
+		return decimal.Zero
+	}
+
+	sum := r.sum.Sum()
+	return n.Mul(r.squares.Sum()).Sub(sum.Mul(sum)).Div(n.Mul(n.Sub(decimal.NewFromInt(1))))
+}
+
+// Full 窗口是否已满
+func (r *RollingVariance) Full() bool { return r.sum.Full() }
+
+// Clone 复制，用于快照
+func (r RollingVariance) Clone() RollingVariance {
+	return RollingVariance{sum: r.sum.Clone(), squares: r.squares.Clone()}
+}
+
+// dequeItem 单调队列中的值和它在序列中的位置
+type dequeItem struct {
+	index int
+	value decimal.Decimal
+}
+
+// RollingExtreme 用单调队列计算滑动窗口的最大值或最小值
+type RollingExtreme struct {
+	size    int
+	max     bool
+	count   int
+	deque   []dequeItem
+	current decimal.Decimal
+}
+
+// NewRollingMax 创建滑动窗口最大值
+func NewRollingMax(size int) RollingExtreme {
+	return RollingExtreme{size: size, max: true}
+}
+
+// NewRollingMin 创建滑动窗口最小值
+func NewRollingMin(size int) RollingExtreme {
+	return RollingExtreme{size: size}
+}
+
+// better 判断 a 是否不劣于 b（最大值时 a >= b，最小值时 a <= b）
+func (r *RollingExtreme) better(a, b decimal.Decimal) bool {
+	if r.max {
+		return a.GreaterThanOrEqual(b)
+	}
+	return a.LessThanOrEqual(b)
+}
+
+// Push 按处理方式推入一个值
+func (r *RollingExtreme) Push(action Action, value decimal.Decimal) {
+	switch action {
+	case ActionAppend:
+		if r.count > 0 && r.size > 1 {
+			// 上一个值收盘，进入单调队列，队尾不优于它的值不可能再成为极值
+			index := r.count - 1
+			for len(r.deque) > 0 && r.better(r.current, r.deque[len(r.deque)-1].value) {
+				r.deque = r.deque[:len(r.deque)-1]
+			}
+			r.deque = append(r.deque, dequeItem{index: index, value: r.current})
+		}
+		r.count++
+		r.current = value
+
+		// 移出窗口外的值：窗口为 [count-size, count-1]，最后一个是 current
+		for len(r.deque) > 0 && r.deque[0].index <= r.count-1-r.size {
+			r.deque = r.deque[1:]
+		}
+	case ActionReplace:
+		if r.count > 0 {
+			r.current = value
+		}
+	}
+}
+
+// Value 返回窗口内的极值
+func (r *RollingExtreme) Value() decimal.Decimal {
+	if r.count == 0 {
+		return decimal.Zero
+	}
+	if len(r.deque) > 0 && r.better(r.deque[0].value, r.current) {
+		return r.deque[0].value
+	}
+	return r.current
+}
+
+// Full 窗口是否已满
+func (r *RollingExtreme) Full() bool { return r.count >= r.size }
+
+// Clone 复制，用于快照
+func (r RollingExtreme) Clone() RollingExtreme {
+	deque := make([]dequeItem, len(r.deque))
+	copy(deque, r.deque)
+	r.deque = deque
+	return r
+}
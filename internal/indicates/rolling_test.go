@@ -0,0 +1,106 @@
+package indicates
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+// rollingStep 一次推入操作
+type rollingStep struct {
+	action Action
+	value  decimal.Decimal
+}
+
+// randomSteps 生成随机的追加和替换操作，替换约占三分之一
+func randomSteps(count int) []rollingStep {
+	r := rand.New(rand.NewSource(1))
+	steps := make([]rollingStep, count)
+	for i := range steps {
+		action := ActionAppend
+		if i > 0 && r.Intn(3) == 0 {
+			action = ActionReplace
+		}
+		steps[i] = rollingStep{action: action, value: decimal.NewFromInt(int64(r.Intn(2000) - 1000)).Shift(-2)}
+	}
+	return steps
+}
+
+func TestRolling(t *testing.T) {
+	for _, size := range []int{1, 2, 5, 20} {
+		var (
+			window   = NewWindow(size)
+			sum      = NewRollingSum(size)
+			weighted = NewRollingWeightedSum(size)
+			variance = NewRollingVariance(size)
+			highest  = NewRollingMax(size)
+			lowest   = NewRollingMin(size)
+		)
+
+		for i, step := range randomSteps(500) {
+			window.Push(step.action, step.value)
+			sum.Push(step.action, step.value)
+			weighted.Push(step.action, step.value)
+			variance.Push(step.action, step.value)
+			highest.Push(step.action, step.value)
+			lowest.Push(step.action, step.value)
+
+			values := window.Values()
+			var expectedSum, expectedSquares, expectedWeighted = decimal.Zero, decimal.Zero, decimal.Zero
+			var expectedMax, expectedMin = values[0], values[0]
+			for j, v := range values {
+				expectedSum = expectedSum.Add(v)
+				expectedWeighted = expectedWeighted.Add(v.Mul(decimal.NewFromInt(int64(j + 1))))
+				expectedSquares = expectedSquares.Add(v.Mul(v))
+				expectedMax = decimal.Max(expectedMax, v)
+				expectedMin = decimal.Min(expectedMin, v)
+			}
+			n := decimal.NewFromInt(int64(len(values)))
+			expectedVariance := n.Mul(expectedSquares).Sub(expectedSum.Mul(expectedSum)).Div(n.Mul(n))
+			expectedSample := decimal.Zero
+			if len(values) > 1 {
+				expectedSample = expectedVariance.Mul(n).Div(n.Sub(decimal.NewFromInt(1)))
+			}
+
+			if sum.Full() != window.Full() || sum.Len() != len(values) {
+				t.Fatalf("窗口 %d 第 %d 步：预期长度 %d，实际为 %d", size, i, len(values), sum.Len())
+			}
+			if !sum.Sum().Equal(expectedSum) {
+				t.Fatalf("窗口 %d 第 %d 步：预期和为 %s，实际为 %s", size, i, expectedSum, sum.Sum())
+			}
+			if !weighted.Sum().Equal(expectedWeighted) || weighted.Len() != len(values) {
+				t.Fatalf("窗口 %d 第 %d 步：预期加权和为 %s，实际为 %s", size, i, expectedWeighted, weighted.Sum())
+			}
+			if !variance.Variance().Equal(expectedVariance) {
+				t.Fatalf("窗口 %d 第 %d 步：预期方差为 %s，实际为 %s", size, i, expectedVariance, variance.Variance())
+			}
+			if !variance.SampleVariance().Round(12).Equal(expectedSample.Round(12)) {
+				t.Fatalf("窗口 %d 第 %d 步：预期样本方差为 %s，实际为 %s", size, i, expectedSample, variance.SampleVariance())
+			}
+			if !highest.Value().Equal(expectedMax) || !lowest.Value().Equal(expectedMin) {
+				t.Fatalf("窗口 %d 第 %d 步：预期最大最小值为 %s/%s，实际为 %s/%s",
+					size, i, expectedMax, expectedMin, highest.Value(), lowest.Value())
+			}
+		}
+	}
+
+	t.Run("克隆后互不影响", func(t *testing.T) {
+		sum := NewRollingSum(3)
+		highest := NewRollingMax(3)
+		for _, v := range []int64{5, 9, 1} {
+			sum.Push(ActionAppend, decimal.NewFromInt(v))
+			highest.Push(ActionAppend, decimal.NewFromInt(v))
+		}
+
+		sumClone, highestClone := sum.Clone(), highest.Clone()
+		for range 3 {
+			sum.Push(ActionAppend, decimal.Zero)
+			highest.Push(ActionAppend, decimal.Zero)
+		}
+
+		if !sumClone.Sum().Equal(decimal.NewFromInt(15)) || !highestClone.Value().Equal(decimal.NewFromInt(9)) {
+			t.Errorf("预期克隆的和为 15、最大值为 9，实际为 %s/%s", sumClone.Sum(), highestClone.Value())
+		}
+	})
+}
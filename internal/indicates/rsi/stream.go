@@ -0,0 +1,129 @@
+package rsi
+
+import (
+	"fmt"
+	"snake/internal/indicates"
+	"snake/internal/kline"
+
+	"github.com/shopspring/decimal"
+)
+
+// Stream 流式相对强弱指标，使用 Wilder 平滑：
+// 前 period 个价格变化取简单平均，之后 avg = ((period-1) * avg + 当前值) / period
+type Stream struct {
+	period int
+	state  streamState
+}
+
+type streamState struct {
+	seq indicates.Sequence
+	// 推入最后一根K线前后的状态，最后一根K线更新时从 prev 重新计算
+	prev rsiState
+	cur  rsiState
+}
+
+type rsiState struct {
+	count    int
+	close    decimal.Decimal
+	seedGain decimal.Decimal
+	seedLoss decimal.Decimal
+	avgGain  decimal.Decimal
+	avgLoss  decimal.Decimal
+	value    decimal.Decimal
+}
+
+var _ indicates.Indicator = (*Stream)(nil)
+
+// NewStream 创建流式相对强弱指标
+func NewStream(period int) *Stream {
+	return &Stream{period: period}
+}
+
+func (s *Stream) Name() string { return fmt.Sprintf("RSI%d", s.period) }
+
+func (s *Stream) Update(kline *kline.Kline) {
+	switch s.state.seq.Next(kline) {
+	case indicates.ActionAppend:
+		s.state.prev = s.state.cur
+	case indicates.ActionIgnore:
+		return
+	}
+	s.state.cur = s.next(s.state.prev, kline.C)
+}
+
+// next 计算推入收盘价后的状态
+func (s *Stream) next(state rsiState, price decimal.Decimal) rsiState {
+	state.count++
+	if state.count == 1 {
+		state.close = price
+		return state
+	}
+
+	change := price.Sub(state.close)
+	state.close = price
+
+	var gain, loss = decimal.Zero, decimal.Zero
+	if change.IsPositive() {
+		gain = change
+	} else {
+		loss = change.Neg()
+	}
+
+	period := decimal.NewFromInt(int64(s.period))
+	changes := state.count - 1
+	switch {
+	case changes < s.period:
+		state.seedGain = state.seedGain.Add(gain)
+		state.seedLoss = state.seedLoss.Add(loss)
+		return state
+	case changes == s.period:
+		state.avgGain = state.seedGain.Add(gain).Div(period)
+		state.avgLoss = state.seedLoss.Add(loss).Div(period)
+	default:
+		periodMinusOne := decimal.NewFromInt(int64(s.period - 1))
+		state.avgGain = periodMinusOne.Mul(state.avgGain).Add(gain).Div(period)
+		state.avgLoss = periodMinusOne.Mul(state.avgLoss).Add(loss).Div(period)
+	}
+
+	state.value = relativeStrength(state.avgGain, state.avgLoss)
+	return state
+}
+
+// relativeStrength 根据平均上涨和平均下跌计算 RSI
+func relativeStrength(avgGain, avgLoss decimal.Decimal) decimal.Decimal {
+	if avgLoss.IsZero() {
+		if avgGain.IsZero() {
+			return decimal.NewFromInt(50) // 如果没有变化，则RSI为50
+		}
+		return decimal.NewFromInt(100) // 如果只有上涨，没有下跌，则RSI为100
+	}
+
+	rs := avgGain.Div(avgLoss)
+	return rs.Div(rs.Add(decimal.NewFromInt(1))).Mul(decimal.NewFromInt(100))
+}
+
+func (s *Stream) Value() decimal.Decimal { return s.state.cur.value }
+
+func (s *Stream) Values() map[string]decimal.Decimal {
+	return map[string]decimal.Decimal{"rsi": s.state.cur.value}
+}
+
+func (s *Stream) Ready() bool { return s.state.cur.count > s.period }
+
+func (s *Stream) WarmUp() int { return s.period + 1 }
+
+func (s *Stream) Reset() { s.state = streamState{} }
+
+func (s *Stream) Snapshot() indicates.Snapshot {
+	state := s.state
+	return &state
+}
+
+func (s *Stream) Restore(snapshot indicates.Snapshot) error {
+	state, ok := snapshot.(*streamState)
+	if !ok {
+		return indicates.ErrSnapshotMismatch
+	}
+	s.state = *state
+	return nil
+}
@@ -0,0 +1,35 @@
+package rsi
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStream(t *testing.T) {
+	klines := generateTestKlines(time.Now().UnixMilli(), 40)
+	// NextKline 按开盘时间与上一根的收盘时间判断是否为新K线，收盘时间取开盘前 1 毫秒
+	for _, k := range klines {
+		k.E--
+	}
+
+	stream := NewStream(14)
+	var expected *RSI
+	for i, k := range klines {
+		stream.Update(k)
+		switch {
+		case i < 14:
+			if stream.Ready() {
+				t.Fatalf("第 %d 根K线后预期未就绪", i+1)
+			}
+			continue
+		case i == 14:
+			expected = NewWithPeriod(14, klines[:i+1]...)
+		default:
+			expected = expected.NextKline(k)
+		}
+
+		if !stream.Value().Equal(expected.Value) {
+			t.Errorf("第 %d 根K线后预期 %s，实际为 %s", i+1, expected.Value, stream.Value())
+		}
+	}
+}
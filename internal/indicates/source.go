@@ -0,0 +1,106 @@
+package indicates
+
+import (
+	"fmt"
+	"snake/internal/kline"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// Source 指标读取的价格来源
+type Source string
+
+const (
+	SourceOpen   Source = "OPEN"
+	SourceHigh   Source = "HIGH"
+	SourceLow    Source = "LOW"
+	SourceClose  Source = "CLOSE"
+	SourceHL2    Source = "HL2"   // (最高价 + 最低价) / 2
+	SourceHLC3   Source = "HLC3"  // (最高价 + 最低价 + 收盘价) / 3，即典型价格
+	SourceOHLC4  Source = "OHLC4" // (开盘价 + 最高价 + 最低价 + 收盘价) / 4
+	SourceVolume Source = "VOLUME"
+)
+
+// SourceTypical 典型价格，与 HLC3 相同
+const SourceTypical = SourceHLC3
+
+var sources = []Source{SourceOpen, SourceHigh, SourceLow, SourceClose, SourceHL2, SourceHLC3, SourceOHLC4, SourceVolume}
+
+// Sources 返回所有价格来源
+func Sources() []Source { return sources }
+
+func (s Source) String() string { return string(s) }
+
+// ParseSource 解析价格来源，不区分大小写，空字符串为收盘价，typical 为 HLC3
+func ParseSource(s string) (Source, error) {
+	switch upper := Source(strings.ToUpper(s)); upper {
+	case "":
+		return SourceClose, nil
+	case "TYPICAL":
+		return SourceTypical, nil
+	default:
+		for _, source := range sources {
+			if upper == source {
+				return source, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("unknown price source: %s", s)
+}
+
+var (
+	two   = decimal.NewFromInt(2)
+	three = decimal.NewFromInt(3)
+	four  = decimal.NewFromInt(4)
+)
+
+// Value 返回K线在该来源下的值，未知的来源为收盘价
+func (s Source) Value(k *kline.Kline) decimal.Decimal {
+	switch s {
+	case SourceOpen:
+		return k.O
+	case SourceHigh:
+		return k.H
+	case SourceLow:
+		return k.L
+	case SourceHL2:
+		return k.H.Add(k.L).Div(two)
+	case SourceHLC3:
+		return k.H.Add(k.L).Add(k.C).Div(three)
+	case SourceOHLC4:
+		return k.O.Add(k.H).Add(k.L).Add(k.C).Div(four)
+	case SourceVolume:
+		return k.V
+	default:
+		return k.C
+	}
+}
+
+// sourced 把K线的价格换成指定来源的值后再推入指标
+type sourced struct {
+	Indicator
+	source Source
+}
+
+// WithSource 返回读取指定价格来源的指标，例如 HLC3 上的 RSI、成交量的移动平均线
+//
+// 推入的K线的开高低收都替换为来源的值，其余字段不变，因此适用于只读取价格的指标
+// （ma、bollingband、macd、rsi 等）；读取最高价、最低价的指标（atr、stochastic 等）
+// 会把每根K线视为没有振幅。来源为收盘价时直接返回原指标
+func WithSource(indicator Indicator, source Source) Indicator {
+	if source == SourceClose {
+		return indicator
+	}
+	return &sourced{Indicator: indicator, source: source}
+}
+
+// Name 在原指标名称后加上来源，如 RSI14_HLC3
+func (s *sourced) Name() string { return s.Indicator.Name() + "_" + s.source.String() }
+
+func (s *sourced) Update(k *kline.Kline) {
+	bar := *k
+	value := s.source.Value(k)
+	bar.O, bar.H, bar.L, bar.C = value, value, value, value
+	s.Indicator.Update(&bar)
+}
@@ -0,0 +1,105 @@
+package indicates_test
+
+import (
+	"snake/internal/indicates"
+	"snake/internal/indicates/ma"
+	"snake/internal/indicates/rsi"
+	"snake/internal/kline"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestSourceValue(t *testing.T) {
+	k := &kline.Kline{
+		O: decimal.NewFromInt(10),
+		H: decimal.NewFromInt(16),
+		L: decimal.NewFromInt(8),
+		C: decimal.NewFromInt(12),
+		V: decimal.NewFromInt(500),
+	}
+	tests := []struct {
+		source indicates.Source
+		want   string
+	}{
+		{source: indicates.SourceOpen, want: "10"},
+		{source: indicates.SourceHigh, want: "16"},
+		{source: indicates.SourceLow, want: "8"},
+		{source: indicates.SourceClose, want: "12"},
+		{source: indicates.SourceHL2, want: "12"},
+		{source: indicates.SourceHLC3, want: "12"},
+		{source: indicates.SourceOHLC4, want: "11.5"},
+		{source: indicates.SourceVolume, want: "500"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.source.Value(k); got.String() != tt.want {
+			t.Errorf("%s 的值为 %s，预期 %s", tt.source, got, tt.want)
+		}
+	}
+}
+
+func TestParseSource(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    indicates.Source
+		wantErr bool
+	}{
+		{input: "", want: indicates.SourceClose},
+		{input: "hlc3", want: indicates.SourceHLC3},
+		{input: "typical", want: indicates.SourceHLC3},
+		{input: "Volume", want: indicates.SourceVolume},
+		{input: "vwap", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := indicates.ParseSource(tt.input)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseSource(%q) = %s, %v，预期 %s", tt.input, got, err, tt.want)
+		}
+	}
+}
+
+func TestWithSource(t *testing.T) {
+	klines := waveKlines(40)
+	// 最高价与收盘价的距离不同，HLC3 不等于收盘价
+	for i, k := range klines {
+		k.H = k.H.Add(decimal.NewFromInt(int64(i%3 + 1)))
+		k.V = decimal.NewFromInt(int64(1000 + i*10))
+	}
+
+	tests := []struct {
+		name    string
+		source  indicates.Source
+		factory func() indicates.Indicator
+	}{
+		{name: "RSI5_HLC3", source: indicates.SourceHLC3, factory: func() indicates.Indicator { return rsi.NewStream(5) }},
+		{name: "MA5_VOLUME", source: indicates.SourceVolume, factory: func() indicates.Indicator { return ma.NewStream(5) }},
+		{name: "MA5", source: indicates.SourceClose, factory: func() indicates.Indicator { return ma.NewStream(5) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			indicator := indicates.WithSource(tt.factory(), tt.source)
+			if indicator.Name() != tt.name {
+				t.Errorf("预期名称为 %s，实际为 %s", tt.name, indicator.Name())
+			}
+
+			// 与直接推入收盘价为来源值的K线结果相同
+			expected := tt.factory()
+			for i, k := range klines {
+				bar := *k
+				bar.C = tt.source.Value(k)
+				expected.Update(&bar)
+				indicator.Update(k)
+
+				if indicator.Ready() != expected.Ready() || !indicator.Value().Equal(expected.Value()) {
+					t.Fatalf("第 %d 根K线后预期 %s，实际为 %s", i+1, expected.Value(), indicator.Value())
+				}
+			}
+			if klines[0].C.Equal(tt.source.Value(klines[0])) && tt.source != indicates.SourceClose {
+				t.Fatalf("测试数据的来源值不应等于收盘价")
+			}
+		})
+	}
+}
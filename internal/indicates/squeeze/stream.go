@@ -0,0 +1,211 @@
+package squeeze
+
+import (
+	"fmt"
+	"snake/internal/indicates"
+	bollingband "snake/internal/indicates/bolling-band"
+	"snake/internal/indicates/keltner"
+	"snake/internal/indicates/ma"
+	"snake/internal/kline"
+
+	"github.com/shopspring/decimal"
+)
+
+// Config 挤压检测的参数
+type Config struct {
+	// MA 布林带和肯特纳通道中轨的移动平均线类型
+	MA     ma.Type
+	Period int
+	// BBMultiplier 布林带的标准差倍数
+	BBMultiplier decimal.Decimal
+	// KCMultiplier 肯特纳通道的 ATR 倍数
+	KCMultiplier decimal.Decimal
+	ATRPeriod    int
+}
+
+// DefaultConfig 返回常用参数：20 周期简单移动平均线，2 倍标准差的布林带，1.5 倍 ATR(20) 的肯特纳通道
+func DefaultConfig() Config {
+	return Config{
+		MA:           ma.TypeSMA,
+		Period:       20,
+		BBMultiplier: decimal.NewFromInt(2),
+		KCMultiplier: decimal.NewFromFloat(1.5),
+		ATRPeriod:    20,
+	}
+}
+
+// Validate 检查参数是否有效
+func (c Config) Validate() error {
+	if _, err := c.bollinger(); err != nil {
+		return err
+	}
+	if _, err := c.keltner(); err != nil {
+		return err
+	}
+	if !c.KCMultiplier.IsPositive() {
+		return fmt.Errorf("invalid keltner multiplier: %s", c.KCMultiplier)
+	}
+	return nil
+}
+
+func (c Config) bollinger() (*bollingband.Stream, error) {
+	return bollingband.NewStreamWithConfig(bollingband.Config{
+		MA:         c.MA,
+		Period:     c.Period,
+		Multiplier: c.BBMultiplier,
+		Deviation:  bollingband.DeviationPopulation,
+	})
+}
+
+func (c Config) keltner() (*keltner.Stream, error) {
+	return keltner.NewStreamWithMA(c.MA, c.Period, c.ATRPeriod, c.KCMultiplier)
+}
+
+// Stream 流式波动率挤压检测：布林带完全位于肯特纳通道内时为挤压，
+// 表示波动率收缩到低于平均真实波幅，之后布林带扩张到通道外（释放）时常伴随突破行情
+type Stream struct {
+	config    Config
+	bollinger *bollingband.Stream
+	keltner   *keltner.Stream
+	state     streamState
+}
+
+type streamState struct {
+	seq indicates.Sequence
+	// 推入最后一根K线前后的状态，最后一根K线更新时从 prev 重新计算
+	prev squeezeState
+	cur  squeezeState
+}
+
+type squeezeState struct {
+	on       bool
+	released bool
+	// 挤压时为已经连续挤压的K线数量，释放的K线为刚结束的挤压持续的K线数量，否则为零
+	bars int
+}
+
+// snapshot 挤压检测和两个通道的状态
+type snapshot struct {
+	bollinger indicates.Snapshot
+	keltner   indicates.Snapshot
+	state     streamState
+}
+
+var _ indicates.Indicator = (*Stream)(nil)
+
+// NewStream 使用常用参数创建挤压检测
+func NewStream() *Stream {
+	s, _ := NewStreamWithConfig(DefaultConfig())
+	return s
+}
+
+// NewStreamWithConfig 使用指定参数创建挤压检测，参数无效时返回错误
+func NewStreamWithConfig(config Config) (*Stream, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	bollinger, _ := config.bollinger()
+	keltner, _ := config.keltner()
+	return &Stream{config: config, bollinger: bollinger, keltner: keltner}, nil
+}
+
+// Name 为 SQUEEZE<period>_<bbMultiplier>_<kcMultiplier>，如 SQUEEZE20_2_1.5
+func (s *Stream) Name() string {
+	return fmt.Sprintf("SQUEEZE%d_%s_%s", s.config.Period, s.config.BBMultiplier, s.config.KCMultiplier)
+}
+
+func (s *Stream) Update(k *kline.Kline) {
+	switch s.state.seq.Next(k) {
+	case indicates.ActionIgnore:
+		return
+	case indicates.ActionAppend:
+		s.state.prev = s.state.cur
+	}
+
+	s.bollinger.Update(k)
+	s.keltner.Update(k)
+	if !s.Ready() {
+		return
+	}
+
+	bb, kc := s.bollinger.Values(), s.keltner.Values()
+	prev := s.state.prev
+	state := squeezeState{on: bb["upper"].LessThan(kc["upper"]) && bb["lower"].GreaterThan(kc["lower"])}
+	switch {
+	case state.on && prev.on:
+		state.bars = prev.bars + 1
+	case state.on:
+		state.bars = 1
+	case prev.on:
+		state.released, state.bars = true, prev.bars
+	}
+	s.state.cur = state
+}
+
+// On 最后一根K线是否处于挤压中
+func (s *Stream) On() bool { return s.state.cur.on }
+
+// Released 最后一根K线是否结束了挤压，即上一根K线挤压而这根没有
+func (s *Stream) Released() bool { return s.state.cur.released }
+
+// Bars 挤压时返回已经连续挤压的K线数量，释放时返回刚结束的挤压持续的K线数量，否则为零
+func (s *Stream) Bars() int { return s.state.cur.bars }
+
+// Bollinger 返回布林带
+func (s *Stream) Bollinger() *bollingband.Stream { return s.bollinger }
+
+// Value 挤压时为 1，否则为 0
+func (s *Stream) Value() decimal.Decimal { return flag(s.On()) }
+
+// Values 返回挤压、释放（1 或 0）和K线数量，以及布林带和肯特纳通道的上下轨
+func (s *Stream) Values() map[string]decimal.Decimal {
+	bb, kc := s.bollinger.Values(), s.keltner.Values()
+	return map[string]decimal.Decimal{
+		"squeeze":   flag(s.On()),
+		"released":  flag(s.Released()),
+		"bars":      decimal.NewFromInt(int64(s.Bars())),
+		"upper":     bb["upper"],
+		"middle":    bb["middle"],
+		"lower":     bb["lower"],
+		"kc_upper":  kc["upper"],
+		"kc_lower":  kc["lower"],
+		"bandwidth": bb["bandwidth"],
+	}
+}
+
+func flag(b bool) decimal.Decimal {
+	if b {
+		return decimal.NewFromInt(1)
+	}
+	return decimal.Zero
+}
+
+func (s *Stream) Ready() bool { return s.bollinger.Ready() && s.keltner.Ready() }
+
+func (s *Stream) WarmUp() int { return max(s.bollinger.WarmUp(), s.keltner.WarmUp()) }
+
+func (s *Stream) Reset() {
+	s.bollinger.Reset()
+	s.keltner.Reset()
+	s.state = streamState{}
+}
+
+func (s *Stream) Snapshot() indicates.Snapshot {
+	return &snapshot{bollinger: s.bollinger.Snapshot(), keltner: s.keltner.Snapshot(), state: s.state}
+}
+
+func (s *Stream) Restore(snap indicates.Snapshot) error {
+	saved, ok := snap.(*snapshot)
+	if !ok {
+		return indicates.ErrSnapshotMismatch
+	}
+	if err := s.bollinger.Restore(saved.bollinger); err != nil {
+		return err
+	}
+	if err := s.keltner.Restore(saved.keltner); err != nil {
+		return err
+	}
+	s.state = saved.state
+	return nil
+}
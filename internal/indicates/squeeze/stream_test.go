@@ -0,0 +1,113 @@
+package squeeze
+
+import (
+	"snake/internal/kline"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+// phaseKlines 生成三段K线：前 30 根快速上涨，中间 40 根窄幅横盘，最后 20 根再次快速上涨
+func phaseKlines() []*kline.Kline {
+	var klines []*kline.Kline
+	price := 100.0
+	for i := range 90 {
+		switch {
+		case i < 30, i >= 70:
+			price += 3
+		case i%2 == 0:
+			price += 0.1
+		default:
+			price -= 0.1
+		}
+		close := decimal.NewFromFloat(price)
+		klines = append(klines, &kline.Kline{
+			O: close,
+			C: close,
+			H: close.Add(decimal.NewFromInt(1)),
+			L: close.Sub(decimal.NewFromInt(1)),
+			S: int64(i) * 60000,
+			E: int64(i)*60000 + 59999,
+		})
+	}
+	return klines
+}
+
+func TestStream(t *testing.T) {
+	stream := NewStream()
+	if stream.Name() != "SQUEEZE20_2_1.5" {
+		t.Errorf("预期名称为 SQUEEZE20_2_1.5，实际为 %s", stream.Name())
+	}
+
+	var first, released, length = -1, -1, 0
+	var onBars int
+	for i, k := range phaseKlines() {
+		stream.Update(k)
+		if i+1 < stream.WarmUp() {
+			if stream.Ready() || stream.On() {
+				t.Fatalf("第 %d 根K线未预热，预期未就绪", i+1)
+			}
+			continue
+		}
+
+		if stream.On() {
+			onBars++
+			if first < 0 {
+				first = i
+			}
+			if stream.Bars() != onBars {
+				t.Errorf("第 %d 根K线预期已挤压 %d 根，实际为 %d", i+1, onBars, stream.Bars())
+			}
+			if !stream.Value().Equal(decimal.NewFromInt(1)) {
+				t.Errorf("第 %d 根K线挤压时预期值为 1", i+1)
+			}
+		}
+		if stream.Released() {
+			if released >= 0 {
+				t.Errorf("第 %d 根K线预期只释放一次，之前在第 %d 根", i+1, released+1)
+			}
+			released, length = i, stream.Bars()
+			values := stream.Values()
+			if !values["released"].Equal(decimal.NewFromInt(1)) || !values["squeeze"].IsZero() {
+				t.Errorf("第 %d 根K线释放时的输出不正确: %v", i+1, values)
+			}
+		}
+		if !stream.On() && !stream.Released() && stream.Bars() != 0 {
+			t.Errorf("第 %d 根K线没有挤压，预期K线数量为零，实际为 %d", i+1, stream.Bars())
+		}
+	}
+
+	if first < 30 || first >= 70 {
+		t.Errorf("预期在横盘阶段开始挤压，实际在第 %d 根K线", first+1)
+	}
+	if released < 70 {
+		t.Fatalf("预期在第二次上涨时释放，实际在第 %d 根K线", released+1)
+	}
+	if length != onBars || released-first != length {
+		t.Errorf("预期释放时返回挤压持续的 %d 根K线，实际为 %d", onBars, length)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(c *Config)
+		wantErr bool
+	}{
+		{name: "默认参数", modify: func(c *Config) {}},
+		{name: "周期为零", modify: func(c *Config) { c.Period = 0 }, wantErr: true},
+		{name: "ATR 周期为零", modify: func(c *Config) { c.ATRPeriod = 0 }, wantErr: true},
+		{name: "布林带倍数为零", modify: func(c *Config) { c.BBMultiplier = decimal.Zero }, wantErr: true},
+		{name: "肯特纳倍数为负", modify: func(c *Config) { c.KCMultiplier = decimal.NewFromInt(-1) }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			tt.modify(&config)
+			if _, err := NewStreamWithConfig(config); (err != nil) != tt.wantErr {
+				t.Errorf("预期错误为 %v，实际为 %v", tt.wantErr, err)
+			}
+		})
+	}
+}
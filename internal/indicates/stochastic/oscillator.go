@@ -0,0 +1,90 @@
+package stochastic
+
+import (
+	"fmt"
+	"snake/internal/indicates"
+	"snake/internal/indicates/ma"
+
+	"github.com/shopspring/decimal"
+)
+
+// Oscillator 对任意数值序列增量计算随机指标，K线的随机指标和随机 RSI 共用
+//
+//	原始 %K = 100 * (当前值 - period 内最低值) / (period 内最高值 - period 内最低值)
+//	%K = 原始 %K 的 kSmooth 周期移动平均，kSmooth 为 1 时即快速随机指标
+//	%D = %K 的 dPeriod 周期移动平均
+type Oscillator struct {
+	period  int
+	highest indicates.RollingExtreme
+	lowest  indicates.RollingExtreme
+	k       ma.Average
+	d       ma.Average
+}
+
+// NewOscillator 创建随机指标，kind 为 %K 平滑和 %D 使用的移动平均线类型
+func NewOscillator(kind ma.Type, period, kSmooth, dPeriod int) (*Oscillator, error) {
+	if period < 1 {
+		return nil, fmt.Errorf("invalid stochastic period: %d", period)
+	}
+	k, err := ma.NewAverage(kind, kSmooth)
+	if err != nil {
+		return nil, err
+	}
+	d, err := ma.NewAverage(kind, dPeriod)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Oscillator{
+		period:  period,
+		highest: indicates.NewRollingMax(period),
+		lowest:  indicates.NewRollingMin(period),
+		k:       k,
+		d:       d,
+	}, nil
+}
+
+// Push 推入一个周期的最高值、最低值和当前值，对单一数值序列三者相同
+func (o *Oscillator) Push(action indicates.Action, high, low, value decimal.Decimal) {
+	o.highest.Push(action, high)
+	o.lowest.Push(action, low)
+	if !o.highest.Full() {
+		return
+	}
+
+	o.k.Push(action, Raw(value, o.highest.Value(), o.lowest.Value()))
+	if o.k.Ready() {
+		o.d.Push(action, o.k.Value())
+	}
+}
+
+// Raw 计算原始 %K，区间宽度为 0 时返回 50
+func Raw(value, highest, lowest decimal.Decimal) decimal.Decimal {
+	width := highest.Sub(lowest)
+	if width.IsZero() {
+		return decimal.NewFromInt(50)
+	}
+	return value.Sub(lowest).Div(width).Mul(decimal.NewFromInt(100))
+}
+
+// K 返回 %K
+func (o *Oscillator) K() decimal.Decimal { return o.k.Value() }
+
+// D 返回 %D
+func (o *Oscillator) D() decimal.Decimal { return o.d.Value() }
+
+// Ready %D 是否已就绪
+func (o *Oscillator) Ready() bool { return o.d.Ready() }
+
+// WarmUp 返回 %D 就绪所需的值的数量
+func (o *Oscillator) WarmUp() int { return o.period + o.k.WarmUp() + o.d.WarmUp() - 2 }
+
+func (o *Oscillator) Clone() *Oscillator {
+	return &Oscillator{
+		period:  o.period,
+		highest: o.highest.Clone(),
+		lowest:  o.lowest.Clone(),
+		k:       o.k.Clone(),
+		d:       o.d.Clone(),
+	}
+}
@@ -0,0 +1,92 @@
+package stochastic
+
+import (
+	"fmt"
+	"snake/internal/indicates"
+	"snake/internal/indicates/ma"
+	"snake/internal/kline"
+
+	"github.com/shopspring/decimal"
+)
+
+// Stream 流式随机指标（Stochastic Oscillator），对K线的最高价、最低价和收盘价计算
+type Stream struct {
+	kind    ma.Type
+	period  int
+	kSmooth int
+	dPeriod int
+	state   streamState
+}
+
+type streamState struct {
+	seq        indicates.Sequence
+	oscillator *Oscillator
+}
+
+var _ indicates.Indicator = (*Stream)(nil)
+
+// NewStream 创建使用简单移动平均平滑的流式随机指标，常用参数为 (14, 3, 3)
+func NewStream(period, kSmooth, dPeriod int) *Stream {
+	s, _ := NewStreamWithMA(ma.TypeSMA, period, kSmooth, dPeriod)
+	return s
+}
+
+// NewStreamWithMA 创建 %K 平滑和 %D 使用指定移动平均线的流式随机指标
+func NewStreamWithMA(kind ma.Type, period, kSmooth, dPeriod int) (*Stream, error) {
+	if _, err := NewOscillator(kind, period, kSmooth, dPeriod); err != nil {
+		return nil, err
+	}
+
+	s := &Stream{kind: kind, period: period, kSmooth: kSmooth, dPeriod: dPeriod}
+	s.Reset()
+	return s, nil
+}
+
+// Name 为 STOCH<period>_<kSmooth>_<dPeriod>，非简单移动平均时在末尾加上类型，如 STOCH14_3_3_EMA
+func (s *Stream) Name() string {
+	name := fmt.Sprintf("STOCH%d_%d_%d", s.period, s.kSmooth, s.dPeriod)
+	if s.kind != ma.TypeSMA {
+		name += "_" + s.kind.String()
+	}
+	return name
+}
+
+func (s *Stream) Update(kline *kline.Kline) {
+	action := s.state.seq.Next(kline)
+	if action == indicates.ActionIgnore {
+		return
+	}
+	s.state.oscillator.Push(action, kline.H, kline.L, kline.C)
+}
+
+// Value 返回 %K
+func (s *Stream) Value() decimal.Decimal { return s.state.oscillator.K() }
+
+func (s *Stream) Values() map[string]decimal.Decimal {
+	return map[string]decimal.Decimal{"k": s.state.oscillator.K(), "d": s.state.oscillator.D()}
+}
+
+func (s *Stream) Ready() bool { return s.state.oscillator.Ready() }
+
+func (s *Stream) WarmUp() int { return s.state.oscillator.WarmUp() }
+
+func (s *Stream) Reset() {
+	oscillator, _ := NewOscillator(s.kind, s.period, s.kSmooth, s.dPeriod)
+	s.state = streamState{oscillator: oscillator}
+}
+
+func (s *Stream) Snapshot() indicates.Snapshot {
+	state := s.state
+	state.oscillator = s.state.oscillator.Clone()
+	return &state
+}
+
+func (s *Stream) Restore(snapshot indicates.Snapshot) error {
+	state, ok := snapshot.(*streamState)
+	if !ok {
+		return indicates.ErrSnapshotMismatch
+	}
+	s.state = *state
+	s.state.oscillator = state.oscillator.Clone()
+	return nil
+}
@@ -0,0 +1,109 @@
+package stochastic
+
+import (
+	"snake/internal/indicates/indicatestest"
+	"snake/internal/indicates/ma"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestRaw(t *testing.T) {
+	tests := []struct {
+		name                   string
+		value, highest, lowest int64
+		expected               string
+	}{
+		{"最高值", 12, 12, 8, "100"},
+		{"最低值", 8, 12, 8, "0"},
+		{"区间中间", 11, 12, 8, "75"},
+		{"区间宽度为0", 10, 10, 10, "50"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := Raw(decimal.NewFromInt(tt.value), decimal.NewFromInt(tt.highest), decimal.NewFromInt(tt.lowest))
+			if !raw.Equal(decimal.RequireFromString(tt.expected)) {
+				t.Errorf("预期 %s，实际为 %s", tt.expected, raw)
+			}
+		})
+	}
+}
+
+func TestStream(t *testing.T) {
+	// 周期为 3 时原始 %K 依次为 75, 0, 100, 62.5
+	klines := indicatestest.HLC(
+		[3]float64{10, 8, 9},
+		[3]float64{11, 9, 10},
+		[3]float64{12, 10, 11},
+		[3]float64{12, 9, 9},
+		[3]float64{13, 10, 13},
+		[3]float64{12, 11, 11.5},
+	)
+
+	tests := []struct {
+		name    string
+		kind    ma.Type
+		kSmooth int
+		dPeriod int
+		warmUp  int
+		k       []string // 每根K线后的 %K，空字符串表示未计算
+		d       []string // 每根K线后的 %D，空字符串表示未就绪
+	}{
+		{
+			name: "快速随机指标", kind: ma.TypeSMA, kSmooth: 1, dPeriod: 2, warmUp: 4,
+			k: []string{"", "", "75", "0", "100", "62.5"},
+			d: []string{"", "", "", "37.5", "50", "81.25"},
+		},
+		{
+			name: "慢速随机指标", kind: ma.TypeSMA, kSmooth: 2, dPeriod: 2, warmUp: 5,
+			k: []string{"", "", "", "37.5", "50", "81.25"},
+			d: []string{"", "", "", "", "43.75", "65.625"},
+		},
+		{
+			// %K: 初始值 37.5，之后 100*2/3 + 37.5/3，62.5*2/3 + 79.1667/3
+			// %D: 初始值 (37.5+79.1667)/2，之后 68.0556*2/3 + 58.3333/3
+			name: "EMA平滑", kind: ma.TypeEMA, kSmooth: 2, dPeriod: 2, warmUp: 5,
+			k: []string{"", "", "", "37.5", "79.1666666667", "68.0555555556"},
+			d: []string{"", "", "", "", "58.3333333333", "64.8148148148"},
+		},
+	}
+
+	tolerance := decimal.New(1, -8)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream, err := NewStreamWithMA(tt.kind, 3, tt.kSmooth, tt.dPeriod)
+			if err != nil {
+				t.Fatalf("创建失败: %v", err)
+			}
+			if stream.WarmUp() != tt.warmUp {
+				t.Fatalf("预期 WarmUp 为 %d，实际为 %d", tt.warmUp, stream.WarmUp())
+			}
+
+			for i, k := range klines {
+				stream.Update(k)
+				if ready := tt.d[i] != ""; stream.Ready() != ready {
+					t.Fatalf("第 %d 根K线后预期就绪状态为 %v", i+1, ready)
+				}
+
+				values := stream.Values()
+				if tt.k[i] != "" && values["k"].Sub(decimal.RequireFromString(tt.k[i])).Abs().GreaterThan(tolerance) {
+					t.Errorf("第 %d 根K线后预期 %%K 为 %s，实际为 %s", i+1, tt.k[i], values["k"])
+				}
+				if tt.d[i] != "" && values["d"].Sub(decimal.RequireFromString(tt.d[i])).Abs().GreaterThan(tolerance) {
+					t.Errorf("第 %d 根K线后预期 %%D 为 %s，实际为 %s", i+1, tt.d[i], values["d"])
+				}
+			}
+		})
+	}
+
+	if name := NewStream(14, 3, 3).Name(); name != "STOCH14_3_3" {
+		t.Errorf("预期名称 STOCH14_3_3，实际为 %s", name)
+	}
+	if _, err := NewStreamWithMA(ma.TypeSMA, 0, 3, 3); err == nil {
+		t.Errorf("预期周期为 0 时创建失败")
+	}
+	if _, err := NewStreamWithMA(ma.Type("VWMA"), 14, 3, 3); err == nil {
+		t.Errorf("预期未知类型创建失败")
+	}
+}
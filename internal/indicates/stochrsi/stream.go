@@ -0,0 +1,115 @@
+package stochrsi
+
+import (
+	"fmt"
+	"snake/internal/indicates"
+	"snake/internal/indicates/ma"
+	"snake/internal/indicates/rsi"
+	"snake/internal/indicates/stochastic"
+	"snake/internal/kline"
+
+	"github.com/shopspring/decimal"
+)
+
+// Stream 流式随机 RSI（Stochastic RSI），对 RSI 序列计算随机指标，取值范围 0-100
+//
+//	原始 %K = 100 * (RSI - period 内最低 RSI) / (period 内最高 RSI - period 内最低 RSI)
+//	%K、%D 的平滑方式与随机指标相同
+type Stream struct {
+	kind      ma.Type
+	rsiPeriod int
+	period    int
+	kSmooth   int
+	dPeriod   int
+	state     streamState
+}
+
+type streamState struct {
+	seq        indicates.Sequence
+	rsi        *rsi.Stream
+	oscillator *stochastic.Oscillator
+}
+
+var _ indicates.Indicator = (*Stream)(nil)
+
+// NewStream 创建使用简单移动平均平滑的流式随机 RSI，常用参数为 (14, 14, 3, 3)
+func NewStream(rsiPeriod, period, kSmooth, dPeriod int) *Stream {
+	s, _ := NewStreamWithMA(ma.TypeSMA, rsiPeriod, period, kSmooth, dPeriod)
+	return s
+}
+
+// NewStreamWithMA 创建 %K 平滑和 %D 使用指定移动平均线的流式随机 RSI
+func NewStreamWithMA(kind ma.Type, rsiPeriod, period, kSmooth, dPeriod int) (*Stream, error) {
+	if rsiPeriod < 1 {
+		return nil, fmt.Errorf("invalid rsi period: %d", rsiPeriod)
+	}
+	if _, err := stochastic.NewOscillator(kind, period, kSmooth, dPeriod); err != nil {
+		return nil, err
+	}
+
+	s := &Stream{kind: kind, rsiPeriod: rsiPeriod, period: period, kSmooth: kSmooth, dPeriod: dPeriod}
+	s.Reset()
+	return s, nil
+}
+
+// Name 为 STOCHRSI<rsiPeriod>_<period>_<kSmooth>_<dPeriod>，非简单移动平均时在末尾加上类型
+func (s *Stream) Name() string {
+	name := fmt.Sprintf("STOCHRSI%d_%d_%d_%d", s.rsiPeriod, s.period, s.kSmooth, s.dPeriod)
+	if s.kind != ma.TypeSMA {
+		name += "_" + s.kind.String()
+	}
+	return name
+}
+
+func (s *Stream) Update(kline *kline.Kline) {
+	action := s.state.seq.Next(kline)
+	if action == indicates.ActionIgnore {
+		return
+	}
+
+	s.state.rsi.Update(kline)
+	if !s.state.rsi.Ready() {
+		return
+	}
+	value := s.state.rsi.Value()
+	s.state.oscillator.Push(action, value, value, value)
+}
+
+// Value 返回 %K
+func (s *Stream) Value() decimal.Decimal { return s.state.oscillator.K() }
+
+func (s *Stream) Values() map[string]decimal.Decimal {
+	return map[string]decimal.Decimal{
+		"k":   s.state.oscillator.K(),
+		"d":   s.state.oscillator.D(),
+		"rsi": s.state.rsi.Value(),
+	}
+}
+
+func (s *Stream) Ready() bool { return s.state.oscillator.Ready() }
+
+func (s *Stream) WarmUp() int { return s.state.rsi.WarmUp() + s.state.oscillator.WarmUp() - 1 }
+
+func (s *Stream) Reset() {
+	oscillator, _ := stochastic.NewOscillator(s.kind, s.period, s.kSmooth, s.dPeriod)
+	s.state = streamState{rsi: rsi.NewStream(s.rsiPeriod), oscillator: oscillator}
+}
+
+func (s *Stream) Snapshot() indicates.Snapshot {
+	state := s.state
+	state.rsi = rsi.NewStream(s.rsiPeriod)
+	state.rsi.Restore(s.state.rsi.Snapshot())
+	state.oscillator = s.state.oscillator.Clone()
+	return &state
+}
+
+func (s *Stream) Restore(snapshot indicates.Snapshot) error {
+	state, ok := snapshot.(*streamState)
+	if !ok {
+		return indicates.ErrSnapshotMismatch
+	}
+	s.state = *state
+	s.state.oscillator = state.oscillator.Clone()
+	s.state.rsi = rsi.NewStream(s.rsiPeriod)
+	return s.state.rsi.Restore(state.rsi.Snapshot())
+}
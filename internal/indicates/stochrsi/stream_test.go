@@ -0,0 +1,123 @@
+package stochrsi
+
+import (
+	"math"
+	"snake/internal/indicates/indicatestest"
+	"snake/internal/indicates/rsi"
+	"snake/internal/indicates/stochastic"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestStream(t *testing.T) {
+	tests := []struct {
+		name      string
+		rsiPeriod int
+		period    int
+		kSmooth   int
+		dPeriod   int
+		closes    []float64
+		k         []string // 每根K线后的 %K，空字符串表示未计算
+		d         []string // 每根K线后的 %D，空字符串表示未就绪
+	}{
+		{
+			// 周期为 1 的 RSI 上涨为 100、下跌为 0、不变为 50：-, 100, 0, 50, 100, 0
+			name: "RSI周期为1", rsiPeriod: 1, period: 3, kSmooth: 1, dPeriod: 2,
+			closes: []float64{10, 11, 10, 10, 12, 11},
+			k:      []string{"", "", "", "50", "100", "0"},
+			d:      []string{"", "", "", "", "75", "50"},
+		},
+		{
+			// 持续上涨时 RSI 恒为 100，区间宽度为 0，%K 为 50
+			name: "RSI不变", rsiPeriod: 2, period: 2, kSmooth: 1, dPeriod: 1,
+			closes: []float64{1, 2, 3, 4, 5},
+			k:      []string{"", "", "", "50", "50"},
+			d:      []string{"", "", "", "50", "50"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream := NewStream(tt.rsiPeriod, tt.period, tt.kSmooth, tt.dPeriod)
+			for i, k := range indicatestest.Closes(tt.closes...) {
+				stream.Update(k)
+				if ready := tt.d[i] != ""; stream.Ready() != ready {
+					t.Fatalf("第 %d 根K线后预期就绪状态为 %v", i+1, ready)
+				}
+
+				values := stream.Values()
+				if tt.k[i] != "" && !values["k"].Equal(decimal.RequireFromString(tt.k[i])) {
+					t.Errorf("第 %d 根K线后预期 %%K 为 %s，实际为 %s", i+1, tt.k[i], values["k"])
+				}
+				if tt.d[i] != "" && !values["d"].Equal(decimal.RequireFromString(tt.d[i])) {
+					t.Errorf("第 %d 根K线后预期 %%D 为 %s，实际为 %s", i+1, tt.d[i], values["d"])
+				}
+			}
+		})
+	}
+}
+
+// TestStreamMatchesDefinition 与先计算 RSI 序列、再按定义逐点计算随机指标的结果比较
+func TestStreamMatchesDefinition(t *testing.T) {
+	const rsiPeriod, period, kSmooth, dPeriod = 6, 5, 3, 3
+
+	closes := make([]float64, 80)
+	for i := range closes {
+		closes[i] = math.Round((100+float64(i)*0.2+6*math.Sin(float64(i)/2.5))*100) / 100
+	}
+	klines := indicatestest.Closes(closes...)
+
+	var rsiValues, raws, ks []decimal.Decimal
+	average := func(values []decimal.Decimal, n int) decimal.Decimal {
+		sum := decimal.Zero
+		for _, v := range values[len(values)-n:] {
+			sum = sum.Add(v)
+		}
+		return sum.Div(decimal.NewFromInt(int64(n)))
+	}
+
+	reference := rsi.NewStream(rsiPeriod)
+	stream := NewStream(rsiPeriod, period, kSmooth, dPeriod)
+	if stream.WarmUp() != (rsiPeriod+1)+period+kSmooth+dPeriod-3 {
+		t.Fatalf("WarmUp 为 %d", stream.WarmUp())
+	}
+
+	for i, k := range klines {
+		reference.Update(k)
+		stream.Update(k)
+		if !reference.Ready() {
+			continue
+		}
+
+		rsiValues = append(rsiValues, reference.Value())
+		if len(rsiValues) < period {
+			continue
+		}
+		window := rsiValues[len(rsiValues)-period:]
+		highest, lowest := window[0], window[0]
+		for _, v := range window {
+			highest, lowest = decimal.Max(highest, v), decimal.Min(lowest, v)
+		}
+		raws = append(raws, stochastic.Raw(window[len(window)-1], highest, lowest))
+		if len(raws) < kSmooth {
+			continue
+		}
+		ks = append(ks, average(raws, kSmooth))
+		if len(ks) < dPeriod {
+			if stream.Ready() {
+				t.Fatalf("第 %d 根K线后预期未就绪", i+1)
+			}
+			continue
+		}
+
+		if i+1 < stream.WarmUp() || !stream.Ready() {
+			t.Fatalf("第 %d 根K线后预期就绪", i+1)
+		}
+		values := stream.Values()
+		expectedK, expectedD := ks[len(ks)-1], average(ks, dPeriod)
+		if !values["k"].Equal(expectedK) || !values["d"].Equal(expectedD) {
+			t.Fatalf("第 %d 根K线后预期 %%K/%%D 为 %s/%s，实际为 %s/%s", i+1, expectedK, expectedD, values["k"], values["d"])
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package indicates_test
+
+import (
+	"math/rand"
+	bollingband "snake/internal/indicates/bolling-band"
+	donchianchannel "snake/internal/indicates/donchian-channel"
+	"snake/internal/indicates/ma"
+	"snake/internal/kline"
+	"sync"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+// minutesPerYear 一年的 1 分钟K线数量
+const minutesPerYear = 365 * 24 * 60
+
+// randomWalkKlines 生成价格随机游走的连续 1 分钟K线
+func randomWalkKlines(count int) []*kline.Kline {
+	r := rand.New(rand.NewSource(1))
+	klines := make([]*kline.Kline, count)
+	price := decimal.NewFromInt(30000)
+	for i := range klines {
+		open := price
+		price = price.Add(decimal.NewFromInt(int64(r.Intn(2001) - 1000)).Shift(-2))
+		high := decimal.Max(open, price).Add(decimal.NewFromInt(int64(r.Intn(500))).Shift(-2))
+		low := decimal.Min(open, price).Sub(decimal.NewFromInt(int64(r.Intn(500))).Shift(-2))
+		klines[i] = &kline.Kline{
+			O: open, C: price, H: high, L: low,
+			V: decimal.NewFromInt(int64(r.Intn(100) + 1)),
+			S: int64(i) * 60000,
+			E: int64(i)*60000 + 59999,
+		}
+	}
+	return klines
+}
+
+// approxEqual 比较两个值，允许除法和开方带来的舍入误差
+func approxEqual(a, b decimal.Decimal) bool {
+	return a.Sub(b).Abs().LessThan(decimal.New(1, -8))
+}
+
+// TestStreamMatchesLegacy 流式指标与原有的整窗口重新计算结果一致
+func TestStreamMatchesLegacy(t *testing.T) {
+	const period = 20
+	klines := randomWalkKlines(5000)
+
+	t.Run("MA", func(t *testing.T) {
+		stream := ma.NewStream(period)
+		for i, k := range klines {
+			stream.Update(k)
+			if i+1 < period {
+				continue
+			}
+			expected := ma.New(klines[i+1-period : i+1]...)
+			if !stream.Value().Equal(expected.Price) {
+				t.Fatalf("第 %d 根K线后预期 %s，实际为 %s", i+1, expected.Price, stream.Value())
+			}
+		}
+	})
+
+	t.Run("布林带", func(t *testing.T) {
+		stream := bollingband.NewStream(period, decimal.NewFromInt(2))
+		for i, k := range klines {
+			stream.Update(k)
+			if i+1 < period {
+				continue
+			}
+			expected := bollingband.New(klines[i+1-period : i+1]...)
+			values := stream.Values()
+			if !values["middle"].Equal(expected.MA) || !approxEqual(values["upper"], expected.Upper) || !approxEqual(values["lower"], expected.Lower) {
+				t.Fatalf("第 %d 根K线后预期 %s/%s/%s，实际为 %v", i+1, expected.Upper, expected.MA, expected.Lower, values)
+			}
+		}
+	})
+
+	t.Run("唐奇安通道", func(t *testing.T) {
+		stream := donchianchannel.NewStream(period)
+		for i, k := range klines {
+			stream.Update(k)
+			if i+1 < period {
+				continue
+			}
+			expected := donchianchannel.NewWithPeriod(period, klines[i+1-period:i+1]...)
+			values := stream.Values()
+			if !values["upper"].Equal(expected.Upper) || !values["middle"].Equal(expected.Middle) || !values["lower"].Equal(expected.Lower) {
+				t.Fatalf("第 %d 根K线后预期 %s/%s/%s，实际为 %v", i+1, expected.Upper, expected.Middle, expected.Lower, values)
+			}
+		}
+	})
+}
+
+// 以下基准测试在一年的 1 分钟K线上比较原有实现与流式实现，周期均为 20：
+//
+//	go test -run=^$ -bench=Year -benchmem ./internal/indicates/
+
+const benchPeriod = 20
+
+var yearKlines = sync.OnceValue(func() []*kline.Kline { return randomWalkKlines(minutesPerYear) })
+
+func BenchmarkMAYearLegacy(b *testing.B) {
+	klines := yearKlines()
+	for b.Loop() {
+		indicator := ma.New(klines[:benchPeriod]...)
+		for _, k := range klines[benchPeriod:] {
+			indicator = indicator.NextKline(k)
+		}
+	}
+}
+
+func BenchmarkMAYearStream(b *testing.B) {
+	klines := yearKlines()
+	for b.Loop() {
+		stream := ma.NewStream(benchPeriod)
+		for _, k := range klines {
+			stream.Update(k)
+		}
+	}
+}
+
+func BenchmarkBollingBandYearLegacy(b *testing.B) {
+	klines := yearKlines()
+	for b.Loop() {
+		indicator := bollingband.New(klines[:benchPeriod]...)
+		for _, k := range klines[benchPeriod:] {
+			indicator = indicator.NextKline(k)
+		}
+	}
+}
+
+func BenchmarkBollingBandYearStream(b *testing.B) {
+	klines := yearKlines()
+	for b.Loop() {
+		stream := bollingband.NewStream(benchPeriod, decimal.NewFromInt(2))
+		for _, k := range klines {
+			stream.Update(k)
+		}
+	}
+}
+
+// 原有的 NextKline 会保留全部历史，一年的数据上是 O(n²)，这里按策略中的用法每根K线重建最近一个周期
+func BenchmarkDonchianChannelYearLegacy(b *testing.B) {
+	klines := yearKlines()
+	for b.Loop() {
+		for i := benchPeriod; i <= len(klines); i++ {
+			donchianchannel.NewWithPeriod(benchPeriod, klines[i-benchPeriod:i]...)
+		}
+	}
+}
+
+func BenchmarkDonchianChannelYearStream(b *testing.B) {
+	klines := yearKlines()
+	for b.Loop() {
+		stream := donchianchannel.NewStream(benchPeriod)
+		for _, k := range klines {
+			stream.Update(k)
+		}
+	}
+}
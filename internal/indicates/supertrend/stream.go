@@ -0,0 +1,168 @@
+package supertrend
+
+import (
+	"fmt"
+	"snake/internal/indicates"
+	"snake/internal/indicates/atr"
+	"snake/internal/kline"
+
+	"github.com/shopspring/decimal"
+)
+
+// Stream 流式 SuperTrend 指标
+//
+//	基本上轨 = (最高价 + 最低价) / 2 + multiplier * ATR，基本下轨 = (最高价 + 最低价) / 2 - multiplier * ATR
+//	上轨只在基本上轨更低或上一根收盘价突破上一个上轨时更新，下轨只在基本下轨更高或上一根收盘价跌破上一个下轨时更新
+//	上涨趋势中收盘价跌破下轨时转为下跌，下跌趋势中收盘价突破上轨时转为上涨
+//
+// 上涨趋势时 SuperTrend 为下轨，下跌趋势时为上轨，ATR 就绪的第一根K线趋势为上涨
+type Stream struct {
+	period     int
+	multiplier decimal.Decimal
+	state      streamState
+}
+
+type streamState struct {
+	seq indicates.Sequence
+	atr *atr.Stream
+	// 推入最后一根K线前后的状态，最后一根K线更新时从 prev 重新计算
+	prev bandState
+	cur  bandState
+}
+
+type bandState struct {
+	ready   bool
+	upper   decimal.Decimal
+	lower   decimal.Decimal
+	close   decimal.Decimal
+	trend   indicates.Trend
+	flipped bool
+}
+
+var _ indicates.Indicator = (*Stream)(nil)
+
+var two = decimal.NewFromInt(2)
+
+// NewStream 创建流式 SuperTrend，常用参数为 (10, 3)，ATR 使用 Wilder 平滑
+func NewStream(period int, multiplier decimal.Decimal) *Stream {
+	s, _ := NewStreamWithParams(period, multiplier)
+	return s
+}
+
+// NewStreamWithParams 创建流式 SuperTrend，参数无效时返回错误
+func NewStreamWithParams(period int, multiplier decimal.Decimal) (*Stream, error) {
+	if period < 1 {
+		return nil, fmt.Errorf("invalid supertrend period: %d", period)
+	}
+	if !multiplier.IsPositive() {
+		return nil, fmt.Errorf("invalid supertrend multiplier: %s", multiplier)
+	}
+
+	s := &Stream{period: period, multiplier: multiplier}
+	s.Reset()
+	return s, nil
+}
+
+// Name 为 SUPERTREND<period>_<multiplier>，如 SUPERTREND10_3
+func (s *Stream) Name() string { return fmt.Sprintf("SUPERTREND%d_%s", s.period, s.multiplier) }
+
+func (s *Stream) Update(kline *kline.Kline) {
+	switch s.state.seq.Next(kline) {
+	case indicates.ActionAppend:
+		s.state.prev = s.state.cur
+	case indicates.ActionIgnore:
+		return
+	}
+
+	s.state.atr.Update(kline)
+	if !s.state.atr.Ready() {
+		s.state.cur = bandState{close: kline.C}
+		return
+	}
+	s.state.cur = s.next(s.state.prev, kline, s.state.atr.Value())
+}
+
+// next 根据上一根K线的状态计算当前K线的上下轨和趋势
+func (s *Stream) next(prev bandState, k *kline.Kline, volatility decimal.Decimal) bandState {
+	middle := k.H.Add(k.L).Div(two)
+	width := volatility.Mul(s.multiplier)
+	state := bandState{
+		ready: true,
+		upper: middle.Add(width),
+		lower: middle.Sub(width),
+		close: k.C,
+		trend: indicates.TrendUp,
+	}
+	if !prev.ready {
+		return state
+	}
+
+	if state.upper.GreaterThan(prev.upper) && prev.close.LessThanOrEqual(prev.upper) {
+		state.upper = prev.upper
+	}
+	if state.lower.LessThan(prev.lower) && prev.close.GreaterThanOrEqual(prev.lower) {
+		state.lower = prev.lower
+	}
+
+	state.trend = prev.trend
+	switch {
+	case prev.trend == indicates.TrendUp && k.C.LessThan(state.lower):
+		state.trend, state.flipped = indicates.TrendDown, true
+	case prev.trend == indicates.TrendDown && k.C.GreaterThan(state.upper):
+		state.trend, state.flipped = indicates.TrendUp, true
+	}
+	return state
+}
+
+// Value 返回 SuperTrend：上涨趋势时为下轨，下跌趋势时为上轨
+func (s *Stream) Value() decimal.Decimal {
+	switch s.state.cur.trend {
+	case indicates.TrendUp:
+		return s.state.cur.lower
+	case indicates.TrendDown:
+		return s.state.cur.upper
+	default:
+		return decimal.Zero
+	}
+}
+
+func (s *Stream) Values() map[string]decimal.Decimal {
+	return map[string]decimal.Decimal{
+		"supertrend": s.Value(),
+		"upper":      s.state.cur.upper,
+		"lower":      s.state.cur.lower,
+		"trend":      s.state.cur.trend.Decimal(),
+		"flip":       indicates.FlipDecimal(s.state.cur.flipped),
+	}
+}
+
+// Trend 返回当前趋势方向
+func (s *Stream) Trend() indicates.Trend { return s.state.cur.trend }
+
+// Flipped 最后一根K线上趋势是否反转
+func (s *Stream) Flipped() bool { return s.state.cur.flipped }
+
+func (s *Stream) Ready() bool { return s.state.cur.ready }
+
+func (s *Stream) WarmUp() int { return s.state.atr.WarmUp() }
+
+func (s *Stream) Reset() {
+	s.state = streamState{atr: atr.NewStream(s.period)}
+}
+
+func (s *Stream) Snapshot() indicates.Snapshot {
+	state := s.state
+	state.atr = atr.NewStream(s.period)
+	state.atr.Restore(s.state.atr.Snapshot())
+	return &state
+}
+
+func (s *Stream) Restore(snapshot indicates.Snapshot) error {
+	state, ok := snapshot.(*streamState)
+	if !ok {
+		return indicates.ErrSnapshotMismatch
+	}
+	s.state = *state
+	s.state.atr = atr.NewStream(s.period)
+	return s.state.atr.Restore(state.atr.Snapshot())
+}
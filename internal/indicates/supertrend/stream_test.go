@@ -0,0 +1,88 @@
+package supertrend
+
+import (
+	"snake/internal/indicates"
+	"snake/internal/indicates/indicatestest"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestStream(t *testing.T) {
+	klines := indicatestest.HLC(
+		[3]float64{11, 9, 10},
+		[3]float64{11, 9, 10},
+		[3]float64{12, 10, 11},
+		[3]float64{11, 8, 8.5},
+		[3]float64{9, 7, 7.5},
+		[3]float64{12, 10, 11.5},
+	)
+
+	// 周期为 1 时 ATR 即真实波幅，倍数为 1
+	tests := []struct {
+		upper, lower, supertrend string // 空字符串表示未就绪
+		trend                    indicates.Trend
+		flip                     bool
+	}{
+		{"", "", "", indicates.TrendNone, false},
+		// 中点 10，真实波幅 2
+		{"12", "8", "8", indicates.TrendUp, false},
+		// 基本上轨 13 高于上一个上轨，保持 12；基本下轨 9 更高，更新为 9
+		{"12", "9", "9", indicates.TrendUp, false},
+		// 真实波幅 3，基本下轨 6.5 更低，保持 9；收盘价 8.5 跌破下轨，转为下跌
+		{"12", "9", "12", indicates.TrendDown, true},
+		// 基本上轨 10 更低，更新为 10；上一根收盘价跌破下轨，下轨更新为基本下轨 6
+		{"10", "6", "10", indicates.TrendDown, false},
+		// 真实波幅 4.5，基本上轨 15.5 更高，保持 10；收盘价 11.5 突破上轨，转为上涨
+		{"10", "6.5", "6.5", indicates.TrendUp, true},
+	}
+
+	stream := NewStream(1, decimal.NewFromInt(1))
+	if stream.Name() != "SUPERTREND1_1" || stream.WarmUp() != 2 {
+		t.Fatalf("预期名称 SUPERTREND1_1、WarmUp 为 2，实际为 %s、%d", stream.Name(), stream.WarmUp())
+	}
+
+	for i, k := range klines {
+		stream.Update(k)
+		tt := tests[i]
+		if ready := tt.supertrend != ""; stream.Ready() != ready {
+			t.Fatalf("第 %d 根K线后预期就绪状态为 %v", i+1, ready)
+		}
+		if stream.Trend() != tt.trend || stream.Flipped() != tt.flip {
+			t.Errorf("第 %d 根K线后预期趋势 %s、反转 %v，实际为 %s、%v", i+1, tt.trend, tt.flip, stream.Trend(), stream.Flipped())
+		}
+		if !stream.Ready() {
+			continue
+		}
+
+		values := stream.Values()
+		for _, field := range []struct{ key, expected string }{
+			{"upper", tt.upper}, {"lower", tt.lower}, {"supertrend", tt.supertrend},
+		} {
+			if !values[field.key].Equal(decimal.RequireFromString(field.expected)) {
+				t.Errorf("第 %d 根K线后预期 %s 为 %s，实际为 %s", i+1, field.key, field.expected, values[field.key])
+			}
+		}
+	}
+}
+
+func TestStreamParams(t *testing.T) {
+	tests := []struct {
+		name       string
+		period     int
+		multiplier decimal.Decimal
+		wantErr    bool
+	}{
+		{"常用参数", 10, decimal.NewFromInt(3), false},
+		{"周期为0", 0, decimal.NewFromInt(3), true},
+		{"倍数为0", 10, decimal.Zero, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewStreamWithParams(tt.period, tt.multiplier); (err != nil) != tt.wantErr {
+				t.Errorf("预期错误为 %v，实际为 %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package indicates
+
+import "github.com/shopspring/decimal"
+
+// Trend 趋势方向，用于抛物线转向、SuperTrend 等跟踪趋势的指标
+type Trend int
+
+const (
+	// TrendNone 趋势未确定（指标未就绪）
+	TrendNone Trend = 0
+	// TrendUp 上涨趋势
+	TrendUp Trend = 1
+	// TrendDown 下跌趋势
+	TrendDown Trend = -1
+)
+
+func (t Trend) String() string {
+	switch t {
+	case TrendUp:
+		return "up"
+	case TrendDown:
+		return "down"
+	default:
+		return "none"
+	}
+}
+
+// Decimal 返回趋势方向的数值，上涨为 1，下跌为 -1，未确定为 0，用于 Indicator.Values
+func (t Trend) Decimal() decimal.Decimal { return decimal.NewFromInt(int64(t)) }
+
+// FlipDecimal 返回是否反转的数值，反转为 1，否则为 0，用于 Indicator.Values
+func FlipDecimal(flipped bool) decimal.Decimal {
+	if flipped {
+		return decimal.NewFromInt(1)
+	}
+	return decimal.Zero
+}
@@ -0,0 +1,114 @@
+package vwap
+
+import (
+	"snake/internal/indicates"
+	"snake/internal/kline"
+	"snake/internal/kline/interval"
+
+	"github.com/shopspring/decimal"
+)
+
+// Stream 流式成交量加权平均价（Volume Weighted Average Price），按时段锚定
+//
+//	每个时段（默认按 UTC 自然日）开始时清零，VWAP = 时段内成交额之和 / 时段内成交量之和
+//	K线带有成交额时直接使用成交额，否则以典型价格 * 成交量估算
+type Stream struct {
+	anchor interval.Interval
+	state  streamState
+}
+
+type streamState struct {
+	seq indicates.Sequence
+	// 推入最后一根K线前后的状态，最后一根K线更新时从 prev 重新计算
+	prev vwapState
+	cur  vwapState
+}
+
+type vwapState struct {
+	count   int
+	session int64 // 当前时段的开始时间（毫秒）
+	amount  decimal.Decimal
+	volume  decimal.Decimal
+	typical decimal.Decimal // 最后一根K线的典型价格
+}
+
+var _ indicates.Indicator = (*Stream)(nil)
+
+var three = decimal.NewFromInt(3)
+
+// NewStream 创建按 UTC 自然日锚定的流式 VWAP
+func NewStream() *Stream {
+	return NewStreamWithAnchor(interval.Day1())
+}
+
+// NewStreamWithAnchor 创建按指定周期锚定的流式 VWAP，例如 interval.Week1() 为周 VWAP
+func NewStreamWithAnchor(anchor interval.Interval) *Stream {
+	return &Stream{anchor: anchor}
+}
+
+func (s *Stream) Name() string { return "VWAP_" + s.anchor.String() }
+
+func (s *Stream) Update(kline *kline.Kline) {
+	switch s.state.seq.Next(kline) {
+	case indicates.ActionAppend:
+		s.state.prev = s.state.cur
+	case indicates.ActionIgnore:
+		return
+	}
+
+	state := s.state.prev
+	state.count++
+	if session := s.anchor.Truncate(kline.S); state.count == 1 || session != state.session {
+		state.session = session
+		state.amount = decimal.Zero
+		state.volume = decimal.Zero
+	}
+
+	state.typical = kline.H.Add(kline.L).Add(kline.C).Div(three)
+	amount := kline.A
+	if !amount.IsPositive() {
+		amount = state.typical.Mul(kline.V)
+	}
+	state.amount = state.amount.Add(amount)
+	state.volume = state.volume.Add(kline.V)
+	s.state.cur = state
+}
+
+// Value 返回当前时段的 VWAP，时段内没有成交量时为最后一根K线的典型价格
+func (s *Stream) Value() decimal.Decimal {
+	state := s.state.cur
+	if state.volume.IsZero() {
+		return state.typical
+	}
+	return state.amount.Div(state.volume)
+}
+
+// Session 返回当前时段的开始时间（毫秒）
+func (s *Stream) Session() int64 { return s.state.cur.session }
+
+func (s *Stream) Values() map[string]decimal.Decimal {
+	return map[string]decimal.Decimal{
+		"vwap":   s.Value(),
+		"volume": s.state.cur.volume,
+	}
+}
+
+func (s *Stream) Ready() bool { return s.state.cur.count >= 1 }
+
+func (s *Stream) WarmUp() int { return 1 }
+
+func (s *Stream) Reset() { s.state = streamState{} }
+
+func (s *Stream) Snapshot() indicates.Snapshot {
+	state := s.state
+	return &state
+}
+
+func (s *Stream) Restore(snapshot indicates.Snapshot) error {
+	state, ok := snapshot.(*streamState)
+	if !ok {
+		return indicates.ErrSnapshotMismatch
+	}
+	s.state = *state
+	return nil
+}
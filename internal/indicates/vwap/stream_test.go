@@ -0,0 +1,76 @@
+package vwap
+
+import (
+	"snake/internal/kline"
+	"snake/internal/kline/interval"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestStream(t *testing.T) {
+	hour := int64(3600000)
+	day := 24 * hour
+
+	tests := []struct {
+		name     string
+		start    int64
+		price    float64
+		volume   float64
+		amount   float64
+		expected string
+	}{
+		{"第一根", 22 * hour, 10, 100, 0, "10"},
+		// (1000 + 12*300) / 400
+		{"典型价格估算成交额", 23 * hour, 12, 300, 0, "11.5"},
+		// 新的一天重新开始
+		{"跨日重置", day, 20, 100, 0, "20"},
+		// 优先使用K线的成交额：(2000 + 4400) / 300
+		{"使用成交额", day + hour, 21, 200, 4400, "21.3333333333333333"},
+		// 没有成交量时为典型价格
+		{"没有成交量", 2 * day, 30, 0, 0, "30"},
+	}
+
+	stream := NewStream()
+	if stream.Name() != "VWAP_1d" {
+		t.Fatalf("预期名称 VWAP_1d，实际为 %s", stream.Name())
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			price := decimal.NewFromFloat(tt.price)
+			k := &kline.Kline{
+				H: price, L: price, C: price,
+				V: decimal.NewFromFloat(tt.volume),
+				A: decimal.NewFromFloat(tt.amount),
+				S: tt.start,
+			}
+			// 未收盘K线的成交量随后被替换
+			stream.Update(&kline.Kline{H: price, L: price, C: price, V: k.V.Add(decimal.NewFromInt(500)), S: k.S})
+			stream.Update(k)
+
+			if !stream.Value().Round(10).Equal(decimal.RequireFromString(tt.expected).Round(10)) {
+				t.Errorf("预期 %s，实际为 %s", tt.expected, stream.Value())
+			}
+			if stream.Session() != interval.Day1().Truncate(tt.start) {
+				t.Errorf("预期时段开始于 %d，实际为 %d", interval.Day1().Truncate(tt.start), stream.Session())
+			}
+		})
+	}
+}
+
+func TestStreamWithAnchor(t *testing.T) {
+	stream := NewStreamWithAnchor(interval.Hour4())
+	if stream.Name() != "VWAP_4h" {
+		t.Fatalf("预期名称 VWAP_4h，实际为 %s", stream.Name())
+	}
+
+	hour := int64(3600000)
+	for i, price := range []int64{10, 20, 30} {
+		p := decimal.NewFromInt(price)
+		stream.Update(&kline.Kline{H: p, L: p, C: p, V: decimal.NewFromInt(100), S: int64(i) * 2 * hour})
+	}
+	// 第三根K线开始新的 4 小时时段
+	if !stream.Value().Equal(decimal.NewFromInt(30)) {
+		t.Errorf("预期 30，实际为 %s", stream.Value())
+	}
+}
@@ -0,0 +1,42 @@
+package acl
+
+import (
+	"snake/internal/kline/storage/mysql/models"
+	"snake/pkg/binance"
+
+	"github.com/shopspring/decimal"
+)
+
+func FuturesToDB(src *binance.FuturesKline) *models.Kline {
+	volume, _ := decimal.NewFromString(src.Volume)
+	amount, _ := decimal.NewFromString(src.Amount)
+
+	var m models.Kline
+	m.Volume = src.Volume
+	m.Amount = src.Amount
+	m.Close = src.Close
+	m.CloseTs = src.CloseTime
+	m.High = src.High
+	m.Low = src.Low
+	m.Open = src.Open
+	m.OpenTs = src.OpenTime
+	m.TakerBuyAmount = src.TakerBuyAmount
+	m.TakerBuyVolume = src.TakerBuyVolume
+	m.TradeCount = src.TradeCount
+	m.Average = "0"
+	if !volume.IsZero() {
+		m.Average = amount.Div(volume).String()
+	}
+	return &m
+}
+
+func FundingToDB(src *binance.FundingRate) *models.FundingRate {
+	var m models.FundingRate
+	m.FundingTs = src.FundingTime
+	m.FundingRate = src.FundingRate
+	m.MarkPrice = src.MarkPrice
+	if m.MarkPrice == "" {
+		m.MarkPrice = "0"
+	}
+	return &m
+}
@@ -52,6 +52,15 @@ func DB2Service(_ int, src *models.Kline) (bool, *kline.Kline) {
 
 	dst.S = src.OpenTs
 	dst.E = src.CloseTs
+
+	// 补齐的K线没有成交明细，解析失败时为 0
+	dst.N = src.TradeCount
+	dst.BV, _ = decimal.NewFromString(src.TakerBuyVolume)
+	dst.BA, _ = decimal.NewFromString(src.TakerBuyAmount)
+	dst.P, err = decimal.NewFromString(src.Average)
+	if err != nil {
+		dst.P = kline.AveragePrice(dst.A, dst.V)
+	}
 	return true, &dst
 }
 
@@ -62,6 +71,8 @@ func Ws2Service(_ int, src *klines.Data) (bool, *kline.Kline) {
 	high, _ := decimal.NewFromString(src.High)
 	low, _ := decimal.NewFromString(src.Low)
 	open, _ := decimal.NewFromString(src.Open)
+	takerBuyVolume, _ := decimal.NewFromString(src.TakerBuyVolume)
+	takerBuyAmount, _ := decimal.NewFromString(src.TakerBuyAmount)
 
 	var m kline.Kline
 	m.V = volume
@@ -72,5 +83,9 @@ func Ws2Service(_ int, src *klines.Data) (bool, *kline.Kline) {
 	m.L = low
 	m.O = open
 	m.S = src.OpenTime
+	m.N = src.TradeCount
+	m.BV = takerBuyVolume
+	m.BA = takerBuyAmount
+	m.P = kline.AveragePrice(amount, volume)
 	return true, &m
 }
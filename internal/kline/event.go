@@ -0,0 +1,76 @@
+package kline
+
+import "github.com/shopspring/decimal"
+
+// EventType K线事件类型
+type EventType int
+
+const (
+	// EventBarUpdate 当前K线更新（未收盘）
+	EventBarUpdate EventType = iota
+	// EventBarClose K线收盘
+	EventBarClose
+)
+
+var eventTypeNames = map[EventType]string{
+	EventBarUpdate: "BAR_UPDATE",
+	EventBarClose:  "BAR_CLOSE",
+}
+
+func (t EventType) String() string { return eventTypeNames[t] }
+
+// Event K线事件
+type Event struct {
+	Type  EventType
+	Kline *Kline
+}
+
+// UpdateEvent 创建K线更新事件
+func UpdateEvent(k *Kline) *Event { return &Event{Type: EventBarUpdate, Kline: k} }
+
+// CloseEvent 创建K线收盘事件
+func CloseEvent(k *Kline) *Event { return &Event{Type: EventBarClose, Kline: k} }
+
+// BarTracker 将实盘推送的K线（同一根K线会推送多次）转换为更新和收盘事件
+//
+// 推送中没有收盘标记，收到下一根K线的第一次推送时，认为上一根K线已经收盘
+type BarTracker struct {
+	current *Kline
+}
+
+// NewBarTracker 创建K线事件转换器
+func NewBarTracker() *BarTracker {
+	return &BarTracker{}
+}
+
+// Push 推入一次K线推送，返回产生的事件：换到下一根K线时先返回上一根的收盘事件
+// 早于当前K线的推送（乱序）会被忽略
+func (t *BarTracker) Push(k *Kline) []*Event {
+	if t.current != nil && k.S < t.current.S {
+		return nil
+	}
+
+	var events []*Event
+	if t.current != nil && k.S > t.current.S {
+		events = append(events, CloseEvent(t.current))
+	}
+
+	t.current = k
+	return append(events, UpdateEvent(k))
+}
+
+// IntrabarPath 按K线的开高低收生成盘中的K线快照，用于回测盘中策略：
+// 阳线按 开 -> 低 -> 高 的顺序，阴线按 开 -> 高 -> 低 的顺序，收盘价由收盘事件推送
+func IntrabarPath(k *Kline) []*Kline {
+	snapshot := func(h, l, c decimal.Decimal) *Kline {
+		return &Kline{O: k.O, H: h, L: l, C: c, S: k.S, E: k.E}
+	}
+
+	path := []*Kline{snapshot(k.O, k.O, k.O)}
+	if k.C.GreaterThanOrEqual(k.O) {
+		path = append(path, snapshot(k.O, k.L, k.L), snapshot(k.H, k.L, k.H))
+	} else {
+		path = append(path, snapshot(k.H, k.O, k.H), snapshot(k.H, k.L, k.L))
+	}
+	return path
+}
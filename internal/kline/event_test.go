@@ -0,0 +1,67 @@
+package kline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestBarTracker(t *testing.T) {
+	minute := time.Minute.Milliseconds()
+	tracker := NewBarTracker()
+
+	events := tracker.Push(minuteKline(0, 100))
+	if len(events) != 1 || events[0].Type != EventBarUpdate {
+		t.Fatalf("预期第一次推送为更新事件")
+	}
+
+	events = tracker.Push(minuteKline(0, 101))
+	if len(events) != 1 || events[0].Type != EventBarUpdate {
+		t.Fatalf("预期同一根K线的推送为更新事件")
+	}
+
+	events = tracker.Push(minuteKline(minute, 102))
+	if len(events) != 2 || events[0].Type != EventBarClose || events[1].Type != EventBarUpdate {
+		t.Fatalf("预期换到下一根K线时先收盘再更新，实际为 %d 个事件", len(events))
+	}
+	if !events[0].Kline.C.Equal(decimal.NewFromInt(101)) {
+		t.Errorf("预期收盘事件为最后一次推送，实际收盘价为 %s", events[0].Kline.C)
+	}
+
+	if events := tracker.Push(minuteKline(0, 103)); events != nil {
+		t.Errorf("预期忽略乱序推送")
+	}
+}
+
+func TestIntrabarPath(t *testing.T) {
+	bar := func(o, h, l, c int64) *Kline {
+		return &Kline{O: decimal.NewFromInt(o), H: decimal.NewFromInt(h), L: decimal.NewFromInt(l), C: decimal.NewFromInt(c)}
+	}
+
+	tests := []struct {
+		name  string
+		kline *Kline
+		path  []int64
+	}{
+		{"阳线先到最低价", bar(100, 120, 90, 110), []int64{100, 90, 120}},
+		{"阴线先到最高价", bar(100, 120, 90, 95), []int64{100, 120, 90}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := IntrabarPath(tt.kline)
+			if len(path) != len(tt.path) {
+				t.Fatalf("预期 %d 个快照，实际为 %d", len(tt.path), len(path))
+			}
+			for i, snapshot := range path {
+				if !snapshot.C.Equal(decimal.NewFromInt(tt.path[i])) {
+					t.Errorf("第 %d 个快照预期价格 %d，实际为 %s", i, tt.path[i], snapshot.C)
+				}
+				if snapshot.H.GreaterThan(tt.kline.H) || snapshot.L.LessThan(tt.kline.L) {
+					t.Errorf("快照超出K线范围")
+				}
+			}
+		})
+	}
+}
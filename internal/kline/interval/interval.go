@@ -129,3 +129,39 @@ func Parse(s string) (Interval, error) {
 	}
 	return Interval1m, errors.New("无效的时间间隔")
 }
+
+// mondayOffset 1970-01-01 是周四，周K线从周一 00:00 UTC 开始
+const mondayOffset = 4 * 24 * time.Hour
+
+// Truncate 返回时间戳（毫秒）所在K线的开盘时间，按 UTC 对齐：
+// 周K线从周一开始，月K线从自然月第一天开始，其余按周期整除
+func (i Interval) Truncate(ms int64) int64 {
+	switch i {
+	case Interval1M:
+		t := time.UnixMilli(ms).UTC()
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC).UnixMilli()
+	case Interval1w:
+		offset := mondayOffset.Milliseconds()
+		return floorDiv(ms-offset, i.Duration().Milliseconds())*i.Duration().Milliseconds() + offset
+	default:
+		d := i.Duration().Milliseconds()
+		return floorDiv(ms, d) * d
+	}
+}
+
+// Next 返回时间戳（毫秒）所在K线的下一根K线的开盘时间
+func (i Interval) Next(ms int64) int64 {
+	start := i.Truncate(ms)
+	if i == Interval1M {
+		return time.UnixMilli(start).UTC().AddDate(0, 1, 0).UnixMilli()
+	}
+	return start + i.Duration().Milliseconds()
+}
+
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
@@ -25,6 +25,25 @@ type Kline struct {
 	S int64
 	// end: milisecond
 	E int64
+	// trade count
+	N int64
+	// taker buy volume
+	BV decimal.Decimal
+	// taker buy amount
+	BA decimal.Decimal
+	// average price: amount / volume
+	P decimal.Decimal
+}
+
+// TakerSellVolume 主动卖出成交量，即成交量减去主动买入成交量
+func (k *Kline) TakerSellVolume() decimal.Decimal { return k.V.Sub(k.BV) }
+
+// AveragePrice 根据成交额和成交量计算成交均价，成交量为 0 时返回 0
+func AveragePrice(amount, volume decimal.Decimal) decimal.Decimal {
+	if volume.IsZero() {
+		return decimal.Zero
+	}
+	return amount.Div(volume)
 }
 
 func (k *Kline) MarshalBinary() ([]byte, error) {
@@ -53,9 +72,17 @@ type PositionKline struct {
 	PositionAmount decimal.Decimal
 	// 当前持仓成本
 	PositionCost decimal.Decimal
+	// 累计借币利息
+	Interest decimal.Decimal
+	// 强平价
+	LiquidationPrice decimal.Decimal
+	// 合约仓位的逐仓保证金
+	Margin decimal.Decimal
+	// 累计支付的合约资金费
+	Funding decimal.Decimal
 	// 当前余额
 	Balance decimal.Decimal
-	// 当前资产总值（持仓市值 + 余额）
+	// 当前资产总值（现货为 持仓市值 + 余额，合约为 余额 + 保证金 + 未实现盈亏）
 	TotalValue decimal.Decimal
 	// 当前持仓盈亏（绝对值）
 	ProfitAbsolute decimal.Decimal
@@ -18,3 +18,11 @@ type Repository interface {
 	// ListAll 获取指定时间间隔的所有 kline 数据，按时间升序排序
 	ListAll(ctx context.Context, interval interval.Interval) ([]*models.Kline, error)
 }
+
+// FundingRepository U 本位永续合约资金费率仓库
+type FundingRepository interface {
+	InsertFundingRates(ctx context.Context, rates []*models.FundingRate) error
+	LastFundingRate(ctx context.Context) (*models.FundingRate, error)
+	// ListFundingRates 获取结算时间在 [from, to] 之间的资金费率，按时间升序排序
+	ListFundingRates(ctx context.Context, from, to int64) ([]*models.FundingRate, error)
+}
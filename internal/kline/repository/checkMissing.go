@@ -15,7 +15,7 @@ func (r *Repository) CheckMissing(ctx context.Context, interval interval.Interva
 	var results []int64
 	db := r.db.Db(ctx).Model(&model).
 		Scopes(
-			models.KlineTable(interval),
+			r.table(interval),
 			model.ColumnOpenTs().In(openTs),
 		).
 		Pluck(model.ColumnOpenTs().String(), &results)
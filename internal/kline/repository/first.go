@@ -11,7 +11,7 @@ import (
 func (r *Repository) First(ctx context.Context, interval interval.Interval) (*models.Kline, error) {
 
 	var model models.Kline
-	db := r.db.Db(ctx).Scopes(models.KlineTable(interval)).Order(clause.OrderByColumn{
+	db := r.db.Db(ctx).Scopes(r.table(interval)).Order(clause.OrderByColumn{
 		Column:  clause.Column{Name: model.ColumnOpenTs().String()},
 		Desc:    false,
 		Reorder: false,
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"snake/internal/kline/storage/mysql/models"
+
+	"github.com/CrazyThursdayV50/pkgo/builtin/collector"
+	gmap "github.com/CrazyThursdayV50/pkgo/builtin/map"
+	"gorm.io/gorm/clause"
+)
+
+// InsertFundingRates 保存资金费率，已存在的结算时间会被跳过
+func (r *Repository) InsertFundingRates(ctx context.Context, rates []*models.FundingRate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+
+	ratesGroup := collector.Map(rates, func(_ int, v *models.FundingRate) (bool, int64, *models.FundingRate) {
+		return true, v.FundingTs, v
+	})
+
+	var model models.FundingRate
+	var exists []int64
+	db := r.db.Db(ctx).Model(&model).
+		Scopes(model.ColumnFundingTs().In(gmap.From(ratesGroup).Keys().Unwrap())).
+		Pluck(model.ColumnFundingTs().String(), &exists)
+	if db.Error != nil {
+		return db.Error
+	}
+
+	for _, ts := range exists {
+		delete(ratesGroup, ts)
+	}
+
+	if len(ratesGroup) == 0 {
+		return nil
+	}
+	return r.db.Db(ctx).CreateInBatches(gmap.From(ratesGroup).Values().Unwrap(), 200).Error
+}
+
+// LastFundingRate 获取最近一次结算的资金费率
+func (r *Repository) LastFundingRate(ctx context.Context) (*models.FundingRate, error) {
+	var model models.FundingRate
+	db := r.db.Db(ctx).Order(clause.OrderByColumn{
+		Column: clause.Column{Name: model.ColumnFundingTs().String()},
+		Desc:   true,
+	}).Limit(1).Find(&model)
+	if db.Error != nil {
+		return nil, db.Error
+	}
+	if db.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &model, nil
+}
+
+// ListFundingRates 获取结算时间在 [from, to] 之间的资金费率，按时间升序排序
+func (r *Repository) ListFundingRates(ctx context.Context, from, to int64) ([]*models.FundingRate, error) {
+	var model models.FundingRate
+	var rates []*models.FundingRate
+	db := r.db.Db(ctx).Model(&model).
+		Scopes(
+			model.ColumnFundingTs().Between(from, to),
+			model.ColumnFundingTs().OrderAsc(),
+		).
+		Find(&rates)
+	if db.Error != nil {
+		return nil, db.Error
+	}
+	return rates, nil
+}
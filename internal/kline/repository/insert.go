@@ -21,7 +21,7 @@ func (r *Repository) Insert(ctx context.Context, interval interval.Interval, kli
 
 	var kline models.Kline
 	var tempKlines []*models.Kline
-	r.db.Db(ctx).Model(&kline).Scopes(models.KlineTable(interval)).Scopes(kline.ColumnOpenTs().In(gmap.From(klinesGroup).Keys().Unwrap())).FindInBatches(&tempKlines, 100, models.DefaultFindInBatchesCallback(func() {
+	r.db.Db(ctx).Model(&kline).Scopes(r.table(interval)).Scopes(kline.ColumnOpenTs().In(gmap.From(klinesGroup).Keys().Unwrap())).FindInBatches(&tempKlines, 100, models.DefaultFindInBatchesCallback(func() {
 		for _, t := range tempKlines {
 			delete(klinesGroup, t.OpenTs)
 		}
@@ -35,5 +35,5 @@ func (r *Repository) Insert(ctx context.Context, interval interval.Interval, kli
 	if klinesSlice.Len() == 0 {
 		return nil
 	}
-	return r.db.Db(ctx).Scopes(models.KlineTable(interval)).CreateInBatches(klinesSlice.Unwrap(), 200).Error
+	return r.db.Db(ctx).Scopes(r.table(interval)).CreateInBatches(klinesSlice.Unwrap(), 200).Error
 }
@@ -10,7 +10,7 @@ import (
 
 func (r *Repository) Last(ctx context.Context, interval interval.Interval) (*models.Kline, error) {
 	var model models.Kline
-	db := r.db.Db(ctx).Scopes(models.KlineTable(interval)).Order(clause.OrderByColumn{
+	db := r.db.Db(ctx).Scopes(r.table(interval)).Order(clause.OrderByColumn{
 		Column:  clause.Column{Name: model.ColumnOpenTs().String()},
 		Desc:    true,
 		Reorder: false,
@@ -13,7 +13,7 @@ func (r *Repository) List(ctx context.Context, interval interval.Interval, from,
 	var klines []*models.Kline
 	db := r.db.Db(ctx).Model(&model).
 		Scopes(
-			models.KlineTable(interval),
+			r.table(interval),
 			model.ColumnOpenTs().Between(from, to),
 		).
 		Order(clause.OrderByColumn{Column: clause.Column{Name: model.ColumnOpenTs().String()}}).Find(&klines)
@@ -30,7 +30,7 @@ func (r *Repository) List(ctx context.Context, interval interval.Interval, from,
 	db = r.db.Db(ctx).
 		Model(&model).
 		Scopes(
-			models.KlineTable(interval),
+			r.table(interval),
 			model.ColumnOpenTs().LessThan(from),
 		).
 		Limit(1).
@@ -12,7 +12,7 @@ import (
 func (r *Repository) ListAll(ctx context.Context, interval interval.Interval) ([]*models.Kline, error) {
 	var klines []*models.Kline
 	var model models.Kline
-	db := r.db.Db(ctx).Scopes(models.KlineTable(interval)).
+	db := r.db.Db(ctx).Scopes(r.table(interval)).
 		Order(
 			clause.OrderByColumn{
 				Column: clause.Column{
@@ -1,13 +1,39 @@
 package repository
 
-import "github.com/CrazyThursdayV50/pkgo/store/db/gorm"
+import (
+	"snake/internal/kline/interval"
+	"snake/internal/kline/storage/mysql/models"
+
+	"github.com/CrazyThursdayV50/pkgo/store/db/gorm"
+	gormio "gorm.io/gorm"
+)
 
 type Repository struct {
 	db *gorm.DB
+	// K线所在的表，现货、合约、标记价格K线分别存放
+	table func(interval.Interval) func(*gormio.DB) *gormio.DB
 }
 
+// New 创建现货K线仓库
 func New(db *gorm.DB) *Repository {
 	return &Repository{
-		db: db,
+		db:    db,
+		table: models.KlineTable[interval.Interval],
+	}
+}
+
+// NewFutures 创建 U 本位永续合约K线仓库
+func NewFutures(db *gorm.DB) *Repository {
+	return &Repository{
+		db:    db,
+		table: models.FuturesKlineTable[interval.Interval],
+	}
+}
+
+// NewMarkPrice 创建 U 本位永续合约标记价格K线仓库
+func NewMarkPrice(db *gorm.DB) *Repository {
+	return &Repository{
+		db:    db,
+		table: models.MarkPriceKlineTable[interval.Interval],
 	}
 }
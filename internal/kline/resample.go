@@ -0,0 +1,91 @@
+package kline
+
+import (
+	"fmt"
+	"snake/internal/kline/interval"
+	"sort"
+
+	"github.com/shopspring/decimal"
+)
+
+// IntervalKline 带周期的K线
+type IntervalKline struct {
+	Interval interval.Interval
+	*Kline
+}
+
+// Resampler 将基础周期的K线合成多个更高周期的K线
+//
+// 高周期K线只在其最后一根基础K线收盘后发出，之前只在内部累积，
+// 回测和实盘使用同一个合成器，保证不同周期的K线在时间上对齐且不会用到未来数据
+type Resampler struct {
+	base interval.Interval
+	// 按周期从大到小排列的高周期
+	intervals []interval.Interval
+	// 各周期正在累积的K线
+	bars map[interval.Interval]*Kline
+}
+
+// NewResampler 创建K线合成器，高周期必须不小于基础周期，且是基础周期的整数倍（周线、月线除外）
+func NewResampler(base interval.Interval, intervals ...interval.Interval) (*Resampler, error) {
+	var r = &Resampler{base: base, bars: make(map[interval.Interval]*Kline)}
+	var seen = map[interval.Interval]bool{base: true}
+	for _, iv := range intervals {
+		if seen[iv] {
+			continue
+		}
+		seen[iv] = true
+
+		if iv.Duration() < base.Duration() {
+			return nil, fmt.Errorf("周期 %s 小于基础周期 %s", iv, base)
+		}
+		if iv != interval.Interval1w && iv != interval.Interval1M && iv.Duration()%base.Duration() != 0 {
+			return nil, fmt.Errorf("周期 %s 不是基础周期 %s 的整数倍", iv, base)
+		}
+		r.intervals = append(r.intervals, iv)
+	}
+
+	sort.Slice(r.intervals, func(i, j int) bool {
+		return r.intervals[i].Duration() > r.intervals[j].Duration()
+	})
+	return r, nil
+}
+
+// Close 推入一根已收盘的基础K线，返回此时收盘的所有K线：
+// 高周期按周期从大到小在前，基础周期的K线最后，
+// 这样在基础周期收盘时，策略已经能看到同一时刻收盘的高周期K线
+func (r *Resampler) Close(k *Kline) []*IntervalKline {
+	var closed []*IntervalKline
+	for _, iv := range r.intervals {
+		bar := r.bars[iv]
+
+		// 基础K线不连续时，上一根高周期K线虽然不完整，但已经收盘
+		if bar != nil && k.S > bar.E {
+			closed = append(closed, &IntervalKline{Interval: iv, Kline: bar})
+			bar = nil
+		}
+
+		if bar == nil {
+			start := iv.Truncate(k.S)
+			bar = &Kline{O: k.O, H: k.H, L: k.L, S: start, E: iv.Next(start) - 1}
+		} else {
+			bar.H = decimal.Max(bar.H, k.H)
+			bar.L = decimal.Min(bar.L, k.L)
+		}
+		bar.C = k.C
+		bar.V = bar.V.Add(k.V)
+		bar.A = bar.A.Add(k.A)
+		bar.N += k.N
+		bar.BV = bar.BV.Add(k.BV)
+		bar.BA = bar.BA.Add(k.BA)
+		bar.P = AveragePrice(bar.A, bar.V)
+
+		if k.E >= bar.E {
+			closed = append(closed, &IntervalKline{Interval: iv, Kline: bar})
+			bar = nil
+		}
+		r.bars[iv] = bar
+	}
+
+	return append(closed, &IntervalKline{Interval: r.base, Kline: k})
+}
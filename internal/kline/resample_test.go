@@ -0,0 +1,126 @@
+package kline
+
+import (
+	"snake/internal/kline/interval"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func minuteKline(start int64, price int64) *Kline {
+	p := decimal.NewFromInt(price)
+	return &Kline{O: p, C: p, H: p.Add(decimal.NewFromInt(1)), L: p.Sub(decimal.NewFromInt(1)), V: decimal.NewFromInt(1), A: p, S: start, E: start + time.Minute.Milliseconds() - 1}
+}
+
+func TestResampler(t *testing.T) {
+	minute := time.Minute.Milliseconds()
+
+	t.Run("高周期只在收盘后发出", func(t *testing.T) {
+		r, err := NewResampler(interval.Min1(), interval.Min5(), interval.Min15())
+		if err != nil {
+			t.Fatalf("创建合成器失败: %v", err)
+		}
+
+		var closed []*IntervalKline
+		for i := int64(0); i < 15; i++ {
+			out := r.Close(minuteKline(i*minute, 100+i))
+			if i != 4 && i != 9 && i != 14 && len(out) != 1 {
+				t.Fatalf("第 %d 根K线预期只发出基础周期，实际发出 %d 根", i, len(out))
+			}
+			closed = append(closed, out...)
+		}
+
+		var count = map[interval.Interval]int{}
+		for _, k := range closed {
+			count[k.Interval]++
+		}
+		if count[interval.Min1()] != 15 || count[interval.Min5()] != 3 || count[interval.Min15()] != 1 {
+			t.Fatalf("预期 15/3/1 根K线，实际为 %v", count)
+		}
+
+		// 最后一根基础K线同时收盘 5 分钟和 15 分钟K线，按周期从大到小，基础周期最后
+		last := closed[len(closed)-3:]
+		if last[0].Interval != interval.Min15() || last[1].Interval != interval.Min5() || last[2].Interval != interval.Min1() {
+			t.Errorf("推送顺序错误: %s %s %s", last[0].Interval, last[1].Interval, last[2].Interval)
+		}
+
+		bar := last[0]
+		if !bar.O.Equal(decimal.NewFromInt(100)) || !bar.C.Equal(decimal.NewFromInt(114)) ||
+			!bar.H.Equal(decimal.NewFromInt(115)) || !bar.L.Equal(decimal.NewFromInt(99)) || !bar.V.Equal(decimal.NewFromInt(15)) {
+			t.Errorf("15 分钟K线合成错误: %+v", bar.Kline)
+		}
+		if bar.S != 0 || bar.E != 15*minute-1 {
+			t.Errorf("15 分钟K线时间错误: %d - %d", bar.S, bar.E)
+		}
+	})
+
+	t.Run("基础K线缺失时发出不完整的高周期K线", func(t *testing.T) {
+		r, _ := NewResampler(interval.Min1(), interval.Min5())
+		r.Close(minuteKline(0, 100))
+		out := r.Close(minuteKline(6*minute, 100))
+		if len(out) != 2 || out[0].Interval != interval.Min5() || out[0].E != 5*minute-1 {
+			t.Fatalf("预期发出上一根 5 分钟K线")
+		}
+	})
+
+	t.Run("合并成交笔数和主动买入量", func(t *testing.T) {
+		r, _ := NewResampler(interval.Min1(), interval.Min3())
+		var out []*IntervalKline
+		for i := int64(0); i < 3; i++ {
+			k := minuteKline(i*minute, 100)
+			k.V = decimal.NewFromInt(2)
+			k.A = decimal.NewFromInt(200 + i*6)
+			k.N = 10
+			k.BV = decimal.NewFromInt(1)
+			k.BA = decimal.NewFromInt(100)
+			out = r.Close(k)
+		}
+
+		bar := out[0]
+		// 成交均价 (200 + 206 + 212) / 6
+		if bar.N != 30 || !bar.BV.Equal(decimal.NewFromInt(3)) || !bar.BA.Equal(decimal.NewFromInt(300)) ||
+			!bar.P.Equal(decimal.NewFromInt(103)) || !bar.TakerSellVolume().Equal(decimal.NewFromInt(3)) {
+			t.Errorf("3 分钟K线合成错误: %+v", bar.Kline)
+		}
+	})
+
+	t.Run("无效的周期", func(t *testing.T) {
+		if _, err := NewResampler(interval.Min5(), interval.Min1()); err == nil {
+			t.Errorf("预期小于基础周期时报错")
+		}
+		if _, err := NewResampler(interval.Min15(), interval.Hour1(), interval.Min30()); err != nil {
+			t.Errorf("预期整数倍的周期有效: %v", err)
+		}
+		if _, err := NewResampler(interval.Min3(), interval.Min5()); err == nil {
+			t.Errorf("预期非整数倍时报错")
+		}
+	})
+}
+
+func TestIntervalTruncate(t *testing.T) {
+	// 2024-01-10 (周三) 13:45 UTC
+	ts := time.Date(2024, 1, 10, 13, 45, 0, 0, time.UTC).UnixMilli()
+
+	tests := []struct {
+		interval interval.Interval
+		start    time.Time
+		next     time.Time
+	}{
+		{interval.Hour4(), time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC), time.Date(2024, 1, 10, 16, 0, 0, 0, time.UTC)},
+		{interval.Day1(), time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 11, 0, 0, 0, 0, time.UTC)},
+		{interval.Week1(), time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{interval.Month1(), time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.interval.String(), func(t *testing.T) {
+			if got := tt.interval.Truncate(ts); got != tt.start.UnixMilli() {
+				t.Errorf("预期开盘时间 %s，实际为 %s", tt.start, time.UnixMilli(got).UTC())
+			}
+			if got := tt.interval.Next(ts); got != tt.next.UnixMilli() {
+				t.Errorf("预期下一根开盘时间 %s，实际为 %s", tt.next, time.UnixMilli(got).UTC())
+			}
+		})
+	}
+}
@@ -15,3 +15,18 @@ func AutoMigrate(ctx context.Context, db *gorm.DB) {
 			AutoMigrate(new(models.Kline))
 	}
 }
+
+// AutoMigrateFutures 创建 U 本位永续合约的K线、标记价格K线和资金费率表
+func AutoMigrateFutures(ctx context.Context, db *gorm.DB) {
+	for _, interval := range interval.All() {
+		db.Db(ctx).
+			Scopes(models.FuturesKlineTable(interval)).
+			AutoMigrate(new(models.Kline))
+
+		db.Db(ctx).
+			Scopes(models.MarkPriceKlineTable(interval)).
+			AutoMigrate(new(models.Kline))
+	}
+
+	db.Db(ctx).AutoMigrate(new(models.FundingRate))
+}
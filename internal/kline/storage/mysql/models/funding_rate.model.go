@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// FundingRate U 本位永续合约资金费率，表结构见 funding_rate.sql
+type FundingRate struct {
+	FundingTs   int64     `gorm:"column:funding_ts;type:BIGINT UNSIGNED;primaryKey;not null" json:"funding_ts"`
+	FundingRate string    `gorm:"column:funding_rate;type:VARCHAR(40);size:40;not null" json:"funding_rate"`
+	MarkPrice   string    `gorm:"column:mark_price;type:VARCHAR(40);size:40;not null" json:"mark_price"`
+	CreatedAt   time.Time `gorm:"column:created_at;type:TIMESTAMP;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"column:updated_at;type:TIMESTAMP;not null;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+func funding_rate_funding_ts() Column[int64] {
+	return "funding_ts"
+}
+
+func (s *FundingRate) ColumnFundingTs() Column[int64] {
+	return funding_rate_funding_ts()
+}
+
+func funding_rate_funding_rate() Column[string] {
+	return "funding_rate"
+}
+
+func (s *FundingRate) ColumnFundingRate() Column[string] {
+	return funding_rate_funding_rate()
+}
+
+func funding_rate_mark_price() Column[string] {
+	return "mark_price"
+}
+
+func (s *FundingRate) ColumnMarkPrice() Column[string] {
+	return funding_rate_mark_price()
+}
+
+func funding_rate_created_at() Column[time.Time] {
+	return "created_at"
+}
+
+func (s *FundingRate) ColumnCreatedAt() Column[time.Time] {
+	return funding_rate_created_at()
+}
+
+func funding_rate_updated_at() Column[time.Time] {
+	return "updated_at"
+}
+
+func (s *FundingRate) ColumnUpdatedAt() Column[time.Time] {
+	return funding_rate_updated_at()
+}
+
+func (t *FundingRate) TableName() string {
+	return Schema() + ".funding_rate"
+}
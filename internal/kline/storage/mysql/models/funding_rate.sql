@@ -0,0 +1,8 @@
+CREATE TABLE `funding_rate` (
+  `funding_ts` bigint unsigned NOT NULL,
+  `funding_rate` varchar(40) NOT NULL DEFAULT "0",
+  `mark_price` varchar(40) NOT NULL DEFAULT "0",
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`funding_ts`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -16,6 +16,20 @@ func KlineTable[S s](interval S) func(tx *gorm.DB) *gorm.DB {
 	}
 }
 
+// FuturesKlineTable U 本位永续合约K线表
+func FuturesKlineTable[S s](interval S) func(tx *gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Table(fmt.Sprintf("futures_kline_%s", interval.DB()))
+	}
+}
+
+// MarkPriceKlineTable U 本位永续合约标记价格K线表
+func MarkPriceKlineTable[S s](interval S) func(tx *gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Table(fmt.Sprintf("mark_price_kline_%s", interval.DB()))
+	}
+}
+
 func (m *Kline) ResetDefault(openTs, closeTs uint64, open string) {
 	m.Amount = "0"
 	m.Average = "0"
@@ -0,0 +1,48 @@
+package transform
+
+import (
+	"snake/internal/kline"
+
+	"github.com/shopspring/decimal"
+)
+
+// HeikinAshi 平均K线，每根K线转换为一根平均K线：
+//
+//	收盘价 = (开盘价 + 最高价 + 最低价 + 收盘价) / 4
+//	开盘价 = (上一根平均K线的开盘价 + 上一根平均K线的收盘价) / 2，第一根为 (开盘价 + 收盘价) / 2
+//	最高价 = max(最高价, 开盘价, 收盘价)，最低价 = min(最低价, 开盘价, 收盘价)，均为平均K线的开盘价、收盘价
+//
+// 成交量和时间与原K线相同
+type HeikinAshi struct {
+	prev *kline.Kline
+}
+
+var _ Transformer = (*HeikinAshi)(nil)
+
+var (
+	two  = decimal.NewFromInt(2)
+	four = decimal.NewFromInt(4)
+)
+
+// NewHeikinAshi 创建平均K线转换
+func NewHeikinAshi() *HeikinAshi { return &HeikinAshi{} }
+
+func (h *HeikinAshi) Name() string { return "HA" }
+
+func (h *HeikinAshi) Push(k *kline.Kline) []*kline.Kline {
+	ha := *k
+	ha.C = k.O.Add(k.H).Add(k.L).Add(k.C).Div(four)
+	if h.prev == nil {
+		ha.O = k.O.Add(k.C).Div(two)
+	} else {
+		ha.O = h.prev.O.Add(h.prev.C).Div(two)
+	}
+	ha.H = decimal.Max(k.H, ha.O, ha.C)
+	ha.L = decimal.Min(k.L, ha.O, ha.C)
+
+	h.prev = &ha
+	out := ha
+	return []*kline.Kline{&out}
+}
+
+func (h *HeikinAshi) Reset() { h.prev = nil }
@@ -0,0 +1,59 @@
+package transform
+
+import (
+	"snake/internal/indicates/indicatestest"
+	"snake/internal/kline"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+// closeKlines 生成只有收盘价的K线，最高价、最低价与收盘价相差 1
+func closeKlines(closes ...float64) []*kline.Kline {
+	bars := make([][4]float64, len(closes))
+	for i, c := range closes {
+		bars[i] = [4]float64{c, c + 1, c - 1, c}
+	}
+	return indicatestest.OHLC(bars...)
+}
+
+// equalBar 按 [开盘价, 最高价, 最低价, 收盘价] 比较K线价格
+func equalBar(k *kline.Kline, expected [4]float64) bool {
+	return k.O.Equal(decimal.NewFromFloat(expected[0])) && k.H.Equal(decimal.NewFromFloat(expected[1])) &&
+		k.L.Equal(decimal.NewFromFloat(expected[2])) && k.C.Equal(decimal.NewFromFloat(expected[3]))
+}
+
+func TestHeikinAshi(t *testing.T) {
+	klines := indicatestest.OHLC(
+		[4]float64{10, 12, 9, 11},
+		[4]float64{11, 13, 10, 12},
+		[4]float64{12, 12.5, 8, 9},
+	)
+	expected := [][4]float64{
+		// 收盘价 (10+12+9+11)/4，开盘价 (10+11)/2
+		{10.5, 12, 9, 10.5},
+		// 收盘价 (11+13+10+12)/4，开盘价 (10.5+10.5)/2
+		{10.5, 13, 10, 11.5},
+		// 收盘价 (12+12.5+8+9)/4，开盘价 (10.5+11.5)/2
+		{11, 12.5, 8, 10.375},
+	}
+
+	ha := NewHeikinAshi()
+	bars := Apply(ha, klines)
+	if len(bars) != len(expected) {
+		t.Fatalf("预期 %d 根平均K线，实际为 %d 根", len(expected), len(bars))
+	}
+	for i, bar := range bars {
+		if !equalBar(bar, expected[i]) {
+			t.Errorf("第 %d 根预期 %v，实际为 %+v", i+1, expected[i], bar)
+		}
+		if bar.S != klines[i].S || !bar.V.Equal(klines[i].V) {
+			t.Errorf("第 %d 根预期时间和成交量与原K线相同", i+1)
+		}
+	}
+
+	ha.Reset()
+	if bar := ha.Push(klines[1])[0]; !equalBar(bar, [4]float64{11.5, 13, 10, 11.5}) {
+		t.Errorf("预期重置后按第一根计算开盘价，实际为 %+v", bar)
+	}
+}
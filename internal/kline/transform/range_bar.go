@@ -0,0 +1,90 @@
+package transform
+
+import (
+	"fmt"
+	"snake/internal/kline"
+
+	"github.com/shopspring/decimal"
+)
+
+// RangeBars 等幅K线，每根K线的最高价与最低价之差达到固定大小时收盘，下一根从收盘价开始
+//
+// 只有K线数据时无法知道K线内的价格路径，按阳线 开盘→最低→最高→收盘、阴线 开盘→最高→最低→收盘 的顺序模拟。
+// 成交量计入该K线收盘时仍在形成的等幅K线
+type RangeBars struct {
+	size  decimal.Decimal
+	clock clock
+	flow  flow
+	cur   *kline.Kline // 正在形成的等幅K线
+}
+
+var _ Transformer = (*RangeBars)(nil)
+
+// NewRangeBars 创建振幅为 size 的等幅K线
+func NewRangeBars(size decimal.Decimal) (*RangeBars, error) {
+	if !size.IsPositive() {
+		return nil, fmt.Errorf("invalid range bar size: %s", size)
+	}
+	return &RangeBars{size: size}, nil
+}
+
+func (r *RangeBars) Name() string { return "RANGE" + r.size.String() }
+
+func (r *RangeBars) Push(k *kline.Kline) []*kline.Kline {
+	path := []decimal.Decimal{k.O, k.H, k.L, k.C}
+	if k.C.GreaterThanOrEqual(k.O) {
+		path = []decimal.Decimal{k.O, k.L, k.H, k.C}
+	}
+
+	var bars []*kline.Kline
+	for _, price := range path {
+		bars = append(bars, r.move(k, price)...)
+	}
+	r.flow.add(k)
+	return bars
+}
+
+// move 价格移动到 price，返回因此完成的等幅K线
+func (r *RangeBars) move(k *kline.Kline, price decimal.Decimal) []*kline.Kline {
+	var bars []*kline.Kline
+	for {
+		if r.cur == nil {
+			r.cur = &kline.Kline{O: price, H: price, L: price, C: price, S: r.clock.next(k.S)}
+			return bars
+		}
+
+		var limit decimal.Decimal
+		switch {
+		case price.Sub(r.cur.L).GreaterThanOrEqual(r.size):
+			limit = r.cur.L.Add(r.size)
+		case r.cur.H.Sub(price).GreaterThanOrEqual(r.size):
+			limit = r.cur.H.Sub(r.size)
+		default:
+			r.cur.H = decimal.Max(r.cur.H, price)
+			r.cur.L = decimal.Min(r.cur.L, price)
+			r.cur.C = price
+			return bars
+		}
+
+		// 在振幅达到 size 的价格收盘，剩余的移动由下一根等幅K线继续
+		bar := r.cur
+		bar.H = decimal.Max(bar.H, limit)
+		bar.L = decimal.Min(bar.L, limit)
+		bar.C = limit
+		bar.E = k.E
+		r.flow.drain(bar)
+		bars = append(bars, bar)
+		r.cur = &kline.Kline{O: limit, H: limit, L: limit, C: limit, S: r.clock.next(k.S)}
+	}
+}
+
+// Current 返回正在形成的等幅K线，没有时为 nil
+func (r *RangeBars) Current() *kline.Kline {
+	if r.cur == nil {
+		return nil
+	}
+	current := *r.cur
+	return &current
+}
+
+func (r *RangeBars) Reset() { *r = RangeBars{size: r.size} }
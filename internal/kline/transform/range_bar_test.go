@@ -0,0 +1,61 @@
+package transform
+
+import (
+	"snake/internal/indicates/indicatestest"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestRangeBars(t *testing.T) {
+	bars, err := NewRangeBars(decimal.NewFromInt(2))
+	if err != nil {
+		t.Fatalf("创建失败: %v", err)
+	}
+	if bars.Name() != "RANGE2" {
+		t.Fatalf("预期名称 RANGE2，实际为 %s", bars.Name())
+	}
+
+	klines := indicatestest.OHLC(
+		[4]float64{10, 11, 9.5, 10.5},
+		[4]float64{10.5, 13, 10, 12.5},
+		[4]float64{12.5, 12.5, 6, 7},
+	)
+	tests := []struct {
+		name     string
+		expected [][4]float64
+		current  [4]float64
+	}{
+		{"振幅不足", nil, [4]float64{10, 11, 9.5, 10.5}},
+		// 阳线按 开盘→最低→最高→收盘 模拟，涨到 11.5 时振幅达到 2
+		{"向上收盘", [][4]float64{{10, 11.5, 9.5, 11.5}}, [4]float64{11.5, 13, 11.5, 12.5}},
+		// 阴线按 开盘→最高→最低→收盘 模拟，从 13 跌到 6 完成三根
+		{"一根K线完成多根", [][4]float64{{11.5, 13, 11, 11}, {11, 11, 9, 9}, {9, 9, 7, 7}}, [4]float64{7, 7, 6, 7}},
+	}
+
+	var last int64 = -1
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := bars.Push(klines[i])
+			if len(out) != len(tt.expected) {
+				t.Fatalf("预期 %d 根等幅K线，实际为 %d 根", len(tt.expected), len(out))
+			}
+			for j, bar := range out {
+				if !equalBar(bar, tt.expected[j]) {
+					t.Errorf("第 %d 根预期 %v，实际为 %+v", j+1, tt.expected[j], bar)
+				}
+				if bar.S <= last || bar.E != klines[i].E {
+					t.Errorf("第 %d 根时间错误: %d - %d", j+1, bar.S, bar.E)
+				}
+				last = bar.S
+			}
+			if !equalBar(bars.Current(), tt.current) {
+				t.Errorf("预期正在形成的K线为 %v，实际为 %+v", tt.current, bars.Current())
+			}
+		})
+	}
+
+	if _, err := NewRangeBars(decimal.NewFromInt(-1)); err == nil {
+		t.Errorf("预期振幅为负数时创建失败")
+	}
+}
@@ -0,0 +1,115 @@
+package transform
+
+import (
+	"fmt"
+	"snake/internal/indicates"
+	"snake/internal/indicates/atr"
+	"snake/internal/indicates/ma"
+	"snake/internal/kline"
+
+	"github.com/shopspring/decimal"
+)
+
+// Renko 砖形图，只根据收盘价生成固定大小的砖块：
+//   - 收盘价比最后一块砖的收盘价继续同向移动一个砖块大小时，生成一块同向的砖
+//   - 收盘价向反方向移动到最后一块砖的开盘价之外一个砖块大小时，生成一块反向的砖，即反转需要两个砖块大小
+//
+// 砖块大小可以固定，也可以使用 ATR，ATR 就绪前不生成砖块，之后每根K线使用最新的 ATR。
+// 两块砖之间的成交量计入下一块砖，同一根K线生成的其余砖块成交量为 0
+type Renko struct {
+	box    decimal.Decimal
+	atr    *atr.Stream
+	clock  clock
+	flow   flow
+	trend  indicates.Trend
+	open   decimal.Decimal // 最后一块砖的开盘价
+	close  decimal.Decimal // 最后一块砖的收盘价，尚未生成砖块时为基准价格
+	anchor bool            // 是否已确定基准价格
+}
+
+var _ Transformer = (*Renko)(nil)
+
+// NewRenko 创建固定砖块大小的砖形图
+func NewRenko(box decimal.Decimal) (*Renko, error) {
+	if !box.IsPositive() {
+		return nil, fmt.Errorf("invalid renko box size: %s", box)
+	}
+	return &Renko{box: box}, nil
+}
+
+// NewATRRenko 创建以 ATR(period) 为砖块大小的砖形图
+func NewATRRenko(period int) (*Renko, error) {
+	stream, err := atr.NewStreamWithMA(ma.TypeRMA, period)
+	if err != nil {
+		return nil, err
+	}
+	return &Renko{atr: stream}, nil
+}
+
+func (r *Renko) Name() string {
+	if r.atr != nil {
+		return "RENKO_" + r.atr.Name()
+	}
+	return "RENKO" + r.box.String()
+}
+
+func (r *Renko) Push(k *kline.Kline) []*kline.Kline {
+	r.flow.add(k)
+
+	box := r.box
+	if r.atr != nil {
+		r.atr.Update(k)
+		if !r.atr.Ready() || !r.atr.Value().IsPositive() {
+			return nil
+		}
+		box = r.atr.Value()
+	}
+
+	if !r.anchor {
+		r.open, r.close, r.anchor = k.C, k.C, true
+		return nil
+	}
+
+	var bricks []*kline.Kline
+	for {
+		var open decimal.Decimal
+		switch {
+		case r.trend != indicates.TrendDown && k.C.GreaterThanOrEqual(r.close.Add(box)):
+			open = r.close
+		case r.trend == indicates.TrendDown && k.C.GreaterThanOrEqual(r.open.Add(box)):
+			open = r.open
+		case r.trend != indicates.TrendUp && k.C.LessThanOrEqual(r.close.Sub(box)):
+			open = r.close
+		case r.trend == indicates.TrendUp && k.C.LessThanOrEqual(r.open.Sub(box)):
+			open = r.open
+		default:
+			return bricks
+		}
+
+		close, trend := open.Add(box), indicates.TrendUp
+		if k.C.LessThan(open) {
+			close, trend = open.Sub(box), indicates.TrendDown
+		}
+		brick := &kline.Kline{
+			O: open,
+			C: close,
+			H: decimal.Max(open, close),
+			L: decimal.Min(open, close),
+			S: r.clock.next(k.S),
+			E: k.E,
+		}
+		r.flow.drain(brick)
+		bricks = append(bricks, brick)
+		r.open, r.close, r.trend = open, close, trend
+	}
+}
+
+// Trend 返回最后一块砖的方向，尚未生成砖块时为 TrendNone
+func (r *Renko) Trend() indicates.Trend { return r.trend }
+
+func (r *Renko) Reset() {
+	*r = Renko{box: r.box, atr: r.atr}
+	if r.atr != nil {
+		r.atr.Reset()
+	}
+}
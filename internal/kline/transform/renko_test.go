@@ -0,0 +1,83 @@
+package transform
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestRenko(t *testing.T) {
+	renko, err := NewRenko(decimal.NewFromInt(10))
+	if err != nil {
+		t.Fatalf("创建失败: %v", err)
+	}
+	if renko.Name() != "RENKO10" {
+		t.Fatalf("预期名称 RENKO10，实际为 %s", renko.Name())
+	}
+
+	tests := []struct {
+		name   string
+		close  float64
+		bricks [][2]float64 // [开盘价, 收盘价]
+	}{
+		{"第一根为基准价格", 100, nil},
+		{"不足一个砖块", 105, nil},
+		{"一根K线生成两块砖", 125, [][2]float64{{100, 110}, {110, 120}}},
+		// 反转需要跌破最后一块砖的开盘价 110 一个砖块
+		{"不足以反转", 101, nil},
+		{"反转", 95, [][2]float64{{110, 100}}},
+		{"继续下跌", 79, [][2]float64{{100, 90}, {90, 80}}},
+	}
+
+	klines := closeKlines(100, 105, 125, 101, 95, 79)
+	var last int64 = -1
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bricks := renko.Push(klines[i])
+			if len(bricks) != len(tt.bricks) {
+				t.Fatalf("预期 %d 块砖，实际为 %d 块", len(tt.bricks), len(bricks))
+			}
+			for j, brick := range bricks {
+				if !brick.O.Equal(decimal.NewFromFloat(tt.bricks[j][0])) || !brick.C.Equal(decimal.NewFromFloat(tt.bricks[j][1])) {
+					t.Errorf("第 %d 块砖预期 %v，实际为 %s→%s", j+1, tt.bricks[j], brick.O, brick.C)
+				}
+				if brick.S <= last {
+					t.Errorf("预期开盘时间递增，%d 不晚于 %d", brick.S, last)
+				}
+				last = brick.S
+			}
+		})
+	}
+
+	// 前三根K线的成交量计入第一块砖
+	renko.Reset()
+	bricks := Apply(renko, klines[:3])
+	if !bricks[0].V.Equal(decimal.NewFromInt(3)) || !bricks[1].V.IsZero() {
+		t.Errorf("预期成交量为 3 和 0，实际为 %s 和 %s", bricks[0].V, bricks[1].V)
+	}
+
+	if _, err := NewRenko(decimal.Zero); err == nil {
+		t.Errorf("预期砖块大小为 0 时创建失败")
+	}
+}
+
+func TestATRRenko(t *testing.T) {
+	renko, err := NewATRRenko(2)
+	if err != nil {
+		t.Fatalf("创建失败: %v", err)
+	}
+	if renko.Name() != "RENKO_ATR2" {
+		t.Fatalf("预期名称 RENKO_ATR2，实际为 %s", renko.Name())
+	}
+
+	// 前三根K线 ATR 就绪，值为 2，以第三根收盘价为基准
+	// 第四根真实波幅为 6，ATR = (2 + 6) / 2 = 4
+	bricks := Apply(renko, closeKlines(100, 100, 100, 105))
+	if len(bricks) != 1 || !bricks[0].O.Equal(decimal.NewFromInt(100)) || !bricks[0].C.Equal(decimal.NewFromInt(104)) {
+		t.Fatalf("预期一块 100→104 的砖，实际为 %v", bricks)
+	}
+
+	if _, err := NewATRRenko(0); err == nil {
+		t.Errorf("预期周期为 0 时创建失败")
+	}
+}
@@ -0,0 +1,105 @@
+package transform
+
+import (
+	"fmt"
+	"snake/internal/kline"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// Transformer 把按时间划分的K线转换为另一种K线序列，例如平均K线、砖形图、等幅K线
+//
+// 转换只处理已收盘的K线，一根K线可能产生零根或多根新K线。
+// 同一根K线产生的多根新K线开盘时间依次加 1 毫秒，保证按开盘时间推入指标时都按追加处理
+type Transformer interface {
+	// Name 返回转换名称，包含参数，例如 HA、RENKO10、RANGE5
+	Name() string
+	// Push 推入一根已收盘的K线，返回新完成的K线
+	Push(k *kline.Kline) []*kline.Kline
+	// Reset 清空已推入的K线，恢复到初始状态
+	Reset()
+}
+
+// Parse 按配置字符串创建转换，不区分大小写：
+//   - ha：平均K线（Heikin-Ashi）
+//   - renko:10：砖块大小为 10 的砖形图
+//   - renko:atr14：砖块大小为 ATR(14) 的砖形图
+//   - range:5：振幅为 5 的等幅K线
+func Parse(spec string) (Transformer, error) {
+	kind, arg, _ := strings.Cut(strings.ToLower(strings.TrimSpace(spec)), ":")
+	switch kind {
+	case "ha", "heikin_ashi":
+		return NewHeikinAshi(), nil
+	case "renko":
+		if period, ok := strings.CutPrefix(arg, "atr"); ok {
+			n, err := strconv.Atoi(period)
+			if err != nil {
+				return nil, fmt.Errorf("invalid renko atr period: %s", period)
+			}
+			return NewATRRenko(n)
+		}
+		box, err := decimal.NewFromString(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid renko box size: %s", arg)
+		}
+		return NewRenko(box)
+	case "range":
+		size, err := decimal.NewFromString(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid range bar size: %s", arg)
+		}
+		return NewRangeBars(size)
+	default:
+		return nil, fmt.Errorf("unknown kline transform: %s", spec)
+	}
+}
+
+// Apply 对一组已收盘的K线做转换，返回所有完成的新K线
+func Apply(t Transformer, klines []*kline.Kline) []*kline.Kline {
+	var result []*kline.Kline
+	for _, k := range klines {
+		result = append(result, t.Push(k)...)
+	}
+	return result
+}
+
+// clock 为新K线分配严格递增的开盘时间
+type clock struct {
+	last int64
+	used bool
+}
+
+// next 返回不早于 start 且晚于上一次分配的开盘时间
+func (c *clock) next(start int64) int64 {
+	if c.used && start <= c.last {
+		start = c.last + 1
+	}
+	c.last, c.used = start, true
+	return start
+}
+
+// flow 两根新K线之间累计的成交量等数据，计入下一根完成的新K线
+type flow struct {
+	v  decimal.Decimal
+	a  decimal.Decimal
+	n  int64
+	bv decimal.Decimal
+	ba decimal.Decimal
+}
+
+func (f *flow) add(k *kline.Kline) {
+	f.v = f.v.Add(k.V)
+	f.a = f.a.Add(k.A)
+	f.n += k.N
+	f.bv = f.bv.Add(k.BV)
+	f.ba = f.ba.Add(k.BA)
+}
+
+// drain 把累计的数据写入K线并清空
+func (f *flow) drain(k *kline.Kline) {
+	k.V, k.A, k.N, k.BV, k.BA = f.v, f.a, f.n, f.bv, f.ba
+	k.P = kline.AveragePrice(f.a, f.v)
+	*f = flow{}
+}
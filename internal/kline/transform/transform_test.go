@@ -0,0 +1,34 @@
+package transform
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		spec    string
+		name    string
+		wantErr bool
+	}{
+		{"ha", "HA", false},
+		{"Heikin_Ashi", "HA", false},
+		{"renko:10", "RENKO10", false},
+		{"RENKO:ATR14", "RENKO_ATR14", false},
+		{"range:0.5", "RANGE0.5", false},
+		{"renko", "", true},
+		{"renko:-1", "", true},
+		{"renko:atrx", "", true},
+		{"range:abc", "", true},
+		{"point_figure:1", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			transformer, err := Parse(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("预期错误为 %v，实际为 %v", tt.wantErr, err)
+			}
+			if err == nil && transformer.Name() != tt.name {
+				t.Errorf("预期名称 %s，实际为 %s", tt.name, transformer.Name())
+			}
+		})
+	}
+}
@@ -71,7 +71,7 @@ func Checker(
 				return err
 			}
 
-			updateKlineToStartTime(ctx, marketClient, logger, symbol, interval, uint64(first.OpenTs), storeTrigger)
+			updateKlineToStartTime(ctx, spotKlineFetcher(marketClient), logger, symbol, interval, uint64(first.OpenTs), storeTrigger)
 
 			first, err = repoKline.First(ctx, interval)
 			if err != nil {
@@ -0,0 +1,134 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"snake/internal/kline"
+	"snake/internal/kline/acl"
+	"snake/internal/kline/interval"
+	"snake/internal/kline/storage/mysql/models"
+	"snake/pkg/binance"
+
+	"github.com/CrazyThursdayV50/pkgo/builtin/collector"
+	"github.com/CrazyThursdayV50/pkgo/log"
+	"github.com/CrazyThursdayV50/pkgo/worker"
+)
+
+// FuturesKlineFetcher 获取合约K线或标记价格K线
+type FuturesKlineFetcher func(ctx context.Context, symbol, interval string, startTime, endTime uint64, limit int) ([]*binance.FuturesKline, error)
+
+const updateFundingRatesCount = 1000
+
+// futuresKlineFetcher 把合约K线转换为数据库模型
+func futuresKlineFetcher(fetch FuturesKlineFetcher) klineFetcher {
+	return func(ctx context.Context, symbol, interval string, startTime, endTime uint64, limit int) ([]*models.Kline, error) {
+		resp, err := fetch(ctx, symbol, interval, startTime, endTime, limit)
+		if err != nil {
+			return nil, err
+		}
+
+		return collector.Slice(resp, func(k int, v *binance.FuturesKline) (bool, *models.Kline) {
+			return true, acl.FuturesToDB(v)
+		}), nil
+	}
+}
+
+// UptodateFuturesKline 将合约K线或标记价格K线更新到 stopTimestamp，并补全更早的历史数据
+func UptodateFuturesKline(
+	ctx context.Context,
+	logger log.Logger,
+	name string,
+	symbol string,
+	interval interval.Interval,
+	repoKline kline.Repository,
+	fetch FuturesKlineFetcher,
+	storeTrigger func(*models.Kline),
+) func(uint64) {
+	fetchKlines := futuresKlineFetcher(fetch)
+	worker, trigger := worker.New(fmt.Sprintf("Uptodate%s-%s", name, interval.String()), func(stopTimestamp uint64) {
+		tryFunc(func() error {
+			last, err := repoKline.Last(ctx, interval)
+			if err != nil {
+				return err
+			}
+
+			if last == nil {
+				updateKlineToStartTime(ctx, fetchKlines, logger, symbol, interval, stopTimestamp, storeTrigger)
+				return nil
+			}
+
+			updateKlineFromStartTime(ctx, fetchKlines, logger, symbol, interval, uint64(last.OpenTs), stopTimestamp, storeTrigger)
+
+			first, err := repoKline.First(ctx, interval)
+			if err != nil {
+				return err
+			}
+
+			updateKlineToStartTime(ctx, fetchKlines, logger, symbol, interval, uint64(first.OpenTs), storeTrigger)
+			return nil
+		}, func(err error) {
+			logger.Errorf("Uptodate %s error: %v", name, err)
+		}, 3)
+	})
+
+	worker.WithContext(ctx)
+	worker.WithLogger(logger)
+	worker.WithGraceful(true)
+	worker.Run()
+	return trigger
+}
+
+// UptodateFundingRate 将资金费率历史更新到 stopTimestamp
+func UptodateFundingRate(
+	ctx context.Context,
+	logger log.Logger,
+	symbol string,
+	repoFunding kline.FundingRepository,
+	client *binance.FuturesClient,
+) func(uint64) {
+	worker, trigger := worker.New("UptodateFundingRate", func(stopTimestamp uint64) {
+		tryFunc(func() error {
+			last, err := repoFunding.LastFundingRate(ctx)
+			if err != nil {
+				return err
+			}
+
+			var startTime uint64
+			if last != nil {
+				startTime = uint64(last.FundingTs) + 1
+			}
+
+			for startTime < stopTimestamp {
+				rates, err := client.FundingRates(ctx, symbol, startTime, stopTimestamp, updateFundingRatesCount)
+				if err != nil {
+					return err
+				}
+
+				if len(rates) == 0 {
+					return nil
+				}
+
+				err = repoFunding.InsertFundingRates(ctx, collector.Slice(rates, func(_ int, v *binance.FundingRate) (bool, *models.FundingRate) {
+					return true, acl.FundingToDB(v)
+				}))
+				if err != nil {
+					return err
+				}
+
+				if len(rates) < updateFundingRatesCount {
+					return nil
+				}
+				startTime = uint64(rates[len(rates)-1].FundingTime) + 1
+			}
+			return nil
+		}, func(err error) {
+			logger.Errorf("Uptodate funding rate error: %v", err)
+		}, 3)
+	})
+
+	worker.WithContext(ctx)
+	worker.WithLogger(logger)
+	worker.WithGraceful(true)
+	worker.Run()
+	return trigger
+}
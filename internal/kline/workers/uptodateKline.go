@@ -19,9 +19,32 @@ import (
 
 const updateKlinesCount = 1000
 
+// klineFetcher 按时间范围获取K线并转换为数据库模型
+type klineFetcher func(ctx context.Context, symbol, interval string, startTime, endTime uint64, limit int) ([]*models.Kline, error)
+
+// spotKlineFetcher 获取现货K线
+func spotKlineFetcher(client *binance.MarketClient) klineFetcher {
+	return func(ctx context.Context, symbol, interval string, startTime, endTime uint64, limit int) ([]*models.Kline, error) {
+		resp, err := client.Restful.Klines().
+			StartTime(startTime).
+			EndTime(endTime).
+			Interval(interval).
+			Symbol(symbol).
+			Limit(limit).
+			Do(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return collector.Slice(resp.Unwrap(), func(k int, v klines.Kline) (bool, *models.Kline) {
+			return true, acl.ApiToDB(v)
+		}), nil
+	}
+}
+
 func updateKlineFromStartTime(
 	ctx context.Context,
-	client *binance.MarketClient,
+	fetch klineFetcher,
 	logger log.Logger,
 	symbol string,
 	interval interval.Interval,
@@ -44,23 +67,16 @@ func updateKlineFromStartTime(
 			return
 		}
 
-		resp, err := client.Restful.Klines().
-			StartTime(nextStart).
-			EndTime(endTime).
-			Interval(interval.String()).
-			Symbol(symbol).
-			Limit(updateKlinesCount).
-			Do(ctx)
+		klines, err := fetch(ctx, symbol, interval.String(), nextStart, endTime, updateKlinesCount)
 		if err != nil {
 			logger.Errorf("Failed to fetch klines: %v", err)
 			return
 		}
 
-		klines := collector.Slice(resp.Unwrap(), func(k int, v klines.Kline) (bool, *models.Kline) {
-			return true, acl.ApiToDB(v)
-		})
-
 		klines = utils.FillKlinesDB(klines, interval, int64(endTime))
+		if len(klines) == 0 {
+			return
+		}
 
 		slice.From(klines...).Iter(func(k int, v *models.Kline) (bool, error) {
 			trigger(v)
@@ -72,7 +88,7 @@ func updateKlineFromStartTime(
 
 func updateKlineToStartTime(
 	ctx context.Context,
-	client *binance.MarketClient,
+	fetch klineFetcher,
 	logger log.Logger,
 	symbol string,
 	interval interval.Interval,
@@ -87,30 +103,22 @@ func updateKlineToStartTime(
 			return
 		}
 
-		resp, err := client.Restful.Klines().
-			StartTime(nextStartTime).
-			EndTime(endTime).
-			Interval(interval.String()).
-			Symbol(symbol).
-			Limit(updateKlinesCount).
-			Do(ctx)
+		klines, err := fetch(ctx, symbol, interval.String(), nextStartTime, endTime, updateKlinesCount)
 		if err != nil {
 			logger.Errorf("Failed to fetch klines: %v", err)
 			return
 		}
 
-		klinesData := resp.Unwrap()
-		if len(klinesData) == 0 {
+		if len(klines) == 0 {
 			return
 		}
 
-		slice.From(klinesData...).Iter(func(k int, v klines.Kline) (bool, error) {
-			model := acl.ApiToDB(v)
-			trigger(model)
+		slice.From(klines...).Iter(func(k int, v *models.Kline) (bool, error) {
+			trigger(v)
 			return true, nil
 		})
 
-		startTime = uint64(klinesData[0].OpenTs)
+		startTime = uint64(klines[0].OpenTs)
 	}
 }
 
@@ -124,6 +132,7 @@ func UptodateKline(
 	storeTrigger func(*models.Kline),
 	checkTrigger func(uint64),
 ) func(uint64) {
+	fetch := spotKlineFetcher(marketClient)
 	worker, trigger := worker.New(fmt.Sprintf("UptodateKline-%s", interval.String()), func(stopTimestamp uint64) {
 		tryFunc(func() error {
 			last, err := repoKline.Last(ctx, interval)
@@ -132,19 +141,19 @@ func UptodateKline(
 			}
 
 			if last != nil {
-				updateKlineFromStartTime(ctx, marketClient, logger, symbol, interval, uint64(last.OpenTs), stopTimestamp, storeTrigger)
+				updateKlineFromStartTime(ctx, fetch, logger, symbol, interval, uint64(last.OpenTs), stopTimestamp, storeTrigger)
 
 				first, err := repoKline.First(ctx, interval)
 				if err != nil {
 					return err
 				}
 
-				updateKlineToStartTime(ctx, marketClient, logger, symbol, interval, uint64(first.OpenTs), storeTrigger)
+				updateKlineToStartTime(ctx, fetch, logger, symbol, interval, uint64(first.OpenTs), storeTrigger)
 				checkTrigger(uint64(stopTimestamp))
 				return nil
 			}
 
-			updateKlineToStartTime(ctx, marketClient, logger, symbol, interval, uint64(stopTimestamp), storeTrigger)
+			updateKlineToStartTime(ctx, fetch, logger, symbol, interval, uint64(stopTimestamp), storeTrigger)
 			checkTrigger(uint64(stopTimestamp))
 			return nil
 		}, func(err error) {
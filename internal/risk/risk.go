@@ -0,0 +1,269 @@
+package risk
+
+import (
+	"fmt"
+	"snake/internal/types"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Config 账户级风控规则，数值为零表示不启用该规则
+//
+// 风控只限制增加风险的开仓部分，减仓、平仓的部分始终放行
+type Config struct {
+	// 单个方向最大持仓名义价值（USDT）
+	MaxPositionNotional decimal.Decimal `json:"max_position_notional"`
+	// 单笔交易最大风险占净值的比例，例如 0.02 表示 2%
+	MaxTradeRisk decimal.Decimal `json:"max_trade_risk"`
+	// 计算单笔风险时假设的止损比例，例如 0.05 表示 5%；为零时按全部名义价值计算风险
+	StopLossPercent decimal.Decimal `json:"stop_loss_percent"`
+	// 单日最大亏损占当日初始净值的比例，触发后当日（UTC）不再开仓
+	DailyLossLimit decimal.Decimal `json:"daily_loss_limit"`
+	// 最大回撤比例，触发后熔断，调用 Reset 之前不再开仓
+	MaxDrawdown decimal.Decimal `json:"max_drawdown"`
+	// 每小时最多下单次数
+	MaxOrdersPerHour int `json:"max_orders_per_hour"`
+}
+
+// Action 风控处理结果
+type Action int
+
+const (
+	// ActionAllow 放行
+	ActionAllow Action = iota
+	// ActionResize 缩减数量后放行
+	ActionResize
+	// ActionReject 拒绝
+	ActionReject
+)
+
+var actionNames = map[Action]string{
+	ActionAllow:  "ALLOW",
+	ActionResize: "RESIZE",
+	ActionReject: "REJECT",
+}
+
+func (a Action) String() string { return actionNames[a] }
+
+// 风控规则名称
+const (
+	RuleMaxPositionNotional = "max_position_notional"
+	RuleMaxTradeRisk        = "max_trade_risk"
+	RuleDailyLossLimit      = "daily_loss_limit"
+	RuleMaxDrawdown         = "max_drawdown"
+	RuleMaxOrdersPerHour    = "max_orders_per_hour"
+)
+
+// Order 待检查的交易
+type Order struct {
+	// 买卖方向
+	Side types.SignalType
+	// 交易数量（base 数量）
+	Volume decimal.Decimal
+	// 交易价格
+	Price decimal.Decimal
+	// 交易前的持仓数量，正数为多头，负数为空头
+	Position decimal.Decimal
+	// 交易前的账户净值
+	Equity decimal.Decimal
+}
+
+// Decision 风控检查结果
+type Decision struct {
+	// 检查时间
+	Time time.Time
+	// 处理结果
+	Action Action
+	// 触发的规则，放行时为空
+	Rule string
+	// 原因说明
+	Reason string
+	// 请求的交易数量
+	Requested decimal.Decimal
+	// 允许的交易数量
+	Volume decimal.Decimal
+}
+
+func (d *Decision) String() string {
+	return fmt.Sprintf("%s %s: %s (requested %s, allowed %s)", d.Action, d.Rule, d.Reason, d.Requested, d.Volume)
+}
+
+// Manager 风控管理器，同一账户的所有交易共用一个实例
+// 回测、模拟盘和实盘都通过 Strategy.SetRisk 接入，在策略执行买卖之前检查
+type Manager struct {
+	cfg *Config
+
+	// 最近一次盯市的时间和净值
+	now    time.Time
+	equity decimal.Decimal
+	// 最高净值
+	peak decimal.Decimal
+	// 当日（UTC）开始时间和初始净值
+	day      time.Time
+	dayStart decimal.Decimal
+	// 是否已触发回撤熔断
+	halted bool
+	// 最近一小时内放行的下单时间
+	orders []time.Time
+	// 缩减和拒绝记录
+	decisions []*Decision
+}
+
+// New 创建风控管理器
+func New(cfg *Config) *Manager {
+	return &Manager{cfg: cfg}
+}
+
+// Mark 按最新时间和净值更新风控状态，回测中使用K线时间
+func (m *Manager) Mark(now time.Time, equity decimal.Decimal) {
+	m.now = now
+	m.equity = equity
+
+	if equity.GreaterThan(m.peak) {
+		m.peak = equity
+	}
+
+	day := now.UTC().Truncate(24 * time.Hour)
+	if !day.Equal(m.day) {
+		m.day = day
+		m.dayStart = equity
+	}
+
+	if m.cfg.MaxDrawdown.IsPositive() && m.peak.IsPositive() &&
+		m.peak.Sub(equity).Div(m.peak).GreaterThanOrEqual(m.cfg.MaxDrawdown) {
+		m.halted = true
+	}
+}
+
+// Transfer 记录账户的资金划转，amount 为正表示转入，为负表示转出
+// 最高净值和当日初始净值按划转金额平移，划转不计入回撤和当日亏损
+func (m *Manager) Transfer(amount decimal.Decimal) {
+	if m.now.IsZero() {
+		// 尚未盯市，第一次 Mark 时以划转后的净值为起点
+		return
+	}
+	m.equity = m.equity.Add(amount)
+	m.peak = m.peak.Add(amount)
+	m.dayStart = m.dayStart.Add(amount)
+}
+
+// Check 检查交易，返回允许的数量和原因；减仓部分不受限制
+// 交易执行后需要调用 Record 计入下单次数
+func (m *Manager) Check(order *Order) *Decision {
+	now := m.now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	direction := decimal.NewFromInt(1)
+	if order.Side.IsSell() {
+		direction = direction.Neg()
+	}
+
+	var reducing decimal.Decimal
+	if !order.Position.IsZero() && order.Position.Sign() != direction.Sign() {
+		reducing = decimal.Min(order.Volume, order.Position.Abs())
+	}
+	opening := order.Volume.Sub(reducing)
+
+	decision := &Decision{Time: now, Action: ActionAllow, Requested: order.Volume, Volume: order.Volume}
+	if opening.IsPositive() {
+		// 减仓后剩余的同方向持仓
+		remaining := order.Position.Add(reducing.Mul(direction)).Abs()
+		allowed, rule, reason := m.checkOpening(now, order, opening, remaining)
+		if allowed.LessThan(opening) {
+			decision.Rule = rule
+			decision.Reason = reason
+			decision.Volume = reducing.Add(allowed)
+			decision.Action = ActionResize
+			if !decision.Volume.IsPositive() {
+				decision.Action = ActionReject
+			}
+		}
+	}
+
+	if decision.Action != ActionAllow {
+		m.decisions = append(m.decisions, decision)
+	}
+	return decision
+}
+
+// Record 记录一笔已成交的订单，计入每小时下单次数
+// Check 只做检查，余额或保证金不足导致交易未执行时不应计数
+func (m *Manager) Record() {
+	now := m.now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	m.orders = append(m.recentOrders(now), now)
+}
+
+// checkOpening 依次检查开仓部分，返回允许的开仓数量以及最终限制数量的规则
+func (m *Manager) checkOpening(now time.Time, order *Order, opening, remaining decimal.Decimal) (decimal.Decimal, string, string) {
+	if m.halted {
+		return decimal.Zero, RuleMaxDrawdown, fmt.Sprintf("回撤达到 %s，已熔断", m.cfg.MaxDrawdown)
+	}
+
+	if m.cfg.DailyLossLimit.IsPositive() && m.dayStart.IsPositive() &&
+		m.dayStart.Sub(m.equity).Div(m.dayStart).GreaterThanOrEqual(m.cfg.DailyLossLimit) {
+		return decimal.Zero, RuleDailyLossLimit, fmt.Sprintf("当日亏损达到 %s", m.cfg.DailyLossLimit)
+	}
+
+	if m.cfg.MaxOrdersPerHour > 0 && len(m.recentOrders(now)) >= m.cfg.MaxOrdersPerHour {
+		return decimal.Zero, RuleMaxOrdersPerHour, fmt.Sprintf("一小时内已下单 %d 次", m.cfg.MaxOrdersPerHour)
+	}
+
+	var allowed = opening
+	var rule, reason string
+	if m.cfg.MaxTradeRisk.IsPositive() && order.Price.IsPositive() {
+		riskPerUnit := order.Price
+		if m.cfg.StopLossPercent.IsPositive() {
+			riskPerUnit = order.Price.Mul(m.cfg.StopLossPercent)
+		}
+
+		maxVolume := decimal.Max(order.Equity, decimal.Zero).Mul(m.cfg.MaxTradeRisk).Div(riskPerUnit)
+		if maxVolume.LessThan(allowed) {
+			allowed = maxVolume
+			rule = RuleMaxTradeRisk
+			reason = fmt.Sprintf("单笔风险超过净值的 %s", m.cfg.MaxTradeRisk)
+		}
+	}
+
+	if m.cfg.MaxPositionNotional.IsPositive() && order.Price.IsPositive() {
+		capacity := decimal.Max(m.cfg.MaxPositionNotional.Div(order.Price).Sub(remaining), decimal.Zero)
+		if capacity.LessThan(allowed) {
+			allowed = capacity
+			rule = RuleMaxPositionNotional
+			reason = fmt.Sprintf("持仓名义价值超过 %s", m.cfg.MaxPositionNotional)
+		}
+	}
+
+	return allowed, rule, reason
+}
+
+// recentOrders 返回最近一小时内的下单时间
+func (m *Manager) recentOrders(now time.Time) []time.Time {
+	var i int
+	for i < len(m.orders) && now.Sub(m.orders[i]) >= time.Hour {
+		i++
+	}
+	m.orders = m.orders[i:]
+	return m.orders
+}
+
+// Decisions 返回所有缩减和拒绝记录
+func (m *Manager) Decisions() []*Decision {
+	return m.decisions
+}
+
+// Halted 是否已触发回撤熔断
+func (m *Manager) Halted() bool {
+	return m.halted
+}
+
+// Reset 解除回撤熔断，并以当前净值作为新的最高净值
+func (m *Manager) Reset() {
+	m.halted = false
+	m.peak = m.equity
+}
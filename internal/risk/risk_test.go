@@ -0,0 +1,188 @@
+package risk
+
+import (
+	"snake/internal/types"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func newOrder(side types.SignalType, volume, position int64) *Order {
+	return &Order{
+		Side:     side,
+		Volume:   decimal.NewFromInt(volume),
+		Price:    decimal.NewFromInt(100),
+		Position: decimal.NewFromInt(position),
+		Equity:   decimal.NewFromInt(10000),
+	}
+}
+
+func TestCheck(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		cfg    *Config
+		order  *Order
+		action Action
+		rule   string
+		volume int64
+	}{
+		{
+			name:   "未触发任何规则时放行",
+			cfg:    &Config{MaxPositionNotional: decimal.NewFromInt(100000)},
+			order:  newOrder(types.SignalTypeBuy, 10, 0),
+			action: ActionAllow,
+			volume: 10,
+		},
+		{
+			name:   "超过最大持仓名义价值时缩减",
+			cfg:    &Config{MaxPositionNotional: decimal.NewFromInt(5000)},
+			order:  newOrder(types.SignalTypeBuy, 40, 20),
+			action: ActionResize,
+			rule:   RuleMaxPositionNotional,
+			volume: 30,
+		},
+		{
+			name:   "持仓已满时拒绝开仓",
+			cfg:    &Config{MaxPositionNotional: decimal.NewFromInt(5000)},
+			order:  newOrder(types.SignalTypeBuy, 10, 50),
+			action: ActionReject,
+			rule:   RuleMaxPositionNotional,
+			volume: 0,
+		},
+		{
+			name:   "减仓不受限制",
+			cfg:    &Config{MaxPositionNotional: decimal.NewFromInt(1000)},
+			order:  newOrder(types.SignalTypeSell, 50, 50),
+			action: ActionAllow,
+			volume: 50,
+		},
+		{
+			name:   "反手时只限制开仓部分",
+			cfg:    &Config{MaxPositionNotional: decimal.NewFromInt(1000)},
+			order:  newOrder(types.SignalTypeSell, 80, 50),
+			action: ActionResize,
+			rule:   RuleMaxPositionNotional,
+			volume: 60,
+		},
+		{
+			name:   "单笔风险按止损比例计算",
+			cfg:    &Config{MaxTradeRisk: decimal.NewFromFloat(0.01), StopLossPercent: decimal.NewFromFloat(0.05)},
+			order:  newOrder(types.SignalTypeBuy, 30, 0),
+			action: ActionResize,
+			rule:   RuleMaxTradeRisk,
+			volume: 20,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New(tt.cfg)
+			m.Mark(start, decimal.NewFromInt(10000))
+
+			decision := m.Check(tt.order)
+			if decision.Action != tt.action {
+				t.Fatalf("预期 %s，实际为 %s", tt.action, decision.Action)
+			}
+			if decision.Rule != tt.rule {
+				t.Errorf("预期规则 %q，实际为 %q", tt.rule, decision.Rule)
+			}
+			if !decision.Volume.Equal(decimal.NewFromInt(tt.volume)) {
+				t.Errorf("预期数量 %d，实际为 %s", tt.volume, decision.Volume)
+			}
+		})
+	}
+}
+
+func TestDailyLossLimit(t *testing.T) {
+	m := New(&Config{DailyLossLimit: decimal.NewFromFloat(0.05)})
+	day := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	m.Mark(day, decimal.NewFromInt(10000))
+	m.Mark(day.Add(time.Hour), decimal.NewFromInt(9400))
+	if d := m.Check(newOrder(types.SignalTypeBuy, 1, 0)); d.Action != ActionReject || d.Rule != RuleDailyLossLimit {
+		t.Fatalf("预期当日亏损超限后拒绝开仓，实际为 %s", d)
+	}
+
+	// 第二天以当日初始净值重新计算
+	m.Mark(day.Add(24*time.Hour), decimal.NewFromInt(9400))
+	if d := m.Check(newOrder(types.SignalTypeBuy, 1, 0)); d.Action != ActionAllow {
+		t.Errorf("预期新的一天放行，实际为 %s", d)
+	}
+}
+
+func TestMaxDrawdown(t *testing.T) {
+	m := New(&Config{MaxDrawdown: decimal.NewFromFloat(0.2)})
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	m.Mark(now, decimal.NewFromInt(10000))
+	m.Mark(now.Add(time.Hour), decimal.NewFromInt(12000))
+	m.Mark(now.Add(2*time.Hour), decimal.NewFromInt(9500))
+	if !m.Halted() {
+		t.Fatalf("预期回撤超过 20%% 后熔断")
+	}
+
+	// 净值回升后熔断仍然有效
+	m.Mark(now.Add(3*time.Hour), decimal.NewFromInt(11000))
+	if d := m.Check(newOrder(types.SignalTypeBuy, 1, 0)); d.Action != ActionReject || d.Rule != RuleMaxDrawdown {
+		t.Fatalf("预期熔断后拒绝开仓，实际为 %s", d)
+	}
+	if d := m.Check(newOrder(types.SignalTypeSell, 1, 1)); d.Action != ActionAllow {
+		t.Errorf("预期熔断后仍允许平仓，实际为 %s", d)
+	}
+
+	m.Reset()
+	if d := m.Check(newOrder(types.SignalTypeBuy, 1, 0)); d.Action != ActionAllow {
+		t.Errorf("预期解除熔断后放行，实际为 %s", d)
+	}
+}
+
+func TestTransfer(t *testing.T) {
+	m := New(&Config{MaxDrawdown: decimal.NewFromFloat(0.2), DailyLossLimit: decimal.NewFromFloat(0.05)})
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	m.Mark(now, decimal.NewFromInt(1000))
+	m.Transfer(decimal.NewFromInt(-300))
+	m.Mark(now.Add(time.Hour), decimal.NewFromInt(700))
+	if m.Halted() {
+		t.Fatalf("预期转出资金不计入回撤")
+	}
+	if d := m.Check(newOrder(types.SignalTypeBuy, 1, 0)); d.Action != ActionAllow {
+		t.Fatalf("预期转出资金不计入当日亏损，实际为 %s", d)
+	}
+
+	// 转出后的亏损按平移后的最高净值计算
+	m.Mark(now.Add(2*time.Hour), decimal.NewFromInt(560))
+	if !m.Halted() {
+		t.Errorf("预期划转后回撤超过 20%% 时熔断")
+	}
+}
+
+func TestMaxOrdersPerHour(t *testing.T) {
+	m := New(&Config{MaxOrdersPerHour: 2})
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	m.Mark(now, decimal.NewFromInt(10000))
+	m.Check(newOrder(types.SignalTypeBuy, 1, 0))
+	m.Record()
+	m.Mark(now.Add(10*time.Minute), decimal.NewFromInt(10000))
+	m.Check(newOrder(types.SignalTypeBuy, 1, 1))
+	m.Record()
+
+	m.Mark(now.Add(30*time.Minute), decimal.NewFromInt(10000))
+	if d := m.Check(newOrder(types.SignalTypeBuy, 1, 2)); d.Action != ActionReject || d.Rule != RuleMaxOrdersPerHour {
+		t.Fatalf("预期超过下单次数后拒绝，实际为 %s", d)
+	}
+
+	// 一小时后第一笔订单移出统计窗口
+	m.Mark(now.Add(time.Hour), decimal.NewFromInt(10000))
+	if d := m.Check(newOrder(types.SignalTypeBuy, 1, 2)); d.Action != ActionAllow {
+		t.Errorf("预期一小时后放行，实际为 %s", d)
+	}
+
+	if len(m.Decisions()) != 1 {
+		t.Errorf("预期记录 1 次拒绝，实际为 %d", len(m.Decisions()))
+	}
+}
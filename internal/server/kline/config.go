@@ -13,4 +13,6 @@ type Config struct {
 	Mysql   *gorm.Config
 	Binance *binance.Config
 	Service *service.Config
+	// 指标订阅允许的跨域来源，为空时只允许同源，"*" 允许所有来源
+	AllowedOrigins []string
 }
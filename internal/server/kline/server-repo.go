@@ -7,8 +7,19 @@ import (
 
 type Repositories struct {
 	repoKline kline.Repository
+
+	// U 本位永续合约数据，只有开启合约采集时才会初始化
+	repoFuturesKline   kline.Repository
+	repoMarkPriceKline kline.Repository
+	repoFundingRate    kline.FundingRepository
 }
 
 func (s *Server) initRepositories() {
 	s.repos.repoKline = repository.New(s.clients.db)
+
+	if s.cfg.Binance.Futures {
+		s.repos.repoFuturesKline = repository.NewFutures(s.clients.db)
+		s.repos.repoMarkPriceKline = repository.NewMarkPrice(s.clients.db)
+		s.repos.repoFundingRate = repository.NewFutures(s.clients.db)
+	}
 }
@@ -26,7 +26,8 @@ func (s *Server) initServices() {
 		server.WithTracer(s.tracer.NewTracer("websocket")),
 		server.WithHandler(HandleKlineMessage),
 	)
-	s.Services.kline = kline.NewService(s.logger, s.Wsserver, s.repos.repoKline)
+	s.Services.kline = kline.NewService(s.logger, s.Wsserver, s.repos.repoKline).
+		WithAllowedOrigins(s.cfg.AllowedOrigins)
 }
 
 func (s *Services) Run(ctx context.Context, cfg *service.Config, wg *sync.WaitGroup) {
@@ -42,7 +43,11 @@ func (s *Services) Run(ctx context.Context, cfg *service.Config, wg *sync.WaitGr
 	root := handler.Group("/")
 	root.GET("ping", s.kline.Ping)
 	root.GET("kline", s.kline.GetKlines)
+	root.GET("pivot", s.kline.GetPivot)
+	root.GET("zones", s.kline.GetZones)
+	root.GET("indicator", s.kline.GetIndicator)
 	root.GET("ws", s.kline.Subscribe)
+	root.GET("ws/indicator", s.kline.SubscribeIndicator)
 
 	srv := http.Server{Handler: handler}
 
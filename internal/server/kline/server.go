@@ -12,6 +12,7 @@ import (
 	"snake/internal/kline/workers"
 	"snake/pkg/binance"
 	"sync"
+	"time"
 
 	"github.com/CrazyThursdayV50/pkgo/goo"
 	"github.com/CrazyThursdayV50/pkgo/json"
@@ -27,8 +28,9 @@ import (
 )
 
 type Clients struct {
-	db            *gorm.DB
-	binanceMarket *binance.MarketClient
+	db             *gorm.DB
+	binanceMarket  *binance.MarketClient
+	binanceFutures *binance.FuturesClient
 }
 
 type Workers struct {
@@ -54,6 +56,9 @@ type Server struct {
 
 	min1Uptodater func(uint64)
 	mint1Storer   func(*models.Kline)
+
+	// U 本位永续合约的K线、标记价格K线和资金费率更新器
+	futuresUptodaters []func(uint64)
 }
 
 func New(cfg *Config) *Server {
@@ -81,6 +86,9 @@ func (s *Server) initClients() {
 	s.logger = logger
 	s.clients.db = gorm.NewDB(logger, tracer.NewTracer("mysql"), s.cfg.Mysql)
 	s.clients.binanceMarket = binance.New(s.cfg.Binance)
+	if s.cfg.Binance.Futures {
+		s.clients.binanceFutures = binance.NewFutures(s.cfg.Binance)
+	}
 }
 
 func (s *Server) initWorkers(ctx context.Context) {
@@ -113,6 +121,10 @@ func (s *Server) initWorkers(ctx context.Context) {
 		}
 	}
 
+	if s.cfg.Binance.Futures {
+		s.initFuturesWorkers(ctx)
+	}
+
 	// goo.Goo(func() {
 	// 	s.clients.binanceMarket.Stream.WsCombinedKlineServe(symbolIntervalMap, func(event *binance_connector.WsKlineEvent) {
 	// 		s.logger.Infof("event: %+v", event)
@@ -128,6 +140,44 @@ func (s *Server) initWorkers(ctx context.Context) {
 	// })
 }
 
+// initFuturesWorkers 初始化 U 本位永续合约数据的采集任务，数据保存在单独的表中
+func (s *Server) initFuturesWorkers(ctx context.Context) {
+	symbol := s.cfg.Binance.Symbol
+	futures := s.clients.binanceFutures
+
+	for _, in := range interval.All() {
+		futuresStorer := workers.StoreKline(ctx, s.logger, in, s.repos.repoFuturesKline)
+		s.futuresUptodaters = append(s.futuresUptodaters,
+			workers.UptodateFuturesKline(ctx, s.logger, "FuturesKline", symbol, in, s.repos.repoFuturesKline, futures.Klines, futuresStorer))
+
+		markPriceStorer := workers.StoreKline(ctx, s.logger, in, s.repos.repoMarkPriceKline)
+		s.futuresUptodaters = append(s.futuresUptodaters,
+			workers.UptodateFuturesKline(ctx, s.logger, "MarkPriceKline", symbol, in, s.repos.repoMarkPriceKline, futures.MarkPriceKlines, markPriceStorer))
+	}
+
+	s.futuresUptodaters = append(s.futuresUptodaters,
+		workers.UptodateFundingRate(ctx, s.logger, symbol, s.repos.repoFundingRate, futures))
+}
+
+// runFuturesUptodaters 每分钟将合约数据更新到当前时间
+func (s *Server) runFuturesUptodaters(ctx context.Context) {
+	var ticker = time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		now := uint64(time.Now().UnixMilli())
+		for _, uptodater := range s.futuresUptodaters {
+			uptodater(now)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
 func (s *Server) Run() {
 	s.initClients()
 	s.initRepositories()
@@ -135,8 +185,14 @@ func (s *Server) Run() {
 
 	ctx, cancel := context.WithCancel(context.Background())
 	migrate.AutoMigrate(ctx, s.clients.db)
+	if s.cfg.Binance.Futures {
+		migrate.AutoMigrateFutures(ctx, s.clients.db)
+	}
 
 	s.initWorkers(ctx)
+	if len(s.futuresUptodaters) > 0 {
+		goo.Go(func() { s.runFuturesUptodaters(ctx) })
+	}
 
 	handler := handler.NewWsKline(s.min1Uptodater, s.mint1Storer)
 
@@ -150,6 +206,7 @@ func (s *Server) Run() {
 			_, kline := acl.Ws2Service(0, event)
 			data, _ := kline.MarshalBinary()
 			s.Wsserver.Broadcast(ctx, websocket.TextMessage, data)
+			s.Services.kline.Publish(kline)
 		}, func(err error) {
 			s.logger.Error("get kline error: %v", err)
 		})
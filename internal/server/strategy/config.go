@@ -1,9 +1,11 @@
 package strategy
 
 import (
+	"snake/internal/risk"
 	"snake/internal/service"
 	"snake/internal/strategy/clients"
 	"snake/internal/strategy/repository"
+	"snake/internal/strategy/strategies/wasm"
 
 	defaultlogger "github.com/CrazyThursdayV50/pkgo/log/default"
 )
@@ -13,4 +15,8 @@ type Config struct {
 	Repository *repository.Config
 	Clients    *clients.Config
 	Service    *service.Config
+	// 账户级风控规则，为空时不做风控
+	Risk *risk.Config
+	// 策略插件目录和资源限制，为空时不允许加载插件
+	Plugins *wasm.Config
 }
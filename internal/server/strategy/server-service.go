@@ -20,7 +20,9 @@ type Services struct {
 }
 
 func (s *Server) initServices(ctx context.Context) {
-	s.services.strategy = strategy.NewService(ctx, s.logger, s.repos.klineRepo)
+	s.services.strategy = strategy.NewService(ctx, s.logger, s.repos.klineRepo).
+		WithRisk(s.cfg.Risk).
+		WithPlugins(s.cfg.Plugins)
 }
 
 func (s *Services) Run(ctx context.Context, cfg *service.Config, wg *sync.WaitGroup) {
@@ -0,0 +1,112 @@
+package kline
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"snake/internal/indicates/catalog"
+	"snake/internal/kline"
+	"snake/internal/kline/acl"
+	"snake/internal/kline/interval"
+	"snake/internal/kline/storage/mysql/models"
+	"time"
+
+	"github.com/CrazyThursdayV50/pkgo/builtin/collector"
+	"github.com/gin-gonic/gin"
+)
+
+type GetIndicatorParams struct {
+	// Name 指标名称，见 catalog.Names，例如 rsi、macd、bollinger
+	Name     string `form:"name" binding:"required"`
+	Interval string `form:"interval"`
+	From     int64  `form:"from"`
+	To       int64  `form:"to"`
+}
+
+// indicatorQueryKeys 不属于指标参数的查询参数，其余查询参数都作为指标参数，例如 period=14&ma=ema
+var indicatorQueryKeys = map[string]bool{"name": true, "interval": true, "from": true, "to": true}
+
+// indicatorParams 从查询参数中取出指标参数
+func indicatorParams(ctx *gin.Context) catalog.Params {
+	params := make(catalog.Params)
+	for key, values := range ctx.Request.URL.Query() {
+		if !indicatorQueryKeys[key] && len(values) > 0 {
+			params[key] = values[0]
+		}
+	}
+	return params
+}
+
+// parseInterval 解析周期，默认为 1 分钟
+func parseInterval(s string) (interval.Interval, error) {
+	if s == "" {
+		return interval.Min1(), nil
+	}
+	return interval.Parse(s)
+}
+
+// listKlines 获取开盘时间在 [from, to] 内的K线
+//
+// 仓库在第一根K线不是 from 时会在前面补一根更早的K线（没有时为空记录），计算指标时需要去掉
+func (s *Service) listKlines(ctx context.Context, iv interval.Interval, from, to int64) ([]*kline.Kline, error) {
+	klines, err := s.repoKline.List(ctx, iv, from, to)
+	if err != nil {
+		return nil, err
+	}
+	klines = slices.DeleteFunc(klines, func(k *models.Kline) bool {
+		return k.CloseTs == 0 || k.OpenTs < from || k.OpenTs > to
+	})
+	return collector.Slice(klines, acl.DB2Service), nil
+}
+
+type GetIndicatorData struct {
+	// Name 指标名称，包含参数，例如 RSI14
+	Name     string
+	Interval interval.Interval
+	// WarmUp 指标就绪需要的K线数量，from 之前的这些K线只用于预热，不返回取值
+	WarmUp int
+	// Points 与 [from, to] 内的K线一一对应，按开盘时间升序排序
+	Points []catalog.Point
+}
+
+type GetIndicatorResponse = Response[GetIndicatorData]
+
+func (s *Service) GetIndicator(ctx *gin.Context) {
+	var params GetIndicatorParams
+	err := ctx.ShouldBind(&params)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, failResponse[GetIndicatorData](err.Error(), "invalid params"))
+		return
+	}
+
+	iv, err := parseInterval(params.Interval)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, failResponse[GetIndicatorData](err.Error(), "invalid interval"))
+		return
+	}
+	indicator, err := catalog.New(params.Name, indicatorParams(ctx))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, failResponse[GetIndicatorData](err.Error(), "invalid indicator"))
+		return
+	}
+
+	// 多取 WarmUp 根K线用于预热，月线按 30 天估算，多取的K线不会返回
+	from := params.From
+	if from > 0 {
+		from = max(0, from-int64(indicator.WarmUp())*iv.Duration().Milliseconds())
+	}
+	to := params.To
+	if to == 0 {
+		to = time.Now().UnixMilli()
+	}
+	klines, err := s.listKlines(ctx, iv, from, to)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, failResponse[GetIndicatorData](err.Error(), "list kline failed"))
+		return
+	}
+
+	var data = GetIndicatorData{Name: indicator.Name(), Interval: iv, WarmUp: indicator.WarmUp()}
+	data.Points = catalog.Compute(indicator, klines, params.From)
+
+	ctx.JSON(http.StatusOK, successResponse[GetIndicatorData](&data))
+}
@@ -5,6 +5,7 @@ import (
 	"snake/internal/kline"
 	"snake/internal/kline/acl"
 	"snake/internal/kline/interval"
+	"snake/internal/kline/transform"
 
 	"github.com/CrazyThursdayV50/pkgo/builtin/collector"
 	"github.com/gin-gonic/gin"
@@ -14,6 +15,8 @@ type GetKlinesParams struct {
 	Interval string `form:"interval"`
 	From     int64  `form:"from"`
 	To       int64  `form:"to"`
+	// Transform 可选的K线转换，例如 ha、renko:10、renko:atr14、range:5，见 transform.Parse
+	Transform string `form:"transform"`
 }
 
 type GetKlineData struct {
@@ -30,6 +33,15 @@ func (s *Service) GetKlines(ctx *gin.Context) {
 		return
 	}
 
+	var transformer transform.Transformer
+	if params.Transform != "" {
+		transformer, err = transform.Parse(params.Transform)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, failResponse[GetKlineData](err.Error(), "invalid transform"))
+			return
+		}
+	}
+
 	klines, err := s.repoKline.List(ctx, interval.Interval(params.Interval), params.From, params.To)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, failResponse[GetKlineData](err.Error(), "list kline failed"))
@@ -37,6 +49,9 @@ func (s *Service) GetKlines(ctx *gin.Context) {
 	}
 
 	klineList := collector.Slice(klines, acl.DB2Service)
+	if transformer != nil {
+		klineList = transform.Apply(transformer, klineList)
+	}
 	var data GetKlineData
 	data.List = klineList
 
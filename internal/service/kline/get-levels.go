@@ -0,0 +1,157 @@
+package kline
+
+import (
+	"errors"
+	"net/http"
+	"snake/internal/indicates/pivot"
+	"snake/internal/kline"
+	"snake/internal/kline/acl"
+	"snake/internal/kline/interval"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+type GetPivotParams struct {
+	// Interval 计算枢轴点的高周期，默认为日线
+	Interval string `form:"interval"`
+	// Method classic、fibonacci、camarilla、woodie，默认为 classic
+	Method string `form:"method"`
+	// Time 查询时间（毫秒），使用其所在周期的上一根已收盘K线，默认为当前时间
+	Time int64 `form:"time"`
+}
+
+type GetPivotData struct {
+	Interval interval.Interval
+	Method   pivot.Method
+	// Bar 计算使用的上一根已收盘K线
+	Bar    *kline.Kline
+	Levels pivot.Levels
+}
+
+type GetPivotResponse = Response[GetPivotData]
+
+var errPreviousKlineNotFound = errors.New("previous kline not found")
+
+func (s *Service) GetPivot(ctx *gin.Context) {
+	var params GetPivotParams
+	err := ctx.ShouldBind(&params)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, failResponse[GetPivotData](err.Error(), "invalid params"))
+		return
+	}
+
+	iv := interval.Day1()
+	if params.Interval != "" {
+		iv, err = interval.Parse(params.Interval)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, failResponse[GetPivotData](err.Error(), "invalid interval"))
+			return
+		}
+	}
+	method, err := pivot.ParseMethod(params.Method)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, failResponse[GetPivotData](err.Error(), "invalid method"))
+		return
+	}
+	at := params.Time
+	if at == 0 {
+		at = time.Now().UnixMilli()
+	}
+
+	// 上一根已收盘K线的开盘时间
+	start := iv.Truncate(iv.Truncate(at) - 1)
+	klines, err := s.repoKline.List(ctx, iv, start, start)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, failResponse[GetPivotData](err.Error(), "list kline failed"))
+		return
+	}
+
+	var data = GetPivotData{Interval: iv, Method: method}
+	for _, k := range klines {
+		if k.OpenTs == start {
+			_, data.Bar = acl.DB2Service(0, k)
+		}
+	}
+	if data.Bar == nil {
+		ctx.JSON(http.StatusNotFound, failResponse[GetPivotData](errPreviousKlineNotFound.Error(), "kline not found"))
+		return
+	}
+	data.Levels = pivot.Calculate(method, data.Bar)
+
+	ctx.JSON(http.StatusOK, successResponse[GetPivotData](&data))
+}
+
+type GetZonesParams struct {
+	Interval string `form:"interval"`
+	From     int64  `form:"from"`
+	To       int64  `form:"to"`
+	// Strength 摆动点左右各需要的K线数量，默认为 3
+	Strength int `form:"strength"`
+	// Tolerance 并入同一个区的价格差比例，默认为 0.005
+	Tolerance string `form:"tolerance"`
+	// MaxZones 最多保留的区数量，默认为 10
+	MaxZones int `form:"max_zones"`
+}
+
+type GetZonesData struct {
+	// Close 最后一根K线的收盘价，支撑和阻力相对于该价格
+	Close decimal.Decimal
+	// Zones 按价格从低到高排序
+	Zones      []pivot.Zone
+	Support    *pivot.Zone
+	Resistance *pivot.Zone
+}
+
+type GetZonesResponse = Response[GetZonesData]
+
+func (s *Service) GetZones(ctx *gin.Context) {
+	var params = GetZonesParams{Strength: 3, Tolerance: "0.005", MaxZones: 10}
+	err := ctx.ShouldBind(&params)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, failResponse[GetZonesData](err.Error(), "invalid params"))
+		return
+	}
+
+	tolerance, err := decimal.NewFromString(params.Tolerance)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, failResponse[GetZonesData](err.Error(), "invalid tolerance"))
+		return
+	}
+	stream, err := pivot.NewSwingStreamWithParams(params.Strength, tolerance, params.MaxZones)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, failResponse[GetZonesData](err.Error(), "invalid params"))
+		return
+	}
+
+	iv, err := parseInterval(params.Interval)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, failResponse[GetZonesData](err.Error(), "invalid interval"))
+		return
+	}
+	to := params.To
+	if to == 0 {
+		to = time.Now().UnixMilli()
+	}
+	klines, err := s.listKlines(ctx, iv, params.From, to)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, failResponse[GetZonesData](err.Error(), "list kline failed"))
+		return
+	}
+
+	var data GetZonesData
+	for _, k := range klines {
+		stream.Update(k)
+		data.Close = k.C
+	}
+	data.Zones = stream.Zones()
+	if support, ok := stream.Support(data.Close); ok {
+		data.Support = &support
+	}
+	if resistance, ok := stream.Resistance(data.Close); ok {
+		data.Resistance = &resistance
+	}
+
+	ctx.JSON(http.StatusOK, successResponse[GetZonesData](&data))
+}
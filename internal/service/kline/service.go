@@ -2,6 +2,7 @@ package kline
 
 import (
 	"snake/internal/kline"
+	"sync"
 
 	"github.com/CrazyThursdayV50/pkgo/log"
 	"github.com/CrazyThursdayV50/pkgo/websocket/server"
@@ -16,6 +17,12 @@ type Service struct {
 	repoKline Repository
 	clients   map[*websocket.Conn]bool
 	ws        *server.Server
+
+	// 指标订阅，Publish 推送实时K线时更新
+	subscriptionsMu sync.Mutex
+	subscriptions   map[*websocket.Conn]*indicatorSubscription
+	// 指标订阅允许的跨域来源，为空时只允许同源和非浏览器客户端
+	allowedOrigins []string
 }
 
 // NewService 创建新的K线服务
@@ -25,9 +32,17 @@ func NewService(logger log.Logger, wsserver *server.Server, repoKline Repository
 		repoKline: repoKline,
 		ws:        wsserver,
 		clients:   make(map[*websocket.Conn]bool),
+
+		subscriptions: make(map[*websocket.Conn]*indicatorSubscription),
 	}
 }
 
+// WithAllowedOrigins 设置指标订阅允许的跨域来源，"*" 表示允许所有来源
+func (s *Service) WithAllowedOrigins(origins []string) *Service {
+	s.allowedOrigins = origins
+	return s
+}
+
 type Response[T any] struct {
 	Error   string `json:"error"`
 	Message string `json:"message"`
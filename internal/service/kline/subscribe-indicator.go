@@ -0,0 +1,263 @@
+package kline
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"snake/internal/indicates"
+	"snake/internal/indicates/catalog"
+	"snake/internal/indicates/divergence"
+	"snake/internal/kline"
+	"snake/internal/kline/interval"
+	"strings"
+	"time"
+
+	"github.com/CrazyThursdayV50/pkgo/json"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// IndicatorMessage 指标订阅推送的消息
+type IndicatorMessage struct {
+	Name     string
+	Interval interval.Interval
+	// Closed K线是否已收盘，1 分钟周期每次推送都会更新未收盘的K线，更高周期只在收盘时推送
+	Closed bool
+	Point  catalog.Point
+	// Events 订阅背离检测时，已收盘K线确认的背离
+	Events []divergence.Event
+}
+
+// indicatorSubscription 一个连接订阅的指标
+type indicatorSubscription struct {
+	conn *websocket.Conn
+	// messages 待发送的消息，由连接自己的写协程发送，缓冲区满时取消订阅
+	messages  chan []byte
+	indicator indicates.Indicator
+	// divergence 订阅的是背离检测时不为 nil，收盘推送带上确认的背离
+	divergence *divergence.Stream
+	interval   interval.Interval
+	// tracker 把 1 分钟K线推送转换为更新和收盘事件，更高周期的订阅再用 resampler 合成为该周期的K线
+	tracker   *kline.BarTracker
+	resampler *kline.Resampler
+}
+
+// push 推入一次 1 分钟K线推送，返回需要发送的消息
+func (sub *indicatorSubscription) push(k *kline.Kline) []*IndicatorMessage {
+	var messages []*IndicatorMessage
+	for _, event := range sub.tracker.Push(k) {
+		if sub.resampler == nil {
+			sub.indicator.Update(event.Kline)
+			messages = append(messages, sub.message(event.Kline.S, event.Type == kline.EventBarClose))
+			continue
+		}
+
+		if event.Type != kline.EventBarClose {
+			continue
+		}
+		for _, bar := range sub.resampler.Close(event.Kline) {
+			if bar.Interval == sub.interval {
+				sub.indicator.Update(bar.Kline)
+				messages = append(messages, sub.message(bar.S, true))
+			}
+		}
+	}
+	return messages
+}
+
+func (sub *indicatorSubscription) message(start int64, closed bool) *IndicatorMessage {
+	message := &IndicatorMessage{
+		Name:     sub.indicator.Name(),
+		Interval: sub.interval,
+		Closed:   closed,
+		Point:    catalog.NewPoint(sub.indicator, start),
+	}
+	// 未收盘K线上的背离可能在收盘前消失，只推送已收盘K线确认的背离
+	if closed && sub.divergence != nil {
+		message.Events = sub.divergence.Events()
+	}
+	return message
+}
+
+// enqueue 把消息放入发送缓冲区，缓冲区已满时返回 false
+func (sub *indicatorSubscription) enqueue(message *IndicatorMessage) (bool, error) {
+	data, err := json.JSON().Marshal(message)
+	if err != nil {
+		return true, err
+	}
+	select {
+	case sub.messages <- data:
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// write 依次发送缓冲区中的消息，直到缓冲区被关闭；发送失败时关闭连接，读循环随之退出并取消订阅
+func (sub *indicatorSubscription) write() {
+	for data := range sub.messages {
+		_ = sub.conn.SetWriteDeadline(time.Now().Add(indicatorWriteTimeout))
+		if err := sub.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+			_ = sub.conn.Close()
+			for range sub.messages {
+			}
+			return
+		}
+	}
+}
+
+const (
+	indicatorWriteTimeout = time.Second * 10
+	// indicatorSendBuffer 每个订阅最多缓冲的消息数量，客户端读取过慢导致缓冲区满时断开连接
+	indicatorSendBuffer = 64
+)
+
+// checkOrigin 检查订阅请求的来源：没有 Origin 的非浏览器客户端和同源请求总是允许，
+// 其他来源需要在 allowedOrigins 中，"*" 表示允许所有来源
+func (s *Service) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	return slices.Contains(s.allowedOrigins, "*") || slices.Contains(s.allowedOrigins, origin)
+}
+
+// SubscribeIndicator 订阅指标，参数与 GetIndicator 相同（不需要 from、to）
+//
+// 连接建立后先用最近的K线预热并推送最新的取值，预热失败时关闭连接，之后随实时K线推送：
+// 1 分钟周期每次K线推送都会推送未收盘K线的取值，收到下一根K线时再推送收盘的取值，更高周期在K线收盘时推送；
+// 订阅 divergence 时收盘推送带有该K线确认的背离，告警等服务可以据此订阅背离事件
+func (s *Service) SubscribeIndicator(ctx *gin.Context) {
+	var params GetIndicatorParams
+	err := ctx.ShouldBind(&params)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, failResponse[GetIndicatorData](err.Error(), "invalid params"))
+		return
+	}
+
+	iv, err := parseInterval(params.Interval)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, failResponse[GetIndicatorData](err.Error(), "invalid interval"))
+		return
+	}
+	indicator, err := catalog.New(params.Name, indicatorParams(ctx))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, failResponse[GetIndicatorData](err.Error(), "invalid indicator"))
+		return
+	}
+
+	sub := &indicatorSubscription{indicator: indicator, interval: iv, tracker: kline.NewBarTracker()}
+	sub.divergence, _ = indicator.(*divergence.Stream)
+	if iv != interval.Min1() {
+		sub.resampler, err = kline.NewResampler(interval.Min1(), iv)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, failResponse[GetIndicatorData](err.Error(), "invalid interval"))
+			return
+		}
+	}
+
+	upgrader := websocket.Upgrader{CheckOrigin: s.checkOrigin}
+	sub.conn, err = upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		s.logger.Errorf("upgrade indicator subscription failed: %v", err)
+		return
+	}
+	defer sub.conn.Close()
+	sub.messages = make(chan []byte, indicatorSendBuffer)
+
+	// 预热和注册在同一把锁内完成，期间的K线推送等注册后再推入订阅，不会跳过K线
+	s.subscriptionsMu.Lock()
+	last, err := s.warmUpIndicator(ctx, sub)
+	if err == nil && last != nil {
+		_, err = sub.enqueue(last)
+	}
+	if err != nil {
+		s.subscriptionsMu.Unlock()
+		s.logger.Errorf("warm up indicator failed: %v", err)
+		closing := websocket.FormatCloseMessage(websocket.CloseInternalServerErr, "warm up indicator failed")
+		_ = sub.conn.WriteControl(websocket.CloseMessage, closing, time.Now().Add(indicatorWriteTimeout))
+		return
+	}
+	s.subscriptions[sub.conn] = sub
+	s.subscriptionsMu.Unlock()
+	go sub.write()
+
+	// 只读取控制消息，连接关闭后取消订阅
+	for {
+		if _, _, err := sub.conn.ReadMessage(); err != nil {
+			break
+		}
+	}
+
+	s.subscriptionsMu.Lock()
+	s.unsubscribe(sub)
+	s.subscriptionsMu.Unlock()
+}
+
+// unsubscribe 取消订阅并关闭发送缓冲区，调用方持有 subscriptionsMu
+func (s *Service) unsubscribe(sub *indicatorSubscription) {
+	if _, ok := s.subscriptions[sub.conn]; !ok {
+		return
+	}
+	delete(s.subscriptions, sub.conn)
+	close(sub.messages)
+}
+
+// warmUpIndicator 用最近 WarmUp 根已收盘的K线预热指标，返回最后一根K线的取值
+//
+// 更高周期还要把当前未收盘周期内已收盘的 1 分钟K线推入合成器，该周期收盘时合成完整的K线
+func (s *Service) warmUpIndicator(ctx *gin.Context, sub *indicatorSubscription) (*IndicatorMessage, error) {
+	now := time.Now().UnixMilli()
+	current := sub.interval.Truncate(now)
+	from := max(0, current-int64(sub.indicator.WarmUp())*sub.interval.Duration().Milliseconds())
+	klines, err := s.listKlines(ctx, sub.interval, from, current-1)
+	if err != nil {
+		return nil, err
+	}
+
+	var last *IndicatorMessage
+	for _, k := range klines {
+		sub.indicator.Update(k)
+		last = sub.message(k.S, true)
+	}
+	if sub.resampler == nil {
+		return last, nil
+	}
+
+	bases, err := s.listKlines(ctx, interval.Min1(), current, interval.Min1().Truncate(now)-1)
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range bases {
+		sub.resampler.Close(k)
+	}
+	return last, nil
+}
+
+// Publish 把实时推送的 1 分钟K线推给所有指标订阅
+//
+// 消息只放入各订阅的发送缓冲区，不等待发送，慢速客户端不会阻塞K线推送；
+// 缓冲区已满的订阅被取消并关闭连接
+func (s *Service) Publish(k *kline.Kline) {
+	s.subscriptionsMu.Lock()
+	defer s.subscriptionsMu.Unlock()
+
+	for conn, sub := range s.subscriptions {
+		for _, message := range sub.push(k) {
+			ok, err := sub.enqueue(message)
+			if err != nil {
+				s.logger.Errorf("send indicator failed: %v", err)
+				continue
+			}
+			if !ok {
+				s.logger.Errorf("indicator subscriber %s is too slow, closing", conn.RemoteAddr())
+				s.unsubscribe(sub)
+				_ = conn.Close()
+				break
+			}
+		}
+	}
+}
@@ -9,8 +9,16 @@ type Kline interface {
 
 	// 获取K线数据
 	GetKlines(*gin.Context)
+	// 获取枢轴点
+	GetPivot(*gin.Context)
+	// 获取摆动高低点支撑阻力区
+	GetZones(*gin.Context)
+	// 获取指标序列
+	GetIndicator(*gin.Context)
 	// 订阅K线数据
 	Subscribe(*gin.Context)
+	// 订阅指标
+	SubscribeIndicator(*gin.Context)
 }
 
 // StrategyServiceRepository 策略服务接口
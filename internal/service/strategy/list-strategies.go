@@ -14,6 +14,7 @@ type ListStrategiesStrategy struct {
 	Position         string `json:"position"`
 	Profit           string `json:"profit"`
 	ProfitPercentage string `json:"profit_percentage"`
+	LiquidationPrice string `json:"liquidation_price"`
 }
 
 type ListStrategiesData struct {
@@ -34,6 +35,7 @@ func (s *Service) ListStrategies(ctx *gin.Context) {
 			Position:         v.Position().Amount.String(),
 			Profit:           absolute.String(),
 			ProfitPercentage: percentage.String(),
+			LiquidationPrice: v.Position().LiquidationPrice.String(),
 		})
 		return true, nil
 	})
@@ -0,0 +1,46 @@
+package strategy
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"snake/internal/strategy/strategies/wasm"
+	"time"
+)
+
+// loadedPlugin 已编译的插件和编译时的文件修改时间
+type loadedPlugin struct {
+	plugin  *wasm.Plugin
+	modTime time.Time
+}
+
+// plugin 返回名称对应的插件，插件文件为插件目录下的 <name>.wasm，文件更新后重新编译
+func (s *Service) plugin(name string) (*wasm.Plugin, error) {
+	if s.pluginConfig == nil || s.pluginConfig.Dir == "" {
+		return nil, fmt.Errorf("plugins are not enabled")
+	}
+	if name != filepath.Base(name) || name == "." || name == ".." {
+		return nil, fmt.Errorf("invalid plugin name: %s", name)
+	}
+
+	path := filepath.Join(s.pluginConfig.Dir, name+".wasm")
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s.pluginLock.Lock()
+	defer s.pluginLock.Unlock()
+	if loaded, ok := s.plugins[name]; ok && loaded.modTime.Equal(info.ModTime()) {
+		return loaded.plugin, nil
+	}
+
+	// 旧版本的插件可能仍被运行中的策略使用，不关闭
+	plugin, err := wasm.LoadFile(s.ctx, path, s.pluginConfig.Limits)
+	if err != nil {
+		return nil, err
+	}
+	plugin.WithLogger(s.logger)
+	s.plugins[name] = &loadedPlugin{plugin: plugin, modTime: info.ModTime()}
+	return plugin, nil
+}
@@ -3,7 +3,9 @@ package strategy
 import (
 	"context"
 	"snake/internal/kline"
+	"snake/internal/risk"
 	"snake/internal/strategy"
+	"snake/internal/strategy/strategies/wasm"
 	"snake/pkg/broadcast"
 	"sync"
 
@@ -19,6 +21,14 @@ type Service struct {
 
 	strategyLock sync.RWMutex
 	strategies   map[int64]strategy.Strategy
+
+	// 账户级风控规则，nil 表示不做风控
+	riskConfig *risk.Config
+
+	// 策略插件配置，nil 表示不允许加载插件
+	pluginConfig *wasm.Config
+	pluginLock   sync.Mutex
+	plugins      map[string]*loadedPlugin
 }
 
 func NewService(ctx context.Context, logger log.Logger, repo strategy.KlineRepository) *Service {
@@ -29,9 +39,22 @@ func NewService(ctx context.Context, logger log.Logger, repo strategy.KlineRepos
 		broadcast:  broadcast.New[*kline.Kline](),
 		klineRepo:  repo,
 		strategies: make(map[int64]strategy.Strategy),
+		plugins:    make(map[string]*loadedPlugin),
 	}
 }
 
+// WithRisk 设置风控规则，每个运行中的策略使用独立的风控管理器
+func (s *Service) WithRisk(cfg *risk.Config) *Service {
+	s.riskConfig = cfg
+	return s
+}
+
+// WithPlugins 设置策略插件目录和资源限制
+func (s *Service) WithPlugins(cfg *wasm.Config) *Service {
+	s.pluginConfig = cfg
+	return s
+}
+
 type Response[T any] struct {
 	Error   string `json:"error"`
 	Message string `json:"message"`
@@ -6,7 +6,10 @@ import (
 	"net/http"
 	"snake/internal/kline"
 	"snake/internal/kline/interval"
+	"snake/internal/risk"
+	"snake/internal/strategy"
 	"snake/internal/strategy/strategies/ma_cross"
+	"snake/internal/strategy/strategies/rules"
 	"sync/atomic"
 	"time"
 
@@ -22,6 +25,13 @@ type TestParams struct {
 	Position string `json:"position"`
 	// 仓位总成本
 	Cost string `json:"cost"`
+	// 是否开启保证金账户（允许做空）
+	Margin bool `json:"margin"`
+	// YAML 格式的规则策略定义，见 strategies/rules
+	Rules string `json:"rules"`
+	// 策略插件名称，从插件目录加载 <plugin>.wasm，见 strategies/wasm
+	// 规则和插件都为空时使用均线交叉策略
+	Plugin string `json:"plugin"`
 }
 
 type TestData struct {
@@ -37,9 +47,7 @@ func (s *Service) Test(ctx *gin.Context) {
 		return
 	}
 
-	strategyCtx, cancel := context.WithCancel(s.ctx)
-	strategy := ma_cross.New(strategyCtx, cancel)
-
+	// 先校验参数再创建策略，插件策略创建时会实例化模块
 	position, err := decimal.NewFromString(params.Position)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, failResponse[TestData](err.Error(), "invalid position"))
@@ -52,32 +60,78 @@ func (s *Service) Test(ctx *gin.Context) {
 		return
 	}
 
-	err = strategy.Init(position, balance)
+	var marginCfg *strategy.MarginConfig
+	if params.Margin {
+		marginCfg = strategy.DefaultMarginConfig()
+	}
+
+	strategyCtx, cancel := context.WithCancel(s.ctx)
+	testStrategy, err := s.newStrategy(strategyCtx, cancel, &params)
+	if err != nil {
+		cancel()
+		ctx.JSON(http.StatusBadRequest, failResponse[TestData](err.Error(), "invalid strategy"))
+		return
+	}
+	testStrategy.SetMargin(marginCfg)
+
+	var riskManager *risk.Manager
+	if s.riskConfig != nil {
+		riskManager = risk.New(s.riskConfig)
+	}
+	testStrategy.SetRisk(riskManager)
+
+	// 之后失败时停止策略，取消上下文并释放插件模块
+	err = testStrategy.Init(position, balance)
 	if err != nil {
+		testStrategy.Stop()
 		ctx.JSON(http.StatusInternalServerError, failResponse[TestData](err.Error(), "init strategy failed"))
 		return
 	}
 
 	var interval = interval.Min1()
 	from := time.Now().Add(-time.Hour)
+
+	// 未收盘的更新只推送给盘中策略，多周期策略从 1 分钟K线合成高周期K线
+	feed, err := strategy.NewFeed(testStrategy, interval)
+	if err != nil {
+		testStrategy.Stop()
+		ctx.JSON(http.StatusBadRequest, failResponse[TestData](err.Error(), "invalid intervals"))
+		return
+	}
 	ch := s.klineRepo.GetKlines(strategyCtx, interval, from.Unix()*1000)
 
 	id := atomic.AddInt64(&s.id, 1)
 	s.strategyLock.Lock()
-	s.strategies[id] = strategy
+	s.strategies[id] = testStrategy
 	s.strategyLock.Unlock()
 
 	var data TestData
 	data.ID = id
 
-	worker, _ := worker.New(fmt.Sprintf("Turtle-%d", id), func(job *kline.Kline) {
-		signal, err := strategy.Update(job)
+	var loggedDecisions int
+	worker, _ := worker.New(fmt.Sprintf("Turtle-%d", id), func(job *kline.Event) {
+		if liquidation := testStrategy.Mark(job.Kline); liquidation != nil {
+			s.logger.Infof("liquidation: %#v", liquidation)
+		}
+
+		signals, err := feed.Push(job)
 		if err != nil {
 			s.logger.Errorf("udpate strategy failed: %v", err)
 			return
 		}
 
-		s.logger.Infof("signal: %#v", signal)
+		for _, signal := range signals {
+			s.logger.Infof("signal[%s]: %#v", job.Type, signal)
+		}
+
+		// 记录新产生的风控缩减和拒绝
+		if riskManager != nil {
+			decisions := riskManager.Decisions()
+			for _, decision := range decisions[loggedDecisions:] {
+				s.logger.Infof("risk: %s", decision)
+			}
+			loggedDecisions = len(decisions)
+		}
 	})
 
 	worker.WithLogger(s.logger)
@@ -87,3 +141,33 @@ func (s *Service) Test(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, successResponse(&data))
 }
+
+// newStrategy 创建实盘测试的策略：提供了规则定义时编译规则策略，提供了插件名称时创建插件策略，否则使用均线交叉策略
+func (s *Service) newStrategy(ctx context.Context, cancel context.CancelFunc, params *TestParams) (strategy.Strategy, error) {
+	switch {
+	case params.Rules != "" && params.Plugin != "":
+		return nil, fmt.Errorf("rules and plugin are exclusive")
+	case params.Rules != "":
+		definition, err := rules.Parse([]byte(params.Rules))
+		if err != nil {
+			return nil, err
+		}
+		ruleStrategy, err := rules.New(ctx, cancel, definition)
+		if err != nil {
+			return nil, err
+		}
+		return ruleStrategy, nil
+	case params.Plugin != "":
+		plugin, err := s.plugin(params.Plugin)
+		if err != nil {
+			return nil, err
+		}
+		wasmStrategy, err := plugin.New(ctx, cancel)
+		if err != nil {
+			return nil, err
+		}
+		return wasmStrategy, nil
+	default:
+		return ma_cross.New(ctx, cancel), nil
+	}
+}
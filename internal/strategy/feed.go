@@ -0,0 +1,113 @@
+package strategy
+
+import (
+	"errors"
+	"snake/internal/kline"
+	"snake/internal/kline/interval"
+	"snake/internal/kline/transform"
+)
+
+// UpdateMode 策略的更新方式
+type UpdateMode int
+
+const (
+	// UpdateOnClose 只在K线收盘时更新（Update），默认方式
+	UpdateOnClose UpdateMode = iota
+	// UpdateIntrabar 盘中每次K线更新都调用 UpdateIntrabar，收盘时仍调用 Update
+	UpdateIntrabar
+)
+
+var updateModeNames = map[UpdateMode]string{
+	UpdateOnClose:  "ON_CLOSE",
+	UpdateIntrabar: "INTRABAR",
+}
+
+func (m UpdateMode) String() string { return updateModeNames[m] }
+
+// Feed 将K线事件推送给策略，回测和实盘共用：
+//   - 未收盘的更新事件只推送给盘中策略的 UpdateIntrabar
+//   - 收盘事件推送给 Update，多周期策略按周期推送给 UpdateInterval，转换K线的策略把转换后的K线和原始K线推送给 UpdateTransformed
+type Feed struct {
+	strategy    Strategy
+	resampler   *kline.Resampler
+	transformer transform.Transformer
+}
+
+// ErrTransformWithTimeframes 策略不能同时使用多周期和K线转换
+var ErrTransformWithTimeframes = errors.New("策略不能同时使用多周期和K线转换")
+
+// NewFeed 创建策略的K线事件推送器，base 为推送的K线周期
+func NewFeed(s Strategy, base interval.Interval) (*Feed, error) {
+	var feed = &Feed{strategy: s}
+	if mtf, ok := s.(MultiTimeframeStrategy); ok {
+		resampler, err := kline.NewResampler(base, mtf.Intervals()...)
+		if err != nil {
+			return nil, err
+		}
+		feed.resampler = resampler
+	}
+	if ts, ok := s.(TransformStrategy); ok {
+		if feed.resampler != nil {
+			return nil, ErrTransformWithTimeframes
+		}
+		feed.transformer = ts.Transformer()
+	}
+	return feed, nil
+}
+
+// Push 推送一个K线事件，返回策略产生的非空信号
+func (f *Feed) Push(event *kline.Event) ([]*Signal, error) {
+	if event.Type == kline.EventBarUpdate {
+		if f.strategy.Mode() != UpdateIntrabar {
+			return nil, nil
+		}
+		return collect(f.strategy.UpdateIntrabar(event.Kline))
+	}
+
+	if f.transformer != nil {
+		ts := f.strategy.(TransformStrategy)
+		var signals []*Signal
+		for _, bar := range f.transformer.Push(event.Kline) {
+			signal, err := ts.UpdateTransformed(bar, event.Kline)
+			if err != nil {
+				return nil, err
+			}
+			if signal != nil {
+				signals = append(signals, signal)
+			}
+		}
+		return signals, nil
+	}
+
+	if f.resampler == nil {
+		return collect(f.strategy.Update(event.Kline))
+	}
+
+	mtf := f.strategy.(MultiTimeframeStrategy)
+	var signals []*Signal
+	for _, closed := range f.resampler.Close(event.Kline) {
+		signal, err := mtf.UpdateInterval(closed.Interval, closed.Kline)
+		if err != nil {
+			return nil, err
+		}
+		if signal != nil {
+			signals = append(signals, signal)
+		}
+	}
+	return signals, nil
+}
+
+func collect(signal *Signal, err error) ([]*Signal, error) {
+	if err != nil || signal == nil {
+		return nil, err
+	}
+	return []*Signal{signal}, nil
+}
+
+// Mode 返回策略的更新方式，默认只在K线收盘时更新
+func (s *BaseStrategy) Mode() UpdateMode { return UpdateOnClose }
+
+// UpdateIntrabar 盘中K线更新，默认持有
+func (s *BaseStrategy) UpdateIntrabar(kline *kline.Kline) (*Signal, error) {
+	return s.Hold(), nil
+}
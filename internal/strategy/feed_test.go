@@ -0,0 +1,132 @@
+package strategy
+
+import (
+	"context"
+	"snake/internal/kline"
+	"snake/internal/kline/transform"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+// countingStrategy 记录收盘和盘中更新的次数
+type countingStrategy struct {
+	*BaseStrategy
+	mode     UpdateMode
+	closes   int
+	intrabar int
+}
+
+func (s *countingStrategy) Mode() UpdateMode { return s.mode }
+
+func (s *countingStrategy) Update(k *kline.Kline) (*Signal, error) {
+	s.closes++
+	return s.Hold(), nil
+}
+
+func (s *countingStrategy) UpdateIntrabar(k *kline.Kline) (*Signal, error) {
+	s.intrabar++
+	return s.Hold(), nil
+}
+
+func TestFeed(t *testing.T) {
+	price := decimal.NewFromInt(100)
+	bar := &kline.Kline{O: price, H: price, L: price, C: price}
+	events := []*kline.Event{
+		kline.UpdateEvent(bar),
+		kline.UpdateEvent(bar),
+		kline.CloseEvent(bar),
+		kline.UpdateEvent(bar),
+	}
+
+	tests := []struct {
+		name     string
+		mode     UpdateMode
+		closes   int
+		intrabar int
+	}{
+		{"收盘策略只在收盘时更新", UpdateOnClose, 1, 0},
+		{"盘中策略收到所有更新", UpdateIntrabar, 1, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			s := &countingStrategy{BaseStrategy: NewBaseStrategy(ctx, cancel, "counting"), mode: tt.mode}
+
+			feed, err := NewFeed(s, "1m")
+			if err != nil {
+				t.Fatalf("创建推送器失败: %v", err)
+			}
+			for _, event := range events {
+				if _, err := feed.Push(event); err != nil {
+					t.Fatalf("推送失败: %v", err)
+				}
+			}
+
+			if s.closes != tt.closes || s.intrabar != tt.intrabar {
+				t.Errorf("预期收盘 %d 次、盘中 %d 次，实际为 %d 次、%d 次", tt.closes, tt.intrabar, s.closes, s.intrabar)
+			}
+		})
+	}
+}
+
+// renkoStrategy 使用砖形图的策略，记录收到的K线，第一块砖按原始K线的收盘价买入
+type renkoStrategy struct {
+	*BaseStrategy
+	bars    []*kline.Kline
+	raws    []*kline.Kline
+	signals []*Signal
+}
+
+func (s *renkoStrategy) Transformer() transform.Transformer {
+	renko, _ := transform.NewRenko(decimal.NewFromInt(10))
+	return renko
+}
+
+func (s *renkoStrategy) UpdateTransformed(bar, raw *kline.Kline) (*Signal, error) {
+	s.bars = append(s.bars, bar)
+	s.raws = append(s.raws, raw)
+	if len(s.bars) == 1 {
+		return s.Buy(decimal.NewFromInt(100), raw.C), nil
+	}
+	return s.Hold(), nil
+}
+
+func TestFeedTransform(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &renkoStrategy{BaseStrategy: NewBaseStrategy(ctx, cancel, "renko")}
+	_ = s.Init(decimal.Zero, decimal.NewFromInt(1000))
+	feed, err := NewFeed(s, "1m")
+	if err != nil {
+		t.Fatalf("创建推送器失败: %v", err)
+	}
+
+	for i, close := range []int64{100, 105, 125, 118} {
+		price := decimal.NewFromInt(close)
+		bar := &kline.Kline{O: price, H: price, L: price, C: price, S: int64(i) * 60000, E: int64(i)*60000 + 59999}
+		// 盘中更新不产生砖块
+		if _, err := feed.Push(kline.UpdateEvent(bar)); err != nil {
+			t.Fatalf("推送失败: %v", err)
+		}
+		signals, err := feed.Push(kline.CloseEvent(bar))
+		if err != nil {
+			t.Fatalf("推送失败: %v", err)
+		}
+		s.signals = append(s.signals, signals...)
+	}
+
+	// 100 为基准价格，125 生成 100→110、110→120 两块砖，118 不足以反转
+	if len(s.bars) != 2 || !s.bars[1].C.Equal(decimal.NewFromInt(120)) {
+		t.Fatalf("预期收到 2 块砖，实际为 %d 块", len(s.bars))
+	}
+	// 两块砖都由收盘价为 125 的原始K线产生，买入按真实成交的 125 而不是砖块边界 110
+	for i, raw := range s.raws {
+		if !raw.C.Equal(decimal.NewFromInt(125)) {
+			t.Errorf("第 %d 块砖预期对应收盘价 125 的原始K线，实际为 %s", i+1, raw.C)
+		}
+	}
+	if len(s.signals) == 0 || !s.signals[0].Type.IsBuy() || !s.signals[0].Price.Equal(decimal.NewFromInt(125)) {
+		t.Errorf("预期按原始K线收盘价 125 买入，实际为 %+v", s.signals)
+	}
+}
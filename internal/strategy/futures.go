@@ -0,0 +1,186 @@
+package strategy
+
+import (
+	"snake/internal/kline"
+	"snake/internal/types"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// FuturesConfig U 本位永续合约参数，仓位使用逐仓保证金
+//
+// 合约模式下买入、卖出的数量含义不变（买入为 quote 名义价值，卖出为 base 数量），
+// 开仓时从余额中划转 名义价值 / 杠杆 作为该仓位的保证金：
+//   - 平仓时按比例释放保证金并结算已实现盈亏
+//   - 资金费从仓位保证金中扣除或加入
+//   - 当 保证金 + 未实现盈亏 <= 仓位价值 * 维持保证金率 时强制平仓，仓位保证金全部损失
+type FuturesConfig struct {
+	// 杠杆倍数，例如 5 表示 5 倍杠杆
+	Leverage decimal.Decimal
+	// 维持保证金率，例如 0.004 表示 0.4%
+	MaintenanceMarginRate decimal.Decimal
+}
+
+// DefaultFuturesConfig 返回默认的合约参数
+func DefaultFuturesConfig() *FuturesConfig {
+	return &FuturesConfig{
+		Leverage:              decimal.NewFromInt(1),
+		MaintenanceMarginRate: decimal.NewFromFloat(0.004),
+	}
+}
+
+// SetFutures 设置合约参数，nil 表示现货账户
+func (s *BaseStrategy) SetFutures(cfg *FuturesConfig) {
+	s.futures = cfg
+	s.refreshMargin()
+}
+
+// Equity 按价格计算账户净值
+// 现货和杠杆账户为 余额 + 持仓市值，合约账户为 余额 + 仓位保证金 + 未实现盈亏
+func (s *BaseStrategy) Equity(price decimal.Decimal) decimal.Decimal {
+	if s.futures == nil {
+		return s.balance.Amount.Add(s.position.Amount.Mul(price))
+	}
+	return s.balance.Amount.Add(s.position.Margin).Add(s.position.UnrealizedProfit(price))
+}
+
+// BuyingPower 返回按杠杆放大后的可用资金，现货账户为余额
+func (s *BaseStrategy) BuyingPower() decimal.Decimal {
+	if s.futures == nil {
+		return s.balance.Amount
+	}
+	return s.balance.Amount.Mul(s.futures.Leverage)
+}
+
+// SettleFunding 按资金费率和标记价格结算资金费，返回本次支付的资金费（负数表示收取）
+// 资金费率为正时多头支付给空头，为负时空头支付给多头
+func (s *BaseStrategy) SettleFunding(rate, markPrice decimal.Decimal) decimal.Decimal {
+	if s.futures == nil || s.position.Amount.IsZero() {
+		return decimal.Zero
+	}
+
+	payment := s.position.Amount.Mul(markPrice).Mul(rate)
+	s.position.Margin = s.position.Margin.Sub(payment)
+	s.position.Funding = s.position.Funding.Add(payment)
+	s.refreshMargin()
+	return payment
+}
+
+// SetMarkPrice 设置合约的标记价格K线，盯市时开盘时间相同的标记价格K线代替成交价K线检查强平
+// 交易所按标记价格触发强平，成交价的插针不会导致强平
+func (s *BaseStrategy) SetMarkPrice(kline *kline.Kline) {
+	s.markPrice = kline
+}
+
+// tradeFutures 按成交数量开仓或平仓，先平掉反向仓位，剩余部分按杠杆开仓
+// 开仓所需保证金不足时返回 nil
+func (s *BaseStrategy) tradeFutures(side types.SignalType, volume, price decimal.Decimal) *Signal {
+	if !volume.IsPositive() {
+		return nil
+	}
+
+	var direction = decimal.NewFromInt(1)
+	if side.IsSell() {
+		direction = direction.Neg()
+	}
+
+	var closing, released, closedCost, pnl decimal.Decimal
+	if !s.position.Amount.IsZero() && s.position.Amount.Sign() != direction.Sign() {
+		size := s.position.Amount.Abs()
+		closing = decimal.Min(volume, size)
+		ratio := closing.Div(size)
+		released = s.position.Margin.Mul(ratio)
+		closedCost = s.position.Cost.Mul(ratio)
+
+		pnl = closing.Mul(price).Sub(closedCost)
+		if s.position.Amount.IsNegative() {
+			pnl = pnl.Neg()
+		}
+	}
+
+	opening := volume.Sub(closing)
+	margin := opening.Mul(price).Div(s.futures.Leverage)
+
+	// 逐仓亏损以仓位保证金为限
+	settled := decimal.Max(released.Add(pnl), decimal.Zero)
+	if s.balance.Amount.Add(settled).LessThan(margin) {
+		return nil
+	}
+
+	if closing.IsPositive() {
+		s.balance.Amount = s.balance.Amount.Add(settled)
+		s.position.Margin = s.position.Margin.Sub(released)
+		s.position.Cost = s.position.Cost.Sub(closedCost)
+		s.position.Amount = s.position.Amount.Add(closing.Mul(direction))
+	}
+
+	if opening.IsPositive() {
+		s.balance.Amount = s.balance.Amount.Sub(margin)
+		s.position.Margin = s.position.Margin.Add(margin)
+		s.position.Cost = s.position.Cost.Add(opening.Mul(price))
+		s.position.Amount = s.position.Amount.Add(opening.Mul(direction))
+	}
+
+	if s.position.Amount.IsZero() {
+		s.position.Margin = decimal.Zero
+		s.position.Cost = decimal.Zero
+	}
+
+	s.refreshMargin()
+	s.position.Time = time.Now()
+	s.balance.Time = time.Now()
+
+	return &Signal{
+		Type:   side,
+		Volume: volume,
+		Amount: volume.Mul(price),
+		Price:  price,
+		Time:   time.Now(),
+	}
+}
+
+// refreshFutures 按逐仓保证金计算强平价
+func (s *BaseStrategy) refreshFutures() {
+	size := s.position.Amount.Abs()
+	if size.IsZero() {
+		s.position.LiquidationPrice = decimal.Zero
+		return
+	}
+
+	one := decimal.NewFromInt(1)
+	mmr := s.futures.MaintenanceMarginRate
+	if s.position.Amount.IsPositive() {
+		// 保证金 + 数量 * (价格 - 开仓均价) = 维持保证金率 * 数量 * 价格
+		price := s.position.Cost.Sub(s.position.Margin).Div(size.Mul(one.Sub(mmr)))
+		s.position.LiquidationPrice = decimal.Max(price, decimal.Zero)
+		return
+	}
+
+	// 保证金 + 数量 * (开仓均价 - 价格) = 维持保证金率 * 数量 * 价格
+	s.position.LiquidationPrice = s.position.Cost.Add(s.position.Margin).Div(size.Mul(one.Add(mmr)))
+}
+
+// liquidateFutures 按强平价平掉全部仓位，逐仓保证金全部损失，余额不受影响
+func (s *BaseStrategy) liquidateFutures(price decimal.Decimal) *Signal {
+	var side = types.SignalTypeSell
+	if s.position.Amount.IsNegative() {
+		side = types.SignalTypeBuy
+	}
+
+	volume := s.position.Amount.Abs()
+	s.position.Amount = decimal.Zero
+	s.position.Cost = decimal.Zero
+	s.position.Margin = decimal.Zero
+	s.refreshMargin()
+	s.position.Time = time.Now()
+
+	return &Signal{
+		Type:        side,
+		Volume:      volume,
+		Amount:      volume.Mul(price),
+		Price:       price,
+		Time:        time.Now(),
+		Liquidation: true,
+	}
+}
@@ -0,0 +1,167 @@
+package strategy
+
+import (
+	"context"
+	"snake/internal/kline"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func newFuturesStrategy(leverage int64) *BaseStrategy {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := NewBaseStrategy(ctx, cancel, "futures")
+	s.SetFutures(&FuturesConfig{
+		Leverage:              decimal.NewFromInt(leverage),
+		MaintenanceMarginRate: decimal.NewFromFloat(0.004),
+	})
+	_ = s.Init(decimal.Zero, decimal.NewFromInt(1000))
+	return s
+}
+
+func TestFuturesTrade(t *testing.T) {
+	t.Run("按杠杆开多并平仓", func(t *testing.T) {
+		s := newFuturesStrategy(10)
+		// 名义价值 5000，保证金 500
+		if signal := s.Buy(decimal.NewFromInt(5000), decimal.NewFromInt(100)); signal == nil {
+			t.Fatalf("预期开多成功")
+		}
+		if !s.Position().Amount.Equal(decimal.NewFromInt(50)) {
+			t.Fatalf("预期持仓 50，实际为 %s", s.Position().Amount)
+		}
+		if !s.Position().Margin.Equal(decimal.NewFromInt(500)) || !s.Balance().Amount.Equal(decimal.NewFromInt(500)) {
+			t.Fatalf("预期保证金 500、余额 500，实际为 %s、%s", s.Position().Margin, s.Balance().Amount)
+		}
+
+		// 价格上涨到 110 后平仓，盈利 500
+		if signal := s.Sell(decimal.NewFromInt(50), decimal.NewFromInt(110)); signal == nil {
+			t.Fatalf("预期平仓成功")
+		}
+		if !s.Position().Amount.IsZero() || !s.Position().Margin.IsZero() {
+			t.Errorf("预期仓位全部平掉")
+		}
+		if !s.Balance().Amount.Equal(decimal.NewFromInt(1500)) {
+			t.Errorf("预期余额 1500，实际为 %s", s.Balance().Amount)
+		}
+	})
+
+	t.Run("保证金不足时拒绝开仓", func(t *testing.T) {
+		s := newFuturesStrategy(2)
+		if signal := s.Buy(decimal.NewFromInt(2001), decimal.NewFromInt(100)); signal != nil {
+			t.Fatalf("预期开仓失败")
+		}
+	})
+
+	t.Run("不需要保证金账户即可开空", func(t *testing.T) {
+		s := newFuturesStrategy(5)
+		if signal := s.Sell(decimal.NewFromInt(10), decimal.NewFromInt(100)); signal == nil {
+			t.Fatalf("预期开空成功")
+		}
+
+		s.Mark(&kline.Kline{O: decimal.NewFromInt(100), H: decimal.NewFromInt(100), L: decimal.NewFromInt(90), C: decimal.NewFromInt(90)})
+		absolute, percentage := s.Profit()
+		if !absolute.Equal(decimal.NewFromInt(100)) {
+			t.Errorf("预期盈利 100，实际为 %s", absolute)
+		}
+		// 盈亏百分比相对于保证金 200
+		if !percentage.Equal(decimal.NewFromInt(50)) {
+			t.Errorf("预期盈亏百分比 50，实际为 %s", percentage)
+		}
+		if !s.Equity(decimal.NewFromInt(90)).Equal(decimal.NewFromInt(1100)) {
+			t.Errorf("预期净值 1100，实际为 %s", s.Equity(decimal.NewFromInt(90)))
+		}
+	})
+}
+
+func TestFuturesLiquidation(t *testing.T) {
+	s := newFuturesStrategy(10)
+	s.Buy(decimal.NewFromInt(1000), decimal.NewFromInt(100))
+
+	// 保证金 100，持仓 10，强平价 = (1000 - 100) / (10 * 0.996)
+	expected := decimal.NewFromInt(900).Div(decimal.NewFromFloat(9.96))
+	if !s.Position().LiquidationPrice.Equal(expected) {
+		t.Fatalf("预期强平价 %s，实际为 %s", expected, s.Position().LiquidationPrice)
+	}
+
+	signal := s.Mark(&kline.Kline{O: decimal.NewFromInt(95), H: decimal.NewFromInt(96), L: decimal.NewFromInt(85), C: decimal.NewFromInt(88)})
+	if signal == nil || !signal.Liquidation || !signal.Type.IsSell() {
+		t.Fatalf("预期多头被强平")
+	}
+	if !signal.Price.Equal(expected) {
+		t.Errorf("预期按强平价成交，实际为 %s", signal.Price)
+	}
+	if !s.Position().Amount.IsZero() {
+		t.Errorf("预期强平后没有持仓")
+	}
+	// 逐仓强平只损失仓位保证金
+	if !s.Balance().Amount.Equal(decimal.NewFromInt(900)) {
+		t.Errorf("预期余额 900，实际为 %s", s.Balance().Amount)
+	}
+}
+
+func TestFuturesMarkPriceLiquidation(t *testing.T) {
+	wick := &kline.Kline{S: 60000, O: decimal.NewFromInt(95), H: decimal.NewFromInt(96), L: decimal.NewFromInt(85), C: decimal.NewFromInt(95)}
+	tests := []struct {
+		name string
+		mark *kline.Kline
+		want bool // 是否强平
+	}{
+		{
+			name: "成交价插针而标记价格未触及强平价",
+			mark: &kline.Kline{S: 60000, O: decimal.NewFromInt(95), H: decimal.NewFromInt(96), L: decimal.NewFromInt(94), C: decimal.NewFromInt(95)},
+		},
+		{
+			name: "标记价格触及强平价",
+			mark: &kline.Kline{S: 60000, O: decimal.NewFromInt(95), H: decimal.NewFromInt(96), L: decimal.NewFromInt(90), C: decimal.NewFromInt(95)},
+			want: true,
+		},
+		{
+			name: "标记价格K线不是同一周期时按成交价",
+			mark: &kline.Kline{S: 0, O: decimal.NewFromInt(95), H: decimal.NewFromInt(96), L: decimal.NewFromInt(94), C: decimal.NewFromInt(95)},
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newFuturesStrategy(10)
+			s.Buy(decimal.NewFromInt(1000), decimal.NewFromInt(100))
+			s.SetMarkPrice(tt.mark)
+
+			signal := s.Mark(wick)
+			if (signal != nil && signal.Liquidation) != tt.want {
+				t.Fatalf("预期强平 %v，实际信号为 %+v", tt.want, signal)
+			}
+			if s.Position().Amount.IsZero() != tt.want {
+				t.Errorf("预期强平 %v，实际持仓为 %s", tt.want, s.Position().Amount)
+			}
+		})
+	}
+}
+
+func TestFuturesFunding(t *testing.T) {
+	s := newFuturesStrategy(10)
+	s.Buy(decimal.NewFromInt(1000), decimal.NewFromInt(100))
+	liquidationPrice := s.Position().LiquidationPrice
+
+	// 资金费率为正，多头支付 10 * 100 * 0.001 = 1
+	payment := s.SettleFunding(decimal.NewFromFloat(0.001), decimal.NewFromInt(100))
+	if !payment.Equal(decimal.NewFromInt(1)) {
+		t.Fatalf("预期支付资金费 1，实际为 %s", payment)
+	}
+	if !s.Position().Margin.Equal(decimal.NewFromInt(99)) {
+		t.Errorf("预期资金费从保证金中扣除，实际保证金为 %s", s.Position().Margin)
+	}
+	if !s.Position().LiquidationPrice.GreaterThan(liquidationPrice) {
+		t.Errorf("预期扣除资金费后强平价上移")
+	}
+
+	// 资金费率为负，多头收取资金费
+	payment = s.SettleFunding(decimal.NewFromFloat(-0.002), decimal.NewFromInt(100))
+	if !payment.Equal(decimal.NewFromInt(-2)) {
+		t.Errorf("预期收取资金费 2，实际为 %s", payment)
+	}
+	if !s.Position().Funding.Equal(decimal.NewFromInt(-1)) {
+		t.Errorf("预期累计资金费 -1，实际为 %s", s.Position().Funding)
+	}
+}
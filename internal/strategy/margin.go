@@ -0,0 +1,170 @@
+package strategy
+
+import (
+	"snake/internal/kline"
+	"snake/internal/types"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// hourMilliseconds 一小时的毫秒数，借币利息按整点小时计提
+const hourMilliseconds = int64(time.Hour / time.Millisecond)
+
+// MarginConfig 保证金账户参数
+//
+// 做空时借入 base 资产卖出，卖出所得留在余额中作为保证金：
+//   - 开仓时要求 净资产 >= 空头市值 * 初始保证金率
+//   - 每跨过一个整点小时，按 空头市值 * 小时利率 从余额中扣除借币利息
+//   - 当 余额 - 空头市值 <= 空头市值 * 维持保证金率 时强制平仓
+type MarginConfig struct {
+	// 借币小时利率，例如 0.000004 表示每小时 0.0004%
+	HourlyInterestRate decimal.Decimal
+	// 初始保证金率，例如 0.2 表示最多 5 倍杠杆
+	InitialMarginRate decimal.Decimal
+	// 维持保证金率，例如 0.1 表示 10%
+	MaintenanceMarginRate decimal.Decimal
+}
+
+// DefaultMarginConfig 返回默认的保证金账户参数
+func DefaultMarginConfig() *MarginConfig {
+	return &MarginConfig{
+		HourlyInterestRate:    decimal.NewFromFloat(0.000004),
+		InitialMarginRate:     decimal.NewFromFloat(0.2),
+		MaintenanceMarginRate: decimal.NewFromFloat(0.1),
+	}
+}
+
+// SetMargin 设置保证金账户参数，nil 表示不允许做空
+func (s *BaseStrategy) SetMargin(cfg *MarginConfig) {
+	s.margin = cfg
+	s.refreshMargin()
+}
+
+// canShort 检查卖出 amount 后的空头是否满足初始保证金要求
+func (s *BaseStrategy) canShort(amount, price decimal.Decimal) bool {
+	if s.margin == nil {
+		return false
+	}
+
+	short := s.position.Amount.Sub(amount).Neg()
+	equity := s.balance.Amount.Add(s.position.Amount.Mul(price))
+	return equity.GreaterThanOrEqual(short.Mul(price).Mul(s.margin.InitialMarginRate))
+}
+
+// applyTrade 按成交数量（买入为正，卖出为负）更新持仓和持仓成本
+// 减仓部分按比例扣减成本，开仓部分按成交额增加成本
+func (s *BaseStrategy) applyTrade(volume, price decimal.Decimal) {
+	var closing = decimal.Zero
+	if !s.position.Amount.IsZero() && s.position.Amount.Sign() != volume.Sign() {
+		closing = decimal.Min(volume.Abs(), s.position.Amount.Abs())
+		ratio := closing.Div(s.position.Amount.Abs())
+		s.position.Cost = s.position.Cost.Mul(decimal.NewFromInt(1).Sub(ratio))
+	}
+
+	opening := volume.Abs().Sub(closing)
+	s.position.Cost = s.position.Cost.Add(opening.Mul(price))
+	s.position.Amount = s.position.Amount.Add(volume)
+	s.refreshMargin()
+}
+
+// refreshMargin 更新借币数量和强平价
+func (s *BaseStrategy) refreshMargin() {
+	if s.futures != nil {
+		s.refreshFutures()
+		return
+	}
+
+	if !s.position.Amount.IsNegative() {
+		s.position.Borrowed = decimal.Zero
+		s.position.LiquidationPrice = decimal.Zero
+		return
+	}
+
+	s.position.Borrowed = s.position.Amount.Neg()
+	if s.margin == nil {
+		s.position.LiquidationPrice = decimal.Zero
+		return
+	}
+
+	// 余额 - 空头数量 * 价格 = 维持保证金率 * 空头数量 * 价格
+	one := decimal.NewFromInt(1)
+	s.position.LiquidationPrice = s.balance.Amount.Div(s.position.Borrowed.Mul(one.Add(s.margin.MaintenanceMarginRate)))
+}
+
+// Mark 按K线盯市：计提借币利息、更新盈亏、检查强平
+// 仓位触及强平价时按强平价（跳空时按开盘价）平仓，撤销止损止盈单，并返回强平信号；
+// 合约设置了同一周期的标记价格K线时按标记价格检查强平，见 SetMarkPrice
+func (s *BaseStrategy) Mark(kline *kline.Kline) *Signal {
+	s.accrueInterest(kline)
+
+	trigger := kline
+	if s.futures != nil && s.markPrice != nil && s.markPrice.S == kline.S {
+		trigger = s.markPrice
+	}
+
+	var signal *Signal
+	liquidationPrice := s.position.LiquidationPrice
+	if liquidationPrice.IsPositive() {
+		switch {
+		case s.position.Amount.IsNegative() && trigger.H.GreaterThanOrEqual(liquidationPrice):
+			signal = s.liquidate(decimal.Max(trigger.O, liquidationPrice))
+		case s.position.Amount.IsPositive() && trigger.L.LessThanOrEqual(liquidationPrice):
+			signal = s.liquidate(decimal.Min(trigger.O, liquidationPrice))
+		}
+	}
+	if signal != nil {
+		s.CancelStops(signal)
+	}
+
+	s.updateProfit(kline.C)
+	s.markRisk(kline)
+	return signal
+}
+
+// accrueInterest 按跨过的整点小时数计提空头的借币利息
+func (s *BaseStrategy) accrueInterest(kline *kline.Kline) {
+	last := s.markTime
+	if kline.E > s.markTime {
+		s.markTime = kline.E
+	}
+
+	if last == 0 || s.margin == nil || !s.position.Borrowed.IsPositive() {
+		return
+	}
+
+	hours := kline.E/hourMilliseconds - last/hourMilliseconds
+	if hours <= 0 {
+		return
+	}
+
+	interest := s.position.Borrowed.Mul(kline.C).Mul(s.margin.HourlyInterestRate).Mul(decimal.NewFromInt(hours))
+	s.balance.Amount = s.balance.Amount.Sub(interest)
+	s.position.Interest = s.position.Interest.Add(interest)
+	s.refreshMargin()
+}
+
+// liquidate 按指定价格强制平仓，杠杆账户为买入回补全部空头
+func (s *BaseStrategy) liquidate(price decimal.Decimal) *Signal {
+	if s.futures != nil {
+		return s.liquidateFutures(price)
+	}
+
+	volume := s.position.Borrowed
+	usdtAmount := volume.Mul(price)
+
+	// 穿仓损失不计入余额
+	s.balance.Amount = decimal.Max(s.balance.Amount.Sub(usdtAmount), decimal.Zero)
+	s.applyTrade(volume, price)
+	s.position.Time = time.Now()
+	s.balance.Time = time.Now()
+
+	return &Signal{
+		Type:        types.SignalTypeBuy,
+		Volume:      volume,
+		Amount:      usdtAmount,
+		Price:       price,
+		Time:        time.Now(),
+		Liquidation: true,
+	}
+}
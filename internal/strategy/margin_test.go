@@ -0,0 +1,132 @@
+package strategy
+
+import (
+	"context"
+	"snake/internal/kline"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func newMarginStrategy(margin *MarginConfig) *BaseStrategy {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := NewBaseStrategy(ctx, cancel, "margin")
+	s.SetMargin(margin)
+	_ = s.Init(decimal.Zero, decimal.NewFromInt(1000))
+	return s
+}
+
+func TestShortSell(t *testing.T) {
+	t.Run("未开启保证金时不能做空", func(t *testing.T) {
+		s := newMarginStrategy(nil)
+		if signal := s.Sell(decimal.NewFromInt(1), decimal.NewFromInt(100)); signal != nil {
+			t.Fatalf("预期卖出失败，实际返回 %#v", signal)
+		}
+	})
+
+	t.Run("开空后买入回补", func(t *testing.T) {
+		s := newMarginStrategy(DefaultMarginConfig())
+		signal := s.Sell(decimal.NewFromInt(2), decimal.NewFromInt(100))
+		if signal == nil {
+			t.Fatalf("预期开空成功")
+		}
+		if !s.Position().Amount.Equal(decimal.NewFromInt(-2)) {
+			t.Fatalf("预期持仓 -2，实际为 %s", s.Position().Amount)
+		}
+		if !s.Balance().Amount.Equal(decimal.NewFromInt(1200)) {
+			t.Errorf("预期余额 1200，实际为 %s", s.Balance().Amount)
+		}
+		if !s.Position().Borrowed.Equal(decimal.NewFromInt(2)) {
+			t.Errorf("预期借币 2，实际为 %s", s.Position().Borrowed)
+		}
+
+		// 价格下跌到 90 后回补，盈利 20
+		signal = s.Buy(decimal.NewFromInt(180), decimal.NewFromInt(90))
+		if signal == nil {
+			t.Fatalf("预期回补成功")
+		}
+		if !s.Position().Amount.IsZero() || !s.Position().Borrowed.IsZero() {
+			t.Errorf("预期空头全部回补，实际持仓 %s", s.Position().Amount)
+		}
+		if !s.Balance().Amount.Equal(decimal.NewFromInt(1020)) {
+			t.Errorf("预期余额 1020，实际为 %s", s.Balance().Amount)
+		}
+	})
+
+	t.Run("超过初始保证金要求时拒绝开空", func(t *testing.T) {
+		s := newMarginStrategy(DefaultMarginConfig())
+		// 净资产 1000，初始保证金率 0.2，最多开空 5000 USDT
+		if signal := s.Sell(decimal.NewFromInt(51), decimal.NewFromInt(100)); signal != nil {
+			t.Fatalf("预期开空失败")
+		}
+	})
+
+	t.Run("空头盈亏", func(t *testing.T) {
+		s := newMarginStrategy(DefaultMarginConfig())
+		s.Sell(decimal.NewFromInt(1), decimal.NewFromInt(100))
+		s.Mark(&kline.Kline{O: decimal.NewFromInt(100), H: decimal.NewFromInt(100), L: decimal.NewFromInt(90), C: decimal.NewFromInt(90)})
+
+		absolute, _ := s.Profit()
+		if !absolute.Equal(decimal.NewFromInt(10)) {
+			t.Errorf("预期盈利 10，实际为 %s", absolute)
+		}
+	})
+}
+
+func TestMarginInterest(t *testing.T) {
+	s := newMarginStrategy(DefaultMarginConfig())
+	s.Sell(decimal.NewFromInt(1), decimal.NewFromInt(100))
+
+	price := decimal.NewFromInt(100)
+	mark := func(end int64) {
+		s.Mark(&kline.Kline{O: price, H: price, L: price, C: price, E: end})
+	}
+
+	// 第一次盯市只记录时间，同一小时内不计息
+	mark(hourMilliseconds + 1)
+	mark(hourMilliseconds*2 - 1)
+	if !s.Position().Interest.IsZero() {
+		t.Fatalf("预期未计息，实际为 %s", s.Position().Interest)
+	}
+
+	// 跨过两个整点小时
+	mark(hourMilliseconds*3 + 1)
+	expected := decimal.NewFromFloat(0.000004).Mul(decimal.NewFromInt(200))
+	if !s.Position().Interest.Equal(expected) {
+		t.Errorf("预期利息 %s，实际为 %s", expected, s.Position().Interest)
+	}
+	if !s.Balance().Amount.Equal(decimal.NewFromInt(1100).Sub(expected)) {
+		t.Errorf("预期利息从余额中扣除，实际余额为 %s", s.Balance().Amount)
+	}
+}
+
+func TestMarginLiquidation(t *testing.T) {
+	s := newMarginStrategy(DefaultMarginConfig())
+	s.Sell(decimal.NewFromInt(10), decimal.NewFromInt(100))
+
+	// 余额 2000，空头 10，维持保证金率 0.1，强平价 = 2000 / (10 * 1.1)
+	expected := decimal.NewFromInt(2000).Div(decimal.NewFromInt(11))
+	if !s.Position().LiquidationPrice.Equal(expected) {
+		t.Fatalf("预期强平价 %s，实际为 %s", expected, s.Position().LiquidationPrice)
+	}
+
+	t.Run("未触及强平价", func(t *testing.T) {
+		signal := s.Mark(&kline.Kline{O: decimal.NewFromInt(100), H: decimal.NewFromInt(180), L: decimal.NewFromInt(100), C: decimal.NewFromInt(170)})
+		if signal != nil {
+			t.Fatalf("预期不强平")
+		}
+	})
+
+	t.Run("触及强平价后按强平价回补", func(t *testing.T) {
+		signal := s.Mark(&kline.Kline{O: decimal.NewFromInt(170), H: decimal.NewFromInt(190), L: decimal.NewFromInt(170), C: decimal.NewFromInt(175)})
+		if signal == nil || !signal.Liquidation {
+			t.Fatalf("预期强平")
+		}
+		if !signal.Price.Equal(expected) {
+			t.Errorf("预期强平价 %s，实际为 %s", expected, signal.Price)
+		}
+		if !s.Position().Amount.IsZero() || !s.Position().LiquidationPrice.IsZero() {
+			t.Errorf("预期强平后没有持仓")
+		}
+	})
+}
@@ -0,0 +1,210 @@
+package strategy
+
+import (
+	"snake/internal/types"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Order 表示挂单（限价、止损、止盈、跟踪止损等）
+// 策略通过 Signal.Orders 提交挂单，由回测引擎或执行端按K线的最高价、最低价撮合，
+// 成交后回调 Strategy.Fill 更新余额和持仓
+type Order struct {
+	// 订单编号，由策略在提交时分配
+	ID int64
+	// 订单类型
+	Type types.OrderType
+	// 买卖方向：买入或卖出
+	Side types.SignalType
+	// 交易数量（base 数量，例如 BTC），卖出时使用
+	Volume decimal.Decimal
+	// 交易数量（quote 数量，例如 USDT），买入时使用
+	Amount decimal.Decimal
+	// 限价，用于限价单和止损限价单
+	LimitPrice decimal.Decimal
+	// 触发价，用于止损单、止损限价单和止盈单
+	StopPrice decimal.Decimal
+	// 跟踪距离（绝对价差），用于跟踪止损单
+	TrailingDelta decimal.Decimal
+	// 跟踪比例（百分比，例如 2 表示 2%），跟踪距离为零时使用
+	TrailingPercent decimal.Decimal
+	// OCO 订单的另一条腿，任意一边成交后另一边自动撤销
+	OCO *Order
+	// 下单时间
+	Time time.Time
+}
+
+// newOrder 创建挂单，quantity 的含义与 Buy/Sell 一致：买入为 quote 数量，卖出为 base 数量
+func newOrder(orderType types.OrderType, side types.SignalType, quantity decimal.Decimal) *Order {
+	var order = &Order{Type: orderType, Side: side, Time: time.Now()}
+	if side.IsBuy() {
+		order.Amount = quantity
+	} else {
+		order.Volume = quantity
+	}
+	return order
+}
+
+// LimitOrder 创建限价单
+func LimitOrder(side types.SignalType, quantity, limitPrice decimal.Decimal) *Order {
+	order := newOrder(types.OrderTypeLimit, side, quantity)
+	order.LimitPrice = limitPrice
+	return order
+}
+
+// StopOrder 创建止损单
+func StopOrder(side types.SignalType, quantity, stopPrice decimal.Decimal) *Order {
+	order := newOrder(types.OrderTypeStop, side, quantity)
+	order.StopPrice = stopPrice
+	return order
+}
+
+// StopLimitOrder 创建止损限价单
+func StopLimitOrder(side types.SignalType, quantity, stopPrice, limitPrice decimal.Decimal) *Order {
+	order := newOrder(types.OrderTypeStopLimit, side, quantity)
+	order.StopPrice = stopPrice
+	order.LimitPrice = limitPrice
+	return order
+}
+
+// TakeProfitOrder 创建止盈单
+func TakeProfitOrder(side types.SignalType, quantity, triggerPrice decimal.Decimal) *Order {
+	order := newOrder(types.OrderTypeTakeProfit, side, quantity)
+	order.StopPrice = triggerPrice
+	return order
+}
+
+// TrailingStopOrder 创建跟踪止损单，delta 为零时使用 percent
+func TrailingStopOrder(side types.SignalType, quantity, delta, percent decimal.Decimal) *Order {
+	order := newOrder(types.OrderTypeTrailingStop, side, quantity)
+	order.TrailingDelta = delta
+	order.TrailingPercent = percent
+	return order
+}
+
+// OCOOrder 将两个挂单组成 OCO 订单，返回第一条腿
+func OCOOrder(a, b *Order) *Order {
+	a.OCO = b
+	b.OCO = a
+	return a
+}
+
+// TrailingStopPrice 根据最有利价格计算跟踪止损的触发价
+func (o *Order) TrailingStopPrice(extreme decimal.Decimal) decimal.Decimal {
+	var distance = o.TrailingDelta
+	if distance.IsZero() {
+		distance = extreme.Mul(o.TrailingPercent).Div(decimal.NewFromInt(100))
+	}
+
+	// 卖出跟踪止损在最高价下方，买入跟踪止损在最低价上方
+	if o.Side.IsSell() {
+		return extreme.Sub(distance)
+	}
+	return extreme.Add(distance)
+}
+
+// PlaceOrders 为挂单分配编号并附加到信号上
+func (s *BaseStrategy) PlaceOrders(signal *Signal, orders ...*Order) *Signal {
+	for _, order := range orders {
+		s.assignOrderID(order)
+		signal.Orders = append(signal.Orders, order)
+	}
+	return signal
+}
+
+// CancelOrders 在信号上附加需要撤销的挂单编号
+func (s *BaseStrategy) CancelOrders(signal *Signal, ids ...int64) *Signal {
+	signal.Cancels = append(signal.Cancels, ids...)
+	return signal
+}
+
+// PlaceStops 撤销当前的止损止盈单，为持仓挂出新的止损止盈单，两者都设置时组成 OCO 订单
+// 挂单编号由 BaseStrategy 记录：挂单成交后清除，仓位被强平时随强平信号撤销
+func (s *BaseStrategy) PlaceStops(signal *Signal, stop, takeProfit *Order) *Signal {
+	s.CancelStops(signal)
+
+	var order *Order
+	switch {
+	case stop != nil && takeProfit != nil:
+		order = OCOOrder(stop, takeProfit)
+	case stop != nil:
+		order = stop
+	case takeProfit != nil:
+		order = takeProfit
+	default:
+		return signal
+	}
+	s.PlaceOrders(signal, order)
+	s.stopOrderID = order.ID
+	return signal
+}
+
+// CancelStops 撤销当前的止损止盈单，OCO 的另一条腿一并撤销
+func (s *BaseStrategy) CancelStops(signal *Signal) *Signal {
+	if s.stopOrderID == 0 {
+		return signal
+	}
+
+	s.CancelOrders(signal, s.stopOrderID)
+	s.stopOrderID = 0
+	return signal
+}
+
+// isStopOrder 是否为当前的止损止盈单或其 OCO 的另一条腿
+func (s *BaseStrategy) isStopOrder(order *Order) bool {
+	return s.stopOrderID != 0 && (order.ID == s.stopOrderID || order.OCO != nil && order.OCO.ID == s.stopOrderID)
+}
+
+func (s *BaseStrategy) assignOrderID(order *Order) {
+	if order.ID == 0 {
+		s.orderID++
+		order.ID = s.orderID
+	}
+
+	if order.OCO != nil && order.OCO.ID == 0 {
+		s.orderID++
+		order.OCO.ID = s.orderID
+	}
+}
+
+// Fill 挂单成交回调，按成交价更新余额和持仓，止损止盈单成交后清除其编号
+// 买入金额超过余额、或现货账户卖出数量超过持仓，按可用部分成交，无可用部分时返回 nil
+func (s *BaseStrategy) Fill(order *Order, price decimal.Decimal) *Signal {
+	var signal *Signal
+	if order.Side.IsBuy() {
+		amount := order.Amount
+		if amount.IsZero() {
+			amount = order.Volume.Mul(price)
+		}
+		// 合约的买入金额为名义价值，保证金是否足够由开仓时检查
+		if s.futures == nil && amount.GreaterThan(s.balance.Amount) {
+			amount = s.balance.Amount
+		}
+		if !amount.IsPositive() {
+			return nil
+		}
+		signal = s.Buy(amount, price)
+	} else {
+		volume := order.Volume
+		if volume.IsZero() {
+			volume = order.Amount.Div(price)
+		}
+		// 现货账户不能卖出超过持仓的部分
+		if s.margin == nil && s.futures == nil && volume.GreaterThan(s.position.Amount) {
+			volume = s.position.Amount
+		}
+		if !volume.IsPositive() {
+			return nil
+		}
+		signal = s.Sell(volume, price)
+	}
+
+	if signal != nil {
+		signal.Order = order
+		if s.isStopOrder(order) {
+			s.stopOrderID = 0
+		}
+	}
+	return signal
+}
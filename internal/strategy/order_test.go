@@ -0,0 +1,71 @@
+package strategy
+
+import (
+	"context"
+	"snake/internal/kline"
+	"snake/internal/types"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestStops(t *testing.T) {
+	newStrategy := func() *BaseStrategy {
+		ctx, cancel := context.WithCancel(context.Background())
+		s := NewBaseStrategy(ctx, cancel, "stops")
+		_ = s.Init(decimal.Zero, decimal.NewFromInt(1000))
+		return s
+	}
+	price := decimal.NewFromInt(100)
+
+	t.Run("止损止盈组成 OCO 订单，重新挂单时撤销旧单", func(t *testing.T) {
+		s := newStrategy()
+		signal := s.Buy(decimal.NewFromInt(500), price)
+		s.PlaceStops(signal,
+			StopOrder(types.SignalTypeSell, s.Position().Amount, decimal.NewFromInt(90)),
+			TakeProfitOrder(types.SignalTypeSell, s.Position().Amount, decimal.NewFromInt(120)))
+		if len(signal.Orders) != 1 || signal.Orders[0].OCO == nil {
+			t.Fatalf("预期挂出一个 OCO 订单，实际为 %v", signal.Orders)
+		}
+		first := signal.Orders[0]
+
+		signal = s.Buy(decimal.NewFromInt(100), price)
+		s.PlaceStops(signal, StopOrder(types.SignalTypeSell, s.Position().Amount, decimal.NewFromInt(95)), nil)
+		if len(signal.Cancels) != 1 || signal.Cancels[0] != first.ID {
+			t.Errorf("预期撤销旧的挂单 %d，实际撤销 %v", first.ID, signal.Cancels)
+		}
+		if len(signal.Orders) != 1 || signal.Orders[0].OCO != nil {
+			t.Errorf("预期只挂出止损单，实际为 %v", signal.Orders)
+		}
+	})
+
+	t.Run("OCO 另一条腿成交后清除挂单", func(t *testing.T) {
+		s := newStrategy()
+		signal := s.Buy(decimal.NewFromInt(500), price)
+		s.PlaceStops(signal,
+			StopOrder(types.SignalTypeSell, s.Position().Amount, decimal.NewFromInt(90)),
+			TakeProfitOrder(types.SignalTypeSell, s.Position().Amount, decimal.NewFromInt(120)))
+
+		if fill := s.Fill(signal.Orders[0].OCO, decimal.NewFromInt(120)); fill == nil {
+			t.Fatalf("预期止盈单成交")
+		}
+		if signal := s.CancelStops(s.Hold()); len(signal.Cancels) != 0 {
+			t.Errorf("预期成交后没有挂单，实际撤销 %v", signal.Cancels)
+		}
+	})
+
+	t.Run("强平时撤销挂单", func(t *testing.T) {
+		s := newStrategy()
+		s.SetFutures(&FuturesConfig{Leverage: decimal.NewFromInt(10), MaintenanceMarginRate: decimal.NewFromFloat(0.004)})
+		signal := s.Buy(decimal.NewFromInt(1000), price)
+		s.PlaceStops(signal, nil, TakeProfitOrder(types.SignalTypeSell, s.Position().Amount, decimal.NewFromInt(120)))
+
+		liquidation := s.Mark(&kline.Kline{O: price, H: price, L: decimal.NewFromInt(80), C: decimal.NewFromInt(85)})
+		if liquidation == nil || !liquidation.Liquidation {
+			t.Fatalf("预期被强平")
+		}
+		if len(liquidation.Cancels) != 1 || liquidation.Cancels[0] != signal.Orders[0].ID {
+			t.Errorf("预期随强平信号撤销挂单，实际撤销 %v", liquidation.Cancels)
+		}
+	})
+}
@@ -7,5 +7,6 @@ import (
 )
 
 type KlineRepository interface {
-	GetKlines(ctx context.Context, interval interval.Interval, from int64) <-chan *kline.Kline
+	// GetKlines 订阅K线事件，区分当前K线的更新和K线收盘
+	GetKlines(ctx context.Context, interval interval.Interval, from int64) <-chan *kline.Event
 }
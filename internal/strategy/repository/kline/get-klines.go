@@ -18,10 +18,13 @@ import (
 
 type Kline = k.Kline
 
-func (r *Repository) GetKlines(ctx context.Context, interval interval.Interval, from int64) <-chan *Kline {
-	var ch = make(chan *k.Kline, 100)
+// GetKlines 订阅K线事件：先推送 from 之后的历史K线（收盘事件），
+// 再把实时推送转换为当前K线的更新事件，收到下一根K线时推送上一根的收盘事件
+func (r *Repository) GetKlines(ctx context.Context, interval interval.Interval, from int64) <-chan *k.Event {
+	var ch = make(chan *k.Event, 100)
 
 	var klineInited bool
+	var tracker = k.NewBarTracker()
 	client := client.New(
 		client.WithURL(r.wsEndpoint),
 		client.WithContext(ctx),
@@ -68,15 +71,17 @@ func (r *Repository) GetKlines(ctx context.Context, interval interval.Interval,
 					return nil
 				}
 
-				slice.From(result.Data.List...).Iter(func(k int, v *k.Kline) (bool, error) {
-					ch <- v
+				slice.From(result.Data.List...).Iter(func(_ int, v *k.Kline) (bool, error) {
+					ch <- k.CloseEvent(v)
 					return true, nil
 				})
 
 				klineInited = true
 			}
 
-			ch <- &line
+			for _, event := range tracker.Push(&line) {
+				ch <- event
+			}
 			return nil
 		}),
 	)
@@ -0,0 +1,59 @@
+package strategy
+
+import (
+	"snake/internal/kline"
+	"snake/internal/risk"
+	"snake/internal/types"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// SetRisk 设置风控管理器，nil 表示不做账户级风控
+func (s *BaseStrategy) SetRisk(manager *risk.Manager) {
+	s.risk = manager
+}
+
+// checkRisk 交易前检查风控规则，返回允许的 base 数量
+// 数量被缩减时返回风控结果，被拒绝时返回 false
+func (s *BaseStrategy) checkRisk(side types.SignalType, volume, price decimal.Decimal) (decimal.Decimal, *risk.Decision, bool) {
+	if s.risk == nil {
+		return volume, nil, true
+	}
+
+	decision := s.risk.Check(&risk.Order{
+		Side:     side,
+		Volume:   volume,
+		Price:    price,
+		Position: s.position.Amount,
+		Equity:   s.Equity(price),
+	})
+
+	switch decision.Action {
+	case risk.ActionReject:
+		return decimal.Zero, decision, false
+	case risk.ActionResize:
+		return decision.Volume, decision, true
+	}
+	return volume, nil, true
+}
+
+// markRisk 按K线时间和收盘价净值更新风控状态
+func (s *BaseStrategy) markRisk(kline *kline.Kline) {
+	if s.risk == nil {
+		return
+	}
+	s.risk.Mark(time.UnixMilli(kline.E), s.Equity(kline.C))
+}
+
+// withRisk 交易执行后记录风控结果，并计入下单次数
+func (s *BaseStrategy) withRisk(signal *Signal, decision *risk.Decision) *Signal {
+	if signal == nil {
+		return nil
+	}
+	signal.Risk = decision
+	if s.risk != nil && signal.Volume.IsPositive() {
+		s.risk.Record()
+	}
+	return signal
+}
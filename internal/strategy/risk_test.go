@@ -0,0 +1,90 @@
+package strategy
+
+import (
+	"context"
+	"snake/internal/kline"
+	"snake/internal/risk"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestRisk(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := NewBaseStrategy(ctx, cancel, "risk")
+	_ = s.Init(decimal.Zero, decimal.NewFromInt(10000))
+
+	manager := risk.New(&risk.Config{MaxPositionNotional: decimal.NewFromInt(3000)})
+	s.SetRisk(manager)
+	s.Mark(&kline.Kline{O: decimal.NewFromInt(100), H: decimal.NewFromInt(100), L: decimal.NewFromInt(100), C: decimal.NewFromInt(100), E: 1})
+
+	t.Run("买入被缩减到持仓上限", func(t *testing.T) {
+		signal := s.Buy(decimal.NewFromInt(5000), decimal.NewFromInt(100))
+		if signal == nil || signal.Risk == nil {
+			t.Fatalf("预期买入被缩减")
+		}
+		if !s.Position().Amount.Equal(decimal.NewFromInt(30)) {
+			t.Errorf("预期持仓 30，实际为 %s", s.Position().Amount)
+		}
+		if !s.Balance().Amount.Equal(decimal.NewFromInt(7000)) {
+			t.Errorf("预期余额 7000，实际为 %s", s.Balance().Amount)
+		}
+	})
+
+	t.Run("持仓已满时拒绝买入", func(t *testing.T) {
+		if signal := s.Buy(decimal.NewFromInt(100), decimal.NewFromInt(100)); signal != nil {
+			t.Fatalf("预期买入被拒绝")
+		}
+		if len(manager.Decisions()) != 2 {
+			t.Errorf("预期记录 2 次风控处理，实际为 %d", len(manager.Decisions()))
+		}
+	})
+
+	t.Run("卖出平仓不受限制", func(t *testing.T) {
+		signal := s.Sell(decimal.NewFromInt(30), decimal.NewFromInt(100))
+		if signal == nil || signal.Risk != nil {
+			t.Fatalf("预期卖出直接放行")
+		}
+	})
+}
+
+func TestRiskOrdersPerHour(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := NewBaseStrategy(ctx, cancel, "risk")
+	_ = s.Init(decimal.Zero, decimal.NewFromInt(1000))
+
+	s.SetRisk(risk.New(&risk.Config{MaxOrdersPerHour: 1}))
+	s.Mark(&kline.Kline{O: decimal.NewFromInt(100), H: decimal.NewFromInt(100), L: decimal.NewFromInt(100), C: decimal.NewFromInt(100), E: 1})
+
+	// 余额不足未成交的订单不计入下单次数
+	if signal := s.Buy(decimal.NewFromInt(5000), decimal.NewFromInt(100)); signal != nil {
+		t.Fatalf("预期余额不足时买入失败")
+	}
+	if signal := s.Buy(decimal.NewFromInt(500), decimal.NewFromInt(100)); signal == nil {
+		t.Fatalf("预期第一笔成交的买入被放行")
+	}
+	if signal := s.Buy(decimal.NewFromInt(100), decimal.NewFromInt(100)); signal != nil {
+		t.Errorf("预期超过下单次数后拒绝买入")
+	}
+}
+
+func TestRiskTransfer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := NewBaseStrategy(ctx, cancel, "risk")
+	_ = s.Init(decimal.Zero, decimal.NewFromInt(1000))
+
+	manager := risk.New(&risk.Config{MaxDrawdown: decimal.NewFromFloat(0.2)})
+	s.SetRisk(manager)
+	k := &kline.Kline{O: decimal.NewFromInt(100), H: decimal.NewFromInt(100), L: decimal.NewFromInt(100), C: decimal.NewFromInt(100), E: 1}
+	s.Mark(k)
+
+	// 组合再平衡转出资金后不应触发回撤熔断
+	s.Transfer(decimal.NewFromInt(-300))
+	s.Mark(k)
+	if manager.Halted() {
+		t.Fatalf("预期转出资金不触发回撤熔断")
+	}
+	if signal := s.Buy(decimal.NewFromInt(100), decimal.NewFromInt(100)); signal == nil {
+		t.Errorf("预期转出资金后仍可以买入")
+	}
+}
@@ -3,6 +3,7 @@ package bollingmacd
 import (
 	"context"
 	bollingband "snake/internal/indicates/bolling-band"
+	"snake/internal/indicates/ma"
 	"snake/internal/indicates/macd"
 	"snake/internal/kline"
 	"snake/internal/strategy"
@@ -13,60 +14,75 @@ import (
 // BollingMACDStrategy 布林带-MACD联合策略
 type BollingMACDStrategy struct {
 	*strategy.BaseStrategy
-	// 保存历史K线用于计算指标
-	historicalKlines []*kline.Kline
 	// 布林带参数
 	bbPeriod int
+	bbMAType ma.Type
 	// MACD参数
 	fastEMAPeriod int
 	slowEMAPeriod int
 	signalPeriod  int
-	// 当前指标
-	bb   *bollingband.BB
-	macd *macd.MACD
+	macdMAType    ma.Type
+	// 当前指标，第一次更新时按参数创建
+	bb   *bollingband.Stream
+	macd *macd.Stream
 }
 
 // New 创建布林带-MACD联合策略
 func New(ctx context.Context, cancel context.CancelFunc) *BollingMACDStrategy {
 	return &BollingMACDStrategy{
-		BaseStrategy:     strategy.NewBaseStrategy(ctx, cancel, "Bolling-MACD Strategy"),
-		historicalKlines: make([]*kline.Kline, 0, 60), // 预分配足够容量
-		bbPeriod:         20,
-		fastEMAPeriod:    12,
-		slowEMAPeriod:    26,
-		signalPeriod:     9,
+		BaseStrategy:  strategy.NewBaseStrategy(ctx, cancel, "Bolling-MACD Strategy"),
+		bbPeriod:      20,
+		bbMAType:      ma.TypeSMA,
+		fastEMAPeriod: 12,
+		slowEMAPeriod: 26,
+		signalPeriod:  9,
+		macdMAType:    ma.TypeEMA,
 	}
 }
 
-// Update 更新策略状态
-func (s *BollingMACDStrategy) Update(kline *kline.Kline) (*strategy.Signal, error) {
-	// 添加新的K线到历史数据
-	s.historicalKlines = append(s.historicalKlines, kline)
-
-	// 如果历史数据不足以计算指标，则持有
-	requiredPeriod := s.bbPeriod
-	if s.slowEMAPeriod+s.signalPeriod > requiredPeriod {
-		requiredPeriod = s.slowEMAPeriod + s.signalPeriod
+// SetBollingParams 设置布林带周期和中轨的移动平均线类型
+func (s *BollingMACDStrategy) SetBollingParams(period int, maType ma.Type) error {
+	if _, err := bollingband.NewStreamWithMA(maType, period, decimal.NewFromInt(2)); err != nil {
+		return err
 	}
 
-	if len(s.historicalKlines) < requiredPeriod {
-		return s.Hold(), nil
-	}
+	s.bbPeriod = period
+	s.bbMAType = maType
+	// 重置指标
+	s.bb = nil
+	return nil
+}
 
-	// 保持历史数据长度不超过需要的最大长度
-	if len(s.historicalKlines) > requiredPeriod {
-		s.historicalKlines = s.historicalKlines[len(s.historicalKlines)-requiredPeriod:]
+// SetMACDParams 设置MACD周期和使用的移动平均线类型
+func (s *BollingMACDStrategy) SetMACDParams(fastPeriod, slowPeriod, signalPeriod int, maType ma.Type) error {
+	if _, err := macd.NewStreamWithMA(maType, fastPeriod, slowPeriod, signalPeriod); err != nil {
+		return err
 	}
 
-	// 计算布林带
-	bbKlines := s.historicalKlines[len(s.historicalKlines)-s.bbPeriod:]
-	s.bb = bollingband.New(bbKlines...)
+	s.fastEMAPeriod = fastPeriod
+	s.slowEMAPeriod = slowPeriod
+	s.signalPeriod = signalPeriod
+	s.macdMAType = maType
+	// 重置指标
+	s.macd = nil
+	return nil
+}
 
-	// 计算MACD
-	s.macd = macd.NewWithParams(s.fastEMAPeriod, s.slowEMAPeriod, s.signalPeriod, s.historicalKlines...)
+// Update 更新策略状态
+func (s *BollingMACDStrategy) Update(kline *kline.Kline) (*strategy.Signal, error) {
+	if s.bb == nil {
+		s.bb, _ = bollingband.NewStreamWithMA(s.bbMAType, s.bbPeriod, decimal.NewFromInt(2))
+	}
+	if s.macd == nil {
+		s.macd, _ = macd.NewStreamWithMA(s.macdMAType, s.fastEMAPeriod, s.slowEMAPeriod, s.signalPeriod)
+	}
+
+	// 增量更新指标
+	s.bb.Update(kline)
+	s.macd.Update(kline)
 
-	// 如果无法计算指标，则持有
-	if s.bb == nil || s.macd == nil {
+	// 如果历史数据不足以计算指标，则持有
+	if !s.bb.Ready() || !s.macd.Ready() {
 		return s.Hold(), nil
 	}
 
@@ -89,15 +105,16 @@ func (s *BollingMACDStrategy) getSignal(kline *kline.Kline) *strategy.Signal {
 	tradeAmount := s.Position().Amount.Mul(decimal.NewFromFloat(0.05))
 	// 如果仓位为0，则使用余额的5%
 	if s.Position().Amount.IsZero() {
-		tradeAmount = s.Balance().Amount.Mul(decimal.NewFromFloat(0.05))
+		tradeAmount = s.BuyingPower().Mul(decimal.NewFromFloat(0.05))
 	}
 
 	// 买入条件：
 	// 1. 价格低于布林带下轨
 	// 2. MACD直方图由负变正（MACD金叉死叉）
-	if kline.C.LessThan(s.bb.Lower) &&
-		s.macd.Histogram.IsPositive() &&
-		s.macd.MACD.GreaterThan(s.macd.Signal) {
+	bb, macd := s.bb.Values(), s.macd.Values()
+	if kline.C.LessThan(bb["lower"]) &&
+		macd["histogram"].IsPositive() &&
+		macd["macd"].GreaterThan(macd["signal"]) {
 		signal := s.Buy(tradeAmount, kline.C)
 		if signal != nil {
 			return signal
@@ -107,9 +124,9 @@ func (s *BollingMACDStrategy) getSignal(kline *kline.Kline) *strategy.Signal {
 	// 卖出条件：
 	// 1. 价格高于布林带上轨
 	// 2. MACD直方图由正变负（MACD死叉金叉）
-	if kline.C.GreaterThan(s.bb.Upper) &&
-		s.macd.Histogram.IsNegative() &&
-		s.macd.MACD.LessThan(s.macd.Signal) {
+	if kline.C.GreaterThan(bb["upper"]) &&
+		macd["histogram"].IsNegative() &&
+		macd["macd"].LessThan(macd["signal"]) {
 		signal := s.Sell(tradeAmount, kline.C)
 		if signal != nil {
 			return signal
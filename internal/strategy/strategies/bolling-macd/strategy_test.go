@@ -2,6 +2,7 @@ package bollingmacd
 
 import (
 	"context"
+	"snake/internal/indicates/ma"
 	"snake/internal/kline"
 	"snake/internal/types"
 	"testing"
@@ -106,6 +107,28 @@ func TestUpdateSignalGeneration(t *testing.T) {
 		"Signal should be one of the valid types")
 }
 
+// 测试设置移动平均线类型
+func TestSetParams(t *testing.T) {
+	strategy := New(context.WithCancel(context.TODO()))
+	assert.NoError(t, strategy.SetBollingParams(20, ma.TypeEMA))
+	assert.NoError(t, strategy.SetMACDParams(12, 26, 9, ma.TypeHMA))
+	assert.Error(t, strategy.SetBollingParams(20, ma.Type("VWMA")))
+	assert.Error(t, strategy.SetMACDParams(12, 0, 9, ma.TypeEMA))
+	assert.Equal(t, ma.TypeEMA, strategy.bbMAType)
+	assert.Equal(t, 26, strategy.slowEMAPeriod)
+
+	err := strategy.Init(decimal.Zero, decimal.NewFromInt(1000))
+	assert.NoError(t, err, "Init should not return error")
+
+	for _, k := range createTestKlines(60) {
+		_, err := strategy.Update(k)
+		assert.NoError(t, err, "Update should not return error")
+	}
+	assert.Equal(t, "BB20_EMA", strategy.bb.Name())
+	assert.Equal(t, "MACD12_26_9_HMA", strategy.macd.Name())
+	assert.True(t, strategy.bb.Ready() && strategy.macd.Ready(), "Indicators should be ready")
+}
+
 // 测试策略盈亏计算
 func TestProfit(t *testing.T) {
 	strategy := New(context.WithCancel(context.TODO()))
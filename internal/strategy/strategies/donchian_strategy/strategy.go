@@ -2,6 +2,8 @@ package donchian_strategy
 
 import (
 	"context"
+	"fmt"
+	"snake/internal/indicates/atr"
 	donchianchannel "snake/internal/indicates/donchian-channel"
 	"snake/internal/kline"
 	"snake/internal/strategy"
@@ -12,35 +14,41 @@ import (
 // DonchianStrategy 基于唐奇安通道的交易策略
 type DonchianStrategy struct {
 	*strategy.BaseStrategy
-	// 保存历史K线用于计算指标
-	historicalKlines []*kline.Kline
+	// 已处理的K线数量
+	bars int
 	// 唐奇安通道参数
 	breakoutPeriod int             // 突破周期（默认20）
 	exitPeriod     int             // 退出周期（默认10）
 	riskPercent    decimal.Decimal // 风险百分比（每笔交易的风险）
-	// 唐奇安通道指标
-	breakoutChannel *donchianchannel.DC // 用于入场信号的通道
-	exitChannel     *donchianchannel.DC // 用于出场信号的通道
+	// ATR止损参数
+	atrPeriod     int             // ATR周期（默认20）
+	atrMultiplier decimal.Decimal // 止损距离为ATR的倍数（默认2）
+	// 唐奇安通道指标，第一次更新时按周期创建
+	breakoutChannel *donchianchannel.Stream // 用于入场信号的通道
+	exitChannel     *donchianchannel.Stream // 用于出场信号的通道
+	atrIndicator    *atr.Stream             // 用于止损和仓位计算的ATR
 	// 策略状态
-	position string // "long", "short", "none"
+	position  string          // "long", "short", "none"
+	stopPrice decimal.Decimal // 入场时按ATR设置的止损价，为0表示未设置
 }
 
 // New 创建唐奇安通道策略
 func New(ctx context.Context, cancel context.CancelFunc) *DonchianStrategy {
 	return &DonchianStrategy{
-		BaseStrategy:     strategy.NewBaseStrategy(ctx, cancel, "Donchian Channel Strategy"),
-		historicalKlines: make([]*kline.Kline, 0, 50), // 预分配足够容量
-		breakoutPeriod:   20,
-		exitPeriod:       10,
-		riskPercent:      decimal.NewFromFloat(1.0), // 1%风险
-		position:         "none",
+		BaseStrategy:   strategy.NewBaseStrategy(ctx, cancel, "Donchian Channel Strategy"),
+		breakoutPeriod: 20,
+		exitPeriod:     10,
+		riskPercent:    decimal.NewFromFloat(1.0), // 1%风险
+		atrPeriod:      20,
+		atrMultiplier:  decimal.NewFromInt(2),
+		position:       "none",
 	}
 }
 
 // Update 更新策略状态
 func (s *DonchianStrategy) Update(kline *kline.Kline) (*strategy.Signal, error) {
-	// 添加新的K线到历史数据
-	s.historicalKlines = append(s.historicalKlines, kline)
+	// 增量更新唐奇安通道指标
+	s.updateIndicators(kline)
 
 	// 如果历史数据不足以计算指标，则持有
 	requiredBars := s.breakoutPeriod
@@ -48,13 +56,10 @@ func (s *DonchianStrategy) Update(kline *kline.Kline) (*strategy.Signal, error)
 		requiredBars = s.exitPeriod
 	}
 
-	if len(s.historicalKlines) <= requiredBars {
+	if s.bars <= requiredBars {
 		return s.Hold(), nil
 	}
 
-	// 计算唐奇安通道指标
-	s.calculateIndicators()
-
 	// 获取当前价格（收盘价）
 	currentPrice := kline.C
 
@@ -66,11 +71,13 @@ func (s *DonchianStrategy) Update(kline *kline.Kline) (*strategy.Signal, error)
 		println("当前持仓盈亏：", absolute.String(), "USDT (", percentage.String(), "%)")
 	}
 
+	breakout, exit := s.breakoutChannel.Values(), s.exitChannel.Values()
+
 	// 打印当前通道值
-	println("突破通道上轨: ", s.breakoutChannel.Upper.String())
-	println("突破通道下轨: ", s.breakoutChannel.Lower.String())
-	println("退出通道上轨: ", s.exitChannel.Upper.String())
-	println("退出通道下轨: ", s.exitChannel.Lower.String())
+	println("突破通道上轨: ", breakout["upper"].String())
+	println("突破通道下轨: ", breakout["lower"].String())
+	println("退出通道上轨: ", exit["upper"].String())
+	println("退出通道下轨: ", exit["lower"].String())
 
 	// 计算交易数量（基于风险管理）
 	tradeAmount := s.calculatePositionSize(currentPrice)
@@ -79,47 +86,49 @@ func (s *DonchianStrategy) Update(kline *kline.Kline) (*strategy.Signal, error)
 	switch s.position {
 	case "none":
 		// 无持仓状态，检查是否应该入场
-		if s.breakoutChannel.IsBuySignal(currentPrice) {
+		if currentPrice.GreaterThanOrEqual(breakout["upper"]) {
 			// 价格突破上轨，买入做多
-			s.position = "long"
 			// 计算买入数量（使用当前价格的USDT数量）
 			usdtAmount := tradeAmount.Mul(currentPrice)
 			signal := s.Buy(usdtAmount, currentPrice)
 			if signal != nil {
+				s.position = "long"
+				s.stopPrice = s.stopLevel(currentPrice, true)
 				return signal, nil
 			}
-		} else if currentPrice.LessThanOrEqual(s.breakoutChannel.Lower) {
-			// 价格突破下轨，卖出做空
-			s.position = "short"
-			// 计算卖出数量（使用当前价格的USDT数量）
-			usdtAmount := tradeAmount.Mul(currentPrice)
-			signal := s.Sell(usdtAmount, currentPrice)
+		} else if currentPrice.LessThanOrEqual(breakout["lower"]) {
+			// 价格突破下轨，卖出做空，未开启保证金时无法做空
+			signal := s.Sell(tradeAmount, currentPrice)
 			if signal != nil {
+				s.position = "short"
+				s.stopPrice = s.stopLevel(currentPrice, false)
 				return signal, nil
 			}
 		}
 	case "long":
 		// 做多状态，检查是否应该退出
-		if currentPrice.LessThanOrEqual(s.exitChannel.Lower) {
-			// 价格跌破退出通道下轨，平多
+		stopped := !s.stopPrice.IsZero() && currentPrice.LessThanOrEqual(s.stopPrice)
+		if stopped || currentPrice.LessThanOrEqual(exit["lower"]) {
+			// 价格跌破退出通道下轨或ATR止损价，平多
 			totalPosition := s.Position().Amount
 			if !totalPosition.IsZero() {
-				s.position = "none"
 				signal := s.Sell(totalPosition, currentPrice)
 				if signal != nil {
+					s.resetPosition()
 					return signal, nil
 				}
 			}
 		}
 	case "short":
 		// 做空状态，检查是否应该退出
-		if currentPrice.GreaterThanOrEqual(s.exitChannel.Upper) {
-			// 价格突破退出通道上轨，平空
+		stopped := !s.stopPrice.IsZero() && currentPrice.GreaterThanOrEqual(s.stopPrice)
+		if stopped || currentPrice.GreaterThanOrEqual(exit["upper"]) {
+			// 价格突破退出通道上轨或ATR止损价，买入回补空头
 			totalPosition := s.Position().Amount
-			if !totalPosition.IsZero() {
-				s.position = "none"
-				signal := s.Buy(totalPosition, currentPrice)
+			if totalPosition.IsNegative() {
+				signal := s.Buy(totalPosition.Neg().Mul(currentPrice), currentPrice)
 				if signal != nil {
+					s.resetPosition()
 					return signal, nil
 				}
 			}
@@ -130,39 +139,67 @@ func (s *DonchianStrategy) Update(kline *kline.Kline) (*strategy.Signal, error)
 	return s.Hold(), nil
 }
 
-// calculateIndicators 计算唐奇安通道指标
-func (s *DonchianStrategy) calculateIndicators() {
-	// 确保有足够的历史数据
-	if len(s.historicalKlines) <= s.breakoutPeriod {
-		return
+// resetPosition 平仓成交后重置持仓状态
+func (s *DonchianStrategy) resetPosition() {
+	s.position = "none"
+	s.stopPrice = decimal.Zero
+}
+
+// updateIndicators 推入K线，增量更新唐奇安通道指标
+func (s *DonchianStrategy) updateIndicators(kline *kline.Kline) {
+	if s.breakoutChannel == nil || s.exitChannel == nil || s.atrIndicator == nil {
+		s.breakoutChannel = donchianchannel.NewStream(s.breakoutPeriod)
+		s.exitChannel = donchianchannel.NewStream(s.exitPeriod)
+		s.atrIndicator = atr.NewStream(s.atrPeriod)
+		s.bars = 0
 	}
 
-	// 计算突破通道
-	s.breakoutChannel = donchianchannel.NewWithPeriod(s.breakoutPeriod, s.historicalKlines...)
+	s.bars++
+	s.breakoutChannel.Update(kline)
+	s.exitChannel.Update(kline)
+	s.atrIndicator.Update(kline)
+}
+
+// atrStopDistance 返回ATR止损距离，ATR未就绪时返回0
+func (s *DonchianStrategy) atrStopDistance() decimal.Decimal {
+	if s.atrIndicator == nil || !s.atrIndicator.Ready() {
+		return decimal.Zero
+	}
+	return s.atrIndicator.Value().Mul(s.atrMultiplier)
+}
 
-	// 计算退出通道
-	s.exitChannel = donchianchannel.NewWithPeriod(s.exitPeriod, s.historicalKlines...)
+// stopLevel 计算入场价对应的ATR止损价，ATR未就绪时返回0，只使用通道出场
+func (s *DonchianStrategy) stopLevel(entryPrice decimal.Decimal, long bool) decimal.Decimal {
+	distance := s.atrStopDistance()
+	if distance.IsZero() {
+		return decimal.Zero
+	}
+	if long {
+		return entryPrice.Sub(distance)
+	}
+	return entryPrice.Add(distance)
 }
 
 // calculatePositionSize 计算仓位大小
 func (s *DonchianStrategy) calculatePositionSize(currentPrice decimal.Decimal) decimal.Decimal {
 	// 获取账户总价值
-	balance := s.Balance().Amount
-	positionValue := s.Position().Amount.Mul(currentPrice)
-	accountValue := balance.Add(positionValue)
+	accountValue := s.Equity(currentPrice)
 
 	// 根据风险百分比计算能够承受的风险金额
 	riskAmount := accountValue.Mul(s.riskPercent.Div(decimal.NewFromInt(100)))
 
-	// 计算止损距离
+	// 计算止损距离，优先使用ATR
 	var stopDistance decimal.Decimal
-	if s.exitChannel != nil {
-		if s.breakoutChannel.Upper.LessThan(currentPrice) {
+	if distance := s.atrStopDistance(); !distance.IsZero() {
+		stopDistance = distance
+	} else if s.exitChannel != nil && s.exitChannel.Ready() {
+		breakout, exit := s.breakoutChannel.Values(), s.exitChannel.Values()
+		if breakout["upper"].LessThan(currentPrice) {
 			// 做多，止损是退出通道的下轨
-			stopDistance = currentPrice.Sub(s.exitChannel.Lower)
-		} else if s.breakoutChannel.Lower.GreaterThan(currentPrice) {
+			stopDistance = currentPrice.Sub(exit["lower"])
+		} else if breakout["lower"].GreaterThan(currentPrice) {
 			// 做空，止损是退出通道的上轨
-			stopDistance = s.exitChannel.Upper.Sub(currentPrice)
+			stopDistance = exit["upper"].Sub(currentPrice)
 		} else {
 			// 默认使用1%的止损距离
 			stopDistance = currentPrice.Mul(decimal.NewFromFloat(0.01))
@@ -200,6 +237,34 @@ func (s *DonchianStrategy) SetParams(breakoutPeriod, exitPeriod int, riskPercent
 	// 重置指标
 	s.breakoutChannel = nil
 	s.exitChannel = nil
+	s.atrIndicator = nil
+}
+
+// SetATRParams 设置ATR止损参数，止损距离为 multiplier * ATR(period)
+func (s *DonchianStrategy) SetATRParams(period int, multiplier decimal.Decimal) error {
+	if period < 1 {
+		return fmt.Errorf("invalid ATR period: %d", period)
+	}
+	if !multiplier.IsPositive() {
+		return fmt.Errorf("invalid ATR multiplier: %s", multiplier)
+	}
+
+	s.atrPeriod = period
+	s.atrMultiplier = multiplier
+	// 重置指标
+	s.breakoutChannel = nil
+	s.exitChannel = nil
+	s.atrIndicator = nil
+	return nil
+}
+
+// Mark 按K线盯市，仓位被强平后重置持仓状态
+func (s *DonchianStrategy) Mark(kline *kline.Kline) *strategy.Signal {
+	signal := s.BaseStrategy.Mark(kline)
+	if signal != nil && signal.Liquidation {
+		s.resetPosition()
+	}
+	return signal
 }
 
 // Profit 返回当前盈亏
@@ -3,6 +3,7 @@ package donchian_strategy
 import (
 	"context"
 	"snake/internal/kline"
+	"snake/internal/strategy"
 	"snake/internal/types"
 	"testing"
 	"time"
@@ -193,3 +194,94 @@ func TestCalculatePositionSize(t *testing.T) {
 	assert.True(t, positionSize.GreaterThan(decimal.Zero))
 	assert.True(t, positionSize.LessThan(decimal.NewFromInt(10)), "仓位不应过大")
 }
+
+// 测试ATR止损参数和仓位计算
+func TestATRStop(t *testing.T) {
+	t.Run("参数设置", func(t *testing.T) {
+		strategy := New(context.WithCancel(context.TODO()))
+		assert.Error(t, strategy.SetATRParams(0, decimal.NewFromInt(2)))
+		assert.Error(t, strategy.SetATRParams(14, decimal.Zero))
+		assert.Equal(t, 20, strategy.atrPeriod)
+
+		assert.NoError(t, strategy.SetATRParams(14, decimal.NewFromFloat(1.5)))
+		assert.Equal(t, 14, strategy.atrPeriod)
+		assert.True(t, decimal.NewFromFloat(1.5).Equal(strategy.atrMultiplier))
+		assert.Nil(t, strategy.atrIndicator)
+	})
+
+	t.Run("ATR就绪后按ATR计算仓位", func(t *testing.T) {
+		strategy := New(context.WithCancel(context.TODO()))
+		strategy.Init(decimal.Zero, decimal.NewFromInt(1000))
+		assert.NoError(t, strategy.SetATRParams(5, decimal.NewFromInt(2)))
+
+		klines := generateTestKlines(time.Now().Unix(), 30)
+		for _, k := range klines {
+			strategy.Update(k)
+		}
+		assert.True(t, strategy.atrIndicator.Ready())
+
+		price := decimal.NewFromInt(100)
+		distance := strategy.atrIndicator.Value().Mul(decimal.NewFromInt(2))
+		assert.True(t, distance.Equal(strategy.atrStopDistance()))
+
+		// 风险金额 / 止损距离 / 价格
+		expected := strategy.Equity(price).Mul(decimal.NewFromFloat(0.01)).Div(distance).Div(price)
+		if expected.LessThan(decimal.NewFromFloat(0.01)) {
+			expected = decimal.NewFromFloat(0.01)
+		}
+		assert.True(t, expected.Equal(strategy.calculatePositionSize(price)), "仓位应为 %s", expected)
+
+		assert.True(t, price.Sub(distance).Equal(strategy.stopLevel(price, true)), "多头止损价应在入场价下方")
+		assert.True(t, price.Add(distance).Equal(strategy.stopLevel(price, false)), "空头止损价应在入场价上方")
+	})
+
+	t.Run("ATR未就绪时不设置止损", func(t *testing.T) {
+		strategy := New(context.WithCancel(context.TODO()))
+		strategy.Init(decimal.Zero, decimal.NewFromInt(1000))
+		for _, k := range generateTestKlines(time.Now().Unix(), 5) {
+			strategy.Update(k)
+		}
+		assert.True(t, strategy.stopLevel(decimal.NewFromInt(100), true).IsZero())
+	})
+}
+
+// 测试交易被拒绝时不改变持仓状态
+func TestRejectedTrade(t *testing.T) {
+	strategy := New(context.WithCancel(context.TODO()))
+	// 余额不足以买入最小交易单位
+	err := strategy.Init(decimal.Zero, decimal.NewFromInt(1))
+	assert.NoError(t, err)
+
+	klines := generateTestKlines(time.Now().Unix(), 22)
+	for i := 0; i < 21; i++ {
+		strategy.Update(klines[i])
+	}
+
+	// 收盘在最高价，突破通道上轨
+	klines[21].H = decimal.NewFromFloat(115.0)
+	klines[21].C = decimal.NewFromFloat(115.0)
+	signal, err := strategy.Update(klines[21])
+	assert.NoError(t, err)
+	assert.True(t, signal.Type.IsHold())
+	assert.Equal(t, "none", strategy.position)
+	assert.True(t, strategy.stopPrice.IsZero())
+}
+
+// 测试空头被强平后重置持仓状态
+func TestLiquidation(t *testing.T) {
+	s := New(context.WithCancel(context.TODO()))
+	s.SetMargin(strategy.DefaultMarginConfig())
+	err := s.Init(decimal.Zero, decimal.NewFromInt(1000))
+	assert.NoError(t, err)
+
+	assert.NotNil(t, s.Sell(decimal.NewFromInt(10), decimal.NewFromInt(100)))
+	s.position = "short"
+	s.stopPrice = decimal.NewFromInt(110)
+
+	// 价格涨过强平价
+	signal := s.Mark(&kline.Kline{O: decimal.NewFromInt(170), H: decimal.NewFromInt(190), L: decimal.NewFromInt(170), C: decimal.NewFromInt(175)})
+	assert.NotNil(t, signal)
+	assert.True(t, signal.Liquidation)
+	assert.Equal(t, "none", s.position)
+	assert.True(t, s.stopPrice.IsZero())
+}
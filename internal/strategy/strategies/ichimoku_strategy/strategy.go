@@ -0,0 +1,97 @@
+package ichimoku_strategy
+
+import (
+	"context"
+	"snake/internal/indicates/ichimoku"
+	"snake/internal/kline"
+	"snake/internal/strategy"
+
+	"github.com/shopspring/decimal"
+)
+
+// IchimokuStrategy 基于一目均衡表的趋势跟踪策略
+//
+// 入场：转换线在基准线上方，收盘价在云的上方，且迟行线高于平移前的价格
+// 出场：转换线下穿基准线，或收盘价跌入云中
+type IchimokuStrategy struct {
+	*strategy.BaseStrategy
+	// 一目均衡表参数
+	tenkanPeriod  int
+	kijunPeriod   int
+	senkouBPeriod int
+	displacement  int
+	// 一目均衡表指标，第一次更新时按参数创建
+	ichimoku *ichimoku.Stream
+}
+
+var _ strategy.Strategy = (*IchimokuStrategy)(nil)
+
+// New 创建一目均衡表策略，使用常用参数 (9, 26, 52, 26)
+func New(ctx context.Context, cancel context.CancelFunc) *IchimokuStrategy {
+	return &IchimokuStrategy{
+		BaseStrategy:  strategy.NewBaseStrategy(ctx, cancel, "Ichimoku Strategy"),
+		tenkanPeriod:  9,
+		kijunPeriod:   26,
+		senkouBPeriod: 52,
+		displacement:  26,
+	}
+}
+
+// SetParams 设置转换线、基准线、先行带 B 的周期和平移的K线数量
+func (s *IchimokuStrategy) SetParams(tenkan, kijun, senkouB, displacement int) error {
+	if _, err := ichimoku.NewStreamWithPeriod(tenkan, kijun, senkouB, displacement); err != nil {
+		return err
+	}
+
+	s.tenkanPeriod = tenkan
+	s.kijunPeriod = kijun
+	s.senkouBPeriod = senkouB
+	s.displacement = displacement
+	// 重置指标
+	s.ichimoku = nil
+	return nil
+}
+
+// Update 更新策略状态并生成交易信号
+func (s *IchimokuStrategy) Update(kline *kline.Kline) (*strategy.Signal, error) {
+	if s.ichimoku == nil {
+		s.ichimoku, _ = ichimoku.NewStreamWithPeriod(s.tenkanPeriod, s.kijunPeriod, s.senkouBPeriod, s.displacement)
+	}
+
+	// 增量更新一目均衡表
+	s.ichimoku.Update(kline)
+
+	// 当前K线对应的云未就绪时持有
+	if !s.ichimoku.Ready() {
+		return s.Hold(), nil
+	}
+
+	price := kline.C
+	values := s.ichimoku.Values()
+
+	// 当前没有持仓
+	if s.Position().Amount.IsZero() {
+		bullish := values["tenkan"].GreaterThan(values["kijun"])
+		if bullish && s.ichimoku.AboveCloud(price) && s.ichimoku.ChikouAbovePrice() {
+			// 生成买入信号，使用95%的余额，按市价买入
+			if signal := s.Buy(s.BuyingPower().Mul(decimal.NewFromFloat(0.95)), price); signal != nil {
+				return signal, nil
+			}
+		}
+		return s.Hold(), nil
+	}
+
+	// 当前有持仓，转换线下穿基准线或价格跌入云中时卖出全部持仓
+	if s.ichimoku.TKCross() == ichimoku.CrossDown || !s.ichimoku.AboveCloud(price) {
+		if signal := s.Sell(s.Position().Amount, price); signal != nil {
+			return signal, nil
+		}
+	}
+
+	return s.Hold(), nil
+}
+
+// Profit 返回当前盈亏
+func (s *IchimokuStrategy) Profit() (absolute, percentage decimal.Decimal) {
+	return s.BaseStrategy.Profit()
+}
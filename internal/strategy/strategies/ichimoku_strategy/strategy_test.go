@@ -0,0 +1,116 @@
+package ichimoku_strategy
+
+import (
+	"context"
+	"math"
+	"snake/internal/kline"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// generateTrendKlines 生成先缓慢下跌、再快速上涨、最后下跌的K线
+func generateTrendKlines(startTime int64) []*kline.Kline {
+	var klines []*kline.Kline
+	price := 120.0
+	for i := range 260 {
+		switch {
+		case i < 90:
+			price -= 0.2
+		case i < 170:
+			price += 0.8
+		default:
+			price -= 1.0
+		}
+		close := price + math.Sin(float64(i)/2)*0.6
+		klines = append(klines, &kline.Kline{
+			O: decimal.NewFromFloat(close - 0.2),
+			C: decimal.NewFromFloat(close),
+			H: decimal.NewFromFloat(close + 0.8),
+			L: decimal.NewFromFloat(close - 0.8),
+			V: decimal.NewFromFloat(1000.0),
+			A: decimal.NewFromFloat(close * 1000.0),
+			S: startTime + int64(i)*60000,
+			E: startTime + int64(i+1)*60000 - 1,
+		})
+	}
+	return klines
+}
+
+func TestIchimokuStrategy(t *testing.T) {
+	klines := generateTrendKlines(time.Now().Unix() * 1000)
+
+	strategy := New(context.WithCancel(context.TODO()))
+	if err := strategy.Init(decimal.Zero, decimal.NewFromInt(10000)); err != nil {
+		t.Fatalf("failed to init strategy: %v", err)
+	}
+
+	var buys, sells []int
+	for i, k := range klines {
+		signal, err := strategy.Update(k)
+		if err != nil {
+			t.Fatalf("failed to update strategy: %v", err)
+		}
+
+		switch {
+		case signal.Type.IsBuy():
+			buys = append(buys, i)
+			values := strategy.ichimoku.Values()
+			if !values["tenkan"].GreaterThan(values["kijun"]) || !strategy.ichimoku.AboveCloud(k.C) || !strategy.ichimoku.ChikouAbovePrice() {
+				t.Errorf("第 %d 根K线买入时预期转换线在基准线上方、价格在云上方且迟行线高于价格", i+1)
+			}
+		case signal.Type.IsSell():
+			sells = append(sells, i)
+		case i+1 < strategy.ichimoku.WarmUp() && !signal.Type.IsHold():
+			t.Fatalf("第 %d 根K线指标未就绪，预期持有", i+1)
+		}
+	}
+
+	if len(buys) == 0 || buys[0] < 90 || buys[0] >= 170 {
+		t.Fatalf("预期在上涨阶段买入，实际买入位置为 %v", buys)
+	}
+	if len(sells) == 0 || sells[len(sells)-1] < 170 {
+		t.Fatalf("预期在下跌阶段卖出，实际卖出位置为 %v", sells)
+	}
+	if !strategy.Position().Amount.IsZero() {
+		t.Errorf("预期最后没有持仓，实际为 %s", strategy.Position().Amount)
+	}
+}
+
+func TestSetParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		periods [4]int
+		warmUp  int
+		wantErr bool
+	}{
+		{name: "常用参数", periods: [4]int{9, 26, 52, 26}, warmUp: 78},
+		{name: "加密货币参数", periods: [4]int{20, 60, 120, 30}, warmUp: 150},
+		{name: "无效周期", periods: [4]int{0, 26, 52, 26}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strategy := New(context.WithCancel(context.TODO()))
+			err := strategy.SetParams(tt.periods[0], tt.periods[1], tt.periods[2], tt.periods[3])
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("预期错误为 %v，实际为 %v", tt.wantErr, err)
+			}
+			if tt.wantErr {
+				if strategy.tenkanPeriod != 9 || strategy.displacement != 26 {
+					t.Errorf("预期参数未被修改")
+				}
+				return
+			}
+
+			if err := strategy.Init(decimal.Zero, decimal.NewFromInt(1000)); err != nil {
+				t.Fatalf("failed to init strategy: %v", err)
+			}
+			strategy.Update(generateTrendKlines(0)[0])
+			if strategy.ichimoku.WarmUp() != tt.warmUp {
+				t.Errorf("预期 WarmUp 为 %d，实际为 %d", tt.warmUp, strategy.ichimoku.WarmUp())
+			}
+		})
+	}
+}
@@ -2,6 +2,8 @@ package ma_cross
 
 import (
 	"context"
+	"fmt"
+	"snake/internal/indicates/adx"
 	"snake/internal/indicates/ma"
 	"snake/internal/kline"
 	"snake/internal/strategy"
@@ -14,52 +16,93 @@ import (
 // MACrossStrategy MA 交叉策略
 type MACrossStrategy struct {
 	*strategy.BaseStrategy
-	// 保存历史K线用于计算MA
-	historicalKlines []*kline.Kline
 	// MA参数
 	ma20Period int
 	ma60Period int
-	// MA指标
-	ma20 *ma.MA
-	ma60 *ma.MA
+	maType     ma.Type
+	// ADX过滤参数，minADX 为0时不过滤
+	adxPeriod int
+	minADX    decimal.Decimal
+	// MA指标，第一次更新时按周期创建
+	ma20 *ma.Stream
+	ma60 *ma.Stream
+	adx  *adx.Stream
 }
 
 // New 创建 MA 交叉策略
 func New(ctx context.Context, cancel context.CancelFunc) *MACrossStrategy {
 	return &MACrossStrategy{
-		BaseStrategy:     strategy.NewBaseStrategy(ctx,cancel, "MA Cross Strategy"),
-		historicalKlines: make([]*kline.Kline, 0, 60), // 预分配足够容量
-		ma20Period:       20,
-		ma60Period:       60,
+		BaseStrategy: strategy.NewBaseStrategy(ctx, cancel, "MA Cross Strategy"),
+		ma20Period:   20,
+		ma60Period:   60,
+		maType:       ma.TypeSMA,
+		adxPeriod:    14,
 	}
 }
 
-// Update 更新策略状态
-func (s *MACrossStrategy) Update(kline *kline.Kline) (*strategy.Signal, error) {
-	// 添加新的K线到历史数据
-	s.historicalKlines = append(s.historicalKlines, kline)
+// SetParams 设置快慢两条均线的周期和类型，例如 HMA 交叉
+func (s *MACrossStrategy) SetParams(ma20Period, ma60Period int, maType ma.Type) error {
+	for _, period := range []int{ma20Period, ma60Period} {
+		if _, err := ma.NewAverage(maType, period); err != nil {
+			return err
+		}
+	}
 
-	// 如果历史数据不足以计算MA，则持有
-	if len(s.historicalKlines) < s.ma60Period {
-		return s.Hold(), nil
+	s.ma20Period = ma20Period
+	s.ma60Period = ma60Period
+	s.maType = maType
+	// 重置指标
+	s.ma20 = nil
+	s.ma60 = nil
+	return nil
+}
+
+// SetADXFilter 设置ADX过滤，只在 ADX(period) 不低于 minADX 的趋势行情中交易，minADX 为0时关闭过滤
+func (s *MACrossStrategy) SetADXFilter(period int, minADX decimal.Decimal) error {
+	if period < 1 {
+		return fmt.Errorf("invalid ADX period: %d", period)
+	}
+	if minADX.IsNegative() {
+		return fmt.Errorf("invalid minimum ADX: %s", minADX)
 	}
 
-	// 保持历史数据长度不超过需要的最大长度
-	if len(s.historicalKlines) > s.ma60Period {
-		s.historicalKlines = s.historicalKlines[len(s.historicalKlines)-s.ma60Period:]
+	s.adxPeriod = period
+	s.minADX = minADX
+	// 重置指标
+	s.adx = nil
+	return nil
+}
+
+// trending ADX 是否达到最小值，未开启过滤时总是返回 true
+func (s *MACrossStrategy) trending() bool {
+	if s.minADX.IsZero() {
+		return true
 	}
+	return s.adx.Ready() && s.adx.Value().GreaterThanOrEqual(s.minADX)
+}
 
-	// 计算MA20
-	if len(s.historicalKlines) >= s.ma20Period {
-		ma20Klines := s.historicalKlines[len(s.historicalKlines)-s.ma20Period:]
-		s.ma20 = ma.New(ma20Klines...)
+// Update 更新策略状态
+func (s *MACrossStrategy) Update(kline *kline.Kline) (*strategy.Signal, error) {
+	if s.ma20 == nil || s.ma60 == nil {
+		s.ma20, _ = ma.NewTypedStream(s.maType, s.ma20Period)
+		s.ma60, _ = ma.NewTypedStream(s.maType, s.ma60Period)
+	}
+	if s.adx == nil {
+		s.adx = adx.NewStream(s.adxPeriod)
 	}
 
-	// 计算MA60
-	s.ma60 = ma.New(s.historicalKlines...)
+	// 增量更新MA和ADX
+	s.ma20.Update(kline)
+	s.ma60.Update(kline)
+	s.adx.Update(kline)
 
-	// 如果无法计算MA，则持有
-	if s.ma20 == nil || s.ma60 == nil {
+	// 如果历史数据不足以计算MA，则持有
+	if !s.ma20.Ready() || !s.ma60.Ready() {
+		return s.Hold(), nil
+	}
+
+	// 趋势强度不足时不交易
+	if !s.trending() {
 		return s.Hold(), nil
 	}
 
@@ -67,7 +110,7 @@ func (s *MACrossStrategy) Update(kline *kline.Kline) (*strategy.Signal, error) {
 	tradeAmount := s.Position().Amount.Mul(decimal.NewFromFloat(0.05))
 	// 如果仓位为0，则使用余额的5%
 	if s.Position().Amount.IsZero() {
-		tradeAmount = s.Balance().Amount.Mul(decimal.NewFromFloat(0.05))
+		tradeAmount = s.BuyingPower().Mul(decimal.NewFromFloat(0.05))
 	}
 
 	// 计算当前盈亏
@@ -80,8 +123,8 @@ func (s *MACrossStrategy) Update(kline *kline.Kline) (*strategy.Signal, error) {
 
 	// 获取浮点数值用于比较
 	currentPrice := kline.C.InexactFloat64()
-	ma20Value := s.ma20.Price.InexactFloat64()
-	ma60Value := s.ma60.Price.InexactFloat64()
+	ma20Value := s.ma20.Value().InexactFloat64()
+	ma60Value := s.ma60.Value().InexactFloat64()
 
 	// 如果价格接近两个MA之间的中间值，返回持有信号
 	if ma20Value > ma60Value {
@@ -99,7 +142,7 @@ func (s *MACrossStrategy) Update(kline *kline.Kline) (*strategy.Signal, error) {
 	}
 
 	// 价格大于MA20时卖出
-	if kline.C.GreaterThan(s.ma20.Price) {
+	if kline.C.GreaterThan(s.ma20.Value()) {
 		signal := s.Sell(tradeAmount, kline.C)
 		if signal != nil {
 			return signal, nil
@@ -108,7 +151,7 @@ func (s *MACrossStrategy) Update(kline *kline.Kline) (*strategy.Signal, error) {
 	}
 
 	// 价格小于MA60时买入
-	if kline.C.LessThan(s.ma60.Price) {
+	if kline.C.LessThan(s.ma60.Value()) {
 		signal := s.Buy(tradeAmount, kline.C)
 		if signal != nil {
 			return signal, nil
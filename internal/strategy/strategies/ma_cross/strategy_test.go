@@ -2,6 +2,7 @@ package ma_cross
 
 import (
 	"context"
+	"snake/internal/indicates/ma"
 	"snake/internal/kline"
 	"testing"
 	"time"
@@ -43,7 +44,7 @@ func TestMACrossStrategy(t *testing.T) {
 		strategy := initStrategy(positionAmount, balanceAmount)
 
 		// 获取当前的MA20值，设置当前价格高于MA20
-		ma20Value := strategy.ma20.Price.InexactFloat64()
+		ma20Value := strategy.ma20.Value().InexactFloat64()
 		currentPrice := ma20Value + 1.0 // 确保价格高于MA20
 
 		currentKline := &kline.Kline{
@@ -107,7 +108,7 @@ func TestMACrossStrategy(t *testing.T) {
 		strategy := initStrategy(positionAmount, balanceAmount)
 
 		// 获取当前的MA60值，设置当前价格低于MA60
-		ma60Value := strategy.ma60.Price.InexactFloat64()
+		ma60Value := strategy.ma60.Value().InexactFloat64()
 		currentPrice := ma60Value - 1.0 // 确保价格低于MA60
 
 		currentKline := &kline.Kline{
@@ -171,8 +172,8 @@ func TestMACrossStrategy(t *testing.T) {
 		strategy := initStrategy(positionAmount, balanceAmount)
 
 		// 获取当前的MA20和MA60值，设置当前价格在两者之间
-		ma20Value := strategy.ma20.Price.InexactFloat64()
-		ma60Value := strategy.ma60.Price.InexactFloat64()
+		ma20Value := strategy.ma20.Value().InexactFloat64()
+		ma60Value := strategy.ma60.Value().InexactFloat64()
 
 		// 如果MA20小于MA60，则交换它们的值
 		if ma20Value < ma60Value {
@@ -227,6 +228,114 @@ func TestMACrossStrategy(t *testing.T) {
 	})
 }
 
+func TestSetParams(t *testing.T) {
+	klines := generateTestKlines(time.Now().Unix()*1000, 60)
+
+	tests := []struct {
+		name    string
+		fast    int
+		slow    int
+		maType  ma.Type
+		warmUp  int // 慢线就绪前一直持有
+		wantErr bool
+	}{
+		{name: "SMA", fast: 5, slow: 20, maType: ma.TypeSMA, warmUp: 20},
+		{name: "HMA", fast: 5, slow: 16, maType: ma.TypeHMA, warmUp: 19},
+		{name: "TEMA", fast: 3, slow: 8, maType: ma.TypeTEMA, warmUp: 22},
+		{name: "未知类型", fast: 5, slow: 20, maType: ma.Type("VWMA"), wantErr: true},
+		{name: "无效周期", fast: 0, slow: 20, maType: ma.TypeEMA, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strategy := New(context.WithCancel(context.TODO()))
+			err := strategy.SetParams(tt.fast, tt.slow, tt.maType)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("预期错误为 %v，实际为 %v", tt.wantErr, err)
+			}
+			if tt.wantErr {
+				// 参数无效时保持原有参数
+				if strategy.ma20Period != 20 || strategy.ma60Period != 60 || strategy.maType != ma.TypeSMA {
+					t.Errorf("预期参数未被修改")
+				}
+				return
+			}
+
+			if err := strategy.Init(decimal.NewFromInt(1), decimal.NewFromInt(1000)); err != nil {
+				t.Fatalf("failed to init strategy: %v", err)
+			}
+			for i, k := range klines {
+				signal, err := strategy.Update(k)
+				if err != nil {
+					t.Fatalf("failed to update strategy: %v", err)
+				}
+				if i+1 < tt.warmUp && !signal.Type.IsHold() {
+					t.Fatalf("第 %d 根K线后均线未就绪，预期持有", i+1)
+				}
+			}
+
+			if strategy.ma60.Type() != tt.maType || strategy.ma60.WarmUp() != tt.warmUp {
+				t.Errorf("预期慢线为 %s、WarmUp 为 %d，实际为 %s、%d", tt.maType, tt.warmUp, strategy.ma60.Type(), strategy.ma60.WarmUp())
+			}
+		})
+	}
+}
+
+func TestADXFilter(t *testing.T) {
+	klines := generateTestKlines(time.Now().Unix()*1000, 120)
+
+	tests := []struct {
+		name      string
+		minADX    decimal.Decimal
+		wantTrade bool
+	}{
+		{name: "关闭过滤", minADX: decimal.Zero, wantTrade: true},
+		{name: "门槛较低", minADX: decimal.NewFromInt(1), wantTrade: true},
+		{name: "门槛过高", minADX: decimal.NewFromInt(101), wantTrade: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strategy := New(context.WithCancel(context.TODO()))
+			if err := strategy.SetParams(5, 20, ma.TypeSMA); err != nil {
+				t.Fatalf("failed to set params: %v", err)
+			}
+			if err := strategy.SetADXFilter(14, tt.minADX); err != nil {
+				t.Fatalf("failed to set ADX filter: %v", err)
+			}
+			if err := strategy.Init(decimal.NewFromInt(1), decimal.NewFromInt(1000)); err != nil {
+				t.Fatalf("failed to init strategy: %v", err)
+			}
+
+			traded := false
+			for i, k := range klines {
+				signal, err := strategy.Update(k)
+				if err != nil {
+					t.Fatalf("failed to update strategy: %v", err)
+				}
+				if signal.Type.IsHold() {
+					continue
+				}
+				traded = true
+				if !tt.minADX.IsZero() && (!strategy.adx.Ready() || strategy.adx.Value().LessThan(tt.minADX)) {
+					t.Fatalf("第 %d 根K线 ADX 为 %s，低于 %s 时预期不交易", i+1, strategy.adx.Value(), tt.minADX)
+				}
+			}
+			if traded != tt.wantTrade {
+				t.Errorf("预期是否交易为 %v，实际为 %v", tt.wantTrade, traded)
+			}
+		})
+	}
+
+	strategy := New(context.WithCancel(context.TODO()))
+	if err := strategy.SetADXFilter(0, decimal.NewFromInt(20)); err == nil {
+		t.Errorf("预期周期为 0 时设置失败")
+	}
+	if err := strategy.SetADXFilter(14, decimal.NewFromInt(-1)); err == nil {
+		t.Errorf("预期最小 ADX 为负数时设置失败")
+	}
+}
+
 // 生成测试用的K线数据
 func generateTestKlines(startTime int64, count int) []*kline.Kline {
 	klines := make([]*kline.Kline, count)
@@ -2,6 +2,7 @@ package macd
 
 import (
 	"context"
+	"snake/internal/indicates/ma"
 	"snake/internal/indicates/macd"
 	"snake/internal/kline"
 	"snake/internal/strategy"
@@ -12,19 +13,44 @@ import (
 // MACDStrategy MACD策略
 type MACDStrategy struct {
 	*strategy.BaseStrategy
-	lastMACD *macd.MACD
-	// 保存历史K线用于计算指标
-	historicalKlines []*kline.Kline
+	// MACD参数
+	fastPeriod   int
+	slowPeriod   int
+	signalPeriod int
+	maType       ma.Type
+	// MACD指标，第一次更新时按参数创建
+	macd *macd.Stream
+	// 上一根K线的 MACD 值和信号线，用于判断交叉
+	lastMACD   decimal.Decimal
+	lastSignal decimal.Decimal
 }
 
 // New 创建新的MACD策略实例
 func New(ctx context.Context, cancel context.CancelFunc) strategy.Strategy {
 	return &MACDStrategy{
-		BaseStrategy:     strategy.NewBaseStrategy(ctx, cancel, "MACD Strategy"),
-		historicalKlines: make([]*kline.Kline, 0, 60), // 预分配足够容量
+		BaseStrategy: strategy.NewBaseStrategy(ctx, cancel, "MACD Strategy"),
+		fastPeriod:   12,
+		slowPeriod:   26,
+		signalPeriod: 9,
+		maType:       ma.TypeEMA,
 	}
 }
 
+// SetParams 设置MACD参数，maType 为快慢线和信号线使用的移动平均线类型
+func (s *MACDStrategy) SetParams(fastPeriod, slowPeriod, signalPeriod int, maType ma.Type) error {
+	if _, err := macd.NewStreamWithMA(maType, fastPeriod, slowPeriod, signalPeriod); err != nil {
+		return err
+	}
+
+	s.fastPeriod = fastPeriod
+	s.slowPeriod = slowPeriod
+	s.signalPeriod = signalPeriod
+	s.maType = maType
+	// 重置指标
+	s.macd = nil
+	return nil
+}
+
 // Update 更新策略状态并生成交易信号
 func (s *MACDStrategy) Update(kline *kline.Kline) (*strategy.Signal, error) {
 	// 计算当前盈亏
@@ -35,33 +61,25 @@ func (s *MACDStrategy) Update(kline *kline.Kline) (*strategy.Signal, error) {
 		println("当前持仓盈亏：", absolute.String(), "USDT (", percentage.String(), "%)")
 	}
 
-	// 添加新的K线到历史数据
-	s.historicalKlines = append(s.historicalKlines, kline)
-
-	// 如果历史数据不足以计算MACD，则返回持有信号
-	if len(s.historicalKlines) < 26 { // 至少需要26根K线才能计算MACD
-		return s.Hold(), nil
+	if s.macd == nil {
+		s.macd, _ = macd.NewStreamWithMA(s.maType, s.fastPeriod, s.slowPeriod, s.signalPeriod)
 	}
 
-	// 保持历史数据长度不超过需要的最大长度
-	if len(s.historicalKlines) > 60 {
-		s.historicalKlines = s.historicalKlines[len(s.historicalKlines)-60:]
+	// 增量更新MACD指标
+	wasReady := s.macd.Ready()
+	if wasReady {
+		values := s.macd.Values()
+		s.lastMACD, s.lastSignal = values["macd"], values["signal"]
 	}
+	s.macd.Update(kline)
 
-	// 计算MACD指标
-	var m *macd.MACD
-	if s.lastMACD == nil {
-		m = macd.New(s.historicalKlines...)
-	} else {
-		m = s.lastMACD.NextKline(kline)
-	}
-	if m == nil {
+	// 需要上一根K线的MACD值才能判断交叉
+	if !wasReady || !s.macd.Ready() {
 		return s.Hold(), nil
 	}
-	s.lastMACD = m
 
 	// 生成交易信号
-	signal := s.generateSignal(m)
+	signal := s.generateSignal(kline.C)
 	if signal == nil {
 		return s.Hold(), nil
 	}
@@ -70,20 +88,23 @@ func (s *MACDStrategy) Update(kline *kline.Kline) (*strategy.Signal, error) {
 }
 
 // generateSignal 根据MACD指标生成交易信号
-func (s *MACDStrategy) generateSignal(m *macd.MACD) *strategy.Signal {
+func (s *MACDStrategy) generateSignal(price decimal.Decimal) *strategy.Signal {
+	values := s.macd.Values()
+	current, signal := values["macd"], values["signal"]
+
 	// 当前没有持仓
 	if s.Position().Amount.IsZero() {
 		// MACD金叉（MACD线上穿信号线）
-		if m.MACD.GreaterThan(m.Signal) && s.lastMACD.MACD.LessThanOrEqual(s.lastMACD.Signal) {
+		if current.GreaterThan(signal) && s.lastMACD.LessThanOrEqual(s.lastSignal) {
 			// 生成买入信号，使用95%的余额，按市价买入
-			return s.Buy(s.Balance().Amount.Mul(decimal.NewFromFloat(0.95)), m.LastPrice)
+			return s.Buy(s.BuyingPower().Mul(decimal.NewFromFloat(0.95)), price)
 		}
 	} else {
 		// 当前有持仓
 		// MACD死叉（MACD线下穿信号线）
-		if m.MACD.LessThan(m.Signal) && s.lastMACD.MACD.GreaterThanOrEqual(s.lastMACD.Signal) {
+		if current.LessThan(signal) && s.lastMACD.GreaterThanOrEqual(s.lastSignal) {
 			// 生成卖出信号，卖出全部持仓，按市价卖出
-			return s.Sell(s.Position().Amount, m.LastPrice)
+			return s.Sell(s.Position().Amount, price)
 		}
 	}
 
@@ -2,6 +2,8 @@ package rsi_strategy
 
 import (
 	"context"
+	"fmt"
+	"snake/internal/indicates/adx"
 	"snake/internal/indicates/rsi"
 	"snake/internal/kline"
 	"snake/internal/strategy"
@@ -12,49 +14,52 @@ import (
 // RSIStrategy 基于RSI指标的交易策略
 type RSIStrategy struct {
 	*strategy.BaseStrategy
-	// 保存历史K线用于计算RSI
-	historicalKlines []*kline.Kline
 	// RSI参数
 	rsiPeriod       int
 	oversoldLevel   decimal.Decimal // 超卖水平，默认30
 	overboughtLevel decimal.Decimal // 超买水平，默认70
-	// RSI指标
-	rsiIndicator *rsi.RSI
+	// ADX过滤参数，minADX 为0时不过滤
+	adxPeriod int
+	minADX    decimal.Decimal
+	// RSI指标，第一次更新时按周期创建
+	rsi *rsi.Stream
+	// ADX指标，第一次更新时按周期创建
+	adx *adx.Stream
 }
 
 // New 创建RSI策略
 func New(ctx context.Context, cancel context.CancelFunc) *RSIStrategy {
 	return &RSIStrategy{
-		BaseStrategy:     strategy.NewBaseStrategy(ctx, cancel, "RSI Strategy"),
-		historicalKlines: make([]*kline.Kline, 0, 30), // 预分配足够容量
-		rsiPeriod:        14,
-		oversoldLevel:    decimal.NewFromInt(30),
-		overboughtLevel:  decimal.NewFromInt(70),
+		BaseStrategy:    strategy.NewBaseStrategy(ctx, cancel, "RSI Strategy"),
+		rsiPeriod:       14,
+		oversoldLevel:   decimal.NewFromInt(30),
+		overboughtLevel: decimal.NewFromInt(70),
+		adxPeriod:       14,
 	}
 }
 
 // Update 更新策略状态
 func (s *RSIStrategy) Update(kline *kline.Kline) (*strategy.Signal, error) {
-	// 添加新的K线到历史数据
-	s.historicalKlines = append(s.historicalKlines, kline)
+	// 增量更新RSI
+	if s.rsi == nil {
+		s.rsi = rsi.NewStream(s.rsiPeriod)
+	}
+	s.rsi.Update(kline)
 
-	// 如果历史数据不足以计算RSI，则持有
-	if len(s.historicalKlines) < s.rsiPeriod+1 {
-		return s.Hold(), nil
+	// 增量更新ADX
+	if s.adx == nil {
+		s.adx = adx.NewStream(s.adxPeriod)
 	}
+	s.adx.Update(kline)
 
-	// 初始化或更新RSI指标
-	if s.rsiIndicator == nil {
-		s.rsiIndicator = rsi.NewWithPeriod(s.rsiPeriod, s.historicalKlines...)
-	} else {
-		newRSI := s.rsiIndicator.NextKline(kline)
-		if newRSI != nil {
-			s.rsiIndicator = newRSI
-		}
+	// 如果历史数据不足以计算RSI，则持有
+	if !s.rsi.Ready() {
+		return s.Hold(), nil
 	}
+	value := s.rsi.Value()
 
-	// 如果无法计算RSI，则持有
-	if s.rsiIndicator == nil {
+	// 趋势强度不足时不交易
+	if !s.trending() {
 		return s.Hold(), nil
 	}
 
@@ -64,7 +69,7 @@ func (s *RSIStrategy) Update(kline *kline.Kline) (*strategy.Signal, error) {
 	var buyAmount, sellAmount decimal.Decimal
 
 	// 计算可用的买入数量（余额的10%）
-	buyAmount = s.Balance().Amount.Mul(decimal.NewFromFloat(0.1))
+	buyAmount = s.BuyingPower().Mul(decimal.NewFromFloat(0.1))
 	// 计算可用的卖出数量（持仓的10%）
 	sellAmount = s.Position().Amount.Mul(decimal.NewFromFloat(0.1))
 
@@ -77,11 +82,11 @@ func (s *RSIStrategy) Update(kline *kline.Kline) (*strategy.Signal, error) {
 	}
 
 	// 打印当前RSI值
-	println("当前RSI值：", s.rsiIndicator.Value.String())
+	println("当前RSI值：", value.String())
 
 	// RSI策略
 	// 1. 如果RSI低于超卖水平(30)，买入信号
-	if s.rsiIndicator.Value.LessThanOrEqual(s.oversoldLevel) {
+	if value.LessThanOrEqual(s.oversoldLevel) {
 		// 只有当有足够余额时才买入
 		if !s.Balance().Amount.IsZero() && buyAmount.GreaterThan(decimal.Zero) {
 			signal := s.Buy(buyAmount, kline.C)
@@ -92,7 +97,7 @@ func (s *RSIStrategy) Update(kline *kline.Kline) (*strategy.Signal, error) {
 	}
 
 	// 2. 如果RSI高于超买水平(70)，卖出信号
-	if s.rsiIndicator.Value.GreaterThanOrEqual(s.overboughtLevel) {
+	if value.GreaterThanOrEqual(s.overboughtLevel) {
 		// 只有当有持仓时才卖出
 		if !s.Position().Amount.IsZero() && sellAmount.GreaterThan(decimal.Zero) {
 			signal := s.Sell(sellAmount, kline.C)
@@ -112,7 +117,31 @@ func (s *RSIStrategy) SetParams(period int, oversold, overbought decimal.Decimal
 	s.oversoldLevel = oversold
 	s.overboughtLevel = overbought
 	// 重置指标
-	s.rsiIndicator = nil
+	s.rsi = nil
+}
+
+// SetADXFilter 设置ADX过滤，只在 ADX(period) 不低于 minADX 时交易，minADX 为0时关闭过滤
+func (s *RSIStrategy) SetADXFilter(period int, minADX decimal.Decimal) error {
+	if period < 1 {
+		return fmt.Errorf("invalid ADX period: %d", period)
+	}
+	if minADX.IsNegative() {
+		return fmt.Errorf("invalid minimum ADX: %s", minADX)
+	}
+
+	s.adxPeriod = period
+	s.minADX = minADX
+	// 重置指标
+	s.adx = nil
+	return nil
+}
+
+// trending ADX 是否达到最小值，未开启过滤时总是返回 true
+func (s *RSIStrategy) trending() bool {
+	if s.minADX.IsZero() {
+		return true
+	}
+	return s.adx.Ready() && s.adx.Value().GreaterThanOrEqual(s.minADX)
 }
 
 // Profit 返回当前盈亏
@@ -176,6 +176,50 @@ func TestRSIStrategy(t *testing.T) {
 	})
 }
 
+func TestADXFilter(t *testing.T) {
+	// 持续下跌的行情 ADX 很高，RSI 处于超卖
+	klines := generateFallingKlines(time.Now().Unix()*1000, 20, 100.0, 1.0)
+
+	tests := []struct {
+		name    string
+		minADX  decimal.Decimal
+		wantBuy bool
+	}{
+		{"关闭过滤", decimal.Zero, true},
+		{"趋势强度足够", decimal.NewFromInt(25), true},
+		{"趋势强度不足", decimal.NewFromInt(101), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strategy := New(context.WithCancel(context.TODO()))
+			if err := strategy.SetADXFilter(5, tt.minADX); err != nil {
+				t.Fatalf("failed to set ADX filter: %v", err)
+			}
+			if err := strategy.Init(decimal.NewFromFloat(0.5), decimal.NewFromFloat(10000.0)); err != nil {
+				t.Fatalf("failed to init strategy: %v", err)
+			}
+
+			for _, k := range klines[:15] {
+				if _, err := strategy.Update(k); err != nil {
+					t.Fatalf("failed to update strategy: %v", err)
+				}
+			}
+			signal, err := strategy.Update(klines[15])
+			if err != nil {
+				t.Fatalf("failed to update strategy: %v", err)
+			}
+			if signal.Type.IsBuy() != tt.wantBuy {
+				t.Errorf("ADX 为 %s，预期是否买入为 %v，实际信号为 %v", strategy.adx.Value(), tt.wantBuy, signal.Type)
+			}
+		})
+	}
+
+	if err := New(context.WithCancel(context.TODO())).SetADXFilter(0, decimal.NewFromInt(20)); err == nil {
+		t.Errorf("预期周期为 0 时设置失败")
+	}
+}
+
 // 生成持续上涨的K线序列
 func generateRisingKlines(startTime int64, count int, startPrice, increment float64) []*kline.Kline {
 	klines := make([]*kline.Kline, count)
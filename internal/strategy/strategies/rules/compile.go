@@ -0,0 +1,483 @@
+package rules
+
+import (
+	"fmt"
+	"slices"
+	"snake/internal/indicates"
+	"snake/internal/indicates/catalog"
+	"snake/internal/indicates/ma"
+	"snake/internal/kline"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// kind 表达式的类型
+type kind int
+
+const (
+	kindNumber kind = iota
+	kindCondition
+)
+
+func (k kind) String() string {
+	if k == kindCondition {
+		return "condition"
+	}
+	return "number"
+}
+
+// expr 编译后的表达式，eval 在每根K线的指标更新后调用一次，返回取值和是否有效；
+// 条件表达式成立时取值为 1，否则为 0。用到的指标未就绪时无效
+type expr struct {
+	kind kind
+	eval func(k *kline.Kline) (decimal.Decimal, bool)
+}
+
+// holds 条件表达式在这根K线上是否有效且成立
+func (e *expr) holds(k *kline.Kline) bool {
+	value, ok := e.eval(k)
+	return ok && value.Equal(one)
+}
+
+var one = decimal.NewFromInt(1)
+
+func truth(b bool) decimal.Decimal {
+	if b {
+		return one
+	}
+	return decimal.Zero
+}
+
+// positionalParams 指标按位置传入的参数，其余参数用 name=value 传入
+var positionalParams = map[string][]string{
+	"ma":         {"period"},
+	"bollinger":  {"period", "multiplier"},
+	"squeeze":    {"period"},
+	"donchian":   {"period"},
+	"keltner":    {"period", "multiplier"},
+	"rsi":        {"period"},
+	"macd":       {"fast", "slow", "signal"},
+	"atr":        {"period"},
+	"stochastic": {"period", "k", "d"},
+	"stochrsi":   {"rsi_period", "period", "k", "d"},
+	"adx":        {"period"},
+	"ichimoku":   {"tenkan", "kijun", "senkou_b", "displacement"},
+	"psar":       {"start", "step", "max"},
+	"supertrend": {"period", "multiplier"},
+	"cmf":        {"period"},
+	"mfi":        {"period"},
+	"vwap":       {"anchor"},
+	"pivot":      {"method"},
+	"swing":      {"strength", "tolerance", "max_zones"},
+	"divergence": {"oscillator"},
+}
+
+// series 价格序列标识符，与 indicates.ParseSource 接受的名称相同
+var series = func() map[string]indicates.Source {
+	m := map[string]indicates.Source{"typical": indicates.SourceTypical}
+	for _, source := range indicates.Sources() {
+		m[strings.ToLower(source.String())] = source
+	}
+	return m
+}()
+
+// compiler 把语法树编译为表达式，参数相同的指标只创建一次
+type compiler struct {
+	indicators map[string]indicates.Indicator
+	// order 指标的创建顺序，每根K线按此顺序更新
+	order []indicates.Indicator
+}
+
+func newCompiler() *compiler {
+	return &compiler{indicators: make(map[string]indicates.Indicator)}
+}
+
+// update 推入一根K线，更新规则用到的全部指标
+func (c *compiler) update(k *kline.Kline) {
+	for _, indicator := range c.order {
+		indicator.Update(k)
+	}
+}
+
+// condition 编译条件表达式
+func (c *compiler) condition(input string) (*expr, error) {
+	n, err := parse(input)
+	if err != nil {
+		return nil, err
+	}
+	return c.typed(n, kindCondition)
+}
+
+// typed 编译节点并检查类型
+func (c *compiler) typed(n node, want kind) (*expr, error) {
+	e, err := c.compile(n)
+	if err != nil {
+		return nil, err
+	}
+	if e.kind != want {
+		return nil, fmt.Errorf("expected %s, got %s at %d", want, e.kind, n.position())
+	}
+	return e, nil
+}
+
+func (c *compiler) compile(n node) (*expr, error) {
+	switch n := n.(type) {
+	case *literal:
+		value, err := decimal.NewFromString(n.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at %d", n.text, n.pos)
+		}
+		return &expr{kind: kindNumber, eval: func(*kline.Kline) (decimal.Decimal, bool) { return value, true }}, nil
+	case *ident:
+		source, ok := series[n.name]
+		if !ok {
+			return nil, fmt.Errorf("unknown series %q at %d", n.name, n.pos)
+		}
+		return &expr{kind: kindNumber, eval: func(k *kline.Kline) (decimal.Decimal, bool) { return source.Value(k), true }}, nil
+	case *call:
+		return c.call(n)
+	case *member:
+		target, ok := n.target.(*call)
+		if !ok || builtin(target.name) {
+			return nil, fmt.Errorf("output %q must follow an indicator at %d", n.key, n.pos)
+		}
+		return c.indicator(target, n.key)
+	case *unary:
+		if n.op == "not" {
+			x, err := c.typed(n.x, kindCondition)
+			if err != nil {
+				return nil, err
+			}
+			return &expr{kind: kindCondition, eval: func(k *kline.Kline) (decimal.Decimal, bool) {
+				value, ok := x.eval(k)
+				return truth(!value.Equal(one)), ok
+			}}, nil
+		}
+		x, err := c.typed(n.x, kindNumber)
+		if err != nil {
+			return nil, err
+		}
+		return &expr{kind: kindNumber, eval: func(k *kline.Kline) (decimal.Decimal, bool) {
+			value, ok := x.eval(k)
+			return value.Neg(), ok
+		}}, nil
+	case *binary:
+		return c.binary(n)
+	}
+	return nil, fmt.Errorf("unsupported expression at %d", n.position())
+}
+
+// binary 编译二元运算。and、or 不短路，两边每根K线都会求值，保证 cross_over、prev 等记录了每根K线
+func (c *compiler) binary(n *binary) (*expr, error) {
+	operand := kindNumber
+	if n.op == "and" || n.op == "or" {
+		operand = kindCondition
+	}
+	left, err := c.typed(n.left, operand)
+	if err != nil {
+		return nil, err
+	}
+	right, err := c.typed(n.right, operand)
+	if err != nil {
+		return nil, err
+	}
+
+	var apply func(a, b decimal.Decimal) (decimal.Decimal, bool)
+	result := kindCondition
+	switch n.op {
+	case "and":
+		apply = func(a, b decimal.Decimal) (decimal.Decimal, bool) { return truth(a.Equal(one) && b.Equal(one)), true }
+	case "or":
+		apply = func(a, b decimal.Decimal) (decimal.Decimal, bool) { return truth(a.Equal(one) || b.Equal(one)), true }
+	case "<":
+		apply = func(a, b decimal.Decimal) (decimal.Decimal, bool) { return truth(a.LessThan(b)), true }
+	case "<=":
+		apply = func(a, b decimal.Decimal) (decimal.Decimal, bool) { return truth(a.LessThanOrEqual(b)), true }
+	case ">":
+		apply = func(a, b decimal.Decimal) (decimal.Decimal, bool) { return truth(a.GreaterThan(b)), true }
+	case ">=":
+		apply = func(a, b decimal.Decimal) (decimal.Decimal, bool) { return truth(a.GreaterThanOrEqual(b)), true }
+	case "==":
+		apply = func(a, b decimal.Decimal) (decimal.Decimal, bool) { return truth(a.Equal(b)), true }
+	case "!=":
+		apply = func(a, b decimal.Decimal) (decimal.Decimal, bool) { return truth(!a.Equal(b)), true }
+	case "+":
+		result, apply = kindNumber, func(a, b decimal.Decimal) (decimal.Decimal, bool) { return a.Add(b), true }
+	case "-":
+		result, apply = kindNumber, func(a, b decimal.Decimal) (decimal.Decimal, bool) { return a.Sub(b), true }
+	case "*":
+		result, apply = kindNumber, func(a, b decimal.Decimal) (decimal.Decimal, bool) { return a.Mul(b), true }
+	case "/":
+		// 除数为零时结果无效
+		result, apply = kindNumber, func(a, b decimal.Decimal) (decimal.Decimal, bool) {
+			if b.IsZero() {
+				return decimal.Zero, false
+			}
+			return a.Div(b), true
+		}
+	default:
+		return nil, fmt.Errorf("unknown operator %q at %d", n.op, n.pos)
+	}
+
+	return &expr{kind: result, eval: func(k *kline.Kline) (decimal.Decimal, bool) {
+		a, okA := left.eval(k)
+		b, okB := right.eval(k)
+		if !okA || !okB {
+			return decimal.Zero, false
+		}
+		return apply(a, b)
+	}}, nil
+}
+
+// builtin 是否为内置函数
+func builtin(name string) bool {
+	switch name {
+	case "cross_over", "cross_under", "prev", "abs", "min", "max":
+		return true
+	}
+	return false
+}
+
+// call 编译内置函数或指标调用
+func (c *compiler) call(n *call) (*expr, error) {
+	if !builtin(n.name) {
+		return c.indicator(n, "")
+	}
+
+	for _, arg := range n.args {
+		if arg.name != "" {
+			return nil, fmt.Errorf("%s does not accept named argument %q at %d", n.name, arg.name, n.pos)
+		}
+	}
+
+	switch n.name {
+	case "cross_over", "cross_under":
+		return c.cross(n, n.name == "cross_over")
+	case "prev":
+		return c.prev(n)
+	case "abs":
+		args, err := c.numbers(n, 1)
+		if err != nil {
+			return nil, err
+		}
+		return &expr{kind: kindNumber, eval: func(k *kline.Kline) (decimal.Decimal, bool) {
+			value, ok := args[0].eval(k)
+			return value.Abs(), ok
+		}}, nil
+	default:
+		args, err := c.numbers(n, 2)
+		if err != nil {
+			return nil, err
+		}
+		pick := decimal.Min
+		if n.name == "max" {
+			pick = decimal.Max
+		}
+		return &expr{kind: kindNumber, eval: func(k *kline.Kline) (decimal.Decimal, bool) {
+			a, okA := args[0].eval(k)
+			b, okB := args[1].eval(k)
+			return pick(a, b), okA && okB
+		}}, nil
+	}
+}
+
+// numbers 检查参数数量并把参数编译为数值表达式
+func (c *compiler) numbers(n *call, count int) ([]*expr, error) {
+	if len(n.args) != count {
+		return nil, fmt.Errorf("%s expects %d arguments, got %d at %d", n.name, count, len(n.args), n.pos)
+	}
+	args := make([]*expr, count)
+	for i, arg := range n.args {
+		e, err := c.typed(arg.value, kindNumber)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = e
+	}
+	return args, nil
+}
+
+// cross 编译 cross_over(a, b) 和 cross_under(a, b)：
+// 上穿为上一根K线 a <= b 且这根K线 a > b，下穿相反。同一根K线的重复推入比较的仍是上一根K线
+func (c *compiler) cross(n *call, over bool) (*expr, error) {
+	args, err := c.numbers(n, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	type sample struct {
+		a, b decimal.Decimal
+		ok   bool
+	}
+	var (
+		seq       indicates.Sequence
+		prev, cur sample
+	)
+	return &expr{kind: kindCondition, eval: func(k *kline.Kline) (decimal.Decimal, bool) {
+		a, okA := args[0].eval(k)
+		b, okB := args[1].eval(k)
+		if seq.Next(k) == indicates.ActionAppend {
+			prev = cur
+		}
+		cur = sample{a: a, b: b, ok: okA && okB}
+		if !prev.ok || !cur.ok {
+			return decimal.Zero, false
+		}
+		if over {
+			return truth(prev.a.LessThanOrEqual(prev.b) && cur.a.GreaterThan(cur.b)), true
+		}
+		return truth(prev.a.GreaterThanOrEqual(prev.b) && cur.a.LessThan(cur.b)), true
+	}}, nil
+}
+
+// prev 编译 prev(x, n)：n 根K线之前的 x，n 缺省为 1
+func (c *compiler) prev(n *call) (*expr, error) {
+	if len(n.args) < 1 || len(n.args) > 2 {
+		return nil, fmt.Errorf("prev expects 1 or 2 arguments, got %d at %d", len(n.args), n.pos)
+	}
+	x, err := c.typed(n.args[0].value, kindNumber)
+	if err != nil {
+		return nil, err
+	}
+	bars := 1
+	if len(n.args) == 2 {
+		lit, ok := n.args[1].value.(*literal)
+		if !ok {
+			return nil, fmt.Errorf("prev bars must be a number at %d", n.args[1].value.position())
+		}
+		var err error
+		if bars, err = strconv.Atoi(lit.text); err != nil || bars < 1 {
+			return nil, fmt.Errorf("invalid prev bars %q at %d", lit.text, lit.pos)
+		}
+	}
+
+	type sample struct {
+		value decimal.Decimal
+		ok    bool
+	}
+	var (
+		seq     indicates.Sequence
+		history []sample
+	)
+	return &expr{kind: kindNumber, eval: func(k *kline.Kline) (decimal.Decimal, bool) {
+		value, ok := x.eval(k)
+		if seq.Next(k) == indicates.ActionAppend || len(history) == 0 {
+			history = append(history, sample{})
+			if len(history) > bars+1 {
+				history = history[1:]
+			}
+		}
+		history[len(history)-1] = sample{value: value, ok: ok}
+		if len(history) <= bars {
+			return decimal.Zero, false
+		}
+		return history[0].value, history[0].ok
+	}}, nil
+}
+
+// indicator 编译指标调用，key 非空时取指标的该项输出，否则取主值
+//
+// 名称为移动平均线类型（ema、sma 等）时为该类型的 ma；第一个参数为价格序列时作为 source 参数；
+// 其余按位置对应 positionalParams，或用 name=value 指定。参数必须是字面量，由指标目录校验
+func (c *compiler) indicator(n *call, key string) (*expr, error) {
+	name := n.name
+	params := catalog.Params{}
+	if _, err := ma.ParseType(name); err == nil {
+		params["type"] = name
+		name = "ma"
+	}
+	if !slices.Contains(catalog.Names(), name) {
+		return nil, fmt.Errorf("unknown indicator: %s at %d", n.name, n.pos)
+	}
+
+	positional := positionalParams[name]
+	for i, arg := range n.args {
+		value, err := argumentText(arg.value)
+		if err != nil {
+			return nil, err
+		}
+
+		param := arg.name
+		switch {
+		case param != "":
+		case i == 0 && isSeries(arg.value):
+			param = "source"
+		case len(positional) == 0:
+			return nil, fmt.Errorf("too many arguments for %s at %d", n.name, arg.value.position())
+		default:
+			param, positional = positional[0], positional[1:]
+		}
+		if _, dup := params[param]; dup {
+			return nil, fmt.Errorf("duplicate %s param %s at %d", n.name, param, arg.value.position())
+		}
+		params[param] = value
+	}
+	// 收盘价为默认来源，ema(close, 12) 与 ema(12) 共用同一个指标
+	if params["source"] == "close" {
+		delete(params, "source")
+	}
+
+	id := indicatorKey(name, params)
+	indicator, ok := c.indicators[id]
+	if !ok {
+		var err error
+		indicator, err = catalog.New(name, params)
+		if err != nil {
+			return nil, fmt.Errorf("%s at %d: %w", n.name, n.pos, err)
+		}
+		c.indicators[id] = indicator
+		c.order = append(c.order, indicator)
+	}
+
+	if key != "" {
+		if _, ok := indicator.Values()[key]; !ok {
+			return nil, fmt.Errorf("unknown output %q of %s at %d", key, n.name, n.pos)
+		}
+	}
+	return &expr{kind: kindNumber, eval: func(*kline.Kline) (decimal.Decimal, bool) {
+		if !indicator.Ready() {
+			return decimal.Zero, false
+		}
+		if key == "" {
+			return indicator.Value(), true
+		}
+		return indicator.Values()[key], true
+	}}, nil
+}
+
+// isSeries 节点是否为价格序列标识符
+func isSeries(n node) bool {
+	id, ok := n.(*ident)
+	if !ok {
+		return false
+	}
+	_, ok = series[id.name]
+	return ok
+}
+
+// argumentText 返回指标参数的字面量文本，支持数字、负数和标识符
+func argumentText(n node) (string, error) {
+	switch n := n.(type) {
+	case *literal:
+		return n.text, nil
+	case *ident:
+		return n.name, nil
+	case *unary:
+		if lit, ok := n.x.(*literal); ok && n.op == "-" {
+			return "-" + lit.text, nil
+		}
+	}
+	return "", fmt.Errorf("indicator arguments must be literals at %d", n.position())
+}
+
+// indicatorKey 指标名称和参数组成的唯一键
+func indicatorKey(name string, params catalog.Params) string {
+	keys := make([]string, 0, len(params))
+	for key, value := range params {
+		keys = append(keys, key+"="+value)
+	}
+	slices.Sort(keys)
+	return name + "(" + strings.Join(keys, ",") + ")"
+}
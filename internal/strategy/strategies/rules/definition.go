@@ -0,0 +1,136 @@
+package rules
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/shopspring/decimal"
+	"gopkg.in/yaml.v3"
+)
+
+// Definition 声明式的规则策略定义，从 YAML 解析
+//
+//	name: EMA Cross
+//	entry: cross_over(ema(close, 12), ema(close, 26)) and rsi(close, 14) < 70
+//	exit: cross_under(ema(close, 12), ema(close, 26))
+//	sizing:
+//	  percent: 95
+//	stops:
+//	  stop_loss: 2
+//	  take_profit: 6
+type Definition struct {
+	// Name 策略名称
+	Name string `yaml:"name"`
+	// Entry 入场条件，无持仓时成立则买入
+	Entry string `yaml:"entry"`
+	// Exit 出场条件，有持仓时成立则卖出全部持仓，可以为空，只靠止损止盈出场
+	Exit string `yaml:"exit"`
+	// Sizing 仓位大小
+	Sizing Sizing `yaml:"sizing"`
+	// Stops 入场后挂出的止损止盈单
+	Stops Stops `yaml:"stops"`
+}
+
+// Sizing 每次入场使用的资金
+type Sizing struct {
+	// Percent 使用可用余额的百分比，例如 95 表示 95%，默认为 95
+	Percent decimal.Decimal `yaml:"percent"`
+	// Amount 固定使用的 quote 数量（例如 USDT），不为零时优先于 Percent，超过可用余额时按可用余额
+	Amount decimal.Decimal `yaml:"amount"`
+}
+
+// Stops 止损止盈，均为相对入场价的百分比，为零表示不挂该挂单
+type Stops struct {
+	// StopLoss 止损，入场价下方的百分比
+	StopLoss decimal.Decimal `yaml:"stop_loss"`
+	// TakeProfit 止盈，入场价上方的百分比，与止损同时设置时组成 OCO 订单
+	TakeProfit decimal.Decimal `yaml:"take_profit"`
+	// TrailingStop 跟踪止损，最高价下方的百分比，不能与 StopLoss 同时设置
+	TrailingStop decimal.Decimal `yaml:"trailing_stop"`
+}
+
+var hundred = decimal.NewFromInt(100)
+
+// defaultPercent 默认使用 95% 的可用余额，与其他策略一致
+var defaultPercent = decimal.NewFromInt(95)
+
+// Parse 解析 YAML 格式的策略定义，并编译规则检查表达式和指标参数，有未知字段时返回错误
+func Parse(data []byte) (*Definition, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	var definition Definition
+	if err := decoder.Decode(&definition); err != nil {
+		return nil, fmt.Errorf("invalid rule definition: %w", err)
+	}
+	if err := definition.Validate(); err != nil {
+		return nil, err
+	}
+	return &definition, nil
+}
+
+// LoadFile 读取并解析 YAML 格式的策略定义文件
+func LoadFile(path string) (*Definition, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Validate 检查仓位和止损参数，并编译入场、出场规则
+func (d *Definition) Validate() error {
+	if d.Sizing.Percent.IsNegative() || d.Sizing.Percent.GreaterThan(hundred) || d.Sizing.Amount.IsNegative() {
+		return fmt.Errorf("invalid sizing: percent %s, amount %s", d.Sizing.Percent, d.Sizing.Amount)
+	}
+	stops := d.Stops
+	if stops.StopLoss.IsNegative() || stops.StopLoss.GreaterThanOrEqual(hundred) ||
+		stops.TakeProfit.IsNegative() || stops.TrailingStop.IsNegative() || stops.TrailingStop.GreaterThanOrEqual(hundred) {
+		return fmt.Errorf("invalid stops: stop loss %s, take profit %s, trailing stop %s", stops.StopLoss, stops.TakeProfit, stops.TrailingStop)
+	}
+	if stops.StopLoss.IsPositive() && stops.TrailingStop.IsPositive() {
+		return fmt.Errorf("invalid stops: stop loss and trailing stop are exclusive")
+	}
+	if d.Exit == "" && !stops.StopLoss.IsPositive() && !stops.TakeProfit.IsPositive() && !stops.TrailingStop.IsPositive() {
+		return fmt.Errorf("invalid rule definition: an exit rule or a stop is required")
+	}
+
+	_, err := d.compile()
+	return err
+}
+
+// percent 入场使用的可用余额百分比
+func (d *Definition) percent() decimal.Decimal {
+	if d.Sizing.Percent.IsZero() {
+		return defaultPercent
+	}
+	return d.Sizing.Percent
+}
+
+// program 编译后的规则，持有规则用到的全部指标
+type program struct {
+	*compiler
+	entry *expr
+	// exit 为 nil 表示没有出场规则
+	exit *expr
+}
+
+// compile 编译入场、出场规则，每次编译都创建新的指标
+func (d *Definition) compile() (*program, error) {
+	if d.Entry == "" {
+		return nil, fmt.Errorf("invalid rule definition: entry is required")
+	}
+
+	p := &program{compiler: newCompiler()}
+	var err error
+	if p.entry, err = p.condition(d.Entry); err != nil {
+		return nil, fmt.Errorf("invalid entry rule: %w", err)
+	}
+	if d.Exit != "" {
+		if p.exit, err = p.condition(d.Exit); err != nil {
+			return nil, fmt.Errorf("invalid exit rule: %w", err)
+		}
+	}
+	return p, nil
+}
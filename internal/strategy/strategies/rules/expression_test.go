@@ -0,0 +1,164 @@
+package rules
+
+import (
+	"snake/internal/kline"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+// closeKlines 按收盘价生成 1 分钟K线
+func closeKlines(closes ...float64) []*kline.Kline {
+	klines := make([]*kline.Kline, len(closes))
+	for i, c := range closes {
+		price := decimal.NewFromFloat(c)
+		klines[i] = &kline.Kline{
+			O: price,
+			H: price.Add(decimal.NewFromInt(1)),
+			L: price.Sub(decimal.NewFromInt(1)),
+			C: price,
+			V: decimal.NewFromInt(1000),
+			S: int64(i) * 60000,
+			E: int64(i+1)*60000 - 1,
+		}
+	}
+	return klines
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "close >", want: "unexpected end of expression"},
+		{input: "ema(close, 12", want: `expected "," or ")"`},
+		{input: "close > 1 )", want: `unexpected ")"`},
+		{input: "close ! 1", want: "unexpected character"},
+		{input: "foo > 1", want: `unknown series "foo"`},
+		{input: "close + 1", want: "expected condition, got number"},
+		{input: "rsi(close, 14) and close > 1", want: "expected condition, got number"},
+		{input: "cross_over(close) ", want: "cross_over expects 2 arguments"},
+		{input: "abs(x=1) > 1", want: "does not accept named argument"},
+		{input: "prev(close, 0) > 1", want: "invalid prev bars"},
+		{input: "prev(close, close) > 1", want: "prev bars must be a number"},
+		{input: "rsi(close, 14, 3) > 1", want: "too many arguments for rsi"},
+		{input: "rsi(close, period=14, period=7) > 1", want: "duplicate rsi param period"},
+		{input: "rsi(close, abc) > 1", want: "invalid param period"},
+		{input: "rsi(close, size=3) > 1", want: "unknown rsi param: size"},
+		{input: "ema(rsi(close, 14), 9) > 1", want: "indicator arguments must be literals"},
+		{input: "foo(close, 3) > 1", want: "unknown indicator: foo"},
+		{input: "macd(close).foo > 0", want: `unknown output "foo" of macd`},
+		{input: "(close + 1).foo > 0", want: "must follow an indicator"},
+		{input: "1.2.3 < close", want: `invalid number "1.2.3"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := newCompiler().condition(tt.input)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("预期错误包含 %q，实际为 %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	klines := closeKlines(1, 2, 3, 2, 1, 2, 3)
+	tests := []struct {
+		input string
+		want  []bool
+	}{
+		{input: "close > prev(close)", want: []bool{false, true, true, false, false, true, true}},
+		{input: "cross_over(close, 2)", want: []bool{false, false, true, false, false, false, true}},
+		{input: "cross_under(close, 2)", want: []bool{false, false, false, false, true, false, false}},
+		{input: "sma(close, 3) == 2", want: []bool{false, false, true, false, true, false, true}},
+		{input: "not (close >= 2) or -close / 2 < -1.4", want: []bool{true, false, true, false, true, false, true}},
+		{input: "max(close, 2) - min(close, 2) == abs(close - 2)", want: []bool{true, true, true, true, true, true, true}},
+		// 除数为零时无效，条件不成立
+		{input: "prev(close, 2) / (close - 2) > 0", want: []bool{false, false, true, false, false, false, true}},
+		{input: "high - low == 2 and hl2 == close and volume > 999", want: []bool{true, true, true, true, true, true, true}},
+		{input: "CLOSE*2 >= 4 And Close != 3", want: []bool{false, true, false, true, false, true, false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			c := newCompiler()
+			e, err := c.condition(tt.input)
+			if err != nil {
+				t.Fatalf("编译失败: %v", err)
+			}
+			for i, k := range klines {
+				c.update(k)
+				if got := e.holds(k); got != tt.want[i] {
+					t.Errorf("第 %d 根K线预期为 %v，实际为 %v", i+1, tt.want[i], got)
+				}
+			}
+		})
+	}
+}
+
+// TestEvaluateReplace 同一根K线重复推入时，cross_over 与 prev 比较的仍是上一根K线
+func TestEvaluateReplace(t *testing.T) {
+	c := newCompiler()
+	cross, err := c.condition("cross_over(close, 2)")
+	if err != nil {
+		t.Fatalf("编译失败: %v", err)
+	}
+	rising, err := c.condition("close > prev(close)")
+	if err != nil {
+		t.Fatalf("编译失败: %v", err)
+	}
+
+	klines := closeKlines(1, 3)
+	bars := []struct {
+		kline         *kline.Kline
+		cross, rising bool
+	}{
+		{kline: klines[0]},
+		{kline: klines[1], cross: true, rising: true},
+		{kline: &kline.Kline{C: decimal.NewFromFloat(0.5), S: klines[1].S}},
+		{kline: &kline.Kline{C: decimal.NewFromFloat(2.5), S: klines[1].S}, cross: true, rising: true},
+	}
+
+	for i, bar := range bars {
+		c.update(bar.kline)
+		if got := cross.holds(bar.kline); got != bar.cross {
+			t.Errorf("第 %d 次推入 cross_over 预期为 %v，实际为 %v", i+1, bar.cross, got)
+		}
+		if got := rising.holds(bar.kline); got != bar.rising {
+			t.Errorf("第 %d 次推入 prev 预期为 %v，实际为 %v", i+1, bar.rising, got)
+		}
+	}
+}
+
+func TestIndicatorCalls(t *testing.T) {
+	tests := []struct {
+		input string
+		names []string
+	}{
+		// 参数相同的指标只创建一次，收盘价为默认来源
+		{input: "ema(close, 12) > ema(12) and ema(close, 26) > 0", names: []string{"EMA12", "EMA26"}},
+		{input: "macd(hlc3, 8, 21, 5).histogram > macd(hlc3, 8, 21, 5).signal", names: []string{"MACD8_21_5_HLC3"}},
+		{input: "bollinger(close, 20, multiplier=2.5).percent_b < 0", names: []string{"BB20_2.5"}},
+		{input: "rsi(14, source=typical) > 70 or rsi(period=7) < 30", names: []string{"RSI14_HLC3", "RSI7"}},
+		{input: "divergence(rsi, period=14).regular_bullish > 0", names: []string{"DIVERGENCE_RSI14"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			c := newCompiler()
+			if _, err := c.condition(tt.input); err != nil {
+				t.Fatalf("编译失败: %v", err)
+			}
+			if len(c.order) != len(tt.names) {
+				t.Fatalf("预期创建 %d 个指标，实际为 %d", len(tt.names), len(c.order))
+			}
+			for i, indicator := range c.order {
+				if indicator.Name() != tt.names[i] {
+					t.Errorf("预期第 %d 个指标为 %s，实际为 %s", i+1, tt.names[i], indicator.Name())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// tokenKind 词法单元类型
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	// tokenNumber 数字，或以数字开头的字面量（例如 vwap 的锚定周期 1d）
+	tokenNumber
+	// tokenIdent 标识符：价格序列、函数名、指标名、参数名和关键字 and、or、not
+	tokenIdent
+	// tokenOperator 运算符和标点：+ - * / < <= > >= == != ( ) , . =
+	tokenOperator
+)
+
+// token 词法单元，pos 为在表达式中的字节偏移，用于错误信息
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of expression"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// twoCharOperators 两个字符的运算符
+var twoCharOperators = []string{"<=", ">=", "==", "!="}
+
+// lex 把表达式切分为词法单元，末尾追加 tokenEOF
+func lex(input string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(input); {
+		c := rune(input[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case isDigit(c) || c == '.' && i+1 < len(input) && isDigit(rune(input[i+1])):
+			start := i
+			for i < len(input) && (isIdentChar(rune(input[i])) || input[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: input[start:i], pos: start})
+		case isIdentStart(c):
+			start := i
+			for i < len(input) && isIdentChar(rune(input[i])) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: strings.ToLower(input[start:i]), pos: start})
+		default:
+			text := ""
+			for _, op := range twoCharOperators {
+				if strings.HasPrefix(input[i:], op) {
+					text = op
+					break
+				}
+			}
+			if text == "" {
+				if !strings.ContainsRune("+-*/<>()=,.", c) {
+					return nil, fmt.Errorf("unexpected character %q at %d", c, i)
+				}
+				text = string(c)
+			}
+			tokens = append(tokens, token{kind: tokenOperator, text: text, pos: i})
+			i += len(text)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(input)}), nil
+}
+
+func isDigit(c rune) bool { return c >= '0' && c <= '9' }
+
+func isIdentStart(c rune) bool { return c == '_' || c < unicode.MaxASCII && unicode.IsLetter(c) }
+
+func isIdentChar(c rune) bool { return isIdentStart(c) || isDigit(c) }
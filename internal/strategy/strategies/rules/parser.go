@@ -0,0 +1,258 @@
+package rules
+
+import "fmt"
+
+// node 表达式语法树的节点
+type node interface {
+	// position 节点在表达式中的字节偏移
+	position() int
+}
+
+// literal 数字或以数字开头的字面量
+type literal struct {
+	pos  int
+	text string
+}
+
+// ident 标识符，例如价格序列 close 或指标参数 ema
+type ident struct {
+	pos  int
+	name string
+}
+
+// argument 函数调用的参数，name 非空时为命名参数 name=value
+type argument struct {
+	name  string
+	value node
+}
+
+// call 函数或指标调用
+type call struct {
+	pos  int
+	name string
+	args []argument
+}
+
+// member 选择指标的某个输出，例如 macd(close).histogram
+type member struct {
+	pos    int
+	target node
+	key    string
+}
+
+// unary 一元运算：- 或 not
+type unary struct {
+	pos int
+	op  string
+	x   node
+}
+
+// binary 二元运算：算术、比较、and、or
+type binary struct {
+	pos         int
+	op          string
+	left, right node
+}
+
+func (n *literal) position() int { return n.pos }
+func (n *ident) position() int   { return n.pos }
+func (n *call) position() int    { return n.pos }
+func (n *member) position() int  { return n.pos }
+func (n *unary) position() int   { return n.pos }
+func (n *binary) position() int  { return n.pos }
+
+// 二元运算符的优先级，数字越大结合越紧
+const (
+	precedenceLowest = iota
+	precedenceOr
+	precedenceAnd
+	precedenceNot
+	precedenceCompare
+	precedenceSum
+	precedenceProduct
+	precedencePrefix
+)
+
+var binaryPrecedence = map[string]int{
+	"or":  precedenceOr,
+	"and": precedenceAnd,
+	"<":   precedenceCompare,
+	"<=":  precedenceCompare,
+	">":   precedenceCompare,
+	">=":  precedenceCompare,
+	"==":  precedenceCompare,
+	"!=":  precedenceCompare,
+	"+":   precedenceSum,
+	"-":   precedenceSum,
+	"*":   precedenceProduct,
+	"/":   precedenceProduct,
+}
+
+// parser 按运算符优先级解析表达式
+type parser struct {
+	tokens []token
+	next   int
+}
+
+// parse 解析表达式为语法树
+func parse(input string) (node, error) {
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	n, err := p.expression(precedenceLowest)
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %s at %d", t, t.pos)
+	}
+	return n, nil
+}
+
+func (p *parser) peek() token { return p.tokens[p.next] }
+
+func (p *parser) advance() token {
+	t := p.tokens[p.next]
+	if t.kind != tokenEOF {
+		p.next++
+	}
+	return t
+}
+
+// accept 下一个单元为指定的运算符时跳过它
+func (p *parser) accept(op string) bool {
+	if t := p.peek(); t.kind == tokenOperator && t.text == op {
+		p.next++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(op string) error {
+	if !p.accept(op) {
+		t := p.peek()
+		return fmt.Errorf("expected %q, got %s at %d", op, t, t.pos)
+	}
+	return nil
+}
+
+// infix 返回下一个单元作为二元运算符时的优先级，不是二元运算符时返回 precedenceLowest
+func (p *parser) infix() (string, int) {
+	t := p.peek()
+	if t.kind == tokenEOF || t.kind == tokenNumber {
+		return "", precedenceLowest
+	}
+	return t.text, binaryPrecedence[t.text]
+}
+
+// expression 解析优先级高于 precedence 的表达式
+func (p *parser) expression(precedence int) (node, error) {
+	left, err := p.prefix()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		op, next := p.infix()
+		if next <= precedence {
+			return left, nil
+		}
+		t := p.advance()
+		right, err := p.expression(next)
+		if err != nil {
+			return nil, err
+		}
+		left = &binary{pos: t.pos, op: op, left: left, right: right}
+	}
+}
+
+// prefix 解析一元运算、括号、字面量、标识符和调用
+func (p *parser) prefix() (node, error) {
+	t := p.advance()
+	var n node
+	switch {
+	case t.kind == tokenNumber:
+		n = &literal{pos: t.pos, text: t.text}
+	case t.kind == tokenIdent && t.text == "not":
+		x, err := p.expression(precedenceNot)
+		if err != nil {
+			return nil, err
+		}
+		return &unary{pos: t.pos, op: "not", x: x}, nil
+	case t.kind == tokenIdent && (t.text == "and" || t.text == "or"):
+		return nil, fmt.Errorf("unexpected %s at %d", t, t.pos)
+	case t.kind == tokenIdent:
+		if !p.accept("(") {
+			n = &ident{pos: t.pos, name: t.text}
+			break
+		}
+		args, err := p.arguments()
+		if err != nil {
+			return nil, err
+		}
+		n = &call{pos: t.pos, name: t.text, args: args}
+	case t.kind == tokenOperator && t.text == "-":
+		x, err := p.expression(precedencePrefix)
+		if err != nil {
+			return nil, err
+		}
+		return &unary{pos: t.pos, op: "-", x: x}, nil
+	case t.kind == tokenOperator && t.text == "(":
+		x, err := p.expression(precedenceLowest)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		n = x
+	default:
+		return nil, fmt.Errorf("unexpected %s at %d", t, t.pos)
+	}
+
+	// 输出选择，例如 macd(close).histogram
+	for p.accept(".") {
+		t := p.advance()
+		if t.kind != tokenIdent {
+			return nil, fmt.Errorf("expected output name, got %s at %d", t, t.pos)
+		}
+		n = &member{pos: t.pos, target: n, key: t.text}
+	}
+	return n, nil
+}
+
+// arguments 解析调用的参数列表，左括号已被读取
+func (p *parser) arguments() ([]argument, error) {
+	var args []argument
+	if p.accept(")") {
+		return args, nil
+	}
+
+	for {
+		var arg argument
+		// 命名参数 name=value
+		if t := p.peek(); t.kind == tokenIdent {
+			if next := p.tokens[p.next+1]; next.kind == tokenOperator && next.text == "=" {
+				p.next += 2
+				arg.name = t.text
+			}
+		}
+		value, err := p.expression(precedenceLowest)
+		if err != nil {
+			return nil, err
+		}
+		arg.value = value
+		args = append(args, arg)
+
+		if p.accept(")") {
+			return args, nil
+		}
+		if !p.accept(",") {
+			t := p.peek()
+			return nil, fmt.Errorf(`expected "," or ")", got %s at %d`, t, t.pos)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package rules
+
+import (
+	"context"
+	"snake/internal/kline"
+	"snake/internal/strategy"
+	"snake/internal/types"
+
+	"github.com/shopspring/decimal"
+)
+
+// RuleStrategy 由声明式规则定义编译的策略
+//
+// 无持仓时入场条件成立则按仓位设置买入，并挂出止损止盈单；有持仓时出场条件成立则卖出全部持仓，
+// 撤销未成交的止损止盈单。只做多
+type RuleStrategy struct {
+	*strategy.BaseStrategy
+	definition *Definition
+	// 编译后的入场、出场规则
+	program *program
+}
+
+var _ strategy.Strategy = (*RuleStrategy)(nil)
+
+// New 按规则定义创建策略，规则无效时返回错误。每个策略持有独立的指标
+func New(ctx context.Context, cancel context.CancelFunc, definition *Definition) (*RuleStrategy, error) {
+	if err := definition.Validate(); err != nil {
+		return nil, err
+	}
+	program, err := definition.compile()
+	if err != nil {
+		return nil, err
+	}
+
+	name := definition.Name
+	if name == "" {
+		name = "Rule Strategy"
+	}
+	return &RuleStrategy{
+		BaseStrategy: strategy.NewBaseStrategy(ctx, cancel, name),
+		definition:   definition,
+		program:      program,
+	}, nil
+}
+
+// Update 更新策略状态并生成交易信号
+func (s *RuleStrategy) Update(kline *kline.Kline) (*strategy.Signal, error) {
+	// 每根K线都求值入场、出场规则，保证 cross_over、prev 记录了每根K线
+	s.program.update(kline)
+	entry := s.program.entry.holds(kline)
+	exit := s.program.exit != nil && s.program.exit.holds(kline)
+
+	price := kline.C
+
+	// 当前没有持仓，入场条件成立时买入
+	if s.Position().Amount.IsZero() {
+		if !entry {
+			return s.Hold(), nil
+		}
+		if signal := s.Buy(s.entryAmount(), price); signal != nil {
+			stop, takeProfit := s.stopOrders(price)
+			return s.PlaceStops(signal, stop, takeProfit), nil
+		}
+		return s.Hold(), nil
+	}
+
+	// 当前有持仓，出场条件成立时卖出全部持仓
+	if exit {
+		if signal := s.Sell(s.Position().Amount, price); signal != nil {
+			return s.CancelStops(signal), nil
+		}
+	}
+
+	return s.Hold(), nil
+}
+
+// entryAmount 入场使用的 quote 数量
+func (s *RuleStrategy) entryAmount() decimal.Decimal {
+	available := s.BuyingPower()
+	if amount := s.definition.Sizing.Amount; amount.IsPositive() {
+		return decimal.Min(amount, available)
+	}
+	return available.Mul(s.definition.percent()).Div(hundred)
+}
+
+// stopOrders 按入场价为全部持仓创建止损（或跟踪止损）和止盈单，未设置的为 nil
+func (s *RuleStrategy) stopOrders(price decimal.Decimal) (stop, takeProfit *strategy.Order) {
+	stops := s.definition.Stops
+	amount := s.Position().Amount
+
+	switch {
+	case stops.StopLoss.IsPositive():
+		stopPrice := price.Mul(hundred.Sub(stops.StopLoss)).Div(hundred)
+		stop = strategy.StopOrder(types.SignalTypeSell, amount, stopPrice)
+	case stops.TrailingStop.IsPositive():
+		stop = strategy.TrailingStopOrder(types.SignalTypeSell, amount, decimal.Zero, stops.TrailingStop)
+	}
+	if stops.TakeProfit.IsPositive() {
+		triggerPrice := price.Mul(hundred.Add(stops.TakeProfit)).Div(hundred)
+		takeProfit = strategy.TakeProfitOrder(types.SignalTypeSell, amount, triggerPrice)
+	}
+	return stop, takeProfit
+}
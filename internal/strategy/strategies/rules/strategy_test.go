@@ -0,0 +1,207 @@
+package rules
+
+import (
+	"context"
+	"snake/internal/types"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+const crossDefinition = `
+name: SMA Cross
+entry: cross_over(close, sma(close, 3))
+exit: cross_under(close, sma(close, 3))
+sizing:
+  percent: 50
+stops:
+  stop_loss: 2
+  take_profit: 6
+`
+
+func TestParse(t *testing.T) {
+	definition, err := Parse([]byte(crossDefinition))
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if definition.Name != "SMA Cross" || definition.Entry != "cross_over(close, sma(close, 3))" {
+		t.Errorf("解析结果不正确: %+v", definition)
+	}
+	if !definition.Sizing.Percent.Equal(decimal.NewFromInt(50)) || !definition.Stops.StopLoss.Equal(decimal.NewFromInt(2)) ||
+		!definition.Stops.TakeProfit.Equal(decimal.NewFromInt(6)) || !definition.Stops.TrailingStop.IsZero() {
+		t.Errorf("仓位或止损参数不正确: %+v", definition)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{name: "未知字段", yaml: "entry: close > 1\nexit: close < 1\nstop: 2", want: "field stop not found"},
+		{name: "缺少入场规则", yaml: "exit: close < 1", want: "entry is required"},
+		{name: "无效入场规则", yaml: "entry: close >\nexit: close < 1", want: "invalid entry rule"},
+		{name: "无效出场规则", yaml: "entry: close > 1\nexit: rsi(close, 0) < 30", want: "invalid exit rule"},
+		{name: "没有出场方式", yaml: "entry: close > 1", want: "an exit rule or a stop is required"},
+		{name: "仓位比例过大", yaml: "entry: close > 1\nexit: close < 1\nsizing:\n  percent: 150", want: "invalid sizing"},
+		{name: "止损比例无效", yaml: "entry: close > 1\nstops:\n  stop_loss: 100", want: "invalid stops"},
+		{name: "止损与跟踪止损同时设置", yaml: "entry: close > 1\nstops:\n  stop_loss: 2\n  trailing_stop: 1", want: "exclusive"},
+		{name: "数值格式错误", yaml: "entry: close > 1\nstops:\n  stop_loss: abc", want: "invalid rule definition"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.yaml))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("预期错误包含 %q，实际为 %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func newRuleStrategy(t *testing.T, yaml string) *RuleStrategy {
+	t.Helper()
+	definition, err := Parse([]byte(yaml))
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.TODO())
+	s, err := New(ctx, cancel, definition)
+	if err != nil {
+		t.Fatalf("创建策略失败: %v", err)
+	}
+	if err := s.Init(decimal.Zero, decimal.NewFromInt(10000)); err != nil {
+		t.Fatalf("failed to init strategy: %v", err)
+	}
+	return s
+}
+
+func TestRuleStrategy(t *testing.T) {
+	s := newRuleStrategy(t, crossDefinition)
+	if s.Name() != "SMA Cross" {
+		t.Errorf("预期策略名称为 SMA Cross，实际为 %s", s.Name())
+	}
+
+	// 第 5 根K线上穿 3 周期均线，第 8 根K线下穿
+	klines := closeKlines(10, 9, 8, 7, 10, 11, 12, 8, 7)
+	var buys, sells []int
+	for i, k := range klines {
+		signal, err := s.Update(k)
+		if err != nil {
+			t.Fatalf("failed to update strategy: %v", err)
+		}
+
+		switch {
+		case signal.Type.IsBuy():
+			buys = append(buys, i)
+			// 使用 50% 的余额，并挂出止损与止盈组成的 OCO 订单
+			if !s.Balance().Amount.Equal(decimal.NewFromInt(5000)) {
+				t.Errorf("预期买入后余额为 5000，实际为 %s", s.Balance().Amount)
+			}
+			if len(signal.Orders) != 1 || signal.Orders[0].OCO == nil {
+				t.Fatalf("预期买入时挂出一个 OCO 订单，实际为 %v", signal.Orders)
+			}
+			stop, takeProfit := signal.Orders[0], signal.Orders[0].OCO
+			if stop.Type != types.OrderTypeStop || !stop.StopPrice.Equal(decimal.NewFromFloat(9.8)) {
+				t.Errorf("预期止损价为 9.8，实际为 %s %s", stop.Type, stop.StopPrice)
+			}
+			if takeProfit.Type != types.OrderTypeTakeProfit || !takeProfit.StopPrice.Equal(decimal.NewFromFloat(10.6)) {
+				t.Errorf("预期止盈价为 10.6，实际为 %s %s", takeProfit.Type, takeProfit.StopPrice)
+			}
+			if !stop.Volume.Equal(s.Position().Amount) || !takeProfit.Volume.Equal(s.Position().Amount) {
+				t.Errorf("预期止损止盈数量为全部持仓 %s", s.Position().Amount)
+			}
+		case signal.Type.IsSell():
+			sells = append(sells, i)
+			if len(signal.Cancels) != 1 || signal.Cancels[0] == 0 {
+				t.Errorf("预期卖出时撤销止损止盈单，实际为 %v", signal.Cancels)
+			}
+		}
+	}
+
+	if len(buys) != 1 || buys[0] != 4 {
+		t.Fatalf("预期在第 5 根K线买入，实际买入位置为 %v", buys)
+	}
+	if len(sells) != 1 || sells[0] != 7 {
+		t.Fatalf("预期在第 8 根K线卖出，实际卖出位置为 %v", sells)
+	}
+	if !s.Position().Amount.IsZero() {
+		t.Errorf("预期最后没有持仓，实际为 %s", s.Position().Amount)
+	}
+}
+
+// TestStopFill 止损单成交后清除挂单编号，再次入场时挂出新的止损单
+func TestStopFill(t *testing.T) {
+	s := newRuleStrategy(t, `
+entry: close > 10
+exit: close < 5
+sizing:
+  amount: 1000
+stops:
+  trailing_stop: 3
+`)
+
+	signal, err := s.Update(closeKlines(11)[0])
+	if err != nil {
+		t.Fatalf("failed to update strategy: %v", err)
+	}
+	if !signal.Type.IsBuy() || !s.Balance().Amount.Equal(decimal.NewFromInt(9000)) {
+		t.Fatalf("预期按固定金额 1000 买入，实际为 %v，余额 %s", signal.Type, s.Balance().Amount)
+	}
+	if len(signal.Orders) != 1 || signal.Orders[0].Type != types.OrderTypeTrailingStop || signal.Orders[0].OCO != nil {
+		t.Fatalf("预期挂出一个跟踪止损单，实际为 %v", signal.Orders)
+	}
+
+	trailing := signal.Orders[0]
+	if fill := s.Fill(trailing, decimal.NewFromFloat(10.5)); fill == nil || !fill.Type.IsSell() {
+		t.Fatalf("预期跟踪止损单成交卖出")
+	}
+	if !s.Position().Amount.IsZero() {
+		t.Fatalf("预期成交后没有持仓，实际为 %s", s.Position().Amount)
+	}
+
+	// 再次入场挂出新的跟踪止损单，已成交的挂单不再撤销
+	signal, err = s.Update(closeKlines(11, 12)[1])
+	if err != nil {
+		t.Fatalf("failed to update strategy: %v", err)
+	}
+	if !signal.Type.IsBuy() || len(signal.Orders) != 1 || signal.Orders[0].ID == trailing.ID {
+		t.Fatalf("预期再次买入并挂出新的跟踪止损单")
+	}
+	if len(signal.Cancels) != 0 {
+		t.Errorf("预期不撤销已成交的挂单，实际撤销 %v", signal.Cancels)
+	}
+}
+
+func TestNew(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	// 直接构造的定义同样会被校验
+	if _, err := New(ctx, cancel, &Definition{Entry: "close > 1"}); err == nil {
+		t.Fatalf("预期缺少出场方式时返回错误")
+	}
+
+	definition := &Definition{Entry: "rsi(close, 3) > 50", Exit: "rsi(close, 3) < 50"}
+	a, err := New(ctx, cancel, definition)
+	if err != nil {
+		t.Fatalf("创建策略失败: %v", err)
+	}
+	b, err := New(ctx, cancel, definition)
+	if err != nil {
+		t.Fatalf("创建策略失败: %v", err)
+	}
+	if a.Name() != "Rule Strategy" {
+		t.Errorf("预期默认名称为 Rule Strategy，实际为 %s", a.Name())
+	}
+
+	// 同一个定义创建的策略持有各自的指标
+	for _, k := range closeKlines(1, 2, 3, 4) {
+		a.program.update(k)
+	}
+	if !a.program.order[0].Ready() || b.program.order[0].Ready() {
+		t.Errorf("预期两个策略的指标互不影响")
+	}
+}
@@ -0,0 +1,120 @@
+package squeeze_breakout
+
+import (
+	"context"
+	"fmt"
+	"snake/internal/indicates/squeeze"
+	"snake/internal/kline"
+	"snake/internal/strategy"
+
+	"github.com/shopspring/decimal"
+)
+
+// SqueezeBreakoutStrategy 基于波动率挤压的突破策略
+//
+// 布林带收缩到肯特纳通道内（挤压）并持续足够多的K线后，波动率释放时价格常出现单边行情：
+// 挤压释放后的若干根K线内收盘价突破布林带上轨时买入，收盘价跌破布林带中轨时卖出
+type SqueezeBreakoutStrategy struct {
+	*strategy.BaseStrategy
+	// 挤压检测参数
+	config squeeze.Config
+	// minBars 挤压至少持续的K线数量
+	minBars int
+	// window 挤压释放后等待突破的K线数量
+	window int
+	// 挤压检测指标，第一次更新时按参数创建
+	squeeze *squeeze.Stream
+	// armed 剩余可以入场的K线数量，挤压释放时设为 window
+	armed int
+}
+
+var _ strategy.Strategy = (*SqueezeBreakoutStrategy)(nil)
+
+// New 创建挤压突破策略，使用常用的挤压参数，挤压至少持续 6 根K线，释放后 3 根K线内等待突破
+func New(ctx context.Context, cancel context.CancelFunc) *SqueezeBreakoutStrategy {
+	return &SqueezeBreakoutStrategy{
+		BaseStrategy: strategy.NewBaseStrategy(ctx, cancel, "Squeeze Breakout Strategy"),
+		config:       squeeze.DefaultConfig(),
+		minBars:      6,
+		window:       3,
+	}
+}
+
+// SetSqueezeParams 设置挤压检测的参数
+func (s *SqueezeBreakoutStrategy) SetSqueezeParams(config squeeze.Config) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	s.config = config
+	// 重置指标
+	s.squeeze = nil
+	s.armed = 0
+	return nil
+}
+
+// SetBreakoutParams 设置挤压至少持续的K线数量和释放后等待突破的K线数量
+func (s *SqueezeBreakoutStrategy) SetBreakoutParams(minBars, window int) error {
+	if minBars < 1 || window < 1 {
+		return fmt.Errorf("invalid breakout params: min bars %d, window %d", minBars, window)
+	}
+
+	s.minBars = minBars
+	s.window = window
+	return nil
+}
+
+// Update 更新策略状态并生成交易信号
+func (s *SqueezeBreakoutStrategy) Update(kline *kline.Kline) (*strategy.Signal, error) {
+	if s.squeeze == nil {
+		s.squeeze, _ = squeeze.NewStreamWithConfig(s.config)
+	}
+
+	// 增量更新挤压检测
+	s.squeeze.Update(kline)
+
+	// 指标未就绪时持有
+	if !s.squeeze.Ready() {
+		return s.Hold(), nil
+	}
+
+	switch {
+	case s.squeeze.Released() && s.squeeze.Bars() >= s.minBars:
+		s.armed = s.window
+	case s.squeeze.On():
+		s.armed = 0
+	}
+
+	price := kline.C
+	bands := s.squeeze.Bollinger().Values()
+
+	// 当前没有持仓，挤压释放后等待收盘价突破上轨
+	if s.Position().Amount.IsZero() {
+		if s.armed == 0 {
+			return s.Hold(), nil
+		}
+		s.armed--
+		if price.GreaterThan(bands["upper"]) {
+			s.armed = 0
+			// 生成买入信号，使用95%的余额，按市价买入
+			if signal := s.Buy(s.BuyingPower().Mul(decimal.NewFromFloat(0.95)), price); signal != nil {
+				return signal, nil
+			}
+		}
+		return s.Hold(), nil
+	}
+
+	// 当前有持仓，收盘价跌破中轨时卖出全部持仓
+	if price.LessThan(bands["middle"]) {
+		if signal := s.Sell(s.Position().Amount, price); signal != nil {
+			return signal, nil
+		}
+	}
+
+	return s.Hold(), nil
+}
+
+// Profit 返回当前盈亏
+func (s *SqueezeBreakoutStrategy) Profit() (absolute, percentage decimal.Decimal) {
+	return s.BaseStrategy.Profit()
+}
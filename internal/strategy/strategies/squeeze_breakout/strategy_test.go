@@ -0,0 +1,134 @@
+package squeeze_breakout
+
+import (
+	"context"
+	"snake/internal/indicates/squeeze"
+	"snake/internal/kline"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// generatePhaseKlines 生成四段K线：前 30 根上涨，中间 40 根窄幅横盘，之后 20 根向上突破，最后 30 根下跌
+func generatePhaseKlines(startTime int64) []*kline.Kline {
+	var klines []*kline.Kline
+	price := 100.0
+	for i := range 120 {
+		switch {
+		case i < 30, i >= 70 && i < 90:
+			price += 3
+		case i >= 90:
+			price -= 3
+		case i%2 == 0:
+			price += 0.1
+		default:
+			price -= 0.1
+		}
+		klines = append(klines, &kline.Kline{
+			O: decimal.NewFromFloat(price),
+			C: decimal.NewFromFloat(price),
+			H: decimal.NewFromFloat(price + 1),
+			L: decimal.NewFromFloat(price - 1),
+			V: decimal.NewFromFloat(1000.0),
+			A: decimal.NewFromFloat(price * 1000.0),
+			S: startTime + int64(i)*60000,
+			E: startTime + int64(i+1)*60000 - 1,
+		})
+	}
+	return klines
+}
+
+func TestSqueezeBreakoutStrategy(t *testing.T) {
+	klines := generatePhaseKlines(time.Now().Unix() * 1000)
+
+	strategy := New(context.WithCancel(context.TODO()))
+	if err := strategy.Init(decimal.Zero, decimal.NewFromInt(10000)); err != nil {
+		t.Fatalf("failed to init strategy: %v", err)
+	}
+
+	var buys, sells []int
+	for i, k := range klines {
+		signal, err := strategy.Update(k)
+		if err != nil {
+			t.Fatalf("failed to update strategy: %v", err)
+		}
+
+		switch {
+		case signal.Type.IsBuy():
+			buys = append(buys, i)
+			if upper := strategy.squeeze.Bollinger().Values()["upper"]; !k.C.GreaterThan(upper) {
+				t.Errorf("第 %d 根K线买入时预期收盘价 %s 高于上轨 %s", i+1, k.C, upper)
+			}
+		case signal.Type.IsSell():
+			sells = append(sells, i)
+		case i+1 < strategy.squeeze.WarmUp() && !signal.Type.IsHold():
+			t.Fatalf("第 %d 根K线指标未就绪，预期持有", i+1)
+		}
+	}
+
+	if len(buys) != 1 || buys[0] < 70 || buys[0] >= 70+strategy.window+3 {
+		t.Fatalf("预期在挤压释放后的突破阶段买入一次，实际买入位置为 %v", buys)
+	}
+	if len(sells) != 1 || sells[0] < 90 {
+		t.Fatalf("预期在下跌阶段卖出，实际卖出位置为 %v", sells)
+	}
+	if !strategy.Position().Amount.IsZero() {
+		t.Errorf("预期最后没有持仓，实际为 %s", strategy.Position().Amount)
+	}
+}
+
+// TestMinBars 挤压持续的K线数量不足时不入场
+func TestMinBars(t *testing.T) {
+	strategy := New(context.WithCancel(context.TODO()))
+	if err := strategy.SetBreakoutParams(100, 3); err != nil {
+		t.Fatalf("failed to set params: %v", err)
+	}
+	if err := strategy.Init(decimal.Zero, decimal.NewFromInt(10000)); err != nil {
+		t.Fatalf("failed to init strategy: %v", err)
+	}
+
+	for i, k := range generatePhaseKlines(0) {
+		signal, err := strategy.Update(k)
+		if err != nil {
+			t.Fatalf("failed to update strategy: %v", err)
+		}
+		if !signal.Type.IsHold() {
+			t.Fatalf("第 %d 根K线预期持有，实际为 %v", i+1, signal.Type)
+		}
+	}
+}
+
+func TestSetParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		set     func(s *SqueezeBreakoutStrategy) error
+		wantErr bool
+	}{
+		{name: "挤压参数", set: func(s *SqueezeBreakoutStrategy) error {
+			config := squeeze.DefaultConfig()
+			config.Period = 10
+			return s.SetSqueezeParams(config)
+		}},
+		{name: "无效挤压参数", set: func(s *SqueezeBreakoutStrategy) error {
+			config := squeeze.DefaultConfig()
+			config.Period = 0
+			return s.SetSqueezeParams(config)
+		}, wantErr: true},
+		{name: "突破参数", set: func(s *SqueezeBreakoutStrategy) error { return s.SetBreakoutParams(4, 5) }},
+		{name: "无效突破参数", set: func(s *SqueezeBreakoutStrategy) error { return s.SetBreakoutParams(0, 3) }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strategy := New(context.WithCancel(context.TODO()))
+			err := tt.set(strategy)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("预期错误为 %v，实际为 %v", tt.wantErr, err)
+			}
+			if tt.wantErr && (strategy.config.Period != 20 || strategy.minBars != 6 || strategy.window != 3) {
+				t.Errorf("预期参数未被修改")
+			}
+		})
+	}
+}
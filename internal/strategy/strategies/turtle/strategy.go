@@ -2,9 +2,12 @@ package turtle
 
 import (
 	"context"
+	"snake/internal/indicates/atr"
 	donchianchannel "snake/internal/indicates/donchian-channel"
+	"snake/internal/indicates/ma"
 	"snake/internal/kline"
 	"snake/internal/strategy"
+	"snake/internal/types"
 
 	"github.com/shopspring/decimal"
 )
@@ -12,11 +15,11 @@ import (
 // TurtleStrategy 海龟交易法则策略
 type TurtleStrategy struct {
 	*strategy.BaseStrategy
-	// 保存历史K线用于计算指标
-	historicalKlines []*kline.Kline
 	// 策略参数
 	donchianPeriod int     // 唐奇安通道周期（一般为20）
+	exitPeriod     int     // 离场唐奇安通道周期（一般为10）
 	atrPeriod      int     // ATR计算周期（一般为14）
+	atrSmoothing   ma.Type // ATR平滑方式（默认为 Wilder 平滑）
 	riskPercent    float64 // 风险比例（每次交易风险占总资产的百分比，一般为1-2%）
 	entryUnits     int     // 入场单元数（一般为1-4）
 	currentUnits   int     // 当前持有单元数
@@ -25,61 +28,55 @@ type TurtleStrategy struct {
 	lastEntryPrice decimal.Decimal // 上次入场价格
 	lastExitPrice  decimal.Decimal // 上次出场价格
 	// 唐奇安通道指标
-	donchianChannel *donchianchannel.DC // 唐奇安通道指标
-	atr             decimal.Decimal     // 当前ATR值
-	stopLoss        decimal.Decimal     // 止损价
+	entryChannel *donchianchannel.Stream // 入场唐奇安通道
+	exitChannel  *donchianchannel.Stream // 离场唐奇安通道
+	upper        decimal.Decimal         // 入场通道上轨（周期内最高价）
+	lower        decimal.Decimal         // 入场通道下轨（周期内最低价）
+	atrIndicator *atr.Stream             // ATR指标，第一次更新时按参数创建
+	atr          decimal.Decimal         // 当前ATR值（海龟法则中的 N）
+	stopLoss     decimal.Decimal         // 止损价
 }
 
 // New 创建海龟交易法则策略
 func New(ctx context.Context, cancel context.CancelFunc) *TurtleStrategy {
-	return &TurtleStrategy{
-		BaseStrategy:     strategy.NewBaseStrategy(ctx, cancel, "Turtle Trading Strategy"),
-		historicalKlines: make([]*kline.Kline, 0, 50), // 预分配足够容量
-		donchianPeriod:   20,
-		atrPeriod:        14,
-		riskPercent:      2.0, // 2%
-		entryUnits:       4,
-		currentUnits:     0,
-		position:         "none",
-		lastEntryPrice:   decimal.Zero,
-		lastExitPrice:    decimal.Zero,
-		donchianChannel:  nil,
-		atr:              decimal.Zero,
-		stopLoss:         decimal.Zero,
+	s := &TurtleStrategy{
+		BaseStrategy:   strategy.NewBaseStrategy(ctx, cancel, "Turtle Trading Strategy"),
+		donchianPeriod: 20,
+		exitPeriod:     10,
+		atrPeriod:      14,
+		atrSmoothing:   ma.TypeRMA,
+		riskPercent:    2.0, // 2%
+		entryUnits:     4,
+		currentUnits:   0,
+		position:       "none",
+		lastEntryPrice: decimal.Zero,
+		lastExitPrice:  decimal.Zero,
+		atr:            decimal.Zero,
+		stopLoss:       decimal.Zero,
 	}
+	s.entryChannel = donchianchannel.NewStream(s.donchianPeriod)
+	s.exitChannel = donchianchannel.NewStream(s.exitPeriod)
+	return s
 }
 
 // Update 更新策略状态
 func (s *TurtleStrategy) Update(kline *kline.Kline) (*strategy.Signal, error) {
-	println("Update - 添加K线:", kline.S, "->", kline.E, "收盘价:", kline.C.String())
+	// ATR 和唐奇安通道每根K线都需要更新
+	s.updateATR(kline)
+	s.updateChannels(kline)
 
-	// 添加新的K线到历史数据
-	s.historicalKlines = append(s.historicalKlines, kline)
+	// 止损单成交或仓位被强平后账户已无持仓，重置持仓状态
+	if s.position != "none" && s.Position().Amount.IsZero() {
+		s.resetPosition(kline.C)
+	}
 
 	// 需要足够的历史数据来生成信号
-	if len(s.historicalKlines) < s.donchianPeriod {
-		println("历史数据不足:", len(s.historicalKlines), "<", s.donchianPeriod)
+	if !s.entryChannel.Ready() {
 		return s.Hold(), nil
 	}
 
-	// 限制历史数据长度
-	if len(s.historicalKlines) > s.donchianPeriod*2 {
-		s.historicalKlines = s.historicalKlines[len(s.historicalKlines)-s.donchianPeriod*2:]
-	}
-
-	// 计算指标
-	s.calculateIndicators()
-	println("计算指标 - 最高高点:", s.donchianChannel.Upper.String(), "最低低点:", s.donchianChannel.Lower.String(), "ATR:", s.atr.String())
-
 	// 计算交易数量
 	tradeAmount := s.calculatePositionSize(kline.C)
-	println("计算交易数量:", tradeAmount.String(), "基于价格:", kline.C.String())
-
-	// 当前持仓盈亏
-	if !s.Position().Amount.IsZero() {
-		absolute, percentage := s.BaseStrategy.Profit()
-		println("当前持仓盈亏：", absolute.String(), "USDT (", percentage.String(), "%)")
-	}
 
 	// 根据当前仓位执行不同的交易逻辑
 	var signal *strategy.Signal
@@ -108,79 +105,46 @@ func (s *TurtleStrategy) Update(kline *kline.Kline) (*strategy.Signal, error) {
 	return s.Hold(), nil
 }
 
-// calculateIndicators 计算策略所需的技术指标
-func (s *TurtleStrategy) calculateIndicators() {
-	// 计算唐奇安通道
-	s.calculateDonchianChannel()
-
-	// 计算ATR
-	s.calculateATR()
-}
-
-// calculateDonchianChannel 计算唐奇安通道
-func (s *TurtleStrategy) calculateDonchianChannel() {
-	// 确保有足够的历史数据
-	if len(s.historicalKlines) < s.donchianPeriod {
-		return
+// SetATRParams 设置ATR周期和平滑方式，smoothing 为 ma.TypeRMA（Wilder 平滑）或 ma.TypeSMA 等
+func (s *TurtleStrategy) SetATRParams(period int, smoothing ma.Type) error {
+	if _, err := atr.NewStreamWithMA(smoothing, period); err != nil {
+		return err
 	}
 
-	// 使用donchian-channel包计算唐奇安通道
-	s.donchianChannel = donchianchannel.NewWithPeriod(s.donchianPeriod, s.historicalKlines...)
+	s.atrPeriod = period
+	s.atrSmoothing = smoothing
+	// 重置指标
+	s.atrIndicator = nil
+	s.atr = decimal.Zero
+	return nil
 }
 
-// calculateATR 计算平均真实范围（Average True Range）
-func (s *TurtleStrategy) calculateATR() {
-	// 确保有足够的历史数据
-	if len(s.historicalKlines) < s.atrPeriod+1 {
-		return
+// updateChannels 推入K线，增量更新入场和离场唐奇安通道
+func (s *TurtleStrategy) updateChannels(kline *kline.Kline) {
+	s.entryChannel.Update(kline)
+	s.exitChannel.Update(kline)
+	if s.entryChannel.Ready() {
+		values := s.entryChannel.Values()
+		s.upper, s.lower = values["upper"], values["lower"]
 	}
+}
 
-	// 计算真实范围（True Range）序列
-	trValues := make([]decimal.Decimal, s.atrPeriod)
-
-	// 获取计算范围内的K线
-	dataRange := s.historicalKlines[len(s.historicalKlines)-s.atrPeriod-1:]
-
-	// 计算每个周期的TR值
-	for i := 1; i <= s.atrPeriod; i++ {
-		current := dataRange[i]
-		previous := dataRange[i-1]
-
-		// TR = max(high-low, |high-prev_close|, |low-prev_close|)
-		hl := current.H.Sub(current.L)
-		hpc := current.H.Sub(previous.C).Abs()
-		lpc := current.L.Sub(previous.C).Abs()
-
-		// 找出最大值
-		tr := hl
-		if hpc.GreaterThan(tr) {
-			tr = hpc
-		}
-		if lpc.GreaterThan(tr) {
-			tr = lpc
-		}
-
-		trValues[i-1] = tr
+// updateATR 推入K线，增量更新ATR
+func (s *TurtleStrategy) updateATR(kline *kline.Kline) {
+	if s.atrIndicator == nil {
+		s.atrIndicator, _ = atr.NewStreamWithMA(s.atrSmoothing, s.atrPeriod)
 	}
 
-	// 计算ATR（简单平均）
-	sum := decimal.Zero
-	for _, tr := range trValues {
-		sum = sum.Add(tr)
+	s.atrIndicator.Update(kline)
+	if s.atrIndicator.Ready() {
+		s.atr = s.atrIndicator.Value()
 	}
-
-	s.atr = sum.Div(decimal.NewFromInt(int64(s.atrPeriod)))
 }
 
 // calculatePositionSize 计算交易头寸大小
 func (s *TurtleStrategy) calculatePositionSize(price decimal.Decimal) decimal.Decimal {
 	// 获取账户总值
-	accountValue := s.Balance().Amount
-	positionValue := decimal.Zero
-	if !s.Position().Amount.IsZero() {
-		positionValue = s.Position().Amount.Mul(price)
-	}
-	totalValue := accountValue.Add(positionValue)
+	totalValue := s.Equity(price)
 
 	// 计算单位资金
 	riskAmount := totalValue.Mul(decimal.NewFromFloat(s.riskPercent / 100.0))
@@ -211,13 +175,14 @@ func (s *TurtleStrategy) calculatePositionSize(price decimal.Decimal) decimal.De
 
 // evaluateEntry 评估是否入场
 func (s *TurtleStrategy) evaluateEntry(kline *kline.Kline, tradeAmount decimal.Decimal) (*strategy.Signal, error) {
-	// 调试信息
-	println("evaluateEntry - 收盘价:", kline.C.String(), "最高高点:", s.donchianChannel.Upper.String(), "最低低点:", s.donchianChannel.Lower.String())
-	println("突破条件:", kline.C.GreaterThanOrEqual(s.donchianChannel.Upper), s.position != "long")
-
 	// 系统1：价格突破20日高点，做多入场
-	if kline.C.GreaterThanOrEqual(s.donchianChannel.Upper) && s.position != "long" {
-		println("满足多头入场条件")
+	if kline.C.GreaterThanOrEqual(s.upper) && s.position != "long" {
+		// 生成买入信号，余额不足或被风控拒绝时不入场
+		signal := s.Buy(tradeAmount, kline.C)
+		if signal == nil {
+			return nil, nil
+		}
+
 		// 设置止损价（通常为入场价减去2个ATR）
 		s.stopLoss = kline.C.Sub(s.atr.Mul(decimal.NewFromInt(2)))
 
@@ -226,15 +191,18 @@ func (s *TurtleStrategy) evaluateEntry(kline *kline.Kline, tradeAmount decimal.D
 		s.currentUnits = 1
 		s.lastEntryPrice = kline.C
 
-		// 生成买入信号
-		return s.Buy(tradeAmount, kline.C), nil
+		// 挂出止损单
+		return s.PlaceStops(signal, s.stopOrder(), nil), nil
 	}
 
-	println("突破条件:", kline.C.LessThanOrEqual(s.donchianChannel.Lower), s.position != "short")
-
 	// 系统1：价格突破20日低点，做空入场
-	if kline.C.LessThanOrEqual(s.donchianChannel.Lower) && s.position != "short" {
-		println("满足空头入场条件")
+	if kline.C.LessThanOrEqual(s.lower) && s.position != "short" {
+		// 生成卖出信号，未开启保证金且没有多头持仓时无法做空
+		signal := s.Sell(tradeAmount, kline.C)
+		if signal == nil {
+			return nil, nil
+		}
+
 		// 设置止损价（通常为入场价加上2个ATR）
 		s.stopLoss = kline.C.Add(s.atr.Mul(decimal.NewFromInt(2)))
 
@@ -243,8 +211,7 @@ func (s *TurtleStrategy) evaluateEntry(kline *kline.Kline, tradeAmount decimal.D
 		s.currentUnits = 1
 		s.lastEntryPrice = kline.C
 
-		// 生成卖出信号
-		return s.Sell(tradeAmount, kline.C), nil
+		return s.PlaceStops(signal, s.stopOrder(), nil), nil
 	}
 
 	return nil, nil
@@ -252,38 +219,21 @@ func (s *TurtleStrategy) evaluateEntry(kline *kline.Kline, tradeAmount decimal.D
 
 // evaluateLongPosition 评估多头持仓
 func (s *TurtleStrategy) evaluateLongPosition(kline *kline.Kline, tradeAmount decimal.Decimal) (*strategy.Signal, error) {
-	// 调试信息
-	println("evaluateLongPosition - 收盘价:", kline.C.String(), "止损价:", s.stopLoss.String())
-
 	// 检查是否触发止损
 	if kline.C.LessThanOrEqual(s.stopLoss) {
-		println("触发止损出场")
 		// 平掉所有仓位
 		totalPosition := s.Position().Amount
 		if !totalPosition.IsZero() {
-			s.position = "none"
-			s.currentUnits = 0
-			s.lastExitPrice = kline.C
-			return s.Sell(totalPosition, kline.C), nil
+			return s.closePosition(s.Sell(totalPosition, kline.C), kline.C), nil
 		}
 	}
 
 	// 检查是否触发利润保护（如价格跌破10日低点）
-	exitPeriod := 10 // 退出使用较短周期
-	if len(s.historicalKlines) > exitPeriod {
-		// 创建退出用的短周期唐奇安通道
-		exitDC := donchianchannel.NewWithPeriod(exitPeriod, s.historicalKlines...)
-
-		if exitDC != nil && kline.C.LessThanOrEqual(exitDC.Lower) {
-			println("触发利润保护出场")
-			// 平掉所有仓位
-			totalPosition := s.Position().Amount
-			if !totalPosition.IsZero() {
-				s.position = "none"
-				s.currentUnits = 0
-				s.lastExitPrice = kline.C
-				return s.Sell(totalPosition, kline.C), nil
-			}
+	if s.exitChannel.Ready() && kline.C.LessThanOrEqual(s.exitChannel.Values()["lower"]) {
+		// 平掉所有仓位
+		totalPosition := s.Position().Amount
+		if !totalPosition.IsZero() {
+			return s.closePosition(s.Sell(totalPosition, kline.C), kline.C), nil
 		}
 	}
 
@@ -294,15 +244,16 @@ func (s *TurtleStrategy) evaluateLongPosition(kline *kline.Kline, tradeAmount de
 		nextEntryPrice := s.lastEntryPrice.Add(atrJump)
 
 		if kline.C.GreaterThanOrEqual(nextEntryPrice) {
-			println("触发加仓条件")
-			// 执行加仓
+			// 执行加仓，加仓数量为当前价格的USDT数量
+			signal := s.Buy(tradeAmount.Mul(kline.C), kline.C)
+			if signal == nil {
+				return nil, nil
+			}
 			s.currentUnits++
 			s.lastEntryPrice = kline.C
 			// 更新止损
 			s.stopLoss = kline.C.Sub(s.atr.Mul(decimal.NewFromFloat(2)))
-			// 计算加仓数量（使用当前价格的USDT数量）
-			usdtAmount := tradeAmount.Mul(kline.C)
-			return s.Buy(usdtAmount, kline.C), nil
+			return s.PlaceStops(signal, s.stopOrder(), nil), nil
 		}
 	}
 
@@ -311,38 +262,21 @@ func (s *TurtleStrategy) evaluateLongPosition(kline *kline.Kline, tradeAmount de
 
 // evaluateShortPosition 评估空头持仓
 func (s *TurtleStrategy) evaluateShortPosition(kline *kline.Kline, tradeAmount decimal.Decimal) (*strategy.Signal, error) {
-	// 调试信息
-	println("evaluateShortPosition - 收盘价:", kline.C.String(), "止损价:", s.stopLoss.String())
-
 	// 检查是否触发止损
 	if kline.C.GreaterThanOrEqual(s.stopLoss) {
-		println("触发止损出场")
-		// 平掉所有仓位
+		// 买入回补所有空头
 		totalPosition := s.Position().Amount
-		if !totalPosition.IsZero() {
-			s.position = "none"
-			s.currentUnits = 0
-			s.lastExitPrice = kline.C
-			return s.Buy(totalPosition, kline.C), nil
+		if totalPosition.IsNegative() {
+			return s.closePosition(s.Buy(totalPosition.Neg().Mul(kline.C), kline.C), kline.C), nil
 		}
 	}
 
 	// 检查是否触发利润保护（如价格突破10日高点）
-	exitPeriod := 10 // 退出使用较短周期
-	if len(s.historicalKlines) > exitPeriod {
-		// 创建退出用的短周期唐奇安通道
-		exitDC := donchianchannel.NewWithPeriod(exitPeriod, s.historicalKlines...)
-
-		if exitDC != nil && kline.C.GreaterThanOrEqual(exitDC.Upper) {
-			println("触发利润保护出场")
-			// 平掉所有仓位
-			totalPosition := s.Position().Amount
-			if !totalPosition.IsZero() {
-				s.position = "none"
-				s.currentUnits = 0
-				s.lastExitPrice = kline.C
-				return s.Buy(totalPosition, kline.C), nil
-			}
+	if s.exitChannel.Ready() && kline.C.GreaterThanOrEqual(s.exitChannel.Values()["upper"]) {
+		// 买入回补所有空头
+		totalPosition := s.Position().Amount
+		if totalPosition.IsNegative() {
+			return s.closePosition(s.Buy(totalPosition.Neg().Mul(kline.C), kline.C), kline.C), nil
 		}
 	}
 
@@ -353,37 +287,52 @@ func (s *TurtleStrategy) evaluateShortPosition(kline *kline.Kline, tradeAmount d
 		nextEntryPrice := s.lastEntryPrice.Sub(atrJump)
 
 		if kline.C.LessThanOrEqual(nextEntryPrice) {
-			println("触发加仓条件")
-			// 执行加仓
+			// 执行加仓，加空数量为 base 数量
+			signal := s.Sell(tradeAmount, kline.C)
+			if signal == nil {
+				return nil, nil
+			}
 			s.currentUnits++
 			s.lastEntryPrice = kline.C
 			// 更新止损
 			s.stopLoss = kline.C.Add(s.atr.Mul(decimal.NewFromFloat(2)))
-			// 计算加仓数量（使用当前价格的USDT数量）
-			usdtAmount := tradeAmount.Mul(kline.C)
-			return s.Sell(usdtAmount, kline.C), nil
+			return s.PlaceStops(signal, s.stopOrder(), nil), nil
 		}
 	}
 
 	return nil, nil
 }
 
-// Profit 返回当前盈亏
-func (s *TurtleStrategy) Profit() (absolute, percentage decimal.Decimal) {
-	return s.BaseStrategy.Profit()
+// closePosition 平仓成交后重置持仓状态并撤销止损单，平仓失败时保持原状态
+func (s *TurtleStrategy) closePosition(signal *strategy.Signal, price decimal.Decimal) *strategy.Signal {
+	if signal == nil {
+		return nil
+	}
+
+	s.resetPosition(price)
+	return s.CancelStops(signal)
 }
 
-// 辅助方法，转发到BaseStrategy
-func (s *TurtleStrategy) Buy(amount, price decimal.Decimal) *strategy.Signal {
-	println("发出买入信号, 数量:", amount.String(), "价格:", price.String())
-	return s.BaseStrategy.Buy(amount, price)
+// resetPosition 重置持仓状态，记录出场价格
+func (s *TurtleStrategy) resetPosition(price decimal.Decimal) {
+	s.position = "none"
+	s.currentUnits = 0
+	s.lastExitPrice = price
 }
 
-func (s *TurtleStrategy) Sell(amount, price decimal.Decimal) *strategy.Signal {
-	println("发出卖出信号, 数量:", amount.String(), "价格:", price.String())
-	return s.BaseStrategy.Sell(amount, price)
+// stopOrder 按当前止损价为全部持仓创建止损单
+// 止损单由回测引擎按K线最高价、最低价撮合，不再只用收盘价判断
+func (s *TurtleStrategy) stopOrder() *strategy.Order {
+	if s.Position().Amount.IsNegative() {
+		// 空头止损为买入止损单，按成交价回补全部空头数量
+		order := strategy.StopOrder(types.SignalTypeBuy, decimal.Zero, s.stopLoss)
+		order.Volume = s.Position().Amount.Neg()
+		return order
+	}
+	return strategy.StopOrder(types.SignalTypeSell, s.Position().Amount, s.stopLoss)
 }
 
-func (s *TurtleStrategy) Hold() *strategy.Signal {
-	return s.BaseStrategy.Hold()
+// Profit 返回当前盈亏
+func (s *TurtleStrategy) Profit() (absolute, percentage decimal.Decimal) {
+	return s.BaseStrategy.Profit()
 }
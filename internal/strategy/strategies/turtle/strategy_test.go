@@ -2,7 +2,8 @@ package turtle
 
 import (
 	"context"
-	donchianchannel "snake/internal/indicates/donchian-channel"
+	"snake/internal/indicates/atr"
+	"snake/internal/indicates/ma"
 	"snake/internal/kline"
 	"testing"
 	"time"
@@ -54,13 +55,9 @@ func TestTurtleStrategy(t *testing.T) {
 		err := strategy.Init(decimal.NewFromFloat(0.0), decimal.NewFromFloat(10000.0))
 		assert.NoError(t, err)
 
-		// 不填充历史数据，而是直接设置策略状态
-		// 创建一个唐奇安通道并设置
-		strategy.donchianChannel = &donchianchannel.DC{
-			Upper:  decimal.NewFromFloat(104.0),
-			Lower:  decimal.NewFromFloat(100.0),
-			Middle: decimal.NewFromFloat(102.0),
-		}
+		// 不填充历史数据，而是直接设置入场通道上下轨
+		strategy.upper = decimal.NewFromFloat(104.0)
+		strategy.lower = decimal.NewFromFloat(100.0)
 		strategy.atr = decimal.NewFromFloat(2.0)
 
 		// 创建一个突破K线，其收盘价高于最高高点
@@ -77,7 +74,7 @@ func TestTurtleStrategy(t *testing.T) {
 
 		// 打印调试信息
 		t.Logf("突破前 Upper: %v, 当前位置: %s, 单元数: %d",
-			strategy.donchianChannel.Upper, strategy.position, strategy.currentUnits)
+			strategy.upper, strategy.position, strategy.currentUnits)
 		t.Logf("突破K线信息 - 开盘: %v, 最高: %v, 最低: %v, 收盘: %v",
 			breakoutKline.O, breakoutKline.H, breakoutKline.L, breakoutKline.C)
 
@@ -101,6 +98,36 @@ func TestTurtleStrategy(t *testing.T) {
 		assert.False(t, strategy.stopLoss.IsZero())
 	})
 
+	// 测试余额不足时入场失败，策略状态保持不变
+	t.Run("Rejected Long Entry", func(t *testing.T) {
+		strategy := New(context.WithCancel(context.TODO()))
+
+		err := strategy.Init(decimal.NewFromFloat(0.0), decimal.NewFromFloat(10.0))
+		assert.NoError(t, err)
+
+		strategy.upper = decimal.NewFromFloat(104.0)
+		strategy.lower = decimal.NewFromFloat(100.0)
+		strategy.atr = decimal.NewFromFloat(2.0)
+
+		breakoutKline := &kline.Kline{
+			O: decimal.NewFromFloat(105.0),
+			H: decimal.NewFromFloat(106.0),
+			L: decimal.NewFromFloat(104.0),
+			C: decimal.NewFromFloat(105.5),
+			S: time.Now().Unix() * 1000,
+			E: time.Now().Unix()*1000 + 60000,
+		}
+
+		signal, err := strategy.evaluateEntry(breakoutKline, decimal.NewFromFloat(100.0))
+		assert.NoError(t, err)
+		assert.Nil(t, signal)
+
+		assert.Equal(t, "none", strategy.position)
+		assert.Equal(t, 0, strategy.currentUnits)
+		assert.True(t, strategy.lastEntryPrice.IsZero())
+		assert.True(t, strategy.stopLoss.IsZero())
+	})
+
 	// 测试做空入场信号
 	t.Run("Short Entry Signal", func(t *testing.T) {
 		strategy := New(context.WithCancel(context.TODO()))
@@ -109,13 +136,9 @@ func TestTurtleStrategy(t *testing.T) {
 		err := strategy.Init(decimal.NewFromFloat(1.0), decimal.NewFromFloat(10000.0))
 		assert.NoError(t, err)
 
-		// 不填充历史数据，而是直接设置策略状态
-		// 创建一个唐奇安通道并设置
-		strategy.donchianChannel = &donchianchannel.DC{
-			Upper:  decimal.NewFromFloat(104.0),
-			Lower:  decimal.NewFromFloat(100.0),
-			Middle: decimal.NewFromFloat(102.0),
-		}
+		// 不填充历史数据，而是直接设置入场通道上下轨
+		strategy.upper = decimal.NewFromFloat(104.0)
+		strategy.lower = decimal.NewFromFloat(100.0)
 		strategy.atr = decimal.NewFromFloat(2.0)
 
 		// 创建一个突破低点的K线
@@ -132,7 +155,7 @@ func TestTurtleStrategy(t *testing.T) {
 
 		// 打印调试信息
 		t.Logf("突破前 Lower: %v, 当前位置: %s, 单元数: %d",
-			strategy.donchianChannel.Lower, strategy.position, strategy.currentUnits)
+			strategy.lower, strategy.position, strategy.currentUnits)
 		t.Logf("突破K线信息 - 开盘: %v, 最高: %v, 最低: %v, 收盘: %v",
 			breakoutKline.O, breakoutKline.H, breakoutKline.L, breakoutKline.C)
 
@@ -250,41 +273,129 @@ func TestTurtleStrategy(t *testing.T) {
 		assert.Equal(t, 0, strategy.currentUnits)
 	})
 
-	// 测试止盈功能 - 直接手动测试策略中的逻辑，而不是调用evaluateLongPosition
+	// 测试利润保护：收盘价跌破离场通道下轨时平掉多头
 	t.Run("Profit Protection", func(t *testing.T) {
-		// 测试直接验证逻辑，而不是调用实际的方法
-		// 假设K线低于退出通道的情况
-		kline := &kline.Kline{
-			C: decimal.NewFromFloat(102.5), // 收盘价低于退出通道下轨
+		strategy := New(context.WithCancel(context.TODO()))
+
+		err := strategy.Init(decimal.NewFromFloat(1.0), decimal.NewFromFloat(10000.0))
+		assert.NoError(t, err)
+
+		strategy.position = "long"
+		strategy.currentUnits = 1
+		strategy.lastEntryPrice = decimal.NewFromFloat(105.0)
+		strategy.stopLoss = decimal.NewFromFloat(90.0)
+		strategy.atr = decimal.NewFromFloat(2.0)
+
+		// 前 10 根K线的最低价为 99，离场通道就绪
+		for _, k := range klines[:10] {
+			strategy.updateChannels(k)
 		}
+		assert.True(t, strategy.exitChannel.Ready())
+		assert.False(t, strategy.entryChannel.Ready())
 
-		// 创建一个模拟的退出通道
-		exitDC := &donchianchannel.DC{
-			Lower: decimal.NewFromFloat(103.0), // 下轨设置为103
+		exitKline := &kline.Kline{
+			O: decimal.NewFromFloat(99.5),
+			H: decimal.NewFromFloat(100.0),
+			L: decimal.NewFromFloat(98.0),
+			C: decimal.NewFromFloat(98.0),
+			S: klines[10].S,
+			E: klines[10].E,
 		}
+		strategy.updateChannels(exitKline)
 
-		// 验证当K线低于退出通道下轨时的逻辑
-		isBelowLower := kline.C.LessThanOrEqual(exitDC.Lower)
-		t.Logf("K线收盘价(%s) <= 退出通道下轨(%s): %v",
-			kline.C.String(), exitDC.Lower.String(), isBelowLower)
+		signal, err := strategy.evaluateLongPosition(exitKline, decimal.NewFromFloat(0.5))
+		assert.NoError(t, err)
+		assert.NotNil(t, signal)
+		assert.True(t, signal.Type.IsSell())
+		assert.Equal(t, "none", strategy.position, "触发止盈后位置应变为none")
+		assert.Equal(t, 0, strategy.currentUnits, "触发止盈后单元数应为0")
+	})
+
+	// 测试止损单成交后，下一次更新重置持仓状态
+	t.Run("Stop Order Filled", func(t *testing.T) {
+		strategy := New(context.WithCancel(context.TODO()))
 
-		// 断言K线价格确实低于退出通道下轨
-		assert.True(t, isBelowLower, "K线价格应低于退出通道下轨")
+		err := strategy.Init(decimal.NewFromFloat(0.0), decimal.NewFromFloat(10000.0))
+		assert.NoError(t, err)
 
-		// 模拟策略的状态变化
-		position := "long"
-		currentUnits := 1
+		strategy.upper = decimal.NewFromFloat(104.0)
+		strategy.lower = decimal.NewFromFloat(100.0)
+		strategy.atr = decimal.NewFromFloat(2.0)
 
-		// 模拟退出逻辑
-		if isBelowLower {
-			// 执行卖出操作
-			position = "none"
-			currentUnits = 0
+		breakoutKline := &kline.Kline{
+			O: decimal.NewFromFloat(105.0),
+			H: decimal.NewFromFloat(106.0),
+			L: decimal.NewFromFloat(104.0),
+			C: decimal.NewFromFloat(105.5),
+			S: klines[0].S,
+			E: klines[0].E,
 		}
+		signal, err := strategy.evaluateEntry(breakoutKline, decimal.NewFromFloat(0.5))
+		assert.NoError(t, err)
+		assert.NotNil(t, signal)
+		assert.Len(t, signal.Orders, 1)
+		assert.Equal(t, "long", strategy.position)
+
+		// 止损单按止损价成交，平掉全部多头
+		stop := signal.Orders[0]
+		assert.NotNil(t, strategy.Fill(stop, strategy.stopLoss))
+		assert.True(t, strategy.Position().Amount.IsZero())
 
-		// 验证状态是否正确更新
-		assert.Equal(t, "none", position, "触发止盈后位置应变为none")
-		assert.Equal(t, 0, currentUnits, "触发止盈后单元数应为0")
+		_, err = strategy.Update(klines[1])
+		assert.NoError(t, err)
+		assert.Equal(t, "none", strategy.position)
+		assert.Equal(t, 0, strategy.currentUnits)
+	})
+}
+
+func TestTurtleATR(t *testing.T) {
+	klines := generateTestKlines(time.Now().Unix()*1000, 40)
+
+	// 按定义计算最近 period 根K线真实波幅的简单平均
+	simpleATR := func(klines []*kline.Kline, period int) decimal.Decimal {
+		sum := decimal.Zero
+		for i := len(klines) - period; i < len(klines); i++ {
+			sum = sum.Add(atr.TrueRange(klines[i], klines[i-1].C))
+		}
+		return sum.Div(decimal.NewFromInt(int64(period)))
+	}
+
+	t.Run("默认使用 Wilder 平滑", func(t *testing.T) {
+		strategy := New(context.WithCancel(context.TODO()))
+		assert.NoError(t, strategy.Init(decimal.NewFromFloat(1.0), decimal.NewFromFloat(10000.0)))
+
+		expected := atr.NewStream(14)
+		for i, k := range klines {
+			strategy.Update(k)
+			expected.Update(k)
+			if i < 14 {
+				assert.True(t, strategy.atr.IsZero(), "ATR未就绪前应为0")
+				continue
+			}
+			assert.True(t, expected.Value().Equal(strategy.atr), "第 %d 根K线后ATR应为 %s，实际为 %s", i+1, expected.Value(), strategy.atr)
+		}
+	})
+
+	t.Run("简单平均", func(t *testing.T) {
+		strategy := New(context.WithCancel(context.TODO()))
+		assert.NoError(t, strategy.SetATRParams(14, ma.TypeSMA))
+		assert.NoError(t, strategy.Init(decimal.NewFromFloat(1.0), decimal.NewFromFloat(10000.0)))
+
+		for i, k := range klines {
+			strategy.Update(k)
+			if i >= 14 {
+				expected := simpleATR(klines[:i+1], 14)
+				assert.True(t, expected.Equal(strategy.atr), "第 %d 根K线后ATR应为 %s，实际为 %s", i+1, expected, strategy.atr)
+			}
+		}
+	})
+
+	t.Run("无效参数", func(t *testing.T) {
+		strategy := New(context.WithCancel(context.TODO()))
+		assert.Error(t, strategy.SetATRParams(0, ma.TypeRMA))
+		assert.Error(t, strategy.SetATRParams(14, ma.Type("VWMA")))
+		assert.Equal(t, 14, strategy.atrPeriod)
+		assert.Equal(t, ma.TypeRMA, strategy.atrSmoothing)
 	})
 }
 
@@ -0,0 +1,154 @@
+package wasm
+
+import (
+	"encoding/json"
+	"fmt"
+	"snake/internal/kline"
+
+	"github.com/shopspring/decimal"
+)
+
+// 策略模块与宿主之间的调用约定
+//
+// 模块需要导出：
+//   - memory：线性内存
+//   - snake_alloc(size i32) i32：分配 size 字节，返回地址，宿主用它写入每根K线的输入
+//   - snake_update(ptr i32, len i32) i64：处理一根K线的输入（JSON 格式的 Input），
+//     返回输出（JSON 格式的 Output）的地址和长度，编码为 ptr<<32 | len；返回 0 表示持有
+//
+// 模块可以导出 snake_init()，实例化后调用一次，通常在其中创建指标。
+// 宿主在 snake 模块中提供以下函数，字符串均以地址和长度传递：
+//   - log(ptr i32, len i32)：输出日志
+//   - indicator(ptr i32, len i32) i32：按 JSON 格式的 IndicatorSpec 创建指标，返回句柄，失败时返回 -1
+//   - indicator_value(handle i32, ptr i32, len i32) f64：返回指标的输出，键为空时为主值，
+//     指标未就绪或键不存在时返回 NaN
+//
+// 宿主创建的指标在调用 snake_update 之前用同一根K线更新
+const (
+	hostModule         = "snake"
+	exportMemory       = "memory"
+	exportAlloc        = "snake_alloc"
+	exportUpdate       = "snake_update"
+	exportInit         = "snake_init"
+	hostLog            = "log"
+	hostIndicator      = "indicator"
+	hostIndicatorValue = "indicator_value"
+)
+
+// maxOutputSize 模块单次输出的最大字节数
+const maxOutputSize = 64 * 1024
+
+// Input 每根K线传给模块的输入
+type Input struct {
+	Kline   KlineInput   `json:"kline"`
+	Account AccountInput `json:"account"`
+}
+
+// KlineInput 收盘的K线
+type KlineInput struct {
+	Open      decimal.Decimal `json:"open"`
+	High      decimal.Decimal `json:"high"`
+	Low       decimal.Decimal `json:"low"`
+	Close     decimal.Decimal `json:"close"`
+	Volume    decimal.Decimal `json:"volume"`
+	Amount    decimal.Decimal `json:"amount"`
+	OpenTime  int64           `json:"open_time"`
+	CloseTime int64           `json:"close_time"`
+}
+
+// AccountInput 账户状态
+type AccountInput struct {
+	// Position 持仓数量（base）
+	Position decimal.Decimal `json:"position"`
+	// Cost 持仓总成本（quote）
+	Cost decimal.Decimal `json:"cost"`
+	// Balance 可用余额（quote）
+	Balance decimal.Decimal `json:"balance"`
+	// BuyingPower 买入时可用的金额，合约账户为余额乘以杠杆
+	BuyingPower decimal.Decimal `json:"buying_power"`
+	// Profit 当前盈亏（quote）
+	Profit decimal.Decimal `json:"profit"`
+	// ProfitPercent 当前盈亏百分比
+	ProfitPercent decimal.Decimal `json:"profit_percent"`
+}
+
+// Action 模块返回的操作
+type Action string
+
+const (
+	ActionHold Action = "hold"
+	ActionBuy  Action = "buy"
+	ActionSell Action = "sell"
+)
+
+// Output 模块对一根K线的处理结果
+type Output struct {
+	Action Action `json:"action"`
+	// Amount 买入使用的 quote 数量，超过可用金额时按可用金额
+	Amount decimal.Decimal `json:"amount"`
+	// Volume 卖出的 base 数量，为零时卖出全部持仓
+	Volume decimal.Decimal `json:"volume"`
+	// StopLoss 买入后为全部持仓挂出的止损价，为零表示不挂
+	StopLoss decimal.Decimal `json:"stop_loss"`
+	// TakeProfit 买入后为全部持仓挂出的止盈价，为零表示不挂，与止损同时设置时组成 OCO 订单
+	TakeProfit decimal.Decimal `json:"take_profit"`
+}
+
+// IndicatorSpec 模块创建指标的参数，名称和参数与 indicates/catalog 相同
+type IndicatorSpec struct {
+	Name   string            `json:"name"`
+	Params map[string]string `json:"params"`
+}
+
+func newKlineInput(k *kline.Kline) KlineInput {
+	return KlineInput{
+		Open:      k.O,
+		High:      k.H,
+		Low:       k.L,
+		Close:     k.C,
+		Volume:    k.V,
+		Amount:    k.A,
+		OpenTime:  k.S,
+		CloseTime: k.E,
+	}
+}
+
+// parseOutput 解析并检查模块的输出，空输出为持有
+func parseOutput(data []byte) (*Output, error) {
+	output := &Output{Action: ActionHold}
+	if len(data) == 0 {
+		return output, nil
+	}
+	if err := json.Unmarshal(data, output); err != nil {
+		return nil, fmt.Errorf("invalid plugin output: %w", err)
+	}
+
+	switch output.Action {
+	case "", ActionHold:
+		output.Action = ActionHold
+	case ActionBuy:
+		if !output.Amount.IsPositive() {
+			return nil, fmt.Errorf("invalid plugin output: buy amount %s", output.Amount)
+		}
+	case ActionSell:
+		if output.Volume.IsNegative() {
+			return nil, fmt.Errorf("invalid plugin output: sell volume %s", output.Volume)
+		}
+	default:
+		return nil, fmt.Errorf("invalid plugin output: unknown action %q", output.Action)
+	}
+	if output.StopLoss.IsNegative() || output.TakeProfit.IsNegative() {
+		return nil, fmt.Errorf("invalid plugin output: stop loss %s, take profit %s", output.StopLoss, output.TakeProfit)
+	}
+	return output, nil
+}
+
+// packResult 把地址和长度编码为 snake_update 的返回值
+func packResult(ptr, size uint32) uint64 {
+	return uint64(ptr)<<32 | uint64(size)
+}
+
+// unpackResult 解码 snake_update 的返回值
+func unpackResult(result uint64) (ptr, size uint32) {
+	return uint32(result >> 32), uint32(result)
+}
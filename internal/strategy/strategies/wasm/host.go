@@ -0,0 +1,119 @@
+package wasm
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"snake/internal/indicates"
+	"snake/internal/indicates/catalog"
+	"snake/internal/kline"
+	"strings"
+
+	"github.com/CrazyThursdayV50/pkgo/log"
+)
+
+// maxIndicators 每个模块最多创建的指标数量
+const maxIndicators = 64
+
+// maxWarmUp 每个模块创建的指标预热K线数之和的上限。指标按窗口预先分配内存，
+// 这部分内存在宿主进程中，不受 Limits.MemoryPages 限制
+const maxWarmUp = 4 * catalog.MaxPeriod
+
+// maxLogSize 单条模块日志的最大字节数，超出的部分被截断
+const maxLogSize = 1024
+
+// host 宿主为一个模块实例提供的状态：模块创建的指标和日志输出
+type host struct {
+	name       string
+	indicators []indicates.Indicator
+	// warmUp 已创建指标的预热K线数之和
+	warmUp int
+	// logger 为 nil 时丢弃日志
+	logger log.Logger
+	// maxLogs 每根K线最多输出的日志条数
+	maxLogs int
+	// logs 处理当前K线时已输出的日志条数
+	logs int
+}
+
+// newHost 创建宿主状态，日志带上插件名称，每根K线最多输出 maxLogs 条
+func newHost(name string, logger log.Logger, maxLogs int) *host {
+	return &host{name: name, logger: logger, maxLogs: maxLogs}
+}
+
+// update 推入一根K线，更新模块创建的全部指标
+func (h *host) update(k *kline.Kline) {
+	h.logs = 0
+	for _, indicator := range h.indicators {
+		indicator.Update(k)
+	}
+}
+
+// log 输出模块的日志，过长的日志被截断
+func (h *host) log(message []byte) {
+	if len(message) > maxLogSize {
+		message = message[:maxLogSize]
+	}
+	h.logf("%s", strings.ToValidUTF8(string(message), ""))
+}
+
+// logf 输出日志，超出每根K线的条数上限后丢弃，避免模块刷屏
+func (h *host) logf(format string, args ...any) {
+	if h.logger == nil {
+		return
+	}
+
+	h.logs++
+	switch {
+	case h.logs <= h.maxLogs:
+		h.logger.Infof("plugin %s: %s", h.name, fmt.Sprintf(format, args...))
+	case h.logs == h.maxLogs+1:
+		h.logger.Warnf("plugin %s: too many logs, dropped until next kline", h.name)
+	}
+}
+
+// indicator 按 JSON 格式的参数创建指标，返回句柄，失败时返回 -1
+func (h *host) indicator(spec []byte) int32 {
+	if len(h.indicators) >= maxIndicators {
+		h.logf("too many indicators, max %d", maxIndicators)
+		return -1
+	}
+
+	var s IndicatorSpec
+	if err := json.Unmarshal(spec, &s); err != nil {
+		h.logf("invalid indicator spec: %v", err)
+		return -1
+	}
+	indicator, err := catalog.New(s.Name, s.Params)
+	if err != nil {
+		h.logf("create indicator %s failed: %v", s.Name, err)
+		return -1
+	}
+	if h.warmUp+indicator.WarmUp() > maxWarmUp {
+		h.logf("create indicator %s failed: total warm up exceeds %d", s.Name, maxWarmUp)
+		return -1
+	}
+
+	h.warmUp += indicator.WarmUp()
+	h.indicators = append(h.indicators, indicator)
+	return int32(len(h.indicators) - 1)
+}
+
+// indicatorValue 返回指标的输出，键为空时为主值，句柄无效、指标未就绪或键不存在时返回 NaN
+func (h *host) indicatorValue(handle int32, key []byte) float64 {
+	if handle < 0 || int(handle) >= len(h.indicators) {
+		return math.NaN()
+	}
+	indicator := h.indicators[handle]
+	if !indicator.Ready() {
+		return math.NaN()
+	}
+	if len(key) == 0 {
+		return indicator.Value().InexactFloat64()
+	}
+	value, ok := indicator.Values()[string(key)]
+	if !ok {
+		return math.NaN()
+	}
+	return value.InexactFloat64()
+}
@@ -0,0 +1,290 @@
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/CrazyThursdayV50/pkgo/log"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+// Limits 模块的资源限制，数值为零时使用默认值
+type Limits struct {
+	// MemoryPages 线性内存上限，单位为 64KiB 的页，默认 256 页（16MiB）
+	MemoryPages uint32 `json:"memory_pages"`
+	// Timeout 单次调用的执行时间上限，超时后模块被终止，默认 100 毫秒
+	Timeout time.Duration `json:"timeout"`
+	// LogLines 每根K线最多输出的模块日志条数，超出的日志被丢弃，默认 10 条
+	LogLines int `json:"log_lines"`
+}
+
+// DefaultLimits 默认的资源限制
+func DefaultLimits() Limits {
+	return Limits{MemoryPages: 256, Timeout: 100 * time.Millisecond, LogLines: 10}
+}
+
+func (l Limits) withDefaults() Limits {
+	defaults := DefaultLimits()
+	if l.MemoryPages == 0 {
+		l.MemoryPages = defaults.MemoryPages
+	}
+	if l.Timeout <= 0 {
+		l.Timeout = defaults.Timeout
+	}
+	if l.LogLines <= 0 {
+		l.LogLines = defaults.LogLines
+	}
+	return l
+}
+
+// Config 插件配置
+type Config struct {
+	// Dir 插件目录，名称为 name 的插件从 <Dir>/<name>.wasm 加载
+	Dir string `json:"dir"`
+	// Limits 每个插件实例的资源限制
+	Limits Limits `json:"limits"`
+}
+
+// Plugin 编译后的策略模块，可以创建多个互相隔离的策略实例
+//
+// 模块在纯 Go 实现的 wazero 运行时中执行，没有文件系统、网络和环境变量，
+// 只能通过 snake 模块中的宿主函数与外部交互
+type Plugin struct {
+	name   string
+	binary []byte
+	limits Limits
+	// cache 编译缓存，创建实例时不重复编译
+	cache wazero.CompilationCache
+	// logger 模块日志的输出，未设置时丢弃
+	logger log.Logger
+}
+
+// Load 编译模块并检查导出，模块无效时返回错误
+func Load(ctx context.Context, name string, binary []byte, limits Limits) (*Plugin, error) {
+	p := &Plugin{
+		name:   name,
+		binary: binary,
+		limits: limits.withDefaults(),
+		cache:  wazero.NewCompilationCache(),
+	}
+
+	runtime := wazero.NewRuntimeWithConfig(ctx, p.runtimeConfig())
+	defer runtime.Close(ctx)
+
+	compiled, err := runtime.CompileModule(ctx, binary)
+	if err != nil {
+		_ = p.cache.Close(ctx)
+		return nil, fmt.Errorf("compile plugin %s: %w", name, err)
+	}
+	if err := checkExports(compiled); err != nil {
+		_ = p.cache.Close(ctx)
+		return nil, fmt.Errorf("invalid plugin %s: %w", name, err)
+	}
+	return p, nil
+}
+
+// LoadFile 读取并编译模块文件，插件名称为文件名（不含扩展名）
+func LoadFile(ctx context.Context, path string, limits Limits) (*Plugin, error) {
+	binary, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return Load(ctx, name, binary, limits)
+}
+
+// WithLogger 设置模块日志的输出，只影响之后创建的实例
+func (p *Plugin) WithLogger(logger log.Logger) *Plugin {
+	p.logger = logger
+	return p
+}
+
+// Name 返回插件名称
+func (p *Plugin) Name() string { return p.name }
+
+// Close 释放编译缓存，已经创建的策略实例不受影响
+func (p *Plugin) Close(ctx context.Context) error {
+	return p.cache.Close(ctx)
+}
+
+func (p *Plugin) runtimeConfig() wazero.RuntimeConfig {
+	return wazero.NewRuntimeConfig().
+		WithMemoryLimitPages(p.limits.MemoryPages).
+		// 调用的 context 超时或取消时终止模块，限制执行时间
+		WithCloseOnContextDone(true).
+		WithCompilationCache(p.cache)
+}
+
+// signature 函数的参数和返回值类型
+type signature struct {
+	params, results []api.ValueType
+}
+
+var exports = map[string]signature{
+	exportAlloc:  {params: []api.ValueType{api.ValueTypeI32}, results: []api.ValueType{api.ValueTypeI32}},
+	exportUpdate: {params: []api.ValueType{api.ValueTypeI32, api.ValueTypeI32}, results: []api.ValueType{api.ValueTypeI64}},
+}
+
+// checkExports 检查模块导出了调用约定要求的内存和函数
+func checkExports(compiled wazero.CompiledModule) error {
+	if _, ok := compiled.ExportedMemories()[exportMemory]; !ok {
+		return fmt.Errorf("missing export %s", exportMemory)
+	}
+
+	functions := compiled.ExportedFunctions()
+	for name, want := range exports {
+		definition, ok := functions[name]
+		if !ok {
+			return fmt.Errorf("missing export %s", name)
+		}
+		if !slices.Equal(definition.ParamTypes(), want.params) || !slices.Equal(definition.ResultTypes(), want.results) {
+			return fmt.Errorf("invalid signature of %s", name)
+		}
+	}
+	if definition, ok := functions[exportInit]; ok && (len(definition.ParamTypes()) > 0 || len(definition.ResultTypes()) > 0) {
+		return fmt.Errorf("invalid signature of %s", exportInit)
+	}
+	return nil
+}
+
+// module 运行中的模块实例，每个实例有独立的运行时、内存和宿主状态
+type module struct {
+	runtime  wazero.Runtime
+	mod      api.Module
+	allocFn  api.Function
+	updateFn api.Function
+}
+
+// instantiate 创建模块实例并调用初始化函数
+func (p *Plugin) instantiate(ctx context.Context, h *host) (*module, error) {
+	runtime := wazero.NewRuntimeWithConfig(ctx, p.runtimeConfig())
+	m, err := p.setup(ctx, runtime, h)
+	if err != nil {
+		_ = runtime.Close(ctx)
+		return nil, fmt.Errorf("instantiate plugin %s: %w", p.name, err)
+	}
+	return m, nil
+}
+
+func (p *Plugin) setup(ctx context.Context, runtime wazero.Runtime, h *host) (*module, error) {
+	// 用 TinyGo、Rust 等编译的模块通常依赖 WASI，不挂载文件系统，也不传入环境变量和参数
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, runtime); err != nil {
+		return nil, err
+	}
+	if err := instantiateHost(ctx, runtime, h); err != nil {
+		return nil, err
+	}
+
+	compiled, err := runtime.CompileModule(ctx, p.binary)
+	if err != nil {
+		return nil, err
+	}
+	// 不执行 _start，模块作为库使用
+	mod, err := runtime.InstantiateModule(ctx, compiled, wazero.NewModuleConfig().WithName(p.name).WithStartFunctions())
+	if err != nil {
+		return nil, err
+	}
+
+	// 初始化函数同样受执行时间限制
+	callCtx, cancel := context.WithTimeout(ctx, p.limits.Timeout)
+	defer cancel()
+	for _, name := range []string{"_initialize", exportInit} {
+		if fn := mod.ExportedFunction(name); fn != nil {
+			if _, err := fn.Call(callCtx); err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+		}
+	}
+
+	return &module{
+		runtime:  runtime,
+		mod:      mod,
+		allocFn:  mod.ExportedFunction(exportAlloc),
+		updateFn: mod.ExportedFunction(exportUpdate),
+	}, nil
+}
+
+// instantiateHost 注册宿主函数，字符串参数从调用方模块的内存读取
+func instantiateHost(ctx context.Context, runtime wazero.Runtime, h *host) error {
+	_, err := runtime.NewHostModuleBuilder(hostModule).
+		NewFunctionBuilder().
+		WithFunc(func(_ context.Context, m api.Module, ptr, size uint32) {
+			if message, ok := read(m, ptr, size); ok {
+				h.log(message)
+			}
+		}).
+		Export(hostLog).
+		NewFunctionBuilder().
+		WithFunc(func(_ context.Context, m api.Module, ptr, size uint32) int32 {
+			spec, ok := read(m, ptr, size)
+			if !ok {
+				return -1
+			}
+			return h.indicator(spec)
+		}).
+		Export(hostIndicator).
+		NewFunctionBuilder().
+		WithFunc(func(_ context.Context, m api.Module, handle int32, ptr, size uint32) float64 {
+			key, ok := read(m, ptr, size)
+			if !ok {
+				return math.NaN()
+			}
+			return h.indicatorValue(handle, key)
+		}).
+		Export(hostIndicatorValue).
+		Instantiate(ctx)
+	return err
+}
+
+// read 复制模块内存中的一段数据，越界或超过 maxOutputSize 时返回 false
+func read(m api.Module, ptr, size uint32) ([]byte, bool) {
+	if size > maxOutputSize {
+		return nil, false
+	}
+	data, ok := m.Memory().Read(ptr, size)
+	if !ok {
+		return nil, false
+	}
+	return bytes.Clone(data), true
+}
+
+// update 把输入写入模块内存，调用 snake_update 并读取输出
+func (m *module) update(ctx context.Context, input []byte) ([]byte, error) {
+	results, err := m.allocFn.Call(ctx, uint64(len(input)))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", exportAlloc, err)
+	}
+	ptr := uint32(results[0])
+	if !m.mod.Memory().Write(ptr, input) {
+		return nil, fmt.Errorf("%s returned invalid address %d", exportAlloc, ptr)
+	}
+
+	results, err = m.updateFn.Call(ctx, uint64(ptr), uint64(len(input)))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", exportUpdate, err)
+	}
+	if results[0] == 0 {
+		return nil, nil
+	}
+
+	outPtr, outSize := unpackResult(results[0])
+	output, ok := read(m.mod, outPtr, outSize)
+	if !ok {
+		return nil, fmt.Errorf("%s returned invalid output at %d, size %d", exportUpdate, outPtr, outSize)
+	}
+	return output, nil
+}
+
+// close 关闭运行时，释放模块的内存
+func (m *module) close(ctx context.Context) error {
+	return m.runtime.Close(ctx)
+}
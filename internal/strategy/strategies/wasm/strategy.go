@@ -0,0 +1,178 @@
+package wasm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"snake/internal/kline"
+	"snake/internal/strategy"
+	"snake/internal/types"
+	"sync"
+
+	"github.com/shopspring/decimal"
+)
+
+// guest 策略模块实例，测试时可以替换为不依赖运行时的实现
+type guest interface {
+	// update 处理一根K线的输入，返回模块的输出
+	update(ctx context.Context, input []byte) ([]byte, error)
+	// close 终止模块并释放资源
+	close(ctx context.Context) error
+}
+
+// WasmStrategy 由 WebAssembly 模块实现交易逻辑的策略
+//
+// 每根收盘K线先更新模块创建的指标，再把K线和账户状态传给模块，按模块返回的操作下单。
+// 模块执行出错、超时、超出内存限制或返回无效输出时被终止，之后的更新都返回该错误，不会影响进程。只做多
+type WasmStrategy struct {
+	*strategy.BaseStrategy
+	ctx    context.Context
+	limits Limits
+	host   *host
+	guest  guest
+	// mu 保护模块调用和终止，Stop 可能在其他 goroutine 中调用
+	mu sync.Mutex
+	// err 模块被终止的原因
+	err error
+}
+
+var _ strategy.Strategy = (*WasmStrategy)(nil)
+
+// New 创建插件的策略实例，每个实例有独立的模块内存和指标
+func (p *Plugin) New(ctx context.Context, cancel context.CancelFunc) (*WasmStrategy, error) {
+	h := newHost(p.name, p.logger, p.limits.LogLines)
+	m, err := p.instantiate(ctx, h)
+	if err != nil {
+		return nil, err
+	}
+	return newStrategy(ctx, cancel, p.name, p.limits, h, m), nil
+}
+
+func newStrategy(ctx context.Context, cancel context.CancelFunc, name string, limits Limits, h *host, g guest) *WasmStrategy {
+	return &WasmStrategy{
+		BaseStrategy: strategy.NewBaseStrategy(ctx, cancel, "WASM "+name),
+		ctx:          ctx,
+		limits:       limits.withDefaults(),
+		host:         h,
+		guest:        g,
+	}
+}
+
+// Update 更新策略状态并生成交易信号，模块已被终止时返回终止的原因
+func (s *WasmStrategy) Update(kline *kline.Kline) (*strategy.Signal, error) {
+	output, err := s.call(kline)
+	if err != nil {
+		return nil, err
+	}
+
+	price := kline.C
+	switch output.Action {
+	case ActionBuy:
+		amount := decimal.Min(output.Amount, s.BuyingPower())
+		if !amount.IsPositive() {
+			break
+		}
+		if signal := s.Buy(amount, price); signal != nil {
+			// 加仓后按全部持仓重新挂出止损止盈单
+			stop, takeProfit := s.stopOrders(output)
+			return s.PlaceStops(signal, stop, takeProfit), nil
+		}
+	case ActionSell:
+		position := s.Position().Amount
+		if !position.IsPositive() {
+			break
+		}
+		volume := output.Volume
+		if volume.IsZero() || volume.GreaterThan(position) {
+			volume = position
+		}
+		if signal := s.Sell(volume, price); signal != nil {
+			if s.Position().Amount.IsZero() {
+				s.CancelStops(signal)
+			}
+			return signal, nil
+		}
+	}
+
+	return s.Hold(), nil
+}
+
+// call 更新指标，把K线和账户状态传给模块，模块出错时终止模块
+func (s *WasmStrategy) call(kline *kline.Kline) (*Output, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	s.host.update(kline)
+	output, err := s.invoke(kline)
+	if err != nil {
+		s.terminate(fmt.Errorf("plugin %s terminated: %w", s.Name(), err))
+		return nil, s.err
+	}
+	return output, nil
+}
+
+// terminate 记录终止原因并释放模块，调用方持有 mu
+func (s *WasmStrategy) terminate(err error) {
+	s.err = err
+	_ = s.guest.close(context.Background())
+}
+
+// invoke 调用模块处理一根K线，调用受执行时间限制
+func (s *WasmStrategy) invoke(kline *kline.Kline) (output *Output, err error) {
+	// 宿主函数中的意外错误同样只终止模块
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	absolute, percentage := s.BaseStrategy.Profit()
+	input, err := json.Marshal(Input{
+		Kline: newKlineInput(kline),
+		Account: AccountInput{
+			Position:      s.Position().Amount,
+			Cost:          s.Position().Cost,
+			Balance:       s.Balance().Amount,
+			BuyingPower:   s.BuyingPower(),
+			Profit:        absolute,
+			ProfitPercent: percentage,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(s.ctx, s.limits.Timeout)
+	defer cancel()
+	data, err := s.guest.update(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return parseOutput(data)
+}
+
+// stopOrders 按模块返回的价格为全部持仓创建止损和止盈单，未设置的为 nil
+func (s *WasmStrategy) stopOrders(output *Output) (stop, takeProfit *strategy.Order) {
+	amount := s.Position().Amount
+	if output.StopLoss.IsPositive() {
+		stop = strategy.StopOrder(types.SignalTypeSell, amount, output.StopLoss)
+	}
+	if output.TakeProfit.IsPositive() {
+		takeProfit = strategy.TakeProfitOrder(types.SignalTypeSell, amount, output.TakeProfit)
+	}
+	return stop, takeProfit
+}
+
+// Stop 停止策略并终止模块，正在执行的调用因 context 取消而中断
+func (s *WasmStrategy) Stop() {
+	s.BaseStrategy.Stop()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err == nil {
+		s.terminate(fmt.Errorf("plugin %s stopped", s.Name()))
+	}
+}
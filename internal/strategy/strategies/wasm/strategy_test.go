@@ -0,0 +1,341 @@
+package wasm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"snake/internal/indicates/catalog"
+	"snake/internal/kline"
+	"snake/internal/types"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// fakeGuest 用 Go 函数代替模块，按输入返回输出
+type fakeGuest struct {
+	handle func(ctx context.Context, input Input) ([]byte, error)
+	closed bool
+}
+
+// testLogger 记录日志，Info 级别的记入 infos，Warn 级别的记入 warns
+type testLogger struct {
+	infos []string
+	warns []string
+}
+
+func (l *testLogger) Debug(...any)          {}
+func (l *testLogger) Debugf(string, ...any) {}
+func (l *testLogger) Info(args ...any)      { l.infos = append(l.infos, fmt.Sprint(args...)) }
+func (l *testLogger) Infof(format string, args ...any) {
+	l.infos = append(l.infos, fmt.Sprintf(format, args...))
+}
+func (l *testLogger) Warn(args ...any) { l.warns = append(l.warns, fmt.Sprint(args...)) }
+func (l *testLogger) Warnf(format string, args ...any) {
+	l.warns = append(l.warns, fmt.Sprintf(format, args...))
+}
+func (l *testLogger) Error(...any)          {}
+func (l *testLogger) Errorf(string, ...any) {}
+
+func (g *fakeGuest) update(ctx context.Context, data []byte) ([]byte, error) {
+	var input Input
+	if err := json.Unmarshal(data, &input); err != nil {
+		return nil, err
+	}
+	return g.handle(ctx, input)
+}
+
+func (g *fakeGuest) close(context.Context) error {
+	g.closed = true
+	return nil
+}
+
+// closeKlines 按收盘价生成 1 分钟K线
+func closeKlines(closes ...float64) []*kline.Kline {
+	klines := make([]*kline.Kline, len(closes))
+	for i, c := range closes {
+		price := decimal.NewFromFloat(c)
+		klines[i] = &kline.Kline{
+			O: price,
+			H: price.Add(decimal.NewFromInt(1)),
+			L: price.Sub(decimal.NewFromInt(1)),
+			C: price,
+			V: decimal.NewFromInt(1000),
+			S: int64(i) * 60000,
+			E: int64(i+1)*60000 - 1,
+		}
+	}
+	return klines
+}
+
+func newTestStrategy(t *testing.T, limits Limits, handle func(ctx context.Context, input Input) ([]byte, error)) (*WasmStrategy, *fakeGuest) {
+	t.Helper()
+	g := &fakeGuest{handle: handle}
+	ctx, cancel := context.WithCancel(context.TODO())
+	s := newStrategy(ctx, cancel, "test", limits, newHost("test", nil, DefaultLimits().LogLines), g)
+	if err := s.Init(decimal.Zero, decimal.NewFromInt(10000)); err != nil {
+		t.Fatalf("failed to init strategy: %v", err)
+	}
+	return s, g
+}
+
+func TestParseOutput(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Action
+		wantErr bool
+	}{
+		{input: "", want: ActionHold},
+		{input: `{}`, want: ActionHold},
+		{input: `{"action":"buy","amount":"100","stop_loss":95}`, want: ActionBuy},
+		{input: `{"action":"sell"}`, want: ActionSell},
+		{input: `{"action":"buy"}`, wantErr: true},
+		{input: `{"action":"sell","volume":"-1"}`, wantErr: true},
+		{input: `{"action":"short"}`, wantErr: true},
+		{input: `{"action":"buy","amount":1,"take_profit":-1}`, wantErr: true},
+		{input: `not json`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		output, err := parseOutput([]byte(tt.input))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseOutput(%q) 预期错误为 %v，实际为 %v", tt.input, tt.wantErr, err)
+			continue
+		}
+		if err == nil && output.Action != tt.want {
+			t.Errorf("parseOutput(%q) 预期操作为 %s，实际为 %s", tt.input, tt.want, output.Action)
+		}
+	}
+}
+
+func TestPackResult(t *testing.T) {
+	ptr, size := unpackResult(packResult(1024, 77))
+	if ptr != 1024 || size != 77 {
+		t.Errorf("预期地址 1024、长度 77，实际为 %d、%d", ptr, size)
+	}
+}
+
+func TestHostIndicator(t *testing.T) {
+	logger := &testLogger{}
+	h := newHost("test", logger, DefaultLimits().LogLines)
+
+	rsi := h.indicator([]byte(`{"name":"rsi","params":{"period":"3"}}`))
+	macd := h.indicator([]byte(`{"name":"macd","params":{"fast":"2","slow":"3","signal":"2"}}`))
+	if rsi != 0 || macd != 1 {
+		t.Fatalf("预期句柄为 0 和 1，实际为 %d 和 %d", rsi, macd)
+	}
+	for _, spec := range []string{`{"name":"foo"}`, `{"name":"rsi","params":{"period":"0"}}`, `{`} {
+		if handle := h.indicator([]byte(spec)); handle != -1 {
+			t.Errorf("无效指标 %s 预期返回 -1，实际为 %d", spec, handle)
+		}
+	}
+	if len(logger.infos) != 3 {
+		t.Errorf("预期记录 3 条错误日志，实际为 %d", len(logger.infos))
+	}
+
+	if !math.IsNaN(h.indicatorValue(rsi, nil)) {
+		t.Errorf("指标未就绪时预期为 NaN")
+	}
+	for _, k := range closeKlines(1, 2, 3, 2, 3, 4) {
+		h.update(k)
+	}
+
+	tests := []struct {
+		handle int32
+		key    string
+		nan    bool
+	}{
+		{handle: rsi},
+		{handle: rsi, key: "rsi"},
+		{handle: macd, key: "histogram"},
+		{handle: macd, key: "foo", nan: true},
+		{handle: 2, nan: true},
+		{handle: -1, nan: true},
+	}
+	for _, tt := range tests {
+		if got := h.indicatorValue(tt.handle, []byte(tt.key)); math.IsNaN(got) != tt.nan {
+			t.Errorf("句柄 %d 键 %q 预期 NaN 为 %v，实际为 %v", tt.handle, tt.key, tt.nan, got)
+		}
+	}
+}
+
+func TestHostIndicatorBudget(t *testing.T) {
+	logger := &testLogger{}
+	h := newHost("test", logger, DefaultLimits().LogLines)
+
+	if handle := h.indicator([]byte(`{"name":"ma","params":{"period":"20000000"}}`)); handle != -1 {
+		t.Fatalf("周期超过上限时预期返回 -1，实际为 %d", handle)
+	}
+
+	// 每个指标预热 MaxPeriod 根K线，总量达到上限后不能再创建
+	spec := []byte(fmt.Sprintf(`{"name":"ma","params":{"period":"%d"}}`, catalog.MaxPeriod))
+	for i := 0; i < maxWarmUp/catalog.MaxPeriod; i++ {
+		if handle := h.indicator(spec); handle != int32(i) {
+			t.Fatalf("第 %d 个指标预期句柄为 %d，实际为 %d", i+1, i, handle)
+		}
+	}
+	if handle := h.indicator([]byte(`{"name":"ma","params":{"period":"2"}}`)); handle != -1 {
+		t.Errorf("预热K线数超过上限时预期返回 -1，实际为 %d", handle)
+	}
+	if len(logger.infos) != 2 {
+		t.Errorf("预期记录 2 条错误日志，实际为 %d", len(logger.infos))
+	}
+}
+
+func TestHostLog(t *testing.T) {
+	logger := &testLogger{}
+	h := newHost("test", logger, 2)
+
+	h.log([]byte(strings.Repeat("a", maxLogSize+100)))
+	for i := 0; i < 5; i++ {
+		h.log([]byte("hello"))
+	}
+	if len(logger.infos) != 2 || len(logger.warns) != 1 {
+		t.Fatalf("预期输出 2 条日志和 1 条丢弃提示，实际为 %d 和 %d", len(logger.infos), len(logger.warns))
+	}
+	if expected := "plugin test: " + strings.Repeat("a", maxLogSize); logger.infos[0] != expected {
+		t.Errorf("预期过长的日志被截断为 %d 字节，实际长度为 %d", maxLogSize, len(logger.infos[0])-len("plugin test: "))
+	}
+
+	// 下一根K线重新计数
+	h.update(closeKlines(1)[0])
+	h.log([]byte("hello"))
+	if len(logger.infos) != 3 || logger.infos[2] != "plugin test: hello" {
+		t.Errorf("预期下一根K线的日志正常输出，实际为 %v", logger.infos)
+	}
+
+	// 未设置 logger 时丢弃日志
+	newHost("test", nil, 2).log([]byte("hello"))
+}
+
+func TestWasmStrategy(t *testing.T) {
+	var inputs []Input
+	s, _ := newTestStrategy(t, Limits{}, func(_ context.Context, input Input) ([]byte, error) {
+		inputs = append(inputs, input)
+		switch {
+		case input.Account.Position.IsZero() && input.Kline.Close.GreaterThan(decimal.NewFromInt(10)):
+			return []byte(`{"action":"buy","amount":"5000","stop_loss":"10","take_profit":"13"}`), nil
+		case input.Account.Position.IsPositive() && input.Kline.Close.LessThan(decimal.NewFromInt(11)):
+			return []byte(`{"action":"sell"}`), nil
+		}
+		return nil, nil
+	})
+
+	var buys, sells []int
+	for i, k := range closeKlines(9, 11, 12, 10, 9) {
+		signal, err := s.Update(k)
+		if err != nil {
+			t.Fatalf("failed to update strategy: %v", err)
+		}
+
+		switch {
+		case signal.Type.IsBuy():
+			buys = append(buys, i)
+			if len(signal.Orders) != 1 || signal.Orders[0].OCO == nil {
+				t.Fatalf("预期买入时挂出一个 OCO 订单，实际为 %v", signal.Orders)
+			}
+			stop, takeProfit := signal.Orders[0], signal.Orders[0].OCO
+			if stop.Type != types.OrderTypeStop || !stop.StopPrice.Equal(decimal.NewFromInt(10)) || !stop.Volume.Equal(s.Position().Amount) {
+				t.Errorf("止损单不正确: %s %s %s", stop.Type, stop.StopPrice, stop.Volume)
+			}
+			if takeProfit.Type != types.OrderTypeTakeProfit || !takeProfit.StopPrice.Equal(decimal.NewFromInt(13)) {
+				t.Errorf("止盈单不正确: %s %s", takeProfit.Type, takeProfit.StopPrice)
+			}
+		case signal.Type.IsSell():
+			sells = append(sells, i)
+			if len(signal.Cancels) != 1 {
+				t.Errorf("预期卖出全部持仓时撤销止损止盈单，实际为 %v", signal.Cancels)
+			}
+		}
+	}
+
+	if len(buys) != 1 || buys[0] != 1 || len(sells) != 1 || sells[0] != 3 {
+		t.Fatalf("预期第 2 根K线买入、第 4 根K线卖出，实际为 %v、%v", buys, sells)
+	}
+	if !s.Position().Amount.IsZero() {
+		t.Errorf("预期最后没有持仓，实际为 %s", s.Position().Amount)
+	}
+
+	// 模块收到K线和账户状态
+	if len(inputs) != 5 || !inputs[2].Kline.Close.Equal(decimal.NewFromInt(12)) || inputs[2].Kline.OpenTime != 120000 {
+		t.Fatalf("模块收到的K线不正确: %+v", inputs)
+	}
+	if !inputs[2].Account.Balance.Equal(decimal.NewFromInt(5000)) || !inputs[2].Account.Position.IsPositive() {
+		t.Errorf("模块收到的账户状态不正确: %+v", inputs[2].Account)
+	}
+}
+
+func TestWasmStrategyTerminate(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits Limits
+		handle func(ctx context.Context, input Input) ([]byte, error)
+		want   string
+	}{
+		{
+			name: "执行出错",
+			handle: func(context.Context, Input) ([]byte, error) {
+				return nil, errors.New("unreachable")
+			},
+			want: "unreachable",
+		},
+		{
+			name:   "执行超时",
+			limits: Limits{Timeout: 10 * time.Millisecond},
+			handle: func(ctx context.Context, _ Input) ([]byte, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			},
+			want: "deadline exceeded",
+		},
+		{
+			name:   "无效输出",
+			handle: func(context.Context, Input) ([]byte, error) { return []byte(`{"action":"short"}`), nil },
+			want:   "unknown action",
+		},
+		{
+			name:   "意外错误",
+			handle: func(context.Context, Input) ([]byte, error) { panic("boom") },
+			want:   "panic: boom",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, g := newTestStrategy(t, tt.limits, tt.handle)
+			klines := closeKlines(10, 11)
+
+			_, err := s.Update(klines[0])
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("预期错误包含 %q，实际为 %v", tt.want, err)
+			}
+			if !g.closed {
+				t.Errorf("预期出错后模块被终止")
+			}
+
+			// 终止后不再调用模块
+			g.handle = func(context.Context, Input) ([]byte, error) {
+				t.Fatalf("模块终止后不应再被调用")
+				return nil, nil
+			}
+			if _, again := s.Update(klines[1]); again == nil || again.Error() != err.Error() {
+				t.Errorf("预期之后的更新返回同样的错误，实际为 %v", again)
+			}
+		})
+	}
+}
+
+func TestStop(t *testing.T) {
+	s, g := newTestStrategy(t, Limits{}, func(context.Context, Input) ([]byte, error) { return nil, nil })
+	s.Stop()
+	if !g.closed {
+		t.Fatalf("预期停止时模块被终止")
+	}
+	if _, err := s.Update(closeKlines(10)[0]); err == nil || !strings.Contains(err.Error(), "stopped") {
+		t.Errorf("预期停止后更新返回错误，实际为 %v", err)
+	}
+}
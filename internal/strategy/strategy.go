@@ -3,6 +3,7 @@ package strategy
 import (
 	"context"
 	"snake/internal/kline"
+	"snake/internal/risk"
 	"snake/internal/types"
 	"time"
 
@@ -11,14 +12,34 @@ import (
 
 // Position 表示当前持仓
 type Position struct {
-	// 当前持仓数量（例如 BTC 数量）
+	// 当前持仓数量（例如 BTC 数量），正数为多头，负数为空头
 	Amount decimal.Decimal
-	// 持仓成本（USDT），只有在 Amount > 0 时才有意义
+	// 持仓成本（USDT）：多头为买入成本，空头为开空所得，只有在 Amount 不为零时才有意义
 	Cost decimal.Decimal
+	// 借入的 base 资产数量，即空头数量
+	Borrowed decimal.Decimal
+	// 累计支付的借币利息（USDT）
+	Interest decimal.Decimal
+	// 强平价，没有可被强平的持仓时为零
+	LiquidationPrice decimal.Decimal
+	// 合约仓位的逐仓保证金（USDT）
+	Margin decimal.Decimal
+	// 累计支付的合约资金费（USDT），负数表示累计收取
+	Funding decimal.Decimal
 	// 持仓时间
 	Time time.Time
 }
 
+// UnrealizedProfit 按价格计算持仓的未实现盈亏
+// 多头为市值减成本，空头为开空所得减回补成本
+func (p *Position) UnrealizedProfit(price decimal.Decimal) decimal.Decimal {
+	currentValue := p.Amount.Mul(price)
+	if p.Amount.IsNegative() {
+		return p.Cost.Add(currentValue)
+	}
+	return currentValue.Sub(p.Cost)
+}
+
 // Balance 表示当前余额
 type Balance struct {
 	// 余额数量（例如 USDT 数量）
@@ -39,6 +60,16 @@ type Signal struct {
 	Price decimal.Decimal
 	// 信号时间
 	Time time.Time
+	// 随信号提交的挂单，由回测引擎或执行端撮合
+	Orders []*Order
+	// 需要撤销的挂单编号
+	Cancels []int64
+	// 成交对应的挂单，市价信号为 nil
+	Order *Order
+	// 是否为强制平仓
+	Liquidation bool
+	// 风控缩减数量时的检查结果
+	Risk *risk.Decision
 }
 
 // Strategy 策略接口
@@ -47,14 +78,36 @@ type Strategy interface {
 	Name() string
 	// Init 初始化策略
 	Init(positionAmount, balanceAmount decimal.Decimal, cost ...decimal.Decimal) error
-	// Update 更新策略状态
+	// Update 更新策略状态，K线收盘时调用
 	Update(kline *kline.Kline) (*Signal, error)
+	// Mode 返回策略的更新方式：只在收盘时更新，或盘中也更新
+	Mode() UpdateMode
+	// UpdateIntrabar 盘中K线更新，只有 Mode 为 UpdateIntrabar 的策略会被调用
+	UpdateIntrabar(kline *kline.Kline) (*Signal, error)
 	// Position 返回当前持仓
 	Position() *Position
 	// Balance 返回当前余额
 	Balance() *Balance
 	// Profit 返回当前盈亏
 	Profit() (absolute, percentage decimal.Decimal)
+	// Fill 挂单成交回调
+	Fill(order *Order, price decimal.Decimal) *Signal
+	// SetMargin 设置保证金账户参数，nil 表示不允许做空
+	SetMargin(cfg *MarginConfig)
+	// Mark 按K线盯市：计提借币利息、更新盈亏、检查强平
+	Mark(kline *kline.Kline) *Signal
+	// SetFutures 设置合约参数，nil 表示现货账户
+	SetFutures(cfg *FuturesConfig)
+	// SettleFunding 结算合约资金费
+	SettleFunding(rate, markPrice decimal.Decimal) decimal.Decimal
+	// SetMarkPrice 设置合约的标记价格K线，盯市时按标记价格检查强平
+	SetMarkPrice(kline *kline.Kline)
+	// Equity 按价格计算账户净值
+	Equity(price decimal.Decimal) decimal.Decimal
+	// SetRisk 设置风控管理器，nil 表示不做账户级风控
+	SetRisk(manager *risk.Manager)
+	// Transfer 划转资金，正数为转入，负数为转出
+	Transfer(amount decimal.Decimal) decimal.Decimal
 
 	Stop()
 }
@@ -75,6 +128,20 @@ type BaseStrategy struct {
 		absolute   decimal.Decimal
 		percentage decimal.Decimal
 	}
+	// 最近分配的挂单编号
+	orderID int64
+	// 当前止损止盈挂单编号，OCO 订单为第一条腿，见 PlaceStops
+	stopOrderID int64
+	// 保证金账户参数，nil 表示不允许做空
+	margin *MarginConfig
+	// 合约参数，nil 表示现货账户
+	futures *FuturesConfig
+	// 风控管理器，nil 表示不做账户级风控
+	risk *risk.Manager
+	// 上次盯市的时间（毫秒）
+	markTime int64
+	// 合约的标记价格K线，与盯市K线的开盘时间相同时用于检查强平
+	markPrice *kline.Kline
 }
 
 func (s *BaseStrategy) Stop() { s.cancel() }
@@ -114,6 +181,13 @@ func (s *BaseStrategy) Init(positionAmount, balanceAmount decimal.Decimal, cost
 		s.position.Cost = decimal.Zero
 	}
 
+	// 合约初始持仓的保证金按 持仓成本 / 杠杆 计算，不从余额中扣除
+	s.position.Margin = decimal.Zero
+	if s.futures != nil {
+		s.position.Margin = s.position.Cost.Div(s.futures.Leverage)
+	}
+	s.refreshMargin()
+
 	return nil
 }
 
@@ -127,8 +201,47 @@ func (s *BaseStrategy) Balance() *Balance {
 	return s.balance
 }
 
-// Buy 执行买入操作
+// Transfer 划转资金，正数为转入，负数为转出，返回实际划转的金额
+// 转出不超过可用余额，杠杆账户的开空所得作为保证金不能转出
+func (s *BaseStrategy) Transfer(amount decimal.Decimal) decimal.Decimal {
+	if amount.IsNegative() {
+		available := s.balance.Amount
+		if s.futures == nil && s.position.Amount.IsNegative() {
+			available = available.Sub(s.position.Cost)
+		}
+		amount = decimal.Max(amount, decimal.Min(available, s.balance.Amount).Neg())
+		if amount.IsPositive() {
+			amount = decimal.Zero
+		}
+	}
+
+	s.balance.Amount = s.balance.Amount.Add(amount)
+	s.balance.Time = time.Now()
+	s.refreshMargin()
+	if s.risk != nil {
+		s.risk.Transfer(amount)
+	}
+	return amount
+}
+
+// Buy 执行买入操作，持有空头时先买入平空，剩余部分开多
+// 设置了风控时先经过风控检查，开仓数量可能被缩减，被拒绝时返回 nil
 func (s *BaseStrategy) Buy(amount, price decimal.Decimal) *Signal {
+	volume, decision, ok := s.checkRisk(types.SignalTypeBuy, amount.Div(price), price)
+	if !ok {
+		return nil
+	}
+	if decision != nil {
+		amount = volume.Mul(price)
+	}
+	return s.withRisk(s.buy(amount, price), decision)
+}
+
+func (s *BaseStrategy) buy(amount, price decimal.Decimal) *Signal {
+	if s.futures != nil {
+		return s.tradeFutures(types.SignalTypeBuy, amount.Div(price), price)
+	}
+
 	// 计算需要的 USDT 数量
 	usdtAmount := amount
 
@@ -142,9 +255,7 @@ func (s *BaseStrategy) Buy(amount, price decimal.Decimal) *Signal {
 
 	// 更新余额和仓位
 	s.balance.Amount = s.balance.Amount.Sub(usdtAmount)
-	s.position.Amount = s.position.Amount.Add(btcAmount)
-	// 更新持仓成本：新成本 = 旧成本 + 新买入成本
-	s.position.Cost = s.position.Cost.Add(usdtAmount)
+	s.applyTrade(btcAmount, price)
 	s.position.Time = time.Now()
 	s.balance.Time = time.Now()
 
@@ -157,14 +268,28 @@ func (s *BaseStrategy) Buy(amount, price decimal.Decimal) *Signal {
 	}
 }
 
-// Sell 执行卖出操作
+// Sell 执行卖出操作，卖出超过多头持仓的部分为借币开空，需要开启保证金账户
+// 设置了风控时先经过风控检查，开仓数量可能被缩减，被拒绝时返回 nil
 func (s *BaseStrategy) Sell(amount, price decimal.Decimal) *Signal {
-	if s.position.Amount.LessThan(amount) {
+	volume, decision, ok := s.checkRisk(types.SignalTypeSell, amount, price)
+	if !ok {
 		return nil
 	}
-	
+	return s.withRisk(s.sell(volume, price), decision)
+}
+
+func (s *BaseStrategy) sell(amount, price decimal.Decimal) *Signal {
+	if s.futures != nil {
+		return s.tradeFutures(types.SignalTypeSell, amount, price)
+	}
+
+	shortAmount := amount.Sub(decimal.Max(s.position.Amount, decimal.Zero))
+	if shortAmount.IsPositive() && !s.canShort(amount, price) {
+		return nil
+	}
+
 	// 检查仓位是否足够
-	if s.position.Amount.IsZero() {
+	if s.position.Amount.IsZero() && !shortAmount.IsPositive() {
 		return &Signal{
 			Type:   types.SignalTypeSell,
 			Volume: decimal.Zero,
@@ -179,10 +304,7 @@ func (s *BaseStrategy) Sell(amount, price decimal.Decimal) *Signal {
 
 	// 更新余额和仓位
 	s.balance.Amount = s.balance.Amount.Add(usdtAmount)
-	s.position.Amount = s.position.Amount.Sub(amount)
-	// 更新持仓成本：新成本 = 旧成本 * (1 - 卖出比例)
-	sellRatio := amount.Div(s.position.Amount.Add(amount))
-	s.position.Cost = s.position.Cost.Mul(decimal.NewFromInt(1).Sub(sellRatio))
+	s.applyTrade(amount.Neg(), price)
 	s.position.Time = time.Now()
 	s.balance.Time = time.Now()
 
@@ -218,18 +340,21 @@ func (s *BaseStrategy) updateProfit(currentPrice decimal.Decimal) {
 		return
 	}
 
-	// 计算当前持仓市值
-	currentValue := s.position.Amount.Mul(currentPrice)
-
 	// 计算盈亏绝对数量
-	s.profit.absolute = currentValue.Sub(s.position.Cost)
+	s.profit.absolute = s.position.UnrealizedProfit(currentPrice)
+
+	// 合约的盈亏百分比相对于仓位保证金
+	base := s.position.Cost
+	if s.futures != nil {
+		base = s.position.Margin
+	}
 
 	// 计算盈亏百分比
-	if s.position.Cost.IsZero() {
+	if base.IsZero() {
 		s.profit.percentage = decimal.Zero
 	} else {
-		// 计算盈亏百分比：(当前市值 - 持仓成本) / 持仓成本 * 100
-		s.profit.percentage = s.profit.absolute.Div(s.position.Cost).Mul(decimal.NewFromInt(100))
+		// 计算盈亏百分比：盈亏 / 持仓成本 * 100
+		s.profit.percentage = s.profit.absolute.Div(base).Mul(decimal.NewFromInt(100))
 	}
 }
 
@@ -0,0 +1,18 @@
+package strategy
+
+import (
+	"snake/internal/kline"
+	"snake/internal/kline/interval"
+)
+
+// MultiTimeframeStrategy 多周期策略，例如用日线趋势过滤 15 分钟的入场信号
+//
+// 回测和实盘通过 Feed 用 kline.Resampler 从基础周期合成高周期K线，高周期K线只在收盘后推送，
+// 同一时刻收盘的K线按周期从大到小推送，基础周期最后
+type MultiTimeframeStrategy interface {
+	Strategy
+	// Intervals 返回策略需要的高周期，基础周期由回测或实盘的K线周期决定
+	Intervals() []interval.Interval
+	// UpdateInterval 某个周期的K线收盘后更新策略，基础周期的K线也通过此方法推送，不再调用 Update
+	UpdateInterval(interval interval.Interval, kline *kline.Kline) (*Signal, error)
+}
@@ -0,0 +1,21 @@
+package strategy
+
+import (
+	"snake/internal/kline"
+	"snake/internal/kline/transform"
+)
+
+// TransformStrategy 使用转换后K线的策略，例如基于平均K线或砖形图的策略
+//
+// 回测和实盘通过 Feed 把收盘的K线交给 Transformer，转换出的K线逐根推送给 UpdateTransformed，
+// 不再调用 Update；盘中更新（UpdateIntrabar）和订单撮合仍使用原始K线
+type TransformStrategy interface {
+	Strategy
+	// Transformer 返回K线转换，Feed 创建时调用一次
+	Transformer() transform.Transformer
+	// UpdateTransformed 推送一根转换后的K线，raw 为产生它的收盘原始K线
+	//
+	// 转换后K线的价格不一定真实成交过（平均K线的收盘价是开高低收的均值，砖形图和范围K线是砖块边界），
+	// 只能用于判断信号；市价买卖必须按 raw.C 成交，或者改为挂单
+	UpdateTransformed(bar, raw *kline.Kline) (*Signal, error)
+}
@@ -0,0 +1,45 @@
+package types
+
+// OrderType 表示订单类型
+type OrderType int
+
+const (
+	// OrderTypeMarket 市价单，按信号价格立即成交
+	OrderTypeMarket OrderType = iota
+	// OrderTypeLimit 限价单，价格达到或优于限价时成交
+	OrderTypeLimit
+	// OrderTypeStop 止损单，价格触及触发价后按市价成交
+	OrderTypeStop
+	// OrderTypeStopLimit 止损限价单，价格触及触发价后挂出限价单
+	OrderTypeStopLimit
+	// OrderTypeTakeProfit 止盈单，价格触及触发价后按市价成交
+	OrderTypeTakeProfit
+	// OrderTypeTrailingStop 跟踪止损单，触发价随最有利价格移动
+	OrderTypeTrailingStop
+)
+
+var orderTypeNames = map[OrderType]string{
+	OrderTypeMarket:       "MARKET",
+	OrderTypeLimit:        "LIMIT",
+	OrderTypeStop:         "STOP",
+	OrderTypeStopLimit:    "STOP_LIMIT",
+	OrderTypeTakeProfit:   "TAKE_PROFIT",
+	OrderTypeTrailingStop: "TRAILING_STOP",
+}
+
+func (o OrderType) String() string { return orderTypeNames[o] }
+
+// IsMarket 判断是否为市价单
+func (o OrderType) IsMarket() bool {
+	return o == OrderTypeMarket
+}
+
+// IsStop 判断是否为止损类订单（触发后以不利方向成交）
+func (o OrderType) IsStop() bool {
+	return o == OrderTypeStop || o == OrderTypeStopLimit || o == OrderTypeTrailingStop
+}
+
+// IsLimit 判断是否为限价类订单（以有利方向成交）
+func (o OrderType) IsLimit() bool {
+	return o == OrderTypeLimit || o == OrderTypeTakeProfit
+}
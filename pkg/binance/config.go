@@ -4,4 +4,6 @@ type Config struct {
 	APIKey    string
 	SecretKey string
 	Symbol    string
+	// 是否同时采集 U 本位永续合约的K线、标记价格K线和资金费率
+	Futures bool
 }
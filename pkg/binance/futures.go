@@ -0,0 +1,145 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const futuresBaseURL = "https://fapi.binance.com"
+
+// FuturesKline U 本位永续合约K线，标记价格K线没有成交量相关字段
+type FuturesKline struct {
+	OpenTime       int64
+	Open           string
+	High           string
+	Low            string
+	Close          string
+	Volume         string
+	CloseTime      int64
+	Amount         string
+	TradeCount     int64
+	TakerBuyVolume string
+	TakerBuyAmount string
+}
+
+// FundingRate 资金费率结算记录
+type FundingRate struct {
+	Symbol      string `json:"symbol"`
+	FundingTime int64  `json:"fundingTime"`
+	FundingRate string `json:"fundingRate"`
+	MarkPrice   string `json:"markPrice"`
+}
+
+// FuturesClient U 本位永续合约行情接口，只使用无需签名的公开接口
+type FuturesClient struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewFutures(cfg *Config) *FuturesClient {
+	return &FuturesClient{
+		baseURL: futuresBaseURL,
+		client: &http.Client{
+			Timeout:   time.Second * 60,
+			Transport: &http.Transport{Proxy: http.ProxyFromEnvironment},
+		},
+	}
+}
+
+// Klines 获取合约K线
+func (c *FuturesClient) Klines(ctx context.Context, symbol, interval string, startTime, endTime uint64, limit int) ([]*FuturesKline, error) {
+	return c.klines(ctx, "/fapi/v1/klines", symbol, interval, startTime, endTime, limit)
+}
+
+// MarkPriceKlines 获取标记价格K线
+func (c *FuturesClient) MarkPriceKlines(ctx context.Context, symbol, interval string, startTime, endTime uint64, limit int) ([]*FuturesKline, error) {
+	return c.klines(ctx, "/fapi/v1/markPriceKlines", symbol, interval, startTime, endTime, limit)
+}
+
+// FundingRates 获取资金费率历史，按结算时间升序排序
+func (c *FuturesClient) FundingRates(ctx context.Context, symbol string, startTime, endTime uint64, limit int) ([]*FundingRate, error) {
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	params.Set("startTime", strconv.FormatUint(startTime, 10))
+	params.Set("endTime", strconv.FormatUint(endTime, 10))
+	params.Set("limit", strconv.Itoa(limit))
+
+	var rates []*FundingRate
+	if err := c.get(ctx, "/fapi/v1/fundingRate", params, &rates); err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+func (c *FuturesClient) klines(ctx context.Context, path, symbol, interval string, startTime, endTime uint64, limit int) ([]*FuturesKline, error) {
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	params.Set("interval", interval)
+	params.Set("startTime", strconv.FormatUint(startTime, 10))
+	params.Set("endTime", strconv.FormatUint(endTime, 10))
+	params.Set("limit", strconv.Itoa(limit))
+
+	var rows [][]json.RawMessage
+	if err := c.get(ctx, path, params, &rows); err != nil {
+		return nil, err
+	}
+
+	var klines = make([]*FuturesKline, 0, len(rows))
+	for _, row := range rows {
+		kline, err := parseFuturesKline(row)
+		if err != nil {
+			return nil, err
+		}
+		klines = append(klines, kline)
+	}
+	return klines, nil
+}
+
+func (c *FuturesClient) get(ctx context.Context, path string, params url.Values, result any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request %s failed: status %d, body: %s", path, resp.StatusCode, data)
+	}
+	return json.Unmarshal(data, result)
+}
+
+// parseFuturesKline 解析K线数组：
+// [开盘时间, 开盘价, 最高价, 最低价, 收盘价, 成交量, 收盘时间, 成交额, 成交笔数, 主动买入成交量, 主动买入成交额, 忽略]
+func parseFuturesKline(row []json.RawMessage) (*FuturesKline, error) {
+	if len(row) < 11 {
+		return nil, fmt.Errorf("invalid kline: %d fields", len(row))
+	}
+
+	var kline FuturesKline
+	var fields = []any{
+		&kline.OpenTime, &kline.Open, &kline.High, &kline.Low, &kline.Close, &kline.Volume,
+		&kline.CloseTime, &kline.Amount, &kline.TradeCount, &kline.TakerBuyVolume, &kline.TakerBuyAmount,
+	}
+	for i, field := range fields {
+		if err := json.Unmarshal(row[i], field); err != nil {
+			return nil, fmt.Errorf("invalid kline field %d: %w", i, err)
+		}
+	}
+	return &kline, nil
+}
@@ -32,23 +32,30 @@ func Sqrt(x decimal.Decimal) decimal.Decimal {
 	return z
 }
 
-// StandardDeviation 计算多个 decimal.Decimal 的标准差
+// StandardDeviation 计算多个 decimal.Decimal 的总体标准差
 func StandardDeviation(decimals ...decimal.Decimal) decimal.Decimal {
 	if len(decimals) == 0 {
 		return decimal.Zero
 	}
+	return Sqrt(squaredDeviations(decimals).Div(decimal.NewFromInt(int64(len(decimals)))))
+}
 
-	// 计算平均值
+// SampleStandardDeviation 计算多个 decimal.Decimal 的样本标准差（除以 n-1），少于两个值时为零
+func SampleStandardDeviation(decimals ...decimal.Decimal) decimal.Decimal {
+	if len(decimals) < 2 {
+		return decimal.Zero
+	}
+	return Sqrt(squaredDeviations(decimals).Div(decimal.NewFromInt(int64(len(decimals) - 1))))
+}
+
+// squaredDeviations 计算离差平方和
+func squaredDeviations(decimals []decimal.Decimal) decimal.Decimal {
 	mean := AverageDecimals(decimals...)
 
-	// 计算方差
-	var variance = decimal.Zero
+	var sum = decimal.Zero
 	for _, d := range decimals {
 		diff := d.Sub(mean)
-		variance = variance.Add(diff.Mul(diff))
+		sum = sum.Add(diff.Mul(diff))
 	}
-	variance = variance.Div(decimal.NewFromInt(int64(len(decimals))))
-
-	// 计算标准差
-	return Sqrt(variance)
+	return sum
 }